@@ -0,0 +1,48 @@
+package ingitdb
+
+// specscore: feature/column-validation
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// CheckDef is a named record invariant declared under a collection's `checks:`
+// map, e.g.
+//
+//	checks:
+//	  dates_ordered:
+//	    expr: end_date >= start_date
+//	    message: end_date must not be before start_date
+//
+// Expr is a Starlark expression over the record's stored columns, in the same
+// grammar as formula and required_when, and must evaluate to True or False. A
+// column the record omits is bound as None, so a check over optional columns
+// should guard for it: `end_date == None or end_date >= start_date`.
+type CheckDef struct {
+	Expr string `yaml:"expr"`
+	// Message is reported when the check fails. Without it the failure names
+	// the check and its expression.
+	Message string `yaml:"message,omitempty"`
+}
+
+// validateChecks resolves every check of the collection against its stored
+// columns, so a typo or a reference to a computed column is a load-time error
+// rather than a failure on every record.
+func (v *CollectionDef) validateChecks() error {
+	for _, name := range slices.Sorted(maps.Keys(v.Checks)) {
+		check := v.Checks[name]
+		if check == nil || check.Expr == "" {
+			return fmt.Errorf("collection '%s': check '%s' is missing 'expr'", v.ID, name)
+		}
+		if err := resolveStoredExpr(check.Expr, v.Columns); err != nil {
+			if ref := computedColumnReference(err, v.Columns); ref != "" {
+				return fmt.Errorf("collection '%s': check '%s' references computed column '%s': a check may reference only stored fields",
+					v.ID, name, ref)
+			}
+			return fmt.Errorf("collection '%s': invalid expr for check '%s': %w", v.ID, name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package ingitdb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCollectionDef_ValidateChecks(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		checks  map[string]*CheckDef
+		wantErr string
+	}{
+		{name: "valid", checks: map[string]*CheckDef{
+			"dates_ordered": {Expr: "end_date == None or end_date >= start_date", Message: "end before start"},
+		}},
+		{name: "missing expr", checks: map[string]*CheckDef{"empty": {}}, wantErr: "check 'empty' is missing 'expr'"},
+		{name: "nil check", checks: map[string]*CheckDef{"empty": nil}, wantErr: "check 'empty' is missing 'expr'"},
+		{name: "undeclared identifier", checks: map[string]*CheckDef{"typo": {Expr: "end_dat >= start_date"}}, wantErr: "end_dat"},
+		{name: "computed column", checks: map[string]*CheckDef{"days": {Expr: "days > 0"}}, wantErr: "references computed column 'days'"},
+		{name: "syntax error", checks: map[string]*CheckDef{"bad": {Expr: "end_date >="}}, wantErr: "invalid expr for check 'bad'"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			def := &CollectionDef{
+				ID:         "c",
+				RecordFile: &RecordFileDef{Name: "{key}.json", Format: RecordFormatJSON, RecordType: "map[string]any"},
+				Columns: map[string]*ColumnDef{
+					"start_date": {Type: ColumnTypeDate},
+					"end_date":   {Type: ColumnTypeDate},
+					"days":       {Type: ColumnTypeInt, Formula: "1"},
+				},
+				Checks: tc.checks,
+			}
+			err := def.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -34,8 +34,19 @@ type CollectionDef struct {
 	// DescribeCollection can round-trip the real PK column names instead of
 	// the synthesized "$key" placeholder. Omitted from older
 	// definition.yaml files; callers should fall back to "$key" when empty.
-	PrimaryKey  []string `yaml:"primary_key,omitempty"`
-	DefaultView *ViewDef `yaml:"default_view,omitempty"`
+	PrimaryKey []string `yaml:"primary_key,omitempty"`
+	// Unique lists column tuples whose combined values must differ between all
+	// records of the collection, e.g. [[country, code]]. A single-column
+	// constraint can equally be declared as `unique: true` on the column. See
+	// ColumnDef.Unique for how missing values and subcollections are treated.
+	Unique [][]string `yaml:"unique,omitempty"`
+	// ForeignKeys are composite foreign keys, spanning several columns. A
+	// single-column reference is declared as `foreign_key` on the column.
+	ForeignKeys []ForeignKeyDef `yaml:"foreign_keys,omitempty"`
+	// Checks are named record invariants, each a boolean expression over the
+	// record's stored columns that every record must satisfy. See CheckDef.
+	Checks      map[string]*CheckDef `yaml:"checks,omitempty"`
+	DefaultView *ViewDef             `yaml:"default_view,omitempty"`
 	// SubCollections are not part of the collection definition file,
 	// they are stored in the "subcollections" subdirectory as directories,
 	// each containing their own .collection/definition.yaml.
@@ -43,6 +54,9 @@ type CollectionDef struct {
 	// Views are not part of the collection definition file,
 	// they are stored in the "views" subdirectory.
 	Views map[string]*ViewDef `yaml:"-" json:"-"`
+	// Triggers are not part of the collection definition file either,
+	// they are stored next to it as trigger_<name>.yaml workflow files.
+	Triggers map[string]*TriggerDef `yaml:"-" json:"-"`
 
 	Readme *CollectionReadmeDef `yaml:"readme,omitempty" json:"readme,omitempty"`
 
@@ -114,6 +128,25 @@ func (v *CollectionDef) Validate() error {
 				return err
 			}
 		}
+		if col.OnDelete != "" {
+			if col.ForeignKey == "" {
+				return fmt.Errorf("invalid column '%s': on_delete requires foreign_key", id)
+			}
+			if err := validateOnDelete(col.OnDelete, v.Columns, []string{id}); err != nil {
+				return fmt.Errorf("invalid column '%s': %w", id, err)
+			}
+		}
+	}
+	if err := v.validateUniqueConstraints(); err != nil {
+		return err
+	}
+	if err := v.validateChecks(); err != nil {
+		return err
+	}
+	for i, fk := range v.ForeignKeys {
+		if err := fk.validate(v.Columns); err != nil {
+			return fmt.Errorf("collection '%s': invalid foreign_keys[%d]: %w", v.ID, i, err)
+		}
 	}
 	for i, colName := range v.ColumnsOrder {
 		if _, ok := v.Columns[colName]; !ok {
@@ -142,6 +175,10 @@ func (v *CollectionDef) Validate() error {
 		for id, viewDef := range v.Views {
 			if err := viewDef.Validate(); err != nil {
 				allErrors = append(allErrors, fmt.Errorf("invalid view '%s': %w", id, err))
+				continue
+			}
+			if err := ValidateViewWhere(v.ID, viewDef, v.Columns); err != nil {
+				allErrors = append(allErrors, err)
 			}
 		}
 	}
@@ -151,6 +188,8 @@ func (v *CollectionDef) Validate() error {
 		v.DefaultView.ID = DefaultViewID
 		if err := v.DefaultView.Validate(); err != nil {
 			allErrors = append(allErrors, fmt.Errorf("invalid default_view: %w", err))
+		} else if err := ValidateViewWhere(v.ID, v.DefaultView, v.Columns); err != nil {
+			allErrors = append(allErrors, err)
 		}
 	}
 
@@ -173,6 +212,9 @@ func (v *CollectionDef) Validate() error {
 		if err := v.Readme.Validate(); err != nil {
 			return fmt.Errorf("invalid readme: %w", err)
 		}
+		if err := ValidateViewWhere(v.ID, v.Readme.DataPreview, v.Columns); err != nil {
+			return fmt.Errorf("invalid readme: %w", err)
+		}
 	}
 
 	return nil
@@ -62,6 +62,21 @@ func (c *CollectionDef) MarshalYAML() (interface{}, error) {
 		_ = pkNode.Encode(c.PrimaryKey)
 		addNode("primary_key", pkNode)
 	}
+	if len(c.Unique) > 0 {
+		uNode := &yaml.Node{}
+		_ = uNode.Encode(c.Unique)
+		addNode("unique", uNode)
+	}
+	if len(c.ForeignKeys) > 0 {
+		fkNode := &yaml.Node{}
+		_ = fkNode.Encode(c.ForeignKeys)
+		addNode("foreign_keys", fkNode)
+	}
+	if len(c.Checks) > 0 {
+		checksNode := &yaml.Node{}
+		_ = checksNode.Encode(c.Checks)
+		addNode("checks", checksNode)
+	}
 	if c.DefaultView != nil {
 		dvNode := &yaml.Node{}
 		_ = dvNode.Encode(c.DefaultView)
@@ -67,6 +67,32 @@ func TestCollectionDef_MarshalYAML_WithPrimaryKey(t *testing.T) {
 	}
 }
 
+func TestCollectionDef_MarshalYAML_WithUnique(t *testing.T) {
+	t.Parallel()
+
+	def := &CollectionDef{
+		Unique: [][]string{{"country", "code"}},
+		Columns: map[string]*ColumnDef{
+			"code":    {Type: ColumnTypeString, Unique: true},
+			"country": {Type: ColumnTypeString},
+		},
+	}
+	out, err := yaml.Marshal(def)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var back CollectionDef
+	if err = yaml.Unmarshal(out, &back); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(back.Unique) != 1 || strings.Join(back.Unique[0], ",") != "country,code" {
+		t.Errorf("unique did not round-trip, got:\n%s", out)
+	}
+	if !back.Columns["code"].Unique {
+		t.Errorf("column unique did not round-trip, got:\n%s", out)
+	}
+}
+
 func TestCollectionDef_MarshalYAML_WithDefaultView(t *testing.T) {
 	t.Parallel()
 
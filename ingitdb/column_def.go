@@ -10,6 +10,14 @@ type ColumnDef struct {
 	Titles     map[string]string `yaml:"titles,omitempty"`
 	ValueTitle string            `yaml:"valueTitle,omitempty"`
 	Required   bool              `yaml:"required,omitempty"`
+	// Unique requires the column's value to differ between all records of the
+	// collection (per parent record, for a subcollection). Records without a
+	// value are not compared. Uniqueness across several columns together is
+	// declared on the collection instead (CollectionDef.Unique).
+	//
+	// Only scalar columns can be unique, and a computed column cannot be: its
+	// value is not stored, so there is nothing to compare at validation time.
+	Unique bool `yaml:"unique,omitempty"`
 	// RequiredWhen makes the column required only when the expression evaluates
 	// to Starlark True. It is a single Starlark expression over the record's
 	// stored sibling fields, and reuses Formula's parser and evaluator rather
@@ -39,10 +47,19 @@ type ColumnDef struct {
 	// a plain int the natural "!= 0" guard reads it as unset and enforces
 	// nothing. It also makes "a length constraint declared on a bool column" a
 	// detectable definition-load error rather than an invisible no-op.
-	Length     *int   `yaml:"length,omitempty"`
-	MinLength  *int   `yaml:"min_length,omitempty"`
-	MaxLength  *int   `yaml:"max_length,omitempty"`
+	Length    *int `yaml:"length,omitempty"`
+	MinLength *int `yaml:"min_length,omitempty"`
+	MaxLength *int `yaml:"max_length,omitempty"`
+	// ForeignKey names the collection whose record keys this column's value
+	// must be one of (see ResolveForeignKey). On a list column such as
+	// `[]string` every element must be a key. A reference spanning several
+	// columns is declared on the collection (CollectionDef.ForeignKeys).
 	ForeignKey string `yaml:"foreign_key,omitempty"`
+	// OnDelete is what deleting the referenced record does to this one:
+	// restrict (the default), cascade or set_null. It requires ForeignKey.
+	// Incremental validation reports references a deletion left behind, and
+	// the refactions package applies the action when deleting a record.
+	OnDelete OnDeleteAction `yaml:"on_delete,omitempty"`
 	// MinValue and MaxValue constrain a numeric column's value inclusively.
 	//
 	// Pointer-typed on purpose: a declared zero must be distinguishable from
@@ -69,16 +86,28 @@ type ColumnDef struct {
 	// When writing, this column is stored as-is in the file; if the pair column contains an entry
 	// for the primary locale key, that entry is promoted here and removed from the pair column.
 	Locale string `yaml:"locale,omitempty"`
+	// Pattern is a regular expression (Go RE2 syntax) every value of a string
+	// column, or every element of a []string column, must match. Like JSON
+	// Schema's pattern it is not anchored: use ^...$ to match the whole value.
+	// A pattern that does not compile is a definition-load error.
+	Pattern string `yaml:"pattern,omitempty"`
 	// Format is an optional, free-form hint about the column's logical content
 	// type. Well-known values include `markdown`, `html`, `json`, `jsonl`,
-	// `yaml`, `uri`, `email`, `pdf`. inGitDB does not validate the value;
-	// tooling may use it to choose a renderer or preview strategy.
+	// `yaml`, `uri`, `email`, `pdf`. By default inGitDB does not validate the
+	// value; tooling may use it to choose a renderer or preview strategy.
 	Format string `yaml:"format,omitempty"`
+	// ValidateFormat opts in to enforcing Format on a string or []string
+	// column (see CheckFormat). Only email, uri, json, yaml and markdown can
+	// be enforced; asking for any other format is a definition-load error.
+	ValidateFormat bool `yaml:"validate_format,omitempty"`
 	// Formula declares this column as a computed (virtual) column. When set,
 	// it must be a single Starlark expression that references only stored
 	// (non-computed) sibling fields. Computed columns support only the
 	// string, int, float, bool, and any declared types.
 	//
+	// Computed values are never stored; they are evaluated when records are
+	// read for views, exports and feeds (see EvaluateComputedColumns).
+	//
 	// Note: Starlark's `/` operator is float division, so for an int column
 	// use integer division `//` (e.g. `total // count`) — `a / b` yields a
 	// float and fails coercion into an int column unless the result is whole.
@@ -92,7 +121,12 @@ func (v *ColumnDef) Validate() error {
 		}
 		return err
 	}
-	return nil
+	if v.Unique {
+		if err := validateUniqueColumn(v); err != nil {
+			return err
+		}
+	}
+	return validateStringConstraints(v)
 }
 
 type ColumnDefWithID struct {
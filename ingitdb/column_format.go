@@ -0,0 +1,119 @@
+package ingitdb
+
+// specscore: feature/column-validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb/markdown"
+)
+
+// Column formats that CheckFormat can enforce when a column declares
+// `validate_format: true`.
+const (
+	ColumnFormatEmail    = "email"
+	ColumnFormatURI      = "uri"
+	ColumnFormatJSON     = "json"
+	ColumnFormatYAML     = "yaml"
+	ColumnFormatMarkdown = "markdown"
+)
+
+var validatableColumnFormats = []string{
+	ColumnFormatEmail,
+	ColumnFormatURI,
+	ColumnFormatJSON,
+	ColumnFormatYAML,
+	ColumnFormatMarkdown,
+}
+
+// isStringValuedColumn reports whether pattern and format constraints apply
+// to a column of type t: a string, or a list of strings checked element-wise.
+func isStringValuedColumn(t ColumnType) bool {
+	return t == ColumnTypeString || t == "[]string"
+}
+
+// validateStringConstraints checks a column's pattern and validate_format at
+// definition load, so a regex that does not compile or a format that cannot
+// be enforced fails once rather than on every record.
+func validateStringConstraints(v *ColumnDef) error {
+	if v.Pattern != "" {
+		if !isStringValuedColumn(v.Type) {
+			return fmt.Errorf("pattern is not supported on a column of type '%s': use string or []string", v.Type)
+		}
+		if _, err := regexp.Compile(v.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", v.Pattern, err)
+		}
+	}
+	if v.ValidateFormat {
+		if v.Format == "" {
+			return errors.New("validate_format requires 'format'")
+		}
+		if !slices.Contains(validatableColumnFormats, v.Format) {
+			return fmt.Errorf("validate_format: format %q cannot be validated, must be one of: email, uri, json, yaml, markdown", v.Format)
+		}
+		if !isStringValuedColumn(v.Type) {
+			return fmt.Errorf("validate_format is not supported on a column of type '%s': use string or []string", v.Type)
+		}
+	}
+	return nil
+}
+
+// CheckFormat returns why s is not a well-formed value of format, or nil. The
+// formats that can be checked are those validate_format accepts:
+//
+//   - email: a bare address such as ada@example.com, without a display name;
+//   - uri: an absolute URI, i.e. one with a scheme;
+//   - json: a single JSON value;
+//   - yaml: a YAML document;
+//   - markdown: valid UTF-8 whose frontmatter, if it has any, parses.
+//
+// Any other format is an error.
+func CheckFormat(format, s string) error {
+	switch format {
+	case ColumnFormatEmail:
+		addr, err := mail.ParseAddress(s)
+		if err != nil {
+			return err
+		}
+		if addr.Name != "" || addr.Address != s {
+			return errors.New("expected a bare address without a display name")
+		}
+	case ColumnFormatURI:
+		u, err := url.Parse(s)
+		if err != nil {
+			return err
+		}
+		if u.Scheme == "" {
+			return errors.New("missing scheme")
+		}
+	case ColumnFormatJSON:
+		var v any
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return err
+		}
+	case ColumnFormatYAML:
+		var v any
+		if err := yaml.Unmarshal([]byte(s), &v); err != nil {
+			return err
+		}
+	case ColumnFormatMarkdown:
+		if !utf8.ValidString(s) {
+			return errors.New("invalid UTF-8")
+		}
+		if _, _, err := markdown.Parse([]byte(s)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("format %q cannot be validated", format)
+	}
+	return nil
+}
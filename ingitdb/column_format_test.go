@@ -0,0 +1,74 @@
+package ingitdb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestColumnDef_Validate_StringConstraints(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		col     ColumnDef
+		wantErr string
+	}{
+		{name: "pattern on string", col: ColumnDef{Type: ColumnTypeString, Pattern: `^[a-z]+$`}},
+		{name: "pattern on []string", col: ColumnDef{Type: "[]string", Pattern: `^[a-z]+$`}},
+		{name: "pattern on int", col: ColumnDef{Type: ColumnTypeInt, Pattern: `^1$`}, wantErr: "pattern is not supported on a column of type 'int'"},
+		{name: "pattern does not compile", col: ColumnDef{Type: ColumnTypeString, Pattern: `[a-`}, wantErr: "invalid pattern"},
+		{name: "format hint only", col: ColumnDef{Type: ColumnTypeString, Format: "pdf"}},
+		{name: "validate email", col: ColumnDef{Type: ColumnTypeString, Format: ColumnFormatEmail, ValidateFormat: true}},
+		{name: "validate without format", col: ColumnDef{Type: ColumnTypeString, ValidateFormat: true}, wantErr: "validate_format requires 'format'"},
+		{name: "validate unsupported format", col: ColumnDef{Type: ColumnTypeString, Format: "pdf", ValidateFormat: true}, wantErr: `format "pdf" cannot be validated`},
+		{name: "validate on map", col: ColumnDef{Type: "map[string]any", Format: ColumnFormatJSON, ValidateFormat: true}, wantErr: "validate_format is not supported"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.col.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckFormat(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		format string
+		value  string
+		valid  bool
+	}{
+		{ColumnFormatEmail, "ada@example.com", true},
+		{ColumnFormatEmail, "Ada <ada@example.com>", false},
+		{ColumnFormatEmail, "ada.example.com", false},
+		{ColumnFormatURI, "https://example.com/a?b=c", true},
+		{ColumnFormatURI, "mailto:ada@example.com", true},
+		{ColumnFormatURI, "/relative/path", false},
+		{ColumnFormatURI, "http://[::1", false},
+		{ColumnFormatJSON, `{"a": [1, 2]}`, true},
+		{ColumnFormatJSON, `{"a": }`, false},
+		{ColumnFormatYAML, "a:\n  - 1\n", true},
+		{ColumnFormatYAML, "a: [1\n", false},
+		{ColumnFormatMarkdown, "# Title\n\nBody", true},
+		{ColumnFormatMarkdown, "---\ntitle: x\n---\nBody", true},
+		{ColumnFormatMarkdown, "---\ntitle: x\nBody", false},
+		{ColumnFormatMarkdown, "\xff", false},
+		{"pdf", "anything", false},
+	}
+	for _, tc := range cases {
+		err := CheckFormat(tc.format, tc.value)
+		if (err == nil) != tc.valid {
+			t.Errorf("CheckFormat(%q, %q) = %v, want valid=%v", tc.format, tc.value, err, tc.valid)
+		}
+	}
+}
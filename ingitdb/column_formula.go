@@ -86,14 +86,7 @@ func validateComputedColumnName(collectionID, colName string) error {
 // is what turns a reference to one into "undefined: X", which is remapped here
 // into a message naming the actual problem.
 func validateFormulaExpr(collectionID, colName, kind, expr string, columns map[string]*ColumnDef) error {
-	stored := make([]string, 0, len(columns))
-	for name, def := range columns {
-		if def.Formula == "" {
-			stored = append(stored, name)
-		}
-	}
-
-	if _, err := compileFormulaStrict(expr, stored); err != nil {
+	if err := resolveStoredExpr(expr, columns); err != nil {
 		if ref := computedColumnReference(err, columns); ref != "" {
 			return fmt.Errorf("collection '%s': %s for column '%s' references computed column '%s': a %s may reference only stored fields",
 				collectionID, kind, colName, ref, kind)
@@ -103,6 +96,19 @@ func validateFormulaExpr(collectionID, colName, kind, expr string, columns map[s
 	return nil
 }
 
+// resolveStoredExpr compiles expr with only the stored columns of columns
+// predeclared; see validateFormulaExpr.
+func resolveStoredExpr(expr string, columns map[string]*ColumnDef) error {
+	stored := make([]string, 0, len(columns))
+	for name, def := range columns {
+		if def.Formula == "" {
+			stored = append(stored, name)
+		}
+	}
+	_, err := compileFormulaStrict(expr, stored)
+	return err
+}
+
 // computedColumnReference reports which computed column an "undefined: X"
 // resolver error refers to, or "" if the undefined name is not a computed
 // column of this collection.
@@ -3,6 +3,7 @@ package ingitdb
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestEvaluateFormula(t *testing.T) {
@@ -365,6 +366,12 @@ func TestEvaluateFormulaUnsupportedFieldType(t *testing.T) {
 	if err == nil {
 		t.Fatal("expected error for unsupported field type")
 	}
+	// YAML timestamps are not bound: formula, required_when and where must
+	// not silently compare them as strings.
+	fields = map[string]any{"d": time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}
+	if _, err = EvaluateFormula("d", fields); err == nil {
+		t.Fatal("expected error for time.Time field")
+	}
 }
 
 // TestEvaluateFormulaUnsupportedResultType ensures a result type with no Go
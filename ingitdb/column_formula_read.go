@@ -0,0 +1,109 @@
+package ingitdb
+
+// specscore: feature/column-validation
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+)
+
+// HasComputedColumns reports whether any column of col declares a formula.
+func HasComputedColumns(col *CollectionDef) bool {
+	for _, def := range col.Columns {
+		if def.Formula != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// EvaluateComputedColumns sets every computed column of a record read from
+// col: it evaluates the column's formula over the record's stored fields and
+// coerces the result to the column's declared type. It returns a copy of data
+// with the computed values added; data itself is not modified.
+//
+// Every stored column is bound, taking the record's value where present and
+// None otherwise — the rule required_when and where follow — so a formula
+// sees the same names for every record. Columns are evaluated in name order.
+//
+// A formula that fails, or whose result cannot be coerced, leaves its column
+// nil and is reported as a ValidationError naming the record and the column,
+// rather than silently dropping the value.
+func EvaluateComputedColumns(col *CollectionDef, recordKey string, data map[string]any) (map[string]any, []ValidationError) {
+	if !HasComputedColumns(col) {
+		return data, nil
+	}
+	stored := make(map[string]any, len(col.Columns))
+	for name, def := range col.Columns {
+		if def.Formula == "" {
+			stored[name] = data[name]
+		}
+	}
+	out := make(map[string]any, len(data)+len(col.Columns)-len(stored))
+	maps.Copy(out, data)
+	var errs []ValidationError
+	for _, name := range slices.Sorted(maps.Keys(col.Columns)) {
+		def := col.Columns[name]
+		if def.Formula == "" {
+			continue
+		}
+		value, err := EvaluateFormula(def.Formula, stored)
+		if err == nil {
+			value, err = coerceComputedValue(value, def.Type)
+		}
+		if err != nil {
+			out[name] = nil
+			errs = append(errs, ValidationError{
+				Severity:     SeverityError,
+				CollectionID: col.ID,
+				RecordKey:    recordKey,
+				FieldName:    name,
+				Message:      fmt.Sprintf("failed to compute column %q", name),
+				Err:          err,
+			})
+			continue
+		}
+		out[name] = value
+	}
+	return out, errs
+}
+
+// coerceComputedValue converts a formula result (see EvaluateFormula) to a
+// computed column's declared type. An int result widens to float; a float
+// result narrows to int only when it is whole, so `a / b` into an int column
+// fails unless it divides evenly. None stays nil for every type.
+func coerceComputedValue(value any, t ColumnType) (any, error) {
+	if value == nil || t == ColumnTypeAny {
+		return value, nil
+	}
+	switch t {
+	case ColumnTypeString:
+		if _, ok := value.(string); ok {
+			return value, nil
+		}
+	case ColumnTypeBool:
+		if _, ok := value.(bool); ok {
+			return value, nil
+		}
+	case ColumnTypeInt:
+		switch v := value.(type) {
+		case int64:
+			return v, nil
+		case float64:
+			if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+				return int64(v), nil
+			}
+			return nil, fmt.Errorf("result %v is not a whole number", v)
+		}
+	case ColumnTypeFloat:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int64:
+			return float64(v), nil
+		}
+	}
+	return nil, fmt.Errorf("result of type %T cannot be stored in a %s column", value, t)
+}
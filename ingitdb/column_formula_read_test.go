@@ -0,0 +1,77 @@
+package ingitdb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluateComputedColumns(t *testing.T) {
+	t.Parallel()
+
+	col := &CollectionDef{
+		ID: "orders",
+		Columns: map[string]*ColumnDef{
+			"qty":      {Type: ColumnTypeInt},
+			"price":    {Type: ColumnTypeFloat},
+			"note":     {Type: ColumnTypeString},
+			"total":    {Type: ColumnTypeFloat, Formula: "qty * price"},
+			"halves":   {Type: ColumnTypeInt, Formula: "qty / 2"},
+			"count":    {Type: ColumnTypeFloat, Formula: "qty"},
+			"has_note": {Type: ColumnTypeBool, Formula: "note != None"},
+		},
+	}
+	data := map[string]any{"qty": 4, "price": 2.5}
+	out, errs := EvaluateComputedColumns(col, "o1", data)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	want := map[string]any{"total": 10.0, "halves": int64(2), "count": 4.0, "has_note": false}
+	for name, value := range want {
+		if out[name] != value {
+			t.Errorf("%s = %#v, want %#v", name, out[name], value)
+		}
+	}
+	if _, mutated := data["total"]; mutated {
+		t.Error("input record must not be modified")
+	}
+}
+
+func TestEvaluateComputedColumns_ReportsFailures(t *testing.T) {
+	t.Parallel()
+
+	col := &CollectionDef{
+		ID: "orders",
+		Columns: map[string]*ColumnDef{
+			"qty":    {Type: ColumnTypeInt},
+			"halves": {Type: ColumnTypeInt, Formula: "qty / 2"},
+			"label":  {Type: ColumnTypeString, Formula: "qty + 1"},
+			"boom":   {Type: ColumnTypeInt, Formula: "qty // 0"},
+		},
+	}
+	out, errs := EvaluateComputedColumns(col, "o1", map[string]any{"qty": 3})
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", errs)
+	}
+	for i, field := range []string{"boom", "halves", "label"} { // name order
+		e := errs[i]
+		if e.FieldName != field || e.RecordKey != "o1" || e.CollectionID != "orders" || e.Err == nil {
+			t.Errorf("error %d = %+v, want field %s of orders/o1 with a cause", i, e, field)
+		}
+		if out[field] != nil {
+			t.Errorf("%s = %v, want nil after a failure", field, out[field])
+		}
+	}
+	if !strings.Contains(errs[1].Err.Error(), "not a whole number") {
+		t.Errorf("halves: unexpected cause %v", errs[1].Err)
+	}
+}
+
+func TestEvaluateComputedColumns_NoComputedColumns(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]any{"a": 1}
+	out, errs := EvaluateComputedColumns(&CollectionDef{Columns: map[string]*ColumnDef{"a": {Type: ColumnTypeInt}}}, "k", data)
+	if errs != nil || len(out) != 1 {
+		t.Errorf("got %v, %v; want the record back unchanged", out, errs)
+	}
+}
@@ -403,10 +403,28 @@ func readRootCollectionsFromFile(dirPath string, _ ingitdb.ReadOptions, readFile
 // optionally validates, and resolves namespace imports.
 // Missing files are not errors; zero-values are used instead.
 func ReadRootConfigFromFile(dirPath string, o ingitdb.ReadOptions) (RootConfig, error) {
-	return readRootConfigFromFile(dirPath, o, os.ReadFile)
+	return readRootConfigFromFile(dirPath, o, os.ReadFile, osStat)
 }
 
-func readRootConfigFromFile(dirPath string, o ingitdb.ReadOptions, readFile func(string) ([]byte, error)) (rootConfig RootConfig, err error) {
+// FileSystem is the read access ReadRootConfigFromFS needs, over OS paths.
+type FileSystem interface {
+	ReadFile(name string) ([]byte, error)
+	Stat(name string) (os.FileInfo, error)
+}
+
+// ReadRootConfigFromFS is ReadRootConfigFromFile reading through fsys instead
+// of the OS, e.g. from a git tree. Missing files must be reported with errors
+// satisfying os.IsNotExist.
+func ReadRootConfigFromFS(dirPath string, o ingitdb.ReadOptions, fsys FileSystem) (RootConfig, error) {
+	return readRootConfigFromFile(dirPath, o, fsys.ReadFile, fsys.Stat)
+}
+
+func readRootConfigFromFile(
+	dirPath string,
+	o ingitdb.ReadOptions,
+	readFile func(string) ([]byte, error),
+	statFn func(string) (os.FileInfo, error),
+) (rootConfig RootConfig, err error) {
 	defer func() {
 		r := recover()
 		if r != nil {
@@ -439,7 +457,7 @@ func readRootConfigFromFile(dirPath string, o ingitdb.ReadOptions, readFile func
 	}
 
 	// Resolve namespace imports after validation
-	if err = rootConfig.resolveNamespaceImports(dirPath, os.UserHomeDir, readFile, osStat); err != nil {
+	if err = rootConfig.resolveNamespaceImports(dirPath, os.UserHomeDir, readFile, statFn); err != nil {
 		return rootConfig, fmt.Errorf("failed to resolve namespace imports: %w", err)
 	}
 
@@ -1017,7 +1017,7 @@ func TestReadRootConfigFromFile_PanicRecovery(t *testing.T) {
 		panic("boom")
 	}
 
-	_, err := readRootConfigFromFile("irrelevant", ingitdb.NewReadOptions(), readFile)
+	_, err := readRootConfigFromFile("irrelevant", ingitdb.NewReadOptions(), readFile, osStat)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -1153,7 +1153,7 @@ func TestReadRootConfigFromFile_RootCollectionsReadError(t *testing.T) {
 		return nil, ioErr
 	}
 
-	_, err := readRootConfigFromFile("dir", ingitdb.NewReadOptions(), readFile)
+	_, err := readRootConfigFromFile("dir", ingitdb.NewReadOptions(), readFile, osStat)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 )
 
 // recordsKey is the key under which a parsed CSV list of rows is exposed
@@ -24,49 +25,58 @@ const recordsKey = "$records"
 // declared as returning map[string]any) without losing list-of-records
 // semantics — the caller unwraps via the recordsKey constant.
 func parseCSVForCollection(content []byte, colDef *CollectionDef) (map[string]any, error) {
+	var rows []map[string]any
+	err := streamCSVRows(bytes.NewReader(content), colDef, func(row map[string]any) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{recordsKey: rows}, nil
+}
+
+// streamCSVRows is parseCSVForCollection's decoder: it checks the header and
+// then calls yield once per data row as it is read.
+func streamCSVRows(r io.Reader, colDef *CollectionDef, yield func(row map[string]any) error) error {
 	if len(colDef.ColumnsOrder) == 0 {
-		return nil, fmt.Errorf("csv read requires non-empty columns_order on the collection definition")
+		return fmt.Errorf("csv read requires non-empty columns_order on the collection definition")
 	}
-	r := csv.NewReader(bytes.NewReader(content))
-	header, err := r.Read()
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true // each row is copied into its own map below
+	header, err := cr.Read()
 	if err == io.EOF {
-		return nil, fmt.Errorf("csv input is empty (expected header row)")
+		return fmt.Errorf("csv input is empty (expected header row)")
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to read csv header: %w", err)
+		return fmt.Errorf("failed to read csv header: %w", err)
 	}
+	header = slices.Clone(header)
 	if err = validateCSVHeader(header, colDef.ColumnsOrder); err != nil {
-		return nil, err
+		return err
 	}
-	var rows []map[string]any
-	for {
-		fields, readErr := r.Read()
+	for rowNum := 1; ; rowNum++ {
+		fields, readErr := cr.Read()
 		if readErr == io.EOF {
-			break
+			return nil
 		}
 		if readErr != nil {
 			// The default csv.Reader locks the field count to the header row, so a
 			// row with a different number of columns surfaces here as ErrFieldCount.
 			if errors.Is(readErr, csv.ErrFieldCount) {
-				return nil, fmt.Errorf("csv row %d has %d columns, header has %d",
-					len(rows)+1, len(fields), len(header))
+				return fmt.Errorf("csv row %d has %d columns, header has %d",
+					rowNum, len(fields), len(header))
 			}
-			return nil, fmt.Errorf("failed to read csv row %d: %w", len(rows)+1, readErr)
+			return fmt.Errorf("failed to read csv row %d: %w", rowNum, readErr)
 		}
-		// The explicit column-count guard below is unreachable: csv.ErrFieldCount
-		// is already handled above for any mismatched row, so len(fields) always
-		// equals len(header) here. Kept commented as documentation.
-		// if len(fields) != len(header) {
-		// 	return nil, fmt.Errorf("csv row %d has %d columns, header has %d",
-		// 		len(rows)+1, len(fields), len(header))
-		// }
 		row := make(map[string]any, len(header))
 		for i, col := range header {
 			row[col] = fields[i]
 		}
-		rows = append(rows, row)
+		if err = yield(row); err != nil {
+			return err
+		}
 	}
-	return map[string]any{recordsKey: rows}, nil
 }
 
 // validateCSVHeader returns an error when header does not match expected
@@ -0,0 +1,267 @@
+package datavalidator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// DefaultCacheDir is where WithCache keeps the validation cache, relative to
+// the database root.
+const DefaultCacheDir = ".ingitdb/cache"
+
+// validationCacheFile is the cache's file name inside the cache directory.
+const validationCacheFile = "validation.json"
+
+// validationCacheVersion is stored in the cache file and folded into every
+// definition hash. Bump it whenever a change to this package can alter the
+// findings for an unchanged file and definition, so stale entries are dropped.
+const validationCacheVersion = 2
+
+// WithCache enables the persistent validation cache under DefaultCacheDir in
+// the database being validated.
+func WithCache() ValidatorOption {
+	return func(sv *simpleValidator) {
+		sv.cache = true
+	}
+}
+
+// WithCacheDir enables the persistent validation cache in dir.
+func WithCacheDir(dir string) ValidatorOption {
+	return func(sv *simpleValidator) {
+		sv.cache = true
+		sv.cacheDir = dir
+	}
+}
+
+// validationCache remembers the schema-pass findings of each record file. An
+// entry is reused when the file's content hash and its collection's definition
+// hash both match, so an unchanged file is not parsed again even without a git
+// range to diff. The definition is hashed after loading, with any `inherits`
+// base already merged in, so editing definition.yaml or a base it inherits
+// from invalidates every file of the collection.
+//
+// Only the per-file schema pass is cached. Record-count bounds, subcollections
+// and foreign keys depend on other files and are always evaluated.
+//
+// The cache is an optimisation: a missing, unreadable or outdated cache file is
+// treated as empty, and failing to write it never fails validation.
+type validationCache struct {
+	path  string
+	files recordFiles // where the validated record files are read from
+
+	prev validationCacheData
+
+	mu   sync.Mutex
+	next validationCacheData
+}
+
+type validationCacheData struct {
+	Version     int                          `json:"version"`
+	Collections map[string]*cachedCollection `json:"collections"`
+}
+
+type cachedCollection struct {
+	DefinitionHash string                 `json:"definition_hash"`
+	Files          map[string]*cachedFile `json:"files"` // keyed by path relative to the collection dir
+}
+
+type cachedFile struct {
+	ContentHash string          `json:"content_hash"`
+	Passed      int             `json:"passed"`
+	Total       int             `json:"total"`
+	Findings    []cachedFinding `json:"findings,omitempty"`
+}
+
+// cachedFinding is a ValidationError without its location, which is implied by
+// the entry it belongs to. The wrapped cause survives only as its message.
+type cachedFinding struct {
+	Severity  ingitdb.Severity `json:"severity"`
+	RecordKey string           `json:"record_key,omitempty"`
+	FieldName string           `json:"field_name,omitempty"`
+	Message   string           `json:"message"`
+	Cause     string           `json:"cause,omitempty"`
+}
+
+// loadValidationCache reads the cache in dir, starting empty when there is
+// none or it cannot be used.
+func loadValidationCache(dir string, files recordFiles) *validationCache {
+	c := &validationCache{
+		path:  filepath.Join(dir, validationCacheFile),
+		files: files,
+		next:  validationCacheData{Version: validationCacheVersion, Collections: map[string]*cachedCollection{}},
+	}
+	content, err := os.ReadFile(c.path)
+	if err != nil {
+		return c
+	}
+	if err = json.Unmarshal(content, &c.prev); err != nil || c.prev.Version != validationCacheVersion {
+		c.prev = validationCacheData{}
+	}
+	return c
+}
+
+// wrap returns validate backed by the cache for one collection. Files whose
+// hash cannot be computed, and collections whose definition cannot be hashed,
+// bypass the cache. parse, when not nil, is called with each file whose
+// findings are served from the cache, for a caller that needs its records.
+func (c *validationCache) wrap(
+	collectionKey string,
+	colDef *ingitdb.CollectionDef,
+	validate func(string) (int, int, []ingitdb.ValidationError),
+	parse func(string),
+) func(string) (int, int, []ingitdb.ValidationError) {
+	defHash, err := collectionDefinitionHash(colDef)
+	if err != nil {
+		return validate
+	}
+	var prevFiles map[string]*cachedFile
+	if prev := c.prev.Collections[collectionKey]; prev != nil && prev.DefinitionHash == defHash {
+		prevFiles = prev.Files
+	}
+	c.mu.Lock()
+	next := &cachedCollection{DefinitionHash: defHash, Files: map[string]*cachedFile{}}
+	c.next.Collections[collectionKey] = next
+	c.mu.Unlock()
+
+	return func(filePath string) (int, int, []ingitdb.ValidationError) {
+		rel, relErr := filepath.Rel(colDef.DirPath, filePath)
+		contentHash, hashErr := c.files.fileContentHash(filePath)
+		if relErr != nil || hashErr != nil {
+			return validate(filePath)
+		}
+		rel = filepath.ToSlash(rel)
+		if hit := prevFiles[rel]; hit != nil && hit.ContentHash == contentHash {
+			c.store(next, rel, hit)
+			if parse != nil {
+				parse(filePath)
+			}
+			return hit.Passed, hit.Total, hit.findings(collectionKey, filePath)
+		}
+		passed, total, errs := validate(filePath)
+		if entry, ok := newCachedFile(collectionKey, filePath, contentHash, passed, total, errs); ok {
+			c.store(next, rel, entry)
+		}
+		return passed, total, errs
+	}
+}
+
+func (c *validationCache) store(col *cachedCollection, rel string, entry *cachedFile) {
+	c.mu.Lock()
+	col.Files[rel] = entry
+	c.mu.Unlock()
+}
+
+// save writes the entries used or produced by this run, dropping those of
+// files and collections that no longer exist. The file is replaced atomically
+// so a concurrent reader never sees it half-written. The directory gets a
+// .gitignore so the cache is never committed.
+func (c *validationCache) save() error {
+	c.mu.Lock()
+	content, err := json.Marshal(c.next)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(c.path)
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	gitignore := filepath.Join(dir, ".gitignore")
+	if _, statErr := os.Stat(gitignore); errors.Is(statErr, os.ErrNotExist) {
+		if err = os.WriteFile(gitignore, []byte("*\n"), 0o644); err != nil {
+			return err
+		}
+	}
+	tmp, err := os.CreateTemp(dir, validationCacheFile+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// newCachedFile converts one file's findings into a cache entry. ok is false
+// when a finding points somewhere other than the file itself, which the entry
+// could not restore faithfully.
+func newCachedFile(collectionKey, filePath, contentHash string, passed, total int, errs []ingitdb.ValidationError) (*cachedFile, bool) {
+	entry := &cachedFile{ContentHash: contentHash, Passed: passed, Total: total}
+	for _, e := range errs {
+		if e.CollectionID != collectionKey || e.FilePath != filePath {
+			return nil, false
+		}
+		finding := cachedFinding{Severity: e.Severity, RecordKey: e.RecordKey, FieldName: e.FieldName, Message: e.Message}
+		if e.Err != nil {
+			finding.Cause = e.Err.Error()
+		}
+		entry.Findings = append(entry.Findings, finding)
+	}
+	return entry, true
+}
+
+func (f *cachedFile) findings(collectionKey, filePath string) []ingitdb.ValidationError {
+	if len(f.Findings) == 0 {
+		return nil
+	}
+	errs := make([]ingitdb.ValidationError, len(f.Findings))
+	for i, finding := range f.Findings {
+		errs[i] = ingitdb.ValidationError{
+			Severity:     finding.Severity,
+			CollectionID: collectionKey,
+			FilePath:     filePath,
+			RecordKey:    finding.RecordKey,
+			FieldName:    finding.FieldName,
+			Message:      finding.Message,
+		}
+		if finding.Cause != "" {
+			errs[i].Err = errors.New(finding.Cause)
+		}
+	}
+	return errs
+}
+
+// collectionDefinitionHash hashes the loaded collection definition. JSON is
+// used because it covers every schema field without a hand-kept list and
+// sorts map keys; DirPath and the subcollection, view and trigger maps are
+// excluded by their json tags and do not affect the per-file pass.
+func collectionDefinitionHash(colDef *ingitdb.CollectionDef) (string, error) {
+	content, err := json.Marshal(struct {
+		Version int                    `json:"v"`
+		Def     *ingitdb.CollectionDef `json:"def"`
+	}{validationCacheVersion, colDef})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// fileContentHash hashes a file without holding it in memory.
+func (files recordFiles) fileContentHash(filePath string) (string, error) {
+	f, err := files.open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
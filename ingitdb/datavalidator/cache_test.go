@@ -0,0 +1,112 @@
+package datavalidator
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// rewriteCachedMessages replaces every cached finding's message, so a later
+// run that reports the replacement provably came from the cache.
+func rewriteCachedMessages(t *testing.T, cacheDir, message string) {
+	t.Helper()
+	path := filepath.Join(cacheDir, validationCacheFile)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read cache: %v", err)
+	}
+	var data validationCacheData
+	if err = json.Unmarshal(content, &data); err != nil {
+		t.Fatalf("parse cache: %v", err)
+	}
+	for _, col := range data.Collections {
+		for _, f := range col.Files {
+			for i := range f.Findings {
+				f.Findings[i].Message = message
+			}
+		}
+	}
+	if content, err = json.Marshal(data); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func messages(result *ingitdb.ValidationResult) []string {
+	var out []string
+	for _, e := range result.Errors() {
+		out = append(out, e.Message)
+	}
+	return out
+}
+
+func TestValidate_CacheReusesFindingsForUnchangedFiles(t *testing.T) {
+	t.Parallel()
+
+	def := taskTestDef(t) // countries: ie.yaml valid, fr.yaml has a wrong type
+	cacheDir := t.TempDir()
+	validate := func() *ingitdb.ValidationResult {
+		t.Helper()
+		result, err := NewValidator(WithCacheDir(cacheDir)).Validate(context.Background(), "", def)
+		if err != nil {
+			t.Fatalf("Validate: %v", err)
+		}
+		return result
+	}
+
+	first := validate()
+	if first.ErrorCount() != 1 {
+		t.Fatalf("expected 1 finding, got %v", first.Errors())
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, ".gitignore")); err != nil {
+		t.Errorf("cache dir should be git-ignored: %v", err)
+	}
+
+	rewriteCachedMessages(t, cacheDir, "from cache")
+	second := validate()
+	if got := messages(second); len(got) != 1 || got[0] != "from cache" {
+		t.Fatalf("unchanged file should be served from the cache, got %v", got)
+	}
+	if passed, total := second.GetRecordCounts("countries"); passed != 1 || total != 2 {
+		t.Errorf("cached counts = %d/%d, want 1/2", passed, total)
+	}
+
+	// Changing the file's content invalidates its entry.
+	recordsDir := filepath.Join(def.Collections["countries"].DirPath, "$records")
+	if err := os.WriteFile(filepath.Join(recordsDir, "fr.yaml"), []byte("name: 124\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(validate()); len(got) != 1 || got[0] == "from cache" {
+		t.Fatalf("a changed file must be re-validated, got %v", got)
+	}
+
+	// Changing the definition invalidates every entry of the collection.
+	rewriteCachedMessages(t, cacheDir, "from cache")
+	def.Collections["countries"].Columns["name"].Required = true
+	if got := messages(validate()); len(got) != 1 || got[0] == "from cache" {
+		t.Fatalf("a changed definition must invalidate the cache, got %v", got)
+	}
+}
+
+func TestValidate_CacheIgnoresCorruptFile(t *testing.T) {
+	t.Parallel()
+
+	def := taskTestDef(t)
+	cacheDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(cacheDir, validationCacheFile), []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := NewValidator(WithCacheDir(cacheDir)).Validate(context.Background(), "", def)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if result.ErrorCount() != 1 {
+		t.Errorf("expected 1 finding, got %v", result.Errors())
+	}
+}
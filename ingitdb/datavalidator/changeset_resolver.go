@@ -3,81 +3,258 @@ package datavalidator
 // specscore: feature/cli/validate
 
 import (
+	"maps"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/gitdiff"
 )
 
 // NewChangeSetResolver returns the default ChangeSetResolver, which maps changed
-// files to the collection records they belong to. It assumes the database
-// directory is the git repository root (changed-file paths are joined onto it).
-func NewChangeSetResolver() ChangeSetResolver {
-	return changeSetResolver{}
+// files to the collection records they belong to from their paths alone. It
+// assumes the database directory is the git repository root (changed-file
+// paths are joined onto it).
+//
+// Given WithGitFileReader, the resolver is also a RecordChangeResolver that
+// reads changed map/list files at both refs to narrow them to their changed
+// records.
+func NewChangeSetResolver(opts ...ChangeSetResolverOption) ChangeSetResolver {
+	var o changeSetResolverOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.reader == nil {
+		return changeSetResolver{}
+	}
+	return recordChangeSetResolver{reader: o.reader, singleRecordFields: o.singleRecordFields}
+}
+
+// ChangeSetResolverOption configures the resolver NewChangeSetResolver returns.
+type ChangeSetResolverOption func(*changeSetResolverOptions)
+
+type changeSetResolverOptions struct {
+	reader             gitdiff.GitFileReader
+	singleRecordFields bool
+}
+
+// WithGitFileReader enables record-level resolution: ResolveRecords reads
+// changed files at a ref through reader.
+func WithGitFileReader(reader gitdiff.GitFileReader) ChangeSetResolverOption {
+	return func(o *changeSetResolverOptions) {
+		o.reader = reader
+	}
+}
+
+// WithSingleRecordFields makes ResolveRecords also read changed single-record
+// files at both refs to fill in their Fields. Validation has no use for them,
+// so they are left out by default. It has no effect without WithGitFileReader.
+func WithSingleRecordFields() ChangeSetResolverOption {
+	return func(o *changeSetResolverOptions) {
+		o.singleRecordFields = true
+	}
 }
 
 type changeSetResolver struct{}
 
-// Resolve maps each changed file to the collection record it affects. Deleted
-// files are skipped (there is nothing to open; orphaned-reference checks are
-// out of scope). For single-record layouts the affected record key is derived
-// from the file name; for map/list layouts the whole shared file is marked
-// affected (RecordKey == "").
+// Resolve maps each changed file to the collection record it affects. For
+// single-record layouts the affected record key is derived from the file name;
+// for map/list layouts the whole shared file is marked affected
+// (RecordKey == ""). Deleted files are reported too, with ChangeKindDeleted:
+// there is nothing left to validate in them, but whatever still references
+// their records is now orphaned. A renamed record file is reported as its new
+// path plus, when the old path was a record file, a deletion of the old one.
 func (changeSetResolver) Resolve(dbPath string, def *ingitdb.Definition, changedFiles []ingitdb.ChangedFile) ([]AffectedRecord, error) {
 	var affected []AffectedRecord
 	for _, cf := range changedFiles {
-		if cf.Kind == ingitdb.ChangeKindDeleted {
-			continue
-		}
-		absPath := filepath.Clean(filepath.Join(dbPath, cf.Path))
-		colID, colDef := CollectionForRecordFile(def, absPath)
-		if colDef == nil {
-			continue
-		}
-		switch colDef.RecordFile.RecordType {
-		case ingitdb.SingleRecord:
-			affected = append(affected, AffectedRecord{
-				CollectionID: colID,
-				FilePath:     absPath,
-				RecordKey:    recordKeyFromFilePath(absPath),
-				ChangeKind:   cf.Kind,
-			})
-		case ingitdb.MapOfRecords, ingitdb.ListOfRecords:
-			affected = append(affected, AffectedRecord{
-				CollectionID: colID,
-				FilePath:     absPath,
-				RecordKey:    "",
-				ChangeKind:   cf.Kind,
-			})
+		if cf.Kind == ingitdb.ChangeKindRenamed && cf.OldPath != "" {
+			affected = append(affected, resolveChangedFile(dbPath, def, ingitdb.ChangedFile{
+				Kind: ingitdb.ChangeKindDeleted,
+				Path: cf.OldPath,
+			})...)
 		}
+		affected = append(affected, resolveChangedFile(dbPath, def, cf)...)
 	}
 	return affected, nil
 }
 
+// resolveChangedFile maps one changed file to the record it affects, if any.
+func resolveChangedFile(dbPath string, def *ingitdb.Definition, cf ingitdb.ChangedFile) []AffectedRecord {
+	absPath := filepath.Clean(filepath.Join(dbPath, cf.Path))
+	owner, ok := resolveRecordFileOwner(def, absPath)
+	if !ok {
+		return nil
+	}
+	ar := AffectedRecord{
+		CollectionID: owner.fullID,
+		FilePath:     absPath,
+		ChangeKind:   cf.Kind,
+		Parents:      owner.parents,
+	}
+	if owner.colDef.RecordFile.RecordType == ingitdb.SingleRecord {
+		ar.RecordKey = recordKeyFromFilePath(absPath)
+	}
+	return []AffectedRecord{ar}
+}
+
 // CollectionForRecordFile returns the collection (and its ID) that owns absPath
 // as a record file, or ("", nil) when no collection's record-file layout
 // matches. It mirrors how the full validator enumerates record files, so the
 // incremental validator, change-set resolver, and `diff` all agree on which
 // files are records.
+//
+// A subcollection record file resolves to the subcollection's full path
+// (e.g. "orders/order_details") and to a copy of its definition whose DirPath
+// points at the owning parent record's instance.
 func CollectionForRecordFile(def *ingitdb.Definition, absPath string) (string, *ingitdb.CollectionDef) {
+	owner, ok := resolveRecordFileOwner(def, absPath)
+	if !ok {
+		return "", nil
+	}
+	return owner.fullID, owner.colDef
+}
+
+// RecordFilePatterns returns, by collection full path, the filepath.Match
+// pattern of each collection's record files: the files CollectionForRecordFile
+// resolves to it. A subcollection's pattern matches every instance, with a "*"
+// in place of each parent record key. Collections whose records are not
+// parsed are left out.
+func RecordFilePatterns(def *ingitdb.Definition) map[string]string {
+	patterns := map[string]string{}
 	for id, colDef := range def.Collections {
-		if shouldSkipRecordParsing(colDef) {
-			continue
-		}
+		addRecordFilePatterns(patterns, id, colDef)
+	}
+	return patterns
+}
+
+func addRecordFilePatterns(patterns map[string]string, fullID string, colDef *ingitdb.CollectionDef) {
+	if colDef == nil {
+		return
+	}
+	if !shouldSkipRecordParsing(colDef) {
 		switch colDef.RecordFile.RecordType {
 		case ingitdb.SingleRecord:
-			pattern, err := singleRecordGlobPattern(colDef)
-			if err != nil {
-				continue
-			}
-			matched, matchErr := filepath.Match(filepath.Clean(pattern), absPath)
-			if matchErr == nil && matched && !skipRecordPath(absPath, colDef.RecordFile) {
-				return id, colDef
+			if pattern, err := singleRecordGlobPattern(colDef); err == nil {
+				patterns[fullID] = pattern
 			}
 		case ingitdb.MapOfRecords, ingitdb.ListOfRecords:
-			if filepath.Clean(collectionRecordFilePath(colDef)) == absPath {
-				return id, colDef
-			}
+			patterns[fullID] = collectionRecordFilePath(colDef)
+		}
+	}
+	for subID, sub := range colDef.SubCollections {
+		if sub == nil {
+			continue
+		}
+		inst := *sub
+		inst.DirPath = subCollectionDataDir(colDef, "*", subID)
+		addRecordFilePatterns(patterns, fullID+"/"+subID, &inst)
+	}
+}
+
+// recordFileOwner is the collection a record file belongs to: a root
+// collection, or a subcollection instance together with its parent chain.
+type recordFileOwner struct {
+	fullID  string
+	colDef  *ingitdb.CollectionDef
+	parents []ParentRecord
+}
+
+func resolveRecordFileOwner(def *ingitdb.Definition, absPath string) (recordFileOwner, bool) {
+	for id, colDef := range def.Collections {
+		if matchesRecordFile(colDef, absPath) {
+			return recordFileOwner{fullID: id, colDef: colDef}, true
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(def.Collections)) {
+		if owner, ok := matchSubCollectionFile(id, def.Collections[id], absPath, nil); ok {
+			return owner, true
+		}
+	}
+	return recordFileOwner{}, false
+}
+
+// matchesRecordFile reports whether absPath is one of colDef's record files.
+func matchesRecordFile(colDef *ingitdb.CollectionDef, absPath string) bool {
+	if shouldSkipRecordParsing(colDef) {
+		return false
+	}
+	switch colDef.RecordFile.RecordType {
+	case ingitdb.SingleRecord:
+		pattern, err := singleRecordGlobPattern(colDef)
+		if err != nil {
+			return false
+		}
+		matched, matchErr := filepath.Match(filepath.Clean(pattern), absPath)
+		return matchErr == nil && matched && !skipRecordPath(absPath, colDef.RecordFile)
+	case ingitdb.MapOfRecords, ingitdb.ListOfRecords:
+		return filepath.Clean(collectionRecordFilePath(colDef)) == absPath
+	}
+	return false
+}
+
+// matchSubCollectionFile resolves absPath against the subcollections of
+// colDef, a collection whose DirPath points at its data. The path is split the
+// way subCollectionDataDir lays instances out —
+// <parent records dir>/<parentKey>/<subID>/... — and matched against that
+// instance, then against its own subcollections, to any depth. The parent
+// record need not exist: a file left under a deleted parent still resolves.
+func matchSubCollectionFile(fullID string, colDef *ingitdb.CollectionDef, absPath string, parents []ParentRecord) (recordFileOwner, bool) {
+	if colDef == nil || len(colDef.SubCollections) == 0 {
+		return recordFileOwner{}, false
+	}
+	base := colDef.DirPath
+	if colDef.RecordFile != nil {
+		base = filepath.Join(base, colDef.RecordFile.RecordsBasePath())
+	}
+	rel, err := filepath.Rel(base, absPath)
+	if err != nil {
+		return recordFileOwner{}, false
+	}
+	parts := strings.SplitN(filepath.ToSlash(rel), "/", 3)
+	if len(parts) < 3 || parts[0] == ".." {
+		return recordFileOwner{}, false
+	}
+	parentKey, subID := parts[0], parts[1]
+	sub := colDef.SubCollections[subID]
+	if sub == nil {
+		return recordFileOwner{}, false
+	}
+	inst := *sub // shallow copy: repoint DirPath without mutating the shared definition
+	inst.DirPath = subCollectionDataDir(colDef, parentKey, subID)
+	chain := append(slices.Clone(parents), ParentRecord{CollectionID: fullID, RecordKey: parentKey})
+	subFullID := fullID + "/" + subID
+	if matchesRecordFile(&inst, absPath) {
+		return recordFileOwner{fullID: subFullID, colDef: &inst, parents: chain}, true
+	}
+	return matchSubCollectionFile(subFullID, &inst, absPath, chain)
+}
+
+// affectedCollection returns the definition ar's record belongs to: the root
+// collection, or the subcollection instance its parent chain leads to, with
+// DirPath pointing at that instance's data. It returns nil when the chain does
+// not match the definition.
+func affectedCollection(def *ingitdb.Definition, ar AffectedRecord) *ingitdb.CollectionDef {
+	if len(ar.Parents) == 0 {
+		return def.Collections[ar.CollectionID]
+	}
+	colDef := def.Collections[ar.Parents[0].CollectionID]
+	for i, parent := range ar.Parents {
+		if colDef == nil {
+			return nil
+		}
+		childID := ar.CollectionID
+		if i+1 < len(ar.Parents) {
+			childID = ar.Parents[i+1].CollectionID
+		}
+		subID := strings.TrimPrefix(childID, parent.CollectionID+"/")
+		sub := colDef.SubCollections[subID]
+		if sub == nil {
+			return nil
 		}
+		inst := *sub
+		inst.DirPath = subCollectionDataDir(colDef, parent.RecordKey, subID)
+		colDef = &inst
 	}
-	return "", nil
+	return colDef
 }
@@ -2,6 +2,7 @@ package datavalidator
 
 import (
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/ingitdb/ingitdb-go/ingitdb"
@@ -41,8 +42,8 @@ func TestChangeSetResolver_Resolve(t *testing.T) {
 		{Kind: ingitdb.ChangeKindModified, Path: "people/$records/alice.yaml"},
 		{Kind: ingitdb.ChangeKindAdded, Path: "people/$records/bob.yaml"},
 		{Kind: ingitdb.ChangeKindModified, Path: "tags/tags.yaml"},
-		{Kind: ingitdb.ChangeKindDeleted, Path: "people/$records/carol.yaml"}, // skipped: deletion
-		{Kind: ingitdb.ChangeKindModified, Path: "README.md"},                 // skipped: not a record file
+		{Kind: ingitdb.ChangeKindDeleted, Path: "people/$records/carol.yaml"},
+		{Kind: ingitdb.ChangeKindModified, Path: "README.md"}, // skipped: not a record file
 	}
 
 	got, err := NewChangeSetResolver().Resolve(dbPath, def, changed)
@@ -55,8 +56,8 @@ func TestChangeSetResolver_Resolve(t *testing.T) {
 	for _, ar := range got {
 		byPath[ar.FilePath] = ar
 	}
-	if len(byPath) != 3 {
-		t.Fatalf("expected 3 affected records, got %d: %+v", len(byPath), got)
+	if len(byPath) != 4 {
+		t.Fatalf("expected 4 affected records, got %d: %+v", len(byPath), got)
 	}
 
 	alice := byPath[filepath.Join(dbPath, "people/$records/alice.yaml")]
@@ -67,10 +68,131 @@ func TestChangeSetResolver_Resolve(t *testing.T) {
 	if tags.CollectionID != "tags" || tags.RecordKey != "" {
 		t.Errorf("tags affected = %+v, want collection tags whole-file key \"\"", tags)
 	}
-	if _, ok := byPath[filepath.Join(dbPath, "people/$records/carol.yaml")]; ok {
-		t.Error("deleted file must not be reported as affected")
+	carol := byPath[filepath.Join(dbPath, "people/$records/carol.yaml")]
+	if carol.RecordKey != "carol" || carol.ChangeKind != ingitdb.ChangeKindDeleted {
+		t.Errorf("carol affected = %+v, want key carol reported as deleted", carol)
 	}
 	if _, ok := byPath[filepath.Join(dbPath, "README.md")]; ok {
 		t.Error("non-record file must not be reported as affected")
 	}
 }
+
+func TestChangeSetResolver_RenameDeletesOldRecord(t *testing.T) {
+	t.Parallel()
+
+	dbPath := "/db"
+	def := resolverTestDef(dbPath)
+	affected, err := NewChangeSetResolver().Resolve(dbPath, def, []ingitdb.ChangedFile{
+		{Kind: ingitdb.ChangeKindRenamed, Path: "people/$records/bob.yaml", OldPath: "people/$records/robert.yaml"},
+		{Kind: ingitdb.ChangeKindRenamed, Path: "people/$records/dan.yaml", OldPath: "notes/dan.yaml"},
+	})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if len(affected) != 3 {
+		t.Fatalf("want 3 affected records, got %+v", affected)
+	}
+	if affected[0].RecordKey != "robert" || affected[0].ChangeKind != ingitdb.ChangeKindDeleted {
+		t.Errorf("affected[0] = %+v, want robert reported as deleted", affected[0])
+	}
+	if affected[1].RecordKey != "bob" || affected[1].ChangeKind != ingitdb.ChangeKindRenamed {
+		t.Errorf("affected[1] = %+v, want bob reported as renamed", affected[1])
+	}
+	if affected[2].RecordKey != "dan" {
+		t.Errorf("affected[2] = %+v, want dan; its old path was not a record file", affected[2])
+	}
+}
+
+// Files under a parent record's directory resolve to the subcollection
+// instance, to any depth, with the parent chain outermost first.
+func TestChangeSetResolver_SubCollections(t *testing.T) {
+	t.Parallel()
+
+	dbPath := "/db"
+	notes := &ingitdb.CollectionDef{
+		ID:         "notes",
+		RecordFile: &ingitdb.RecordFileDef{Name: "notes.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.ListOfRecords},
+	}
+	details := &ingitdb.CollectionDef{
+		ID:             "order_details",
+		RecordFile:     &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord},
+		SubCollections: map[string]*ingitdb.CollectionDef{"notes": notes},
+	}
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"orders": {
+			ID:             "orders",
+			DirPath:        filepath.Join(dbPath, "orders"),
+			RecordFile:     &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord},
+			SubCollections: map[string]*ingitdb.CollectionDef{"order_details": details},
+		},
+	}}
+
+	affected, err := NewChangeSetResolver().Resolve(dbPath, def, []ingitdb.ChangedFile{
+		{Kind: ingitdb.ChangeKindAdded, Path: "orders/$records/ord001/order_details/$records/line1.yaml"},
+		{Kind: ingitdb.ChangeKindModified, Path: "orders/$records/ord001/order_details/$records/line1/notes/notes.yaml"},
+		{Kind: ingitdb.ChangeKindModified, Path: "orders/$records/ord001/shipments/$records/s1.yaml"}, // undeclared
+	})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if len(affected) != 2 {
+		t.Fatalf("want 2 affected records, got %+v", affected)
+	}
+	line := affected[0]
+	if line.CollectionID != "orders/order_details" || line.RecordKey != "line1" ||
+		!reflect.DeepEqual(line.Parents, []ParentRecord{{CollectionID: "orders", RecordKey: "ord001"}}) {
+		t.Errorf("line1 affected = %+v", line)
+	}
+	note := affected[1]
+	wantParents := []ParentRecord{
+		{CollectionID: "orders", RecordKey: "ord001"},
+		{CollectionID: "orders/order_details", RecordKey: "line1"},
+	}
+	if note.CollectionID != "orders/order_details/notes" || note.RecordKey != "" || !reflect.DeepEqual(note.Parents, wantParents) {
+		t.Errorf("notes affected = %+v", note)
+	}
+
+	colDef := affectedCollection(def, note)
+	wantDir := filepath.Join(dbPath, "orders", "$records", "ord001", "order_details", "$records", "line1", "notes")
+	if colDef == nil || colDef.DirPath != wantDir {
+		t.Errorf("affectedCollection = %+v, want DirPath %q", colDef, wantDir)
+	}
+	if notes.DirPath != "" {
+		t.Error("resolving an instance must not mutate the shared definition")
+	}
+}
+
+func TestRecordFilePatterns(t *testing.T) {
+	t.Parallel()
+
+	dbPath := "/db"
+	def := resolverTestDef(dbPath)
+	def.Collections["people"].SubCollections = map[string]*ingitdb.CollectionDef{
+		"notes": {
+			ID:         "notes",
+			RecordFile: &ingitdb.RecordFileDef{Name: "notes.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.ListOfRecords},
+		},
+	}
+	def.Collections["drafts"] = &ingitdb.CollectionDef{ID: "drafts", DirPath: filepath.Join(dbPath, "drafts")} // no record file
+
+	got := RecordFilePatterns(def)
+	want := map[string]string{
+		"people":       filepath.Join(dbPath, "people", "$records", "*.yaml"),
+		"people/notes": filepath.Join(dbPath, "people", "$records", "*", "notes", "notes.yaml"),
+		"tags":         filepath.Join(dbPath, "tags", "tags.yaml"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("RecordFilePatterns = %v, want %v", got, want)
+	}
+	// Every pattern matches the files CollectionForRecordFile resolves to it.
+	for path, wantID := range map[string]string{
+		filepath.Join(dbPath, "people", "$records", "ann.yaml"):                   "people",
+		filepath.Join(dbPath, "people", "$records", "ann", "notes", "notes.yaml"): "people/notes",
+	} {
+		id, _ := CollectionForRecordFile(def, path)
+		matched, err := filepath.Match(got[wantID], path)
+		if id != wantID || !matched || err != nil {
+			t.Errorf("%s: resolves to %q, matched by %q: %v %v", path, id, got[wantID], matched, err)
+		}
+	}
+}
@@ -293,3 +293,37 @@ func TestLength_UnsetDoesNotConstrain(t *testing.T) {
 		t.Fatalf("no length declared, so any string passes; got: %s", errorsJoined(errs))
 	}
 }
+
+func TestPattern_StringAndListElements(t *testing.T) {
+	t.Parallel()
+
+	col := colDefWith("code", ingitdb.ColumnDef{Type: ingitdb.ColumnTypeString, Pattern: `^[A-Z]{2}$`})
+	if errs := validateRecordData("test", "f.json", "r1", col, map[string]any{"code": "IE"}); len(errs) != 0 {
+		t.Errorf("matching value must pass, got: %s", errorsJoined(errs))
+	}
+	errs := validateRecordData("test", "f.json", "r1", col, map[string]any{"code": "ie"})
+	if len(errs) != 1 || errs[0].FieldName != "code" || !strings.Contains(errs[0].Message, `value "ie" for field "code" does not match pattern`) {
+		t.Errorf("expected one pattern error naming the field and value, got: %s", errorsJoined(errs))
+	}
+
+	tags := colDefWith("tags", ingitdb.ColumnDef{Type: "[]string", Pattern: `^[a-z-]+$`})
+	errs = validateRecordData("test", "f.json", "r1", tags, map[string]any{"tags": []any{"ok", "Not OK"}})
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, `field "tags"[1]`) {
+		t.Errorf("expected one pattern error naming the element index, got: %s", errorsJoined(errs))
+	}
+}
+
+func TestValidateFormat_OptIn(t *testing.T) {
+	t.Parallel()
+
+	record := map[string]any{"email": "not an address"}
+	hint := colDefWith("email", ingitdb.ColumnDef{Type: ingitdb.ColumnTypeString, Format: ingitdb.ColumnFormatEmail})
+	if errs := validateRecordData("test", "f.json", "r1", hint, record); len(errs) != 0 {
+		t.Errorf("format without validate_format is only a hint, got: %s", errorsJoined(errs))
+	}
+	enforced := colDefWith("email", ingitdb.ColumnDef{Type: ingitdb.ColumnTypeString, Format: ingitdb.ColumnFormatEmail, ValidateFormat: true})
+	errs := validateRecordData("test", "f.json", "r1", enforced, record)
+	if len(errs) != 1 || errs[0].FieldName != "email" || !strings.Contains(errs[0].Message, `value for field "email" is not a valid email`) {
+		t.Errorf("expected one format error naming the field, got: %s", errorsJoined(errs))
+	}
+}
@@ -4,11 +4,12 @@ package datavalidator
 // specscore: feature/subcollection-record-validation
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"maps"
-	"os"
-	"path/filepath"
 	"slices"
+	"strings"
 
 	ingitdb "github.com/ingitdb/ingitdb-go/ingitdb"
 )
@@ -43,15 +44,30 @@ type loadedRecord struct {
 // Definition load already rejects a foreign_key that resolves to no collection
 // (ingitdb.ValidateForeignKeys), so here the target always resolves; what is
 // checked is the value's existence as a key.
-func validateForeignKeyReferences(def *ingitdb.Definition) []ingitdb.ValidationError {
+func (files recordFiles) validateForeignKeyReferences(def *ingitdb.Definition) []ingitdb.ValidationError {
+	return files.validateForeignKeyReferencesLoaded(def, nil)
+}
+
+// validateForeignKeyReferencesLoaded is validateForeignKeyReferences reusing
+// root collections' records already parsed by the schema pass, keyed by
+// collection ID; collections missing from loaded are read from disk.
+func (files recordFiles) validateForeignKeyReferencesLoaded(def *ingitdb.Definition, loaded map[string][]loadedRecord) []ingitdb.ValidationError {
 	if def == nil {
 		return nil
 	}
+	rootRecords := func(id string, col *ingitdb.CollectionDef) func() ([]loadedRecord, error) {
+		return func() ([]loadedRecord, error) {
+			if records, ok := loaded[id]; ok {
+				return records, nil
+			}
+			return files.loadCollectionRecords(col)
+		}
+	}
 	// The index holds root collections only: a foreign_key resolves to a root
 	// collection, never a subcollection.
 	idx := make(foreignKeyIndex, len(def.Collections))
 	for id, col := range def.Collections {
-		records, err := loadCollectionRecords(col)
+		records, err := rootRecords(id, col)()
 		if err != nil {
 			continue // a read/parse failure is already reported by the schema pass
 		}
@@ -65,22 +81,32 @@ func validateForeignKeyReferences(def *ingitdb.Definition) []ingitdb.ValidationE
 	var errors []ingitdb.ValidationError
 	for _, id := range slices.Sorted(maps.Keys(def.Collections)) {
 		col := def.Collections[id]
-		errors = append(errors, checkCollectionForeignKeys(id, col, def, idx)...)
+		errors = append(errors, checkCollectionForeignKeys(id, col, def, idx, rootRecords(id, col))...)
 		// Subcollection records live once per parent record, not at the
 		// subcollection's loaded DirPath; walkSubCollectionInstances repoints each
 		// instance at its on-disk data so a foreign_key on a subcollection column
 		// is actually checked. This shares one instance-enumeration with the
 		// schema pass (validateSubCollections), so the two passes cannot disagree
 		// on where a subcollection's records live.
-		walkSubCollectionInstances(id, col, func(inst subCollectionInstance) {
-			errors = append(errors, checkCollectionForeignKeys(inst.fullID, inst.colDef, def, idx)...)
+		files.walkSubCollectionInstances(id, col, func(inst subCollectionInstance) {
+			errors = append(errors, checkCollectionForeignKeys(inst.fullID, inst.colDef, def, idx, func() ([]loadedRecord, error) {
+				return files.loadCollectionRecords(inst.colDef)
+			})...)
 		})
 	}
 	return errors
 }
 
 // checkCollectionForeignKeys checks one collection's records against the index.
-func checkCollectionForeignKeys(fullID string, col *ingitdb.CollectionDef, def *ingitdb.Definition, idx foreignKeyIndex) []ingitdb.ValidationError {
+// load supplies the records and is called only if the collection has a
+// foreign_key column or a composite foreign key.
+func checkCollectionForeignKeys(
+	fullID string,
+	col *ingitdb.CollectionDef,
+	def *ingitdb.Definition,
+	idx ForeignKeyIndex,
+	load func() ([]loadedRecord, error),
+) []ingitdb.ValidationError {
 	fkColumns := make(map[string]string) // column name -> resolved target collection
 	for name, colDef := range col.Columns {
 		if colDef.ForeignKey == "" {
@@ -92,11 +118,17 @@ func checkCollectionForeignKeys(fullID string, col *ingitdb.CollectionDef, def *
 		}
 		fkColumns[name] = target
 	}
-	if len(fkColumns) == 0 {
+	compositeTargets := make([]string, len(col.ForeignKeys)) // "" when unresolved
+	for i, fk := range col.ForeignKeys {
+		if target, ok := ingitdb.ResolveForeignKey(fullID, fk.References, def.Collections); ok {
+			compositeTargets[i] = target
+		}
+	}
+	if len(fkColumns) == 0 && len(col.ForeignKeys) == 0 {
 		return nil
 	}
 
-	records, err := loadCollectionRecords(col)
+	records, err := load()
 	if err != nil {
 		return nil // read/parse failure already reported by the schema pass
 	}
@@ -104,19 +136,41 @@ func checkCollectionForeignKeys(fullID string, col *ingitdb.CollectionDef, def *
 	var errors []ingitdb.ValidationError
 	for _, r := range records {
 		for name, target := range fkColumns {
-			raw, present := r.Data[name]
-			if !present || raw == nil {
-				continue // an absent FK value is a required/optional concern, not integrity
-			}
-			value := fmt.Sprintf("%v", raw)
-			if value == "" {
-				continue
+			raw := r.Data[name]
+			_, isList := raw.([]any)
+			if _, isStrings := raw.([]string); isStrings {
+				isList = true
 			}
-			if !idx.Contains(target, value) {
+			// An absent FK value is a required/optional concern, not integrity;
+			// ForeignKeyValues skips it, and skips empty list elements likewise.
+			for _, value := range ingitdb.ForeignKeyValues(raw) {
+				if idx.Contains(target, value) {
+					continue
+				}
 				message := fmt.Sprintf("foreign key %q = %q has no matching record in collection %q", name, value, target)
+				if isList {
+					message = fmt.Sprintf("foreign key %q contains %q, which has no matching record in collection %q", name, value, target)
+				}
 				errors = append(errors, newValidationError(fullID, "", r.Key, name, message, nil))
 			}
 		}
+		for i, fk := range col.ForeignKeys {
+			target := compositeTargets[i]
+			if target == "" {
+				continue
+			}
+			key, ok := ingitdb.CompositeForeignKeyValue(r.Data, fk.Columns)
+			if !ok || idx.Contains(target, key) {
+				continue
+			}
+			values := make([]string, len(fk.Columns))
+			for j, name := range fk.Columns {
+				values[j] = fmt.Sprintf("%q", fmt.Sprintf("%v", r.Data[name]))
+			}
+			message := fmt.Sprintf("foreign key (%s) = (%s) has no matching record in collection %q",
+				strings.Join(fk.Columns, ", "), strings.Join(values, ", "), target)
+			errors = append(errors, newValidationError(fullID, "", r.Key, strings.Join(fk.Columns, ","), message, nil))
+		}
 	}
 	return errors
 }
@@ -125,87 +179,90 @@ func checkCollectionForeignKeys(fullID string, col *ingitdb.CollectionDef, def *
 // the same parse helpers the schema pass uses. It mirrors
 // validateCollectionRecords' dispatch on record type but collects rather than
 // validates.
-func loadCollectionRecords(colDef *ingitdb.CollectionDef) ([]loadedRecord, error) {
+func (files recordFiles) loadCollectionRecords(colDef *ingitdb.CollectionDef) ([]loadedRecord, error) {
 	if shouldSkipRecordParsing(colDef) {
 		return nil, nil
 	}
 	switch colDef.RecordFile.RecordType {
 	case ingitdb.SingleRecord:
-		return loadSingleRecords(colDef)
-	case ingitdb.MapOfRecords:
-		return loadMapRecords(colDef)
-	case ingitdb.ListOfRecords:
-		return loadListRecords(colDef)
+		return files.loadSingleRecords(colDef)
+	case ingitdb.MapOfRecords, ingitdb.ListOfRecords:
+		return files.loadSharedRecords(colDef)
 	default:
 		return nil, nil
 	}
 }
 
-func loadSingleRecords(colDef *ingitdb.CollectionDef) ([]loadedRecord, error) {
+func (files recordFiles) loadSingleRecords(colDef *ingitdb.CollectionDef) ([]loadedRecord, error) {
 	pattern, err := singleRecordGlobPattern(colDef)
 	if err != nil {
 		return nil, err
 	}
-	matches, err := filepath.Glob(pattern)
+	matches, err := files.glob(pattern)
 	if err != nil {
 		return nil, err
 	}
 	var records []loadedRecord
 	for _, filePath := range matches {
-		if skipRecordPath(filePath, colDef.RecordFile) {
-			continue
-		}
-		info, statErr := os.Stat(filePath)
-		if statErr != nil || info.IsDir() {
-			continue
-		}
-		content, readErr := os.ReadFile(filePath)
-		if readErr != nil {
-			continue
+		if record, ok := files.loadSingleRecordFile(colDef, filePath); ok {
+			records = append(records, record)
 		}
-		data, parseErr := ingitdb.ParseRecordContentForCollection(content, colDef)
-		if parseErr != nil {
-			continue
-		}
-		records = append(records, loadedRecord{Key: recordKeyFromFilePath(filePath), Data: data})
 	}
 	return records, nil
 }
 
-func loadMapRecords(colDef *ingitdb.CollectionDef) ([]loadedRecord, error) {
-	filePath := collectionRecordFilePath(colDef)
-	content, ok, _ := readRecordsFile("", filePath)
-	if !ok {
-		return nil, nil
+// loadSingleRecordFile reads one single-record file. ok is false when the path
+// is not a readable, parseable record file.
+func (files recordFiles) loadSingleRecordFile(colDef *ingitdb.CollectionDef, filePath string) (loadedRecord, bool) {
+	if skipRecordPath(filePath, colDef.RecordFile) {
+		return loadedRecord{}, false
 	}
-	parsed, err := ingitdb.ParseMapOfRecordsContent(content, colDef.RecordFile.Format)
-	if err != nil {
-		return nil, err
+	info, statErr := files.stat(filePath)
+	if statErr != nil || info.IsDir() {
+		return loadedRecord{}, false
 	}
-	records := make([]loadedRecord, 0, len(parsed))
-	for key, data := range parsed {
-		records = append(records, loadedRecord{Key: key, Data: data})
+	content, readErr := files.readFile(filePath)
+	if readErr != nil {
+		return loadedRecord{}, false
 	}
-	return records, nil
+	data, parseErr := ingitdb.ParseRecordContentForCollection(content, colDef)
+	if parseErr != nil {
+		return loadedRecord{}, false
+	}
+	return loadedRecord{Key: recordKeyFromFilePath(filePath), Data: data}, true
 }
 
-func loadListRecords(colDef *ingitdb.CollectionDef) ([]loadedRecord, error) {
-	filePath := collectionRecordFilePath(colDef)
-	content, ok, _ := readRecordsFile("", filePath)
+// loadSharedRecords reads the records of a collection's map or list record file.
+func (files recordFiles) loadSharedRecords(colDef *ingitdb.CollectionDef) ([]loadedRecord, error) {
+	f, ok, _ := files.openRecordsFile("", collectionRecordFilePath(colDef))
 	if !ok {
 		return nil, nil
 	}
-	rows, err := parseListRows(content, colDef)
+	defer func() { _ = f.Close() }()
+	return readSharedRecords(f, colDef)
+}
+
+// readSharedRecords reads the records of a map or list record file from r.
+// List rows without a resolvable key are skipped.
+func readSharedRecords(r io.Reader, colDef *ingitdb.CollectionDef) ([]loadedRecord, error) {
+	var records []loadedRecord
+	var err error
+	switch colDef.RecordFile.RecordType {
+	case ingitdb.MapOfRecords:
+		err = ingitdb.StreamMapOfRecords(context.Background(), r, colDef.RecordFile.Format, func(key string, data map[string]any) error {
+			records = append(records, loadedRecord{Key: key, Data: data})
+			return nil
+		})
+	case ingitdb.ListOfRecords:
+		err = ingitdb.StreamListOfRecords(context.Background(), r, colDef, func(row map[string]any) error {
+			if key, keyOK := ingitdb.ResolveListRecordKey(row, colDef); keyOK {
+				records = append(records, loadedRecord{Key: key, Data: row})
+			}
+			return nil
+		})
+	}
 	if err != nil {
 		return nil, err
 	}
-	var records []loadedRecord
-	for _, row := range rows {
-		key, keyOK := ingitdb.ResolveListRecordKey(row, colDef)
-		if !keyOK {
-			continue
-		}
-		records = append(records, loadedRecord{Key: key, Data: row})
-	}
 	return records, nil
 }
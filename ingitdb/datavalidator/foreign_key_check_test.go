@@ -144,3 +144,62 @@ func TestForeignKeyReferences_ModuleRelativeTarget(t *testing.T) {
 		t.Errorf("error must show de dangling against commerce.countries, got: %v", errs[0])
 	}
 }
+
+// Every element of a list FK column must be a key of the target.
+func TestForeignKeyReferences_ListColumnChecksEachElement(t *testing.T) {
+	dir := t.TempDir()
+	tags := writeMapCollection(t, dir, "tags", "go:\n  title: Go\ndb:\n  title: DB\n",
+		map[string]*ingitdb.ColumnDef{"title": {Type: ingitdb.ColumnTypeString}})
+	posts := writeMapCollection(t, dir, "posts",
+		"p1:\n  tags: [go, db]\np2:\n  tags: [go, rust]\n",
+		map[string]*ingitdb.ColumnDef{"tags": {Type: "[]string", ForeignKey: "tags"}})
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{"tags": tags, "posts": posts}}
+
+	errs := osFiles.validateForeignKeyReferences(def)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 dangling-element error, got %d: %v", len(errs), errs)
+	}
+	if errs[0].RecordKey != "p2" || !strings.Contains(errs[0].Message, `"tags" contains "rust"`) {
+		t.Errorf("error must name the record and the missing element, got: %v", errs[0])
+	}
+}
+
+// A composite foreign key must match a list collection's composite primary key.
+func TestForeignKeyReferences_Composite(t *testing.T) {
+	dir := t.TempDir()
+	citiesDir := filepath.Join(dir, "cities")
+	if err := os.MkdirAll(citiesDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(citiesDir, "cities.yaml"),
+		[]byte("- country: ie\n  code: dub\n- country: ie\n  code: cork\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cities := &ingitdb.CollectionDef{
+		ID:         "cities",
+		DirPath:    citiesDir,
+		PrimaryKey: []string{"country", "code"},
+		RecordFile: &ingitdb.RecordFileDef{Name: "cities.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.ListOfRecords},
+		Columns: map[string]*ingitdb.ColumnDef{
+			"country": {Type: ingitdb.ColumnTypeString},
+			"code":    {Type: ingitdb.ColumnTypeString},
+		},
+	}
+	offices := writeMapCollection(t, dir, "offices",
+		"o1:\n  country: ie\n  city: dub\no2:\n  country: uk\n  city: dub\no3:\n  country: ie\n",
+		map[string]*ingitdb.ColumnDef{
+			"country": {Type: ingitdb.ColumnTypeString},
+			"city":    {Type: ingitdb.ColumnTypeString},
+		})
+	offices.ForeignKeys = []ingitdb.ForeignKeyDef{{Columns: []string{"country", "city"}, References: "cities"}}
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{"cities": cities, "offices": offices}}
+
+	errs := osFiles.validateForeignKeyReferences(def)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 dangling composite error, got %d: %v", len(errs), errs)
+	}
+	e := errs[0]
+	if e.RecordKey != "o2" || e.FieldName != "country,city" || !strings.Contains(e.Message, `(country, city) = ("uk", "dub")`) {
+		t.Errorf("error must name the record, the columns and the values, got: %v", e)
+	}
+}
@@ -0,0 +1,133 @@
+package datavalidator
+
+// specscore: feature/column-validation
+// specscore: feature/cli/validate
+
+import (
+	"maps"
+	"slices"
+
+	ingitdb "github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// lazyForeignKeyIndex is a ForeignKeyIndex that reads a root collection's keys
+// the first time a lookup names it, so an incremental pass loads only the
+// collections its changed records reference or that lost records. Unlike the
+// full pass's foreignKeyIndex it is not safe for concurrent use.
+type lazyForeignKeyIndex struct {
+	files recordFiles
+	def   *ingitdb.Definition
+	keys  map[string]map[string]bool
+}
+
+func newLazyForeignKeyIndex(files recordFiles, def *ingitdb.Definition) *lazyForeignKeyIndex {
+	return &lazyForeignKeyIndex{files: files, def: def, keys: make(map[string]map[string]bool)}
+}
+
+// Contains reports whether collectionID has a record with the given key.
+func (idx *lazyForeignKeyIndex) Contains(collectionID, key string) bool {
+	keys, ok := idx.keys[collectionID]
+	if !ok {
+		keys = make(map[string]bool)
+		if col := idx.def.Collections[collectionID]; col != nil {
+			records, _ := idx.files.loadCollectionRecords(col) // a read/parse failure is reported by the schema pass
+			for _, r := range records {
+				keys[r.Key] = true
+			}
+		}
+		idx.keys[collectionID] = keys
+	}
+	return keys[key]
+}
+
+// foreignKeyIndexFunc adapts a function to ForeignKeyIndex.
+type foreignKeyIndexFunc func(collectionID, key string) bool
+
+// Contains calls f.
+func (f foreignKeyIndexFunc) Contains(collectionID, key string) bool {
+	return f(collectionID, key)
+}
+
+// changedRecords lists the records a change set added or modified, one entry
+// per root collection or subcollection instance, keyed by its data directory.
+type changedRecords map[string]*changedCollection
+
+// changedCollection is the changed part of one collection: the files of
+// changed single records, the keys of the changed records of a shared map/list
+// file, or, when whole is set, every record of the shared file.
+type changedCollection struct {
+	fullID string
+	colDef *ingitdb.CollectionDef
+	files  []string
+	keys   map[string]bool
+	whole  bool
+}
+
+// add records ar, whose collection definition (repointed at its instance, for
+// a subcollection record) is colDef.
+func (c changedRecords) add(ar AffectedRecord, colDef *ingitdb.CollectionDef) {
+	entry := c[colDef.DirPath]
+	if entry == nil {
+		entry = &changedCollection{fullID: ar.CollectionID, colDef: colDef}
+		c[colDef.DirPath] = entry
+	}
+	if colDef.RecordFile.RecordType != ingitdb.SingleRecord {
+		if ar.RecordKey == "" {
+			entry.whole = true
+			return
+		}
+		if entry.keys == nil {
+			entry.keys = make(map[string]bool)
+		}
+		entry.keys[ar.RecordKey] = true
+		return
+	}
+	entry.files = append(entry.files, ar.FilePath)
+}
+
+// load reads the changed records from files.
+func (c *changedCollection) load(files recordFiles) ([]loadedRecord, error) {
+	if c.colDef.RecordFile.RecordType != ingitdb.SingleRecord {
+		records, err := files.loadCollectionRecords(c.colDef)
+		if err != nil || c.whole {
+			return records, err
+		}
+		return slices.DeleteFunc(records, func(r loadedRecord) bool { return !c.keys[r.Key] }), nil
+	}
+	records := make([]loadedRecord, 0, len(c.files))
+	for _, filePath := range c.files {
+		if record, ok := files.loadSingleRecordFile(c.colDef, filePath); ok {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// validateChangedForeignKeys checks the outgoing references of the changed
+// records: every foreign key value must name an existing record of its target.
+// Only the targets the changed records reference are loaded, through idx.
+//
+// A value naming a key the change set removed is left to
+// validateDeletedReferences, which reports it with the on_delete action that
+// applies — so the same dangling reference is not reported twice.
+func (files recordFiles) validateChangedForeignKeys(
+	def *ingitdb.Definition,
+	changed changedRecords,
+	deleted deletedRecords,
+	idx ForeignKeyIndex,
+) []ingitdb.ValidationError {
+	if def == nil || len(changed) == 0 {
+		return nil
+	}
+	outgoing := foreignKeyIndexFunc(func(collectionID, key string) bool {
+		return deleted.contains(collectionID, key) || idx.Contains(collectionID, key)
+	})
+	var errors []ingitdb.ValidationError
+	for _, dir := range slices.Sorted(maps.Keys(changed)) {
+		entry := changed[dir]
+		errors = append(errors, checkCollectionForeignKeys(entry.fullID, entry.colDef, def, outgoing, func() ([]loadedRecord, error) {
+			return entry.load(files)
+		})...)
+	}
+	return errors
+}
@@ -0,0 +1,174 @@
+package datavalidator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// incrementalFKTestDef builds countries (a map file), cities (single records
+// referencing countries) and unrelated planets, under dir.
+func incrementalFKTestDef(t *testing.T, dir string) *ingitdb.Definition {
+	t.Helper()
+	for rel, content := range map[string]string{
+		"countries/countries.yaml":    "fr:\n  name: France\n",
+		"cities/$records/paris.yaml":  "country: fr\n",
+		"cities/$records/dublin.yaml": "country: ie\n",
+		"cities/$records/bern.yaml":   "country: ch\n",
+		"planets/planets.yaml":        "earth:\n  name: Earth\n",
+	} {
+		full := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	return &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"countries": {
+			ID: "countries", DirPath: filepath.Join(dir, "countries"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "countries.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords},
+			Columns:    map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}},
+		},
+		"cities": {
+			ID: "cities", DirPath: filepath.Join(dir, "cities"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord},
+			Columns:    map[string]*ingitdb.ColumnDef{"country": {Type: ingitdb.ColumnTypeString, ForeignKey: "countries"}},
+		},
+		"planets": {
+			ID: "planets", DirPath: filepath.Join(dir, "planets"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "planets.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords},
+			Columns:    map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}},
+		},
+	}}
+}
+
+// A changed record's dangling foreign key is reported; an unchanged record's
+// is not, because nothing it references changed.
+func TestIncrementalValidator_ChecksOutgoingForeignKeys(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	def := incrementalFKTestDef(t, dir)
+	differ := fakeDiffer{files: []ingitdb.ChangedFile{
+		{Kind: ingitdb.ChangeKindAdded, Path: "cities/$records/dublin.yaml"},
+		{Kind: ingitdb.ChangeKindModified, Path: "cities/$records/paris.yaml"},
+	}}
+
+	iv := NewIncrementalValidator(differ, NewChangeSetResolver(), NewValidator())
+	result, err := iv.ValidateChanges(context.Background(), dir, def, "base", "HEAD")
+	if err != nil {
+		t.Fatalf("ValidateChanges: %v", err)
+	}
+	joined := strings.Join(errorStrings(result), " | ")
+	if len(result.Errors()) != 1 || !strings.Contains(joined, `foreign key "country" = "ie" has no matching record in collection "countries"`) {
+		t.Errorf("want only dublin's dangling reference, got: %s", joined)
+	}
+	if strings.Contains(joined, "bern") {
+		t.Errorf("unchanged record bern must not be checked, got: %s", joined)
+	}
+}
+
+// A rewritten map file that drops a key orphans the records referencing it,
+// changed or not. Only a key known to have existed before is an orphan:
+// resolved record by record, the dropped key is known; resolved as a whole
+// file, no key is, and an unchanged record's reference — dublin's to ie,
+// which never existed — is not reported either way.
+func TestIncrementalValidator_ChecksIncomingForeignKeys(t *testing.T) {
+	t.Parallel()
+
+	differ := fakeDiffer{files: []ingitdb.ChangedFile{
+		{Kind: ingitdb.ChangeKindModified, Path: "countries/countries.yaml"},
+	}}
+	reader := fakeFileReader{base: map[string]string{
+		"countries/countries.yaml": "fr:\n  name: France\n",
+	}}
+	cases := []struct {
+		name     string
+		resolver ChangeSetResolver
+		want     []string
+	}{
+		{"record level", NewChangeSetResolver(WithGitFileReader(reader)), []string{"fr"}},
+		{"whole file", NewChangeSetResolver(), nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			def := incrementalFKTestDef(t, dir)
+			// fr was removed from countries and paris, referencing it, is
+			// unchanged. dublin's reference to ie was dangling already.
+			if err := os.WriteFile(filepath.Join(dir, "countries", "countries.yaml"), []byte("ch:\n  name: Switzerland\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			iv := NewIncrementalValidator(differ, tc.resolver, NewValidator())
+			result, err := iv.ValidateChanges(context.Background(), dir, def, "base", "HEAD")
+			if err != nil {
+				t.Fatalf("ValidateChanges: %v", err)
+			}
+			joined := strings.Join(errorStrings(result), " | ")
+			for _, key := range tc.want {
+				want := `foreign key "country" references deleted record "` + key + `" in collection "countries"`
+				if !strings.Contains(joined, want) {
+					t.Errorf("expected %q, got: %s", want, joined)
+				}
+			}
+			if len(result.Errors()) != len(tc.want) {
+				t.Errorf("want %d errors, got: %s", len(tc.want), joined)
+			}
+		})
+	}
+}
+
+// A changed record referencing a key its target never had gets the plain
+// dangling foreign key message, even when the target file was rewritten as a
+// whole in the same change.
+func TestIncrementalValidator_NeverExistingKeyIsDangling(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	def := incrementalFKTestDef(t, dir)
+	differ := fakeDiffer{files: []ingitdb.ChangedFile{
+		{Kind: ingitdb.ChangeKindModified, Path: "countries/countries.yaml"},
+		{Kind: ingitdb.ChangeKindAdded, Path: "cities/$records/dublin.yaml"},
+	}}
+	iv := NewIncrementalValidator(differ, NewChangeSetResolver(), NewValidator())
+	result, err := iv.ValidateChanges(context.Background(), dir, def, "base", "HEAD")
+	if err != nil {
+		t.Fatalf("ValidateChanges: %v", err)
+	}
+	joined := strings.Join(errorStrings(result), " | ")
+	if len(result.Errors()) != 1 || !strings.Contains(joined, `foreign key "country" = "ie" has no matching record in collection "countries"`) {
+		t.Errorf("want only dublin's dangling reference, got: %s", joined)
+	}
+}
+
+// The index loads only the collections the changed records reference.
+func TestValidateChangedForeignKeys_LoadsOnlyInvolvedTargets(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	def := incrementalFKTestDef(t, dir)
+	touched := changedRecords{}
+	touched.add(AffectedRecord{
+		CollectionID: "cities",
+		FilePath:     filepath.Join(dir, "cities", "$records", "bern.yaml"),
+		RecordKey:    "bern",
+		ChangeKind:   ingitdb.ChangeKindModified,
+	}, def.Collections["cities"])
+
+	idx := newLazyForeignKeyIndex(osFiles, def)
+	errs := osFiles.validateChangedForeignKeys(def, touched, deletedRecords{}, idx)
+	if len(errs) != 1 || errs[0].RecordKey != "bern" {
+		t.Errorf("want bern's dangling reference only, got: %v", errs)
+	}
+	if len(idx.keys) != 1 || idx.keys["countries"] == nil {
+		t.Errorf("only countries should be loaded, got: %v", idx.keys)
+	}
+}
@@ -4,7 +4,9 @@ package datavalidator
 
 import (
 	"context"
+	"maps"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/ingitdb/ingitdb-go/ingitdb"
@@ -15,15 +17,22 @@ import (
 // NewIncrementalValidator returns an IncrementalValidator that validates only
 // the records whose files changed between two git refs. differ lists changed
 // files, resolver maps them to collection records, and full is used as a
-// fall-back when a definition file changed (the schema itself moved).
-func NewIncrementalValidator(differ gitdiff.GitDiffer, resolver ChangeSetResolver, full DataValidator) IncrementalValidator {
-	return &incrementalValidator{differ: differ, resolver: resolver, full: full}
+// fall-back when a definition file changed (the schema itself moved). Of
+// opts, only those choosing where records are read from, such as
+// WithGitTree, apply to the incremental pass; full takes its own.
+func NewIncrementalValidator(differ gitdiff.GitDiffer, resolver ChangeSetResolver, full DataValidator, opts ...ValidatorOption) IncrementalValidator {
+	sv := simpleValidator{files: osFiles}
+	for _, opt := range opts {
+		opt(&sv)
+	}
+	return &incrementalValidator{differ: differ, resolver: resolver, full: full, files: sv.files}
 }
 
 type incrementalValidator struct {
 	differ   gitdiff.GitDiffer
 	resolver ChangeSetResolver
 	full     DataValidator
+	files    recordFiles
 }
 
 func (iv *incrementalValidator) ValidateChanges(
@@ -43,21 +52,54 @@ func (iv *incrementalValidator) ValidateChanges(
 		return iv.full.Validate(ctx, dbPath, def)
 	}
 
-	affected, err := iv.resolver.Resolve(dbPath, def, changed)
+	// A resolver that can read both refs narrows a changed map/list file to
+	// the records that changed in it; otherwise the whole file is revalidated.
+	var affected []AffectedRecord
+	if recordResolver, ok := iv.resolver.(RecordChangeResolver); ok {
+		affected, err = recordResolver.ResolveRecords(ctx, dbPath, def, changed, fromCommit, toCommit)
+	} else {
+		affected, err = iv.resolver.Resolve(dbPath, def, changed)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	files := iv.files
 	result := &ingitdb.ValidationResult{}
 	type counts struct{ passed, total int }
 	perCollection := map[string]*counts{}
-	wholeFileDone := map[string]bool{} // collectionID → its shared file already validated
+	wholeFileDone := map[string]bool{}             // shared record file → already validated
+	changedKeys := map[string]*changedCollection{} // shared record file → its changed records
+	touched := changedRecords{}
+	deleted := deletedRecords{}
+	instances := map[string]subCollectionInstance{} // touched subcollection instances by data dir
 
 	for _, ar := range affected {
-		colDef := def.Collections[ar.CollectionID]
+		colDef := affectedCollection(def, ar)
 		if colDef == nil {
 			continue
 		}
+		isSubCollection := len(ar.Parents) > 0
+		// An instance whose parent record the change removed is gone with
+		// it; the full pass would not count it either.
+		if isSubCollection && files.parentRecordExists(def, ar) {
+			instances[colDef.DirPath] = subCollectionInstance{
+				fullID:    ar.CollectionID,
+				colDef:    colDef,
+				parentKey: ar.Parents[len(ar.Parents)-1].RecordKey,
+			}
+		}
+		// Foreign keys target root collections only, so only a root
+		// collection's removed records can be referenced.
+		if ar.ChangeKind == ingitdb.ChangeKindDeleted {
+			// Nothing left to validate; the deletion is checked below for
+			// the references it orphaned.
+			if !isSubCollection {
+				deleted.add(ar.CollectionID, ar.RecordKey)
+			}
+			continue
+		}
+		touched.add(ar, colDef)
 		c := perCollection[ar.CollectionID]
 		if c == nil {
 			c = &counts{}
@@ -65,22 +107,63 @@ func (iv *incrementalValidator) ValidateChanges(
 		}
 		switch colDef.RecordFile.RecordType {
 		case ingitdb.SingleRecord:
-			passed, total, errs := validateSingleRecordFile(ar.CollectionID, colDef, ar.FilePath)
+			passed, total, errs := files.validateSingleRecordFile(ar.CollectionID, colDef, ar.FilePath, nil)
 			c.passed += passed
 			c.total += total
 			appendErrors(result, errs)
 		case ingitdb.MapOfRecords, ingitdb.ListOfRecords:
-			if wholeFileDone[ar.CollectionID] {
+			if ar.RecordKey != "" {
+				entry := changedKeys[ar.FilePath]
+				if entry == nil {
+					entry = &changedCollection{fullID: ar.CollectionID, colDef: colDef, keys: map[string]bool{}}
+					changedKeys[ar.FilePath] = entry
+				}
+				entry.keys[ar.RecordKey] = true
 				continue
 			}
-			wholeFileDone[ar.CollectionID] = true
-			passed, total, errs := validateWholeRecordFile(ar.CollectionID, colDef)
+			if wholeFileDone[ar.FilePath] {
+				continue
+			}
+			wholeFileDone[ar.FilePath] = true
+			passed, total, errs := files.validateWholeRecordFile(ar.CollectionID, colDef)
 			c.passed += passed
 			c.total += total
 			appendErrors(result, errs)
 		}
 	}
 
+	for _, filePath := range slices.Sorted(maps.Keys(changedKeys)) {
+		if wholeFileDone[filePath] {
+			continue // every record of the file is already validated
+		}
+		entry := changedKeys[filePath]
+		passed, total, errs := files.validateChangedSharedRecords(entry)
+		c := perCollection[entry.fullID]
+		c.passed += passed
+		c.total += total
+		appendErrors(result, errs)
+	}
+
+	// Record-count bounds apply per subcollection instance; an added or
+	// deleted record can push a touched instance out of them. Instances are
+	// counted with countRecords, as the full pass counts root collections. As in the full
+	// pass, the finding's FilePath names the instance data directory.
+	for _, dir := range slices.Sorted(maps.Keys(instances)) {
+		inst := instances[dir]
+		total, _ := files.countRecords(inst.colDef) // a missing data directory holds no records
+		for _, validationErr := range checkRecordCountConstraints(inst.fullID, inst.colDef, total) {
+			validationErr.FilePath = inst.colDef.DirPath
+			result.Append(validationErr)
+		}
+	}
+
+	// Foreign keys: outgoing references from the changed records, then
+	// incoming references to the records the change set removed. Both look
+	// target keys up in one index, filled only for the collections involved.
+	idx := newLazyForeignKeyIndex(files, def)
+	appendErrors(result, files.validateChangedForeignKeys(def, touched, deleted, idx))
+	appendErrors(result, files.validateDeletedReferences(def, deleted, idx))
+
 	for collectionID, c := range perCollection {
 		result.SetRecordCounts(collectionID, c.passed, c.total)
 		result.SetRecordCount(collectionID, c.total)
@@ -88,14 +171,59 @@ func (iv *incrementalValidator) ValidateChanges(
 	return result, nil
 }
 
+// parentRecordExists reports whether the record owning a subcollection
+// record's instance is still there, read the way walkSubCollectionInstances
+// reads parents: a single-record parent by its file, a map/list parent by
+// its key in the shared file. A parent deleted along with its ancestors, or
+// in a file that no longer parses, does not exist.
+func (files recordFiles) parentRecordExists(def *ingitdb.Definition, ar AffectedRecord) bool {
+	last := len(ar.Parents) - 1
+	parent := ar.Parents[last]
+	parentDef := affectedCollection(def, AffectedRecord{CollectionID: parent.CollectionID, Parents: ar.Parents[:last]})
+	if parentDef == nil || shouldSkipRecordParsing(parentDef) {
+		return false
+	}
+	if parentDef.RecordFile.RecordType == ingitdb.SingleRecord {
+		fileName := strings.ReplaceAll(parentDef.RecordFile.Name, "{key}", parent.RecordKey)
+		info, err := files.stat(filepath.Join(parentDef.DirPath, parentDef.RecordFile.RecordsBasePath(), fileName))
+		return err == nil && !info.IsDir()
+	}
+	records, err := files.loadCollectionRecords(parentDef)
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(records, func(r loadedRecord) bool { return r.Key == parent.RecordKey })
+}
+
+// validateChangedSharedRecords validates the records of a shared map/list file
+// whose keys are in entry.keys, leaving the file's other records unread.
+func (files recordFiles) validateChangedSharedRecords(entry *changedCollection) (int, int, []ingitdb.ValidationError) {
+	filePath := collectionRecordFilePath(entry.colDef)
+	records, err := entry.load(files)
+	if err != nil {
+		return 0, 1, []ingitdb.ValidationError{newValidationError(entry.fullID, filePath, "", "", "failed to parse records file", err)}
+	}
+	passed := 0
+	var errors []ingitdb.ValidationError
+	for _, r := range records {
+		recordErrors := validateRecordData(entry.fullID, filePath, r.Key, entry.colDef, r.Data)
+		if len(recordErrors) > 0 {
+			errors = append(errors, recordErrors...)
+			continue
+		}
+		passed++
+	}
+	return passed, len(records), errors
+}
+
 // validateWholeRecordFile validates every record in a collection's shared
 // map/list record file.
-func validateWholeRecordFile(collectionKey string, colDef *ingitdb.CollectionDef) (int, int, []ingitdb.ValidationError) {
+func (files recordFiles) validateWholeRecordFile(collectionKey string, colDef *ingitdb.CollectionDef) (int, int, []ingitdb.ValidationError) {
 	switch colDef.RecordFile.RecordType {
 	case ingitdb.MapOfRecords:
-		return validateMapOfRecordsFile(collectionKey, colDef)
+		return files.validateMapOfRecordsFile(collectionKey, colDef, nil)
 	case ingitdb.ListOfRecords:
-		return validateListOfRecordsFile(collectionKey, colDef)
+		return files.validateListOfRecordsFile(collectionKey, colDef, nil)
 	default:
 		return 0, 0, nil
 	}
@@ -21,6 +21,28 @@ func (f fakeDiffer) DiffFiles(_ context.Context, _, _, _ string) ([]ingitdb.Chan
 	return f.files, f.err
 }
 
+// fakeFileReader serves record files at the "from" ref out of base (a path
+// absent from it did not exist) and reads every other ref from the working
+// tree, for tests that pair it with fakeDiffer outside a git repository.
+type fakeFileReader struct {
+	base map[string]string
+}
+
+func (f fakeFileReader) ReadFile(_ context.Context, repoPath, ref, path string) ([]byte, bool, error) {
+	if ref == "base" {
+		content, ok := f.base[path]
+		if !ok {
+			return nil, false, nil
+		}
+		return []byte(content), true, nil
+	}
+	content, err := os.ReadFile(filepath.Join(repoPath, path))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	return content, err == nil, err
+}
+
 type fakeFullValidator struct {
 	called bool
 	result *ingitdb.ValidationResult
@@ -165,3 +187,121 @@ func errorStrings(result *ingitdb.ValidationResult) []string {
 	}
 	return out
 }
+
+// A subcollection record edit is validated against the subcollection's schema,
+// and the touched instance's record-count bounds are enforced; instances the
+// change set did not touch are not opened.
+func TestIncrementalValidator_SubCollectionRecords(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for rel, content := range map[string]string{
+		"orders/$records/ord001.yaml":                          "name: First\n",
+		"orders/$records/ord002.yaml":                          "name: Second\n",
+		"orders/$records/ord001/order_details/$records/a.yaml": "qty: many\n",
+		"orders/$records/ord002/order_details/$records/b.yaml": "qty: broken\n",
+	} {
+		full := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	minRecords := 2
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"orders": {
+			ID:         "orders",
+			DirPath:    filepath.Join(dir, "orders"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord},
+			Columns:    map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}},
+			SubCollections: map[string]*ingitdb.CollectionDef{
+				"order_details": {
+					ID:              "order_details",
+					RecordFile:      &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord},
+					Columns:         map[string]*ingitdb.ColumnDef{"qty": {Type: ingitdb.ColumnTypeInt}},
+					MinRecordsCount: &minRecords,
+				},
+			},
+		},
+	}}
+	differ := fakeDiffer{files: []ingitdb.ChangedFile{
+		{Kind: ingitdb.ChangeKindModified, Path: "orders/$records/ord001/order_details/$records/a.yaml"},
+	}}
+
+	iv := NewIncrementalValidator(differ, NewChangeSetResolver(), NewValidator())
+	result, err := iv.ValidateChanges(context.Background(), dir, def, "base", "HEAD")
+	if err != nil {
+		t.Fatalf("ValidateChanges: %v", err)
+	}
+	joined := strings.Join(errorStrings(result), " | ")
+	if len(result.Errors()) != 2 {
+		t.Fatalf("want a type error and a record-count error, got: %s", joined)
+	}
+	if !strings.Contains(joined, "orders/order_details") || !strings.Contains(joined, "min_records_count") {
+		t.Errorf("expected errors for the orders/order_details instance, got: %s", joined)
+	}
+	wantDir := filepath.Join(dir, "orders", "$records", "ord001", "order_details")
+	if !strings.Contains(joined, wantDir+":") {
+		t.Errorf("the record-count finding must name the ord001 instance dir %q, got: %s", wantDir, joined)
+	}
+	if strings.Contains(joined, "ord002") {
+		t.Errorf("the untouched ord002 instance must not be validated, got: %s", joined)
+	}
+}
+
+// Deleting a parent record together with its subcollection records removes
+// the instance; its record-count bounds must not be enforced on what is left.
+func TestIncrementalValidator_SubCollectionParentDeleted(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for rel, content := range map[string]string{
+		"orders/$records/ord002.yaml":                       "name: Second\n",
+		"orders/$records/ord002/order_details/records.yaml": "a: {qty: 1}\n",
+		"orders/$records/ord003.yaml":                       "name: Third\n",
+		"orders/$records/ord003/order_details/records.yaml": "b: {qty: 2}\n",
+	} {
+		full := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	minRecords := 1
+	maxRecords := 1
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"orders": {
+			ID:         "orders",
+			DirPath:    filepath.Join(dir, "orders"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord},
+			Columns:    map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}},
+			SubCollections: map[string]*ingitdb.CollectionDef{
+				"order_details": {
+					ID:              "order_details",
+					RecordFile:      &ingitdb.RecordFileDef{Name: "records.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords},
+					Columns:         map[string]*ingitdb.ColumnDef{"qty": {Type: ingitdb.ColumnTypeInt}},
+					MinRecordsCount: &minRecords,
+					MaxRecordsCount: &maxRecords,
+				},
+			},
+		},
+	}}
+	differ := fakeDiffer{files: []ingitdb.ChangedFile{
+		{Kind: ingitdb.ChangeKindDeleted, Path: "orders/$records/ord001.yaml"},
+		{Kind: ingitdb.ChangeKindDeleted, Path: "orders/$records/ord001/order_details/records.yaml"},
+		{Kind: ingitdb.ChangeKindModified, Path: "orders/$records/ord003/order_details/records.yaml"},
+	}}
+
+	iv := NewIncrementalValidator(differ, NewChangeSetResolver(), NewValidator())
+	result, err := iv.ValidateChanges(context.Background(), dir, def, "base", "HEAD")
+	if err != nil {
+		t.Fatalf("ValidateChanges: %v", err)
+	}
+	if errs := errorStrings(result); len(errs) != 0 {
+		t.Errorf("a removed instance must not fail min_records_count, and a one-record map file is within bounds, got: %v", errs)
+	}
+}
@@ -29,10 +29,37 @@ type DataValidator interface {
 
 // AffectedRecord identifies which record was touched by a file change.
 type AffectedRecord struct {
+	// CollectionID is the collection's full path: a root collection id, or
+	// e.g. "orders/order_details" for a subcollection record.
 	CollectionID string
 	FilePath     string
 	RecordKey    string // empty if entire file changed (list/map format)
 	ChangeKind   ingitdb.ChangeKind
+	// Parents is the chain of records owning a subcollection record,
+	// outermost first; empty for a root collection record.
+	Parents []ParentRecord
+	// Fields lists the fields whose values differ between the two refs,
+	// sorted by name. It is set only by RecordChangeResolver.ResolveRecords,
+	// and for single-record files only with WithSingleRecordFields.
+	Fields []FieldChange
+	// Unparsed is set by ResolveRecords when the file could not be parsed
+	// into records at one of the refs, so Fields says nothing about what
+	// changed.
+	Unparsed bool
+}
+
+// FieldChange is one field's value before and after a record change. Before
+// is nil for a field the change added and After nil for one it removed.
+type FieldChange struct {
+	Field  string
+	Before any
+	After  any
+}
+
+// ParentRecord is one link of a subcollection record's parent chain.
+type ParentRecord struct {
+	CollectionID string // full path of the parent collection
+	RecordKey    string
 }
 
 // ChangeSetResolver maps changed files → (collectionID, recordKey) pairs.
@@ -41,6 +68,22 @@ type ChangeSetResolver interface {
 	Resolve(dbPath string, def *ingitdb.Definition, changedFiles []ingitdb.ChangedFile) ([]AffectedRecord, error)
 }
 
+// RecordChangeResolver is a ChangeSetResolver that can also read changed
+// files at both refs, so a changed map/list file resolves to one
+// AffectedRecord per added, modified or deleted record rather than to the
+// whole file. A file that cannot be parsed at either ref is still reported
+// whole (RecordKey == "").
+type RecordChangeResolver interface {
+	ChangeSetResolver
+	ResolveRecords(
+		ctx context.Context,
+		dbPath string,
+		def *ingitdb.Definition,
+		changedFiles []ingitdb.ChangedFile,
+		fromRef, toRef string,
+	) ([]AffectedRecord, error)
+}
+
 // IncrementalValidator validates only records changed between two git refs.
 type IncrementalValidator interface {
 	ValidateChanges(
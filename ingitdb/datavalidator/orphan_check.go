@@ -0,0 +1,106 @@
+package datavalidator
+
+// specscore: feature/column-validation
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	ingitdb "github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// deletedRecords lists the records a change set removed, by root collection:
+// only keys known to have existed at the from ref. A shared record file
+// resolved as a whole — deleted or rewritten without record-level resolution,
+// or unparseable on one side — does not say which records it lost, so it
+// adds nothing; references into it are then checked from the changed records
+// only, as plain dangling foreign keys.
+type deletedRecords map[string]map[string]bool
+
+func (d deletedRecords) add(collectionID, recordKey string) {
+	if recordKey == "" {
+		return // which records went is unknown
+	}
+	keys := d[collectionID]
+	if keys == nil {
+		keys = make(map[string]bool)
+		d[collectionID] = keys
+	}
+	keys[recordKey] = true
+}
+
+func (d deletedRecords) contains(collectionID, recordKey string) bool {
+	return d[collectionID][recordKey]
+}
+
+// validateDeletedReferences reports the records, root or subcollection, that
+// still reference a record the change set deleted — orphans, whatever the
+// foreign key's on_delete says. The message names the action the deletion
+// should have applied, so the fix is clear: restrict means the deletion
+// itself is wrong; cascade and set_null mean the referencing record should
+// have been deleted or cleared along with it (see the refactions package).
+//
+// A deleted key that exists again in its collection — the record moved to
+// another file — is not an orphan; idx tells which keys are present. A
+// reference to a key the collection never had is not an orphan either: it
+// was dangling before the change, which did not touch it.
+func (files recordFiles) validateDeletedReferences(def *ingitdb.Definition, deleted deletedRecords, idx ForeignKeyIndex) []ingitdb.ValidationError {
+	if def == nil || len(deleted) == 0 {
+		return nil
+	}
+
+	var errors []ingitdb.ValidationError
+	check := func(fullID string, col *ingitdb.CollectionDef) {
+		var refs []ingitdb.ForeignKeyRef
+		for _, ref := range ingitdb.ForeignKeyRefs(fullID, col, def.Collections) {
+			if _, ok := deleted[ref.Target]; ok {
+				refs = append(refs, ref)
+			}
+		}
+		if len(refs) == 0 {
+			return
+		}
+		records, err := files.loadCollectionRecords(col)
+		if err != nil {
+			return // read/parse failure is reported by the schema pass
+		}
+		for _, r := range records {
+			for _, ref := range refs {
+				for _, key := range ref.Keys(r.Data) {
+					if !deleted.contains(ref.Target, key) || idx.Contains(ref.Target, key) {
+						continue
+					}
+					errors = append(errors, newValidationError(fullID, "", r.Key, strings.Join(ref.Columns, ","),
+						orphanMessage(ref, key), nil))
+				}
+			}
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(def.Collections)) {
+		col := def.Collections[id]
+		check(id, col)
+		files.walkSubCollectionInstances(id, col, func(inst subCollectionInstance) {
+			check(inst.fullID, inst.colDef)
+		})
+	}
+	return errors
+}
+
+func orphanMessage(ref ingitdb.ForeignKeyRef, key string) string {
+	what := fmt.Sprintf("foreign key %q", ref.Columns[0])
+	if ref.Composite {
+		what = fmt.Sprintf("foreign key (%s)", strings.Join(ref.Columns, ", "))
+		key = strings.ReplaceAll(key, "\x1f", ", ")
+	}
+	message := fmt.Sprintf("%s references deleted record %q in collection %q", what, key, ref.Target)
+	switch ref.Action() {
+	case ingitdb.OnDeleteCascade:
+		return message + " (on_delete: cascade — delete this record too)"
+	case ingitdb.OnDeleteSetNull:
+		return message + " (on_delete: set_null — clear the reference)"
+	default:
+		return message + " (on_delete: restrict — the record must not be deleted while referenced)"
+	}
+}
@@ -0,0 +1,79 @@
+package datavalidator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func TestIncrementalValidator_ReportsOrphansOfDeletedRecords(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(rel, content string) {
+		full := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	// ie.yaml and fr.yaml were deleted; fr came back as a new file, so only
+	// references to ie are orphaned.
+	write("countries/$records/fr.yaml", "name: France\n")
+	write("cities/cities.yaml", "dub:\n  country: ie\nparis:\n  country: fr\n")
+	write("posts/posts.yaml", "p1:\n  countries: [fr, ie]\n")
+
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"countries": {
+			ID: "countries", DirPath: filepath.Join(dir, "countries"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord},
+			Columns:    map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}},
+		},
+		"cities": {
+			ID: "cities", DirPath: filepath.Join(dir, "cities"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "cities.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords},
+			Columns: map[string]*ingitdb.ColumnDef{
+				"country": {Type: ingitdb.ColumnTypeString, ForeignKey: "countries", OnDelete: ingitdb.OnDeleteCascade},
+			},
+		},
+		"posts": {
+			ID: "posts", DirPath: filepath.Join(dir, "posts"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "posts.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords},
+			Columns: map[string]*ingitdb.ColumnDef{
+				"countries": {Type: "[]string", ForeignKey: "countries"},
+			},
+		},
+	}}
+	differ := fakeDiffer{files: []ingitdb.ChangedFile{
+		{Kind: ingitdb.ChangeKindDeleted, Path: "countries/$records/ie.yaml"},
+		{Kind: ingitdb.ChangeKindDeleted, Path: "countries/$records/fr.yaml"},
+	}}
+
+	iv := NewIncrementalValidator(differ, NewChangeSetResolver(), NewValidator())
+	result, err := iv.ValidateChanges(context.Background(), dir, def, "base", "HEAD")
+	if err != nil {
+		t.Fatalf("ValidateChanges: %v", err)
+	}
+	errs := result.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("want 2 orphan errors, got: %v", errorStrings(result))
+	}
+	joined := strings.Join(errorStrings(result), " | ")
+	for _, want := range []string{
+		`foreign key "country" references deleted record "ie" in collection "countries" (on_delete: cascade`,
+		`foreign key "countries" references deleted record "ie" in collection "countries" (on_delete: restrict`,
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected %q, got: %s", want, joined)
+		}
+	}
+	if strings.Contains(joined, `"fr"`) {
+		t.Errorf("fr exists again and must not be reported, got: %s", joined)
+	}
+}
@@ -0,0 +1,186 @@
+package datavalidator
+
+import (
+	"context"
+	"maps"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// ValidatorOption configures the validator returned by NewValidator.
+type ValidatorOption func(*simpleValidator)
+
+// WithConcurrency sets how many record files are validated at once. Values
+// <= 0 mean runtime.NumCPU(); 1 validates serially.
+func WithConcurrency(n int) ValidatorOption {
+	return func(sv *simpleValidator) {
+		sv.concurrency = n
+	}
+}
+
+// collectionRun is one root collection's share of a parallel schema pass: the
+// record files to validate and the per-file results, merged under mu.
+type collectionRun struct {
+	key      string
+	colDef   *ingitdb.CollectionDef
+	files    []string
+	validate func(string) (int, int, []ingitdb.ValidationError)
+
+	mu      sync.Mutex
+	passed  int
+	total   int
+	errors  []ingitdb.ValidationError
+	records []ParsedRecord // only when the pass collects them
+}
+
+func (run *collectionRun) add(passed, total int, errs []ingitdb.ValidationError) {
+	run.mu.Lock()
+	run.passed += passed
+	run.total += total
+	run.errors = append(run.errors, errs...)
+	run.mu.Unlock()
+}
+
+// sink collects the records run's files parse into run.records.
+func (run *collectionRun) sink() recordSink {
+	return func(filePath, recordKey string, data map[string]any) {
+		run.mu.Lock()
+		run.records = append(run.records, ParsedRecord{FilePath: filePath, Key: recordKey, Data: data})
+		run.mu.Unlock()
+	}
+}
+
+// validateRootCollectionsParallel is the schema pass over every root
+// collection, with record files from all collections sharing one pool of
+// concurrency workers, so one large collection does not hold up the rest.
+// Listing a collection's files is cheap and done up front; parsing and
+// checking them is what runs in parallel. Findings are appended to result
+// collection by collection once all workers are done; the caller sorts them.
+//
+// With a non-nil cache, files whose findings are cached are not re-validated.
+//
+// With collect set, it also returns the records parsed from each collection's
+// files, ordered by file, keyed by collection ID; a file whose findings came
+// from the cache is still parsed for them. Collections whose files could not
+// be listed, or that are not parsed at all, are left out.
+//
+// A cancelled ctx stops workers from starting new files and is returned.
+func (files recordFiles) validateRootCollectionsParallel(
+	ctx context.Context,
+	def *ingitdb.Definition,
+	result *ingitdb.ValidationResult,
+	concurrency int,
+	cache *validationCache,
+	collect bool,
+) (map[string][]ParsedRecord, error) {
+	type unit struct {
+		run      *collectionRun
+		filePath string
+	}
+	keys := slices.Sorted(maps.Keys(def.Collections))
+	runs := make([]*collectionRun, 0, len(keys))
+	var units []unit
+	for _, key := range keys {
+		run := &collectionRun{key: key, colDef: def.Collections[key]}
+		runs = append(runs, run)
+		if shouldSkipRecordParsing(run.colDef) {
+			// Mirrors validateCollectionRecords: nothing to parse, so every
+			// record on disk counts as passed.
+			total, err := files.countRecords(run.colDef)
+			if err == nil {
+				run.passed, run.total = total, total
+			}
+			continue
+		}
+		var sink recordSink
+		if collect {
+			sink = run.sink()
+		}
+		filePaths, validate, validationErr := files.collectionRecordItems(key, run.colDef, sink)
+		if validationErr != nil {
+			run.errors = append(run.errors, *validationErr)
+			continue
+		}
+		if cache != nil {
+			var parse func(string)
+			if sink != nil {
+				colDef := run.colDef
+				parse = func(filePath string) { files.parseRecordFile(colDef, filePath, sink) }
+			}
+			validate = cache.wrap(key, run.colDef, validate, parse)
+		}
+		run.files, run.validate = filePaths, validate
+		for _, f := range filePaths {
+			units = append(units, unit{run: run, filePath: f})
+		}
+	}
+
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	concurrency = min(concurrency, max(len(units), 1))
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Go(func() {
+			for ctx.Err() == nil {
+				i := int(next.Add(1)) - 1
+				if i >= len(units) {
+					return
+				}
+				u := units[i]
+				u.run.add(u.run.validate(u.filePath))
+			}
+		})
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, run := range runs {
+		for _, validationErr := range run.errors {
+			result.Append(validationErr)
+		}
+		for _, validationErr := range checkRecordCountConstraints(run.key, run.colDef, run.total) {
+			result.Append(validationErr)
+		}
+		result.SetRecordCounts(run.key, run.passed, run.total)
+		result.SetRecordCount(run.key, run.total)
+	}
+	if !collect {
+		return nil, nil
+	}
+	parsed := make(map[string][]ParsedRecord, len(runs))
+	for _, run := range runs {
+		if run.validate == nil {
+			continue
+		}
+		// Files finish in any order; records of one file stay in file order.
+		slices.SortStableFunc(run.records, func(a, b ParsedRecord) int {
+			return strings.Compare(a.FilePath, b.FilePath)
+		})
+		parsed[run.key] = run.records
+	}
+	return parsed, nil
+}
+
+// parseRecordFile feeds sink the records of one of colDef's record files
+// without validating them, for a file whose findings came from the cache.
+func (files recordFiles) parseRecordFile(colDef *ingitdb.CollectionDef, filePath string, sink recordSink) {
+	if colDef.RecordFile.RecordType == ingitdb.SingleRecord {
+		if record, ok := files.loadSingleRecordFile(colDef, filePath); ok {
+			sink.add(filePath, record.Key, record.Data)
+		}
+		return
+	}
+	records, _ := files.loadSharedRecords(colDef)
+	for _, record := range records {
+		sink.add(filePath, record.Key, record.Data)
+	}
+}
@@ -0,0 +1,97 @@
+package datavalidator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// parallelTestDef builds several single-record collections, each with a mix
+// of valid and invalid records, so workers interleave across collections.
+func parallelTestDef(t *testing.T) *ingitdb.Definition {
+	t.Helper()
+	root := t.TempDir()
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{}}
+	for _, id := range []string{"alpha", "beta", "gamma"} {
+		dir := filepath.Join(root, id)
+		recordsDir := filepath.Join(dir, "$records")
+		if err := os.MkdirAll(recordsDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for i := range 20 {
+			content := fmt.Sprintf("name: n%d\nsize: %d\n", i, i)
+			if i%3 == 0 {
+				content = "name: 1\nsize: big\n" // two field errors
+			}
+			if err := os.WriteFile(filepath.Join(recordsDir, fmt.Sprintf("r%02d.yaml", i)), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		def.Collections[id] = &ingitdb.CollectionDef{
+			ID:      id,
+			DirPath: dir,
+			RecordFile: &ingitdb.RecordFileDef{
+				Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord,
+			},
+			Columns: map[string]*ingitdb.ColumnDef{
+				"name": {Type: ingitdb.ColumnTypeString},
+				"size": {Type: ingitdb.ColumnTypeInt},
+			},
+		}
+	}
+	return def
+}
+
+func TestValidate_ParallelMatchesSerialAndIsSorted(t *testing.T) {
+	t.Parallel()
+
+	def := parallelTestDef(t)
+	serial, err := NewValidator(WithConcurrency(1)).Validate(context.Background(), "", def)
+	if err != nil {
+		t.Fatalf("serial: %v", err)
+	}
+	if serial.ErrorCount() != 3*7*2 {
+		t.Fatalf("expected %d errors, got %d", 3*7*2, serial.ErrorCount())
+	}
+	for range 5 {
+		parallel, err := NewValidator(WithConcurrency(8)).Validate(context.Background(), "", def)
+		if err != nil {
+			t.Fatalf("parallel: %v", err)
+		}
+		if !reflect.DeepEqual(parallel.Errors(), serial.Errors()) {
+			t.Fatal("parallel findings differ from serial findings")
+		}
+		for _, id := range []string{"alpha", "beta", "gamma"} {
+			if passed, total := parallel.GetRecordCounts(id); passed != 13 || total != 20 {
+				t.Errorf("%s: counts = %d/%d, want 13/20", id, passed, total)
+			}
+		}
+	}
+
+	errs := serial.Errors()
+	for i := 1; i < len(errs); i++ {
+		a, b := errs[i-1], errs[i]
+		if a.CollectionID > b.CollectionID ||
+			a.CollectionID == b.CollectionID && a.FilePath > b.FilePath ||
+			a.CollectionID == b.CollectionID && a.FilePath == b.FilePath && a.FieldName > b.FieldName {
+			t.Fatalf("findings not sorted at %d: %+v before %+v", i, a, b)
+		}
+	}
+}
+
+func TestValidate_CancelledContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewValidator().Validate(ctx, "", parallelTestDef(t))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package datavalidator
+
+// specscore: feature/cli/validate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"slices"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/gitdiff"
+)
+
+// recordChangeSetResolver is the RecordChangeResolver NewChangeSetResolver
+// returns when given a GitFileReader.
+type recordChangeSetResolver struct {
+	changeSetResolver
+	reader             gitdiff.GitFileReader
+	singleRecordFields bool
+}
+
+// ResolveRecords resolves changedFiles as Resolve does, then reads each record
+// file at fromRef and toRef (an empty toRef is the working tree). A changed
+// map/list file becomes one AffectedRecord per record key added, modified or
+// deleted between the two; records that parse to the same values are left
+// out, so a reformatted file affects nothing. Single-record files are read
+// only with WithSingleRecordFields, to fill in their Fields.
+//
+// A shared file falls back to one whole-file AffectedRecord when it cannot be
+// parsed at either ref, or when a list row has no resolvable or a repeated
+// key: validating the whole file then reports the problem. Such a fallback,
+// and a single-record file that does not parse, is marked Unparsed.
+func (r recordChangeSetResolver) ResolveRecords(
+	ctx context.Context,
+	dbPath string,
+	def *ingitdb.Definition,
+	changedFiles []ingitdb.ChangedFile,
+	fromRef, toRef string,
+) ([]AffectedRecord, error) {
+	affected, err := r.Resolve(dbPath, def, changedFiles)
+	if err != nil {
+		return nil, err
+	}
+	var resolved []AffectedRecord
+	for _, ar := range affected {
+		colDef := affectedCollection(def, ar)
+		isSingle := colDef != nil && colDef.RecordFile.RecordType == ingitdb.SingleRecord
+		if colDef == nil || (isSingle && !r.singleRecordFields) {
+			resolved = append(resolved, ar)
+			continue
+		}
+		before, after, err := r.readBothSides(ctx, dbPath, ar, fromRef, toRef)
+		if err != nil {
+			return nil, err
+		}
+		if isSingle {
+			ar.Fields, ar.Unparsed = singleRecordFieldChanges(before, after, colDef)
+			resolved = append(resolved, ar)
+			continue
+		}
+		records, ok := sharedRecordChanges(ar, before, after, colDef)
+		if !ok {
+			ar.Unparsed = true
+			resolved = append(resolved, ar)
+			continue
+		}
+		resolved = append(resolved, records...)
+	}
+	return resolved, nil
+}
+
+// readBothSides returns the content of ar's file at fromRef and at toRef, nil
+// on the side where the change kind says the file does not exist.
+func (r recordChangeSetResolver) readBothSides(ctx context.Context, dbPath string, ar AffectedRecord, fromRef, toRef string) ([]byte, []byte, error) {
+	rel, err := filepath.Rel(dbPath, ar.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	read := func(ref string) ([]byte, error) {
+		content, _, readErr := r.reader.ReadFile(ctx, dbPath, ref, filepath.ToSlash(rel))
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read %s at %q: %w", rel, ref, readErr)
+		}
+		return content, nil
+	}
+	var before, after []byte
+	// A renamed file has no content at fromRef under its new path; its old
+	// path is resolved as a separate deletion.
+	if ar.ChangeKind == ingitdb.ChangeKindModified || ar.ChangeKind == ingitdb.ChangeKindDeleted {
+		if before, err = read(fromRef); err != nil {
+			return nil, nil, err
+		}
+	}
+	if ar.ChangeKind != ingitdb.ChangeKindDeleted {
+		if after, err = read(toRef); err != nil {
+			return nil, nil, err
+		}
+	}
+	return before, after, nil
+}
+
+// singleRecordFieldChanges compares the two sides of a single-record file.
+// unparsed is true, with no changes, when either side that should exist does
+// not parse.
+func singleRecordFieldChanges(before, after []byte, colDef *ingitdb.CollectionDef) (changes []FieldChange, unparsed bool) {
+	parse := func(content []byte) (map[string]any, bool) {
+		if content == nil {
+			return nil, true
+		}
+		data, err := ingitdb.ParseRecordContentForCollection(content, colDef)
+		return data, err == nil
+	}
+	b, okBefore := parse(before)
+	a, okAfter := parse(after)
+	if !okBefore || !okAfter {
+		return nil, true
+	}
+	return fieldChanges(b, a), false
+}
+
+// sharedRecordChanges splits a changed map/list file into per-record
+// AffectedRecords. ok is false when a side does not parse into keyed records.
+func sharedRecordChanges(ar AffectedRecord, before, after []byte, colDef *ingitdb.CollectionDef) ([]AffectedRecord, bool) {
+	b, okBefore := parseKeyedRecords(before, colDef)
+	a, okAfter := parseKeyedRecords(after, colDef)
+	if !okBefore || !okAfter {
+		return nil, false
+	}
+	keys := slices.Collect(maps.Keys(b))
+	for key := range a {
+		if _, inBefore := b[key]; !inBefore {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	var records []AffectedRecord
+	for _, key := range keys {
+		beforeData, inBefore := b[key]
+		afterData, inAfter := a[key]
+		kind := ingitdb.ChangeKindModified
+		switch {
+		case !inBefore:
+			kind = ingitdb.ChangeKindAdded
+		case !inAfter:
+			kind = ingitdb.ChangeKindDeleted
+		}
+		fields := fieldChanges(beforeData, afterData)
+		if kind == ingitdb.ChangeKindModified && len(fields) == 0 {
+			continue
+		}
+		record := ar
+		record.RecordKey = key
+		record.ChangeKind = kind
+		record.Fields = fields
+		records = append(records, record)
+	}
+	return records, true
+}
+
+// parseKeyedRecords parses a map/list file's content into records by key. Nil
+// content is an absent file and holds no records.
+func parseKeyedRecords(content []byte, colDef *ingitdb.CollectionDef) (map[string]map[string]any, bool) {
+	records := make(map[string]map[string]any)
+	if content == nil {
+		return records, true
+	}
+	var err error
+	switch colDef.RecordFile.RecordType {
+	case ingitdb.MapOfRecords:
+		err = ingitdb.StreamMapOfRecords(context.Background(), bytes.NewReader(content), colDef.RecordFile.Format,
+			func(key string, data map[string]any) error {
+				records[key] = data
+				return nil
+			})
+	case ingitdb.ListOfRecords:
+		err = ingitdb.StreamListOfRecords(context.Background(), bytes.NewReader(content), colDef, func(row map[string]any) error {
+			key, ok := ingitdb.ResolveListRecordKey(row, colDef)
+			if !ok {
+				return fmt.Errorf("list record has no resolvable key")
+			}
+			if _, seen := records[key]; seen {
+				return fmt.Errorf("list record key %q is repeated", key)
+			}
+			records[key] = row
+			return nil
+		})
+	}
+	return records, err == nil
+}
+
+// fieldChanges lists the fields whose values differ between two versions of a
+// record, sorted by name. A nil side is an absent record.
+func fieldChanges(before, after map[string]any) []FieldChange {
+	names := slices.Collect(maps.Keys(before))
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	var changes []FieldChange
+	for _, name := range names {
+		b, a := before[name], after[name]
+		if reflect.DeepEqual(b, a) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: name, Before: b, After: a})
+	}
+	return changes
+}
@@ -0,0 +1,205 @@
+package datavalidator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func recordChangesTestDef(dir string) *ingitdb.Definition {
+	return &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"countries": {
+			ID: "countries", DirPath: filepath.Join(dir, "countries"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "countries.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords},
+			Columns: map[string]*ingitdb.ColumnDef{
+				"name":       {Type: ingitdb.ColumnTypeString},
+				"population": {Type: ingitdb.ColumnTypeInt},
+			},
+		},
+		"cities": {
+			ID: "cities", DirPath: filepath.Join(dir, "cities"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "cities.csv", Format: ingitdb.RecordFormatCSV, RecordType: ingitdb.ListOfRecords},
+			Columns: map[string]*ingitdb.ColumnDef{
+				"id":   {Type: ingitdb.ColumnTypeString},
+				"name": {Type: ingitdb.ColumnTypeString},
+			},
+			ColumnsOrder: []string{"id", "name"},
+		},
+		"people": {
+			ID: "people", DirPath: filepath.Join(dir, "people"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord},
+			Columns:    map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}},
+		},
+	}}
+}
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		full := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+}
+
+func TestChangeSetResolver_ResolveRecords(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		// ie changed population, fr was only re-quoted, de was added, gb removed.
+		"countries/countries.yaml": "ie:\n  name: Ireland\n  population: 5200000\nfr:\n  name: 'France'\nde:\n  name: Germany\n",
+		"cities/cities.csv":        "id,name\ndub,Dublin\ncork,Corcaigh\n",
+		"people/$records/ada.yaml": "name: Ada Lovelace\n",
+	})
+	reader := fakeFileReader{base: map[string]string{
+		"countries/countries.yaml": "fr:\n  name: France\ngb:\n  name: Britain\nie:\n  name: Ireland\n  population: 5000000\n",
+		"cities/cities.csv":        "id,name\ndub,Dublin\ncork,Cork\n",
+		"people/$records/ada.yaml": "name: Ada\n",
+	}}
+	resolver := NewChangeSetResolver(WithGitFileReader(reader), WithSingleRecordFields()).(RecordChangeResolver)
+	affected, err := resolver.ResolveRecords(context.Background(), dir, recordChangesTestDef(dir), []ingitdb.ChangedFile{
+		{Kind: ingitdb.ChangeKindModified, Path: "countries/countries.yaml"},
+		{Kind: ingitdb.ChangeKindModified, Path: "cities/cities.csv"},
+		{Kind: ingitdb.ChangeKindModified, Path: "people/$records/ada.yaml"},
+	}, "base", "")
+	if err != nil {
+		t.Fatalf("ResolveRecords: %v", err)
+	}
+
+	type change struct {
+		collection, key string
+		kind            ingitdb.ChangeKind
+		fields          []FieldChange
+	}
+	var got []change
+	for _, ar := range affected {
+		got = append(got, change{ar.CollectionID, ar.RecordKey, ar.ChangeKind, ar.Fields})
+	}
+	want := []change{
+		{"countries", "de", ingitdb.ChangeKindAdded, []FieldChange{{Field: "name", After: "Germany"}}},
+		{"countries", "gb", ingitdb.ChangeKindDeleted, []FieldChange{{Field: "name", Before: "Britain"}}},
+		{"countries", "ie", ingitdb.ChangeKindModified, []FieldChange{{Field: "population", Before: 5000000, After: 5200000}}},
+		{"cities", "cork", ingitdb.ChangeKindModified, []FieldChange{{Field: "name", Before: "Cork", After: "Corcaigh"}}},
+		{"people", "ada", ingitdb.ChangeKindModified, []FieldChange{{Field: "name", Before: "Ada", After: "Ada Lovelace"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("affected =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+// failingFileReader fails every read, to show a file is not read at all.
+type failingFileReader struct{}
+
+func (failingFileReader) ReadFile(_ context.Context, _, _, path string) ([]byte, bool, error) {
+	return nil, false, fmt.Errorf("unexpected read of %s", path)
+}
+
+// Record-level resolution is opt-in, and even then single-record files are
+// not read unless their Fields are asked for.
+func TestChangeSetResolver_ReadsOnlyWhenAsked(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := NewChangeSetResolver().(RecordChangeResolver); ok {
+		t.Error("the default resolver must not read git")
+	}
+	dir := t.TempDir()
+	resolver := NewChangeSetResolver(WithGitFileReader(failingFileReader{})).(RecordChangeResolver)
+	affected, err := resolver.ResolveRecords(context.Background(), dir, recordChangesTestDef(dir), []ingitdb.ChangedFile{
+		{Kind: ingitdb.ChangeKindModified, Path: "people/$records/ada.yaml"},
+	}, "base", "")
+	if err != nil {
+		t.Fatalf("ResolveRecords: %v", err)
+	}
+	if len(affected) != 1 || affected[0].RecordKey != "ada" || affected[0].Fields != nil {
+		t.Errorf("want ada affected without fields, got %+v", affected)
+	}
+}
+
+// A side that does not parse keeps the whole file affected, so validating it
+// reports the problem.
+func TestChangeSetResolver_ResolveRecords_FallsBackToWholeFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"countries/countries.yaml": "ie: [unclosed\n",
+	})
+	reader := fakeFileReader{base: map[string]string{"countries/countries.yaml": "ie:\n  name: Ireland\n"}}
+	resolver := NewChangeSetResolver(WithGitFileReader(reader)).(RecordChangeResolver)
+	affected, err := resolver.ResolveRecords(context.Background(), dir, recordChangesTestDef(dir), []ingitdb.ChangedFile{
+		{Kind: ingitdb.ChangeKindModified, Path: "countries/countries.yaml"},
+	}, "base", "")
+	if err != nil {
+		t.Fatalf("ResolveRecords: %v", err)
+	}
+	if len(affected) != 1 || affected[0].RecordKey != "" || affected[0].ChangeKind != ingitdb.ChangeKindModified || !affected[0].Unparsed {
+		t.Errorf("want the whole file affected and marked unparsed, got %+v", affected)
+	}
+}
+
+// A single-record file that does not parse at one of the refs is marked
+// Unparsed rather than passed off as a change with no fields.
+func TestChangeSetResolver_ResolveRecords_UnparsedSingleRecord(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"people/$records/ada.yaml":   "name: [unclosed\n",
+		"people/$records/grace.yaml": "name: Grace\n",
+	})
+	reader := fakeFileReader{base: map[string]string{"people/$records/ada.yaml": "name: Ada\n"}}
+	resolver := NewChangeSetResolver(WithGitFileReader(reader), WithSingleRecordFields()).(RecordChangeResolver)
+	affected, err := resolver.ResolveRecords(context.Background(), dir, recordChangesTestDef(dir), []ingitdb.ChangedFile{
+		{Kind: ingitdb.ChangeKindModified, Path: "people/$records/ada.yaml"},
+		{Kind: ingitdb.ChangeKindAdded, Path: "people/$records/grace.yaml"},
+	}, "base", "")
+	if err != nil {
+		t.Fatalf("ResolveRecords: %v", err)
+	}
+	if len(affected) != 2 {
+		t.Fatalf("want 2 affected records, got %+v", affected)
+	}
+	if !affected[0].Unparsed || affected[0].Fields != nil {
+		t.Errorf("ada: want unparsed without fields, got %+v", affected[0])
+	}
+	if affected[1].Unparsed || len(affected[1].Fields) != 1 {
+		t.Errorf("grace: want parsed with its field, got %+v", affected[1])
+	}
+}
+
+// Only the records that changed in a shared file are validated.
+func TestIncrementalValidator_ValidatesOnlyChangedRecordsOfSharedFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"countries/countries.yaml": "ie:\n  population: lots\nfr:\n  population: many\n",
+	})
+	reader := fakeFileReader{base: map[string]string{
+		"countries/countries.yaml": "ie:\n  population: 5000000\nfr:\n  population: many\n",
+	}}
+	differ := fakeDiffer{files: []ingitdb.ChangedFile{{Kind: ingitdb.ChangeKindModified, Path: "countries/countries.yaml"}}}
+	iv := NewIncrementalValidator(differ, NewChangeSetResolver(WithGitFileReader(reader)), NewValidator())
+	result, err := iv.ValidateChanges(context.Background(), dir, recordChangesTestDef(dir), "base", "")
+	if err != nil {
+		t.Fatalf("ValidateChanges: %v", err)
+	}
+	joined := strings.Join(errorStrings(result), " | ")
+	if len(result.Errors()) != 1 || !strings.Contains(joined, "ie") {
+		t.Errorf("want only the changed record ie reported, got: %s", joined)
+	}
+	if _, total := result.GetRecordCounts("countries"); total != 1 {
+		t.Errorf("validated %d records, want only the changed one", total)
+	}
+}
@@ -0,0 +1,137 @@
+package datavalidator
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	ingitdb "github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func TestRecordChecks(t *testing.T) {
+	t.Parallel()
+
+	colDef := &ingitdb.CollectionDef{
+		ID: "offers",
+		Columns: map[string]*ingitdb.ColumnDef{
+			"price":    {Type: ingitdb.ColumnTypeFloat},
+			"discount": {Type: ingitdb.ColumnTypeFloat},
+			"name":     {Type: ingitdb.ColumnTypeString},
+		},
+		Checks: map[string]*ingitdb.CheckDef{
+			"discount_le_price": {Expr: "discount <= price", Message: "discount must not exceed price"},
+			"named":             {Expr: "name != None and len(name) > 0"},
+			"not_bool":          {Expr: "name"},
+		},
+	}
+	cases := []struct {
+		name string
+		data map[string]any
+		want []string
+	}{
+		{
+			name: "all pass except non-bool",
+			data: map[string]any{"price": 10.0, "discount": 2.0, "name": "x"},
+			want: []string{`check "not_bool" must evaluate to True or False, got string`},
+		},
+		{
+			name: "custom message and expression fallback",
+			data: map[string]any{"price": 10.0, "discount": 20.0, "name": ""},
+			want: []string{
+				`check "discount_le_price" failed: discount must not exceed price`,
+				`check "named" failed: name != None and len(name) > 0`,
+				`check "not_bool" must evaluate to True or False`,
+			},
+		},
+		{
+			name: "unevaluable for a missing value",
+			data: map[string]any{"price": 10.0, "name": "x"},
+			want: []string{
+				`check "discount_le_price" could not be evaluated`,
+				`check "not_bool" must evaluate to True or False`,
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			errs := ValidateRecordData(colDef, "r1", tc.data)
+			if len(errs) != len(tc.want) {
+				t.Fatalf("expected %d errors, got %d: %v", len(tc.want), len(errs), errs)
+			}
+			for i, want := range tc.want {
+				if !strings.Contains(errs[i].Message, want) {
+					t.Errorf("errs[%d] = %q, want it to contain %q", i, errs[i].Message, want)
+				}
+				if errs[i].RecordKey != "r1" {
+					t.Errorf("errs[%d] must name the record, got %q", i, errs[i].RecordKey)
+				}
+			}
+		})
+	}
+}
+
+// YAML decodes unquoted dates and timestamps to time.Time; checks compare them
+// in UTC, and a date column compares with a JSON record's date string.
+func TestRecordChecks_Times(t *testing.T) {
+	t.Parallel()
+
+	colDef := &ingitdb.CollectionDef{
+		ID: "events",
+		Columns: map[string]*ingitdb.ColumnDef{
+			"start": {Type: ingitdb.ColumnTypeDateTime},
+			"end":   {Type: ingitdb.ColumnTypeDateTime},
+			"day":   {Type: ingitdb.ColumnTypeDate},
+		},
+		Checks: map[string]*ingitdb.CheckDef{
+			"ordered":   {Expr: "end >= start"},
+			"after_new": {Expr: `day == None or day >= "2024-01-01"`},
+		},
+	}
+	est := time.FixedZone("EST", -5*60*60)
+	cases := []struct {
+		name string
+		data map[string]any
+		want []string
+	}{
+		{
+			// 23:00 EST is 04:00 UTC the next day, after the 01:00 UTC end.
+			name: "datetimes across offsets",
+			data: map[string]any{
+				"start": time.Date(2024, 1, 1, 23, 0, 0, 0, est),
+				"end":   time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC),
+			},
+			want: []string{`check "ordered" failed`},
+		},
+		{
+			name: "datetimes in order",
+			data: map[string]any{
+				"start": time.Date(2024, 1, 1, 19, 0, 0, 0, est),
+				"end":   time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "date against a date string",
+			data: map[string]any{
+				"start": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				"end":   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				"day":   time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC),
+			},
+			want: []string{`check "after_new" failed`},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			errs := ValidateRecordData(colDef, "r1", tc.data)
+			if len(errs) != len(tc.want) {
+				t.Fatalf("expected %d errors, got %d: %v", len(tc.want), len(errs), errs)
+			}
+			for i, want := range tc.want {
+				if !strings.Contains(errs[i].Message, want) {
+					t.Errorf("errs[%d] = %q, want it to contain %q", i, errs[i].Message, want)
+				}
+			}
+		})
+	}
+}
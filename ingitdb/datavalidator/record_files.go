@@ -0,0 +1,47 @@
+package datavalidator
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb/gittree"
+)
+
+// recordFiles is where the validator reads record files from. Its functions
+// mirror os.ReadFile, os.ReadDir, os.Stat, os.Open and filepath.Glob, so the
+// same loaders read the working tree (osFiles) or a git tree (WithGitTree).
+type recordFiles struct {
+	readFile func(string) ([]byte, error)
+	readDir  func(string) ([]os.DirEntry, error)
+	stat     func(string) (os.FileInfo, error)
+	open     func(string) (io.ReadCloser, error)
+	glob     func(string) ([]string, error)
+}
+
+// osFiles reads the working tree.
+var osFiles = recordFiles{
+	readFile: os.ReadFile,
+	readDir:  os.ReadDir,
+	stat:     os.Stat,
+	open:     func(name string) (io.ReadCloser, error) { return os.Open(name) },
+	glob:     filepath.Glob,
+}
+
+// WithGitTree makes the validator read records as of tree's ref rather than
+// from the working tree. dbPath and the collection DirPaths of the definition
+// are OS paths under the tree's repository directory, as
+// validator.NewGitCollectionsReader returns them for the same tree.
+func WithGitTree(tree *gittree.Tree) ValidatorOption {
+	paths := tree.Paths()
+	files := recordFiles{
+		readFile: paths.ReadFile,
+		readDir:  paths.ReadDir,
+		stat:     paths.Stat,
+		open:     func(name string) (io.ReadCloser, error) { return paths.Open(name) },
+		glob:     paths.Glob,
+	}
+	return func(sv *simpleValidator) {
+		sv.files = files
+	}
+}
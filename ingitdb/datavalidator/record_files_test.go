@@ -0,0 +1,103 @@
+package datavalidator
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/gittree"
+)
+
+// Validating with WithGitTree reads the records of the tree's ref: edits in
+// the working tree, committed or not, do not show.
+func TestWithGitTree_ValidatesAtRef(t *testing.T) {
+	t.Parallel()
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		c := exec.Command("git", args...)
+		c.Dir = repo
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(rel, content string) {
+		t.Helper()
+		full := filepath.Join(repo, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	git("init", "-q")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test")
+	write("db/countries/$records/ie.yaml", "name: Ireland\n")
+	write("db/cities/cities.yaml", "dub: {name: Dublin, country: ie}\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "v1")
+	git("tag", "v1")
+	write("db/countries/$records/fr.yaml", "name: 5\n")
+	write("db/cities/cities.yaml", "dub: {name: Dublin, country: ie}\npar: {name: Paris, country: fr}\nlyo: {name: Lyon, country: xx}\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "v2")
+	write("db/countries/$records/ie.yaml", "name: [Ireland]\n")
+
+	dbPath := filepath.Join(repo, "db")
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"countries": {
+			ID:         "countries",
+			DirPath:    filepath.Join(dbPath, "countries"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord},
+			Columns:    map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}},
+		},
+		"cities": {
+			ID:         "cities",
+			DirPath:    filepath.Join(dbPath, "cities"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "cities.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords},
+			Columns: map[string]*ingitdb.ColumnDef{
+				"name":    {Type: ingitdb.ColumnTypeString},
+				"country": {Type: ingitdb.ColumnTypeString, ForeignKey: "countries"},
+			},
+		},
+	}}
+
+	validate := func(opts ...ValidatorOption) string {
+		t.Helper()
+		result, err := NewValidator(opts...).Validate(context.Background(), dbPath, def)
+		if err != nil {
+			t.Fatalf("Validate: %v", err)
+		}
+		return strings.Join(errorStrings(result), " | ")
+	}
+	atRef := func(ref string) ValidatorOption {
+		tree, err := gittree.Open(context.Background(), repo, ref)
+		if err != nil {
+			t.Fatalf("gittree.Open(%s): %v", ref, err)
+		}
+		return WithGitTree(tree)
+	}
+
+	if errs := validate(atRef("v1")); errs != "" {
+		t.Errorf("v1 is valid, got: %s", errs)
+	}
+	head := validate(atRef("HEAD"))
+	for _, want := range []string{"fr.yaml", `"xx"`} {
+		if !strings.Contains(head, want) {
+			t.Errorf("HEAD findings must include %s, got: %s", want, head)
+		}
+	}
+	if strings.Contains(head, "ie.yaml") {
+		t.Errorf("the uncommitted ie.yaml edit must not show at HEAD, got: %s", head)
+	}
+	if worktree := validate(); !strings.Contains(worktree, "ie.yaml") {
+		t.Errorf("the working tree pass must see the ie.yaml edit, got: %s", worktree)
+	}
+}
@@ -3,6 +3,7 @@ package datavalidator
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ingitdb/ingitdb-go/ingitdb"
 )
@@ -111,3 +112,21 @@ func TestRequiredWhen_DoesNotSeeComputedSiblings(t *testing.T) {
 		t.Errorf("expected no errors, got: %v", errs)
 	}
 }
+
+// A YAML timestamp is not turned into a string for required_when, so the
+// expression cannot silently compare it against a date string.
+func TestRequiredWhen_RejectsTimeValue(t *testing.T) {
+	colDef := &ingitdb.CollectionDef{
+		Columns: map[string]*ingitdb.ColumnDef{
+			"since": {Type: ingitdb.ColumnTypeDate},
+			"note":  {Type: ingitdb.ColumnTypeString, RequiredWhen: `since >= "2024-01-01"`},
+		},
+	}
+	errs := ValidateRecordData(colDef, "k", map[string]any{"since": time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)})
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error for a time value in required_when, got %d: %v", len(errs), errs)
+	}
+	if !strings.Contains(errs[0].Error(), "required_when") {
+		t.Errorf("error must mention required_when, got: %v", errs[0])
+	}
+}
@@ -0,0 +1,31 @@
+package datavalidator
+
+import (
+	"context"
+
+	ingitdb "github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// ParsedRecord is one record as the schema pass parsed it from disk.
+type ParsedRecord struct {
+	FilePath string
+	Key      string
+	Data     map[string]any // as stored; locale and $ID are not applied
+}
+
+// ValidateParsed validates def like the DataValidator NewValidator(opts...)
+// returns, and also returns every record the schema pass parsed, keyed by
+// root collection ID and including those that then failed validation, so a
+// caller can reuse them instead of reading the collections again.
+func ValidateParsed(
+	ctx context.Context,
+	dbPath string,
+	def *ingitdb.Definition,
+	opts ...ValidatorOption,
+) (*ingitdb.ValidationResult, map[string][]ParsedRecord, error) {
+	sv := &simpleValidator{files: osFiles}
+	for _, opt := range opts {
+		opt(sv)
+	}
+	return sv.validate(ctx, dbPath, def, true)
+}
@@ -0,0 +1,82 @@
+package datavalidator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func TestValidateParsed_ReturnsParsedRecords(t *testing.T) {
+	t.Parallel()
+
+	def := taskTestDef(t) // countries: ie.yaml valid, fr.yaml wrong type
+	result, parsed, err := ValidateParsed(context.Background(), t.TempDir(), def)
+	if err != nil {
+		t.Fatalf("ValidateParsed: %v", err)
+	}
+	records := parsed["countries"]
+	if len(records) != 2 || records[0].Key != "fr" || records[1].Key != "ie" {
+		t.Fatalf("expected both parsed records in file order, including the invalid one, got %+v", records)
+	}
+	if result.ErrorCount() != 1 {
+		t.Errorf("expected 1 validation error, got %v", result.Errors())
+	}
+	if passed, total := result.GetRecordCounts("countries"); passed != 1 || total != 2 {
+		t.Errorf("record counts = %d/%d, want 1/2", passed, total)
+	}
+}
+
+func TestValidateParsed_CachedFilesStillParsed(t *testing.T) {
+	t.Parallel()
+
+	def := taskTestDef(t)
+	cacheDir := t.TempDir()
+	for run := range 2 {
+		result, parsed, err := ValidateParsed(context.Background(), t.TempDir(), def, WithCache(), WithCacheDir(cacheDir))
+		if err != nil {
+			t.Fatalf("run %d: ValidateParsed: %v", run, err)
+		}
+		if len(parsed["countries"]) != 2 || result.ErrorCount() != 1 {
+			t.Errorf("run %d: records %+v, findings %v", run, parsed["countries"], result.Errors())
+		}
+	}
+}
+
+func TestValidateParsed_ChecksReferences(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	citiesDir := filepath.Join(dbPath, "cities")
+	if err := os.MkdirAll(citiesDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(citiesDir, "cities.yaml"), []byte("dublin:\n  country: ie\n  name: Dublin\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"cities": {
+			ID:      "cities",
+			DirPath: citiesDir,
+			RecordFile: &ingitdb.RecordFileDef{
+				Name: "cities.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords,
+			},
+			Columns: map[string]*ingitdb.ColumnDef{
+				"country": {Type: ingitdb.ColumnTypeString, ForeignKey: "cities"},
+				"name":    {Type: ingitdb.ColumnTypeString},
+			},
+		},
+	}}
+	result, parsed, err := ValidateParsed(context.Background(), dbPath, def)
+	if err != nil {
+		t.Fatalf("ValidateParsed: %v", err)
+	}
+	if len(parsed["cities"]) != 1 {
+		t.Fatalf("parsed = %+v", parsed)
+	}
+	if result.ErrorCount() != 1 || result.Errors()[0].FieldName != "country" {
+		t.Errorf("expected one dangling country reference, got %v", result.Errors())
+	}
+}
@@ -53,11 +53,11 @@ func subCollectionDataDir(parentColDef *ingitdb.CollectionDef, parentKey, subID
 //
 // A read/parse failure at a level is not reported here (the schema pass reports
 // it for that collection); the level simply yields no instances.
-func walkSubCollectionInstances(fullID string, colDef *ingitdb.CollectionDef, fn func(subCollectionInstance)) {
+func (files recordFiles) walkSubCollectionInstances(fullID string, colDef *ingitdb.CollectionDef, fn func(subCollectionInstance)) {
 	if colDef == nil || len(colDef.SubCollections) == 0 {
 		return
 	}
-	parents, err := loadCollectionRecords(colDef)
+	parents, err := files.loadCollectionRecords(colDef)
 	if err != nil {
 		return
 	}
@@ -70,7 +70,7 @@ func walkSubCollectionInstances(fullID string, colDef *ingitdb.CollectionDef, fn
 			inst := *sub // shallow copy: repoint DirPath without mutating the shared definition
 			inst.DirPath = subCollectionDataDir(colDef, pr.Key, subID)
 			fn(subCollectionInstance{fullID: subFullID, colDef: &inst, parentKey: pr.Key})
-			walkSubCollectionInstances(subFullID, &inst, fn)
+			files.walkSubCollectionInstances(subFullID, &inst, fn)
 		}
 	}
 }
@@ -85,7 +85,7 @@ func walkSubCollectionInstances(fullID string, colDef *ingitdb.CollectionDef, fn
 // instances under its full path, so SetRecordCounts is called once per
 // subcollection id after the walk rather than per instance (which would let a
 // later instance overwrite an earlier one).
-func validateSubCollections(def *ingitdb.Definition, result *ingitdb.ValidationResult) {
+func (files recordFiles) validateSubCollections(def *ingitdb.Definition, result *ingitdb.ValidationResult) {
 	if def == nil {
 		return
 	}
@@ -94,8 +94,8 @@ func validateSubCollections(def *ingitdb.Definition, result *ingitdb.ValidationR
 	seen := make(map[string]struct{})
 
 	for _, rootID := range slices.Sorted(maps.Keys(def.Collections)) {
-		walkSubCollectionInstances(rootID, def.Collections[rootID], func(inst subCollectionInstance) {
-			passed, total, errs := validateCollectionRecords(inst.fullID, inst.colDef)
+		files.walkSubCollectionInstances(rootID, def.Collections[rootID], func(inst subCollectionInstance) {
+			passed, total, errs := files.validateCollectionRecords(inst.fullID, inst.colDef, nil)
 			for _, validationErr := range errs {
 				result.Append(validationErr)
 			}
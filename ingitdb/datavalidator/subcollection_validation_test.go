@@ -63,7 +63,7 @@ func TestWalkSubCollectionInstances_PerParentInstances(t *testing.T) {
 	}
 
 	var got []subCollectionInstance
-	walkSubCollectionInstances("orders", parent, func(inst subCollectionInstance) {
+	osFiles.walkSubCollectionInstances("orders", parent, func(inst subCollectionInstance) {
 		got = append(got, inst)
 	})
 
@@ -0,0 +1,167 @@
+package datavalidator
+
+import (
+	"context"
+	"maps"
+	"path/filepath"
+	"slices"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/progress"
+)
+
+// ValidateTaskName is the ProgressEvent.TaskName of validation tasks.
+const ValidateTaskName = "validate"
+
+// NewCollectionValidationTask returns a progress.Task that validates the
+// records of one root collection, appending findings to result.
+//
+// The task's items are record files: each file of a single-record collection,
+// or the one records file of a list/map collection. Every ValidationError is
+// also reported as a ProgressKindError event as soon as it is found, so a TUI
+// can list errors while validation is still running. A skipped file still
+// counts towards the collection's record total (it exists) but not towards
+// the passed count (it was not checked).
+func NewCollectionValidationTask(collectionKey string, colDef *ingitdb.CollectionDef, result *ingitdb.ValidationResult) progress.Task {
+	return &collectionValidationTask{collectionKey: collectionKey, colDef: colDef, result: result}
+}
+
+type collectionValidationTask struct {
+	collectionKey string
+	colDef        *ingitdb.CollectionDef
+	result        *ingitdb.ValidationResult
+}
+
+func (t *collectionValidationTask) Name() string {
+	return ValidateTaskName + " " + t.collectionKey
+}
+
+func (t *collectionValidationTask) Run(ctx context.Context, reporter progress.ProgressReporter, steerer progress.Steerer) error {
+	files, validate, validationErr := osFiles.collectionRecordItems(t.collectionKey, t.colDef, nil)
+	if validationErr != nil {
+		t.appendErrors(reporter, []ingitdb.ValidationError{*validationErr})
+		return nil
+	}
+	// Items are keyed by their path relative to the collection directory:
+	// unique, and short enough to display.
+	keys := make([]string, len(files))
+	byKey := make(map[string]string, len(files))
+	for i, f := range files {
+		keys[i] = f
+		if rel, relErr := filepath.Rel(t.colDef.DirPath, f); relErr == nil {
+			keys[i] = filepath.ToSlash(rel)
+		}
+		byKey[keys[i]] = f
+	}
+	passed, total := 0, 0
+	processed := make(map[string]bool, len(files))
+	err := progress.RunItems(ctx, reporter, steerer, ValidateTaskName, t.collectionKey, keys,
+		func(_ context.Context, key string) error {
+			filePath := byKey[key]
+			processed[filePath] = true
+			filePassed, fileTotal, errs := validate(filePath)
+			passed += filePassed
+			total += fileTotal
+			t.appendErrors(reporter, errs)
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+	if t.colDef.RecordFile.RecordType == ingitdb.SingleRecord {
+		for _, f := range files {
+			if !processed[f] {
+				total++
+			}
+		}
+	}
+	t.appendErrors(reporter, checkRecordCountConstraints(t.collectionKey, t.colDef, total))
+	t.result.SetRecordCounts(t.collectionKey, passed, total)
+	t.result.SetRecordCount(t.collectionKey, total)
+	return nil
+}
+
+// collectionRecordItems lists a root collection's record files and the
+// function validating one of them, which hands the records it parses to sink,
+// or returns the error that prevents listing them. Each file can be validated independently of the others, which is what
+// lets both the task and the parallel Validate spread a collection's files
+// across workers.
+func (files recordFiles) collectionRecordItems(collectionKey string, colDef *ingitdb.CollectionDef, sink recordSink) ([]string, func(string) (int, int, []ingitdb.ValidationError), *ingitdb.ValidationError) {
+	if shouldSkipRecordParsing(colDef) {
+		return nil, nil, nil
+	}
+	switch colDef.RecordFile.RecordType {
+	case ingitdb.SingleRecord:
+		pattern, err := singleRecordGlobPattern(colDef)
+		if err != nil {
+			validationErr := newValidationError(collectionKey, "", "", "", "invalid record file pattern", err)
+			return nil, nil, &validationErr
+		}
+		matches, err := files.glob(pattern)
+		if err != nil {
+			validationErr := newValidationError(collectionKey, pattern, "", "", "failed to glob record files", err)
+			return nil, nil, &validationErr
+		}
+		filePaths := make([]string, 0, len(matches))
+		for _, m := range matches {
+			if !skipRecordPath(m, colDef.RecordFile) {
+				filePaths = append(filePaths, m)
+			}
+		}
+		return filePaths, func(filePath string) (int, int, []ingitdb.ValidationError) {
+			return files.validateSingleRecordFile(collectionKey, colDef, filePath, sink)
+		}, nil
+	case ingitdb.MapOfRecords:
+		return []string{collectionRecordFilePath(colDef)}, func(string) (int, int, []ingitdb.ValidationError) {
+			return files.validateMapOfRecordsFile(collectionKey, colDef, sink)
+		}, nil
+	case ingitdb.ListOfRecords:
+		return []string{collectionRecordFilePath(colDef)}, func(string) (int, int, []ingitdb.ValidationError) {
+			return files.validateListOfRecordsFile(collectionKey, colDef, sink)
+		}, nil
+	default:
+		validationErr := newValidationError(collectionKey, "", "", "", "unsupported record type", nil)
+		return nil, nil, &validationErr
+	}
+}
+
+func (t *collectionValidationTask) appendErrors(reporter progress.ProgressReporter, errs []ingitdb.ValidationError) {
+	for i := range errs {
+		t.result.Append(errs[i])
+		reporter.Report(ingitdb.ProgressEvent{
+			Kind:     ingitdb.ProgressKindError,
+			TaskName: ValidateTaskName,
+			Scope:    t.collectionKey,
+			ItemKey:  errs[i].RecordKey,
+			Err:      &errs[i],
+		})
+	}
+}
+
+// RunValidation validates def like DataValidator.Validate, but schedules the
+// per-collection record checks as tasks on d with the given concurrency
+// (0 = runtime.NumCPU()). The whole-definition passes — subcollection records
+// and foreign keys — run after every collection task has finished, because
+// they need all collections' keys.
+//
+// On abort the partial result is returned together with progress.ErrAborted.
+func RunValidation(ctx context.Context, d progress.Dispatcher, def *ingitdb.Definition, concurrency int) (*ingitdb.ValidationResult, error) {
+	result := &ingitdb.ValidationResult{}
+	keys := slices.Sorted(maps.Keys(def.Collections))
+	tasks := make([]progress.Task, 0, len(keys))
+	for _, key := range keys {
+		tasks = append(tasks, NewCollectionValidationTask(key, def.Collections[key], result))
+	}
+	if err := d.RunParallel(ctx, tasks, concurrency); err != nil {
+		return result, err
+	}
+	osFiles.validateSubCollections(def, result)
+	for _, validationErr := range osFiles.validateForeignKeyReferences(def) {
+		result.Append(validationErr)
+	}
+	for _, validationErr := range osFiles.validateUniqueConstraints(def, nil) {
+		result.Append(validationErr)
+	}
+	result.SortErrors()
+	return result, nil
+}
@@ -0,0 +1,138 @@
+package datavalidator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/progress"
+)
+
+type taskTestReporter struct {
+	mu     sync.Mutex
+	events []ingitdb.ProgressEvent
+}
+
+func (r *taskTestReporter) Report(e ingitdb.ProgressEvent) {
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+}
+
+type taskTestSteerer struct {
+	mu      sync.Mutex
+	signals []progress.Signal
+}
+
+func (s *taskTestSteerer) Steer() progress.Signal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.signals) == 0 {
+		return progress.SignalNone
+	}
+	sig := s.signals[0]
+	s.signals = s.signals[1:]
+	return sig
+}
+
+// taskTestDef lays out a "countries" collection with one valid and one
+// invalid single-record file.
+func taskTestDef(t *testing.T) *ingitdb.Definition {
+	t.Helper()
+	colDir := filepath.Join(t.TempDir(), "countries")
+	recordsDir := filepath.Join(colDir, "$records")
+	if err := os.MkdirAll(recordsDir, 0o755); err != nil {
+		t.Fatalf("setup: mkdir: %v", err)
+	}
+	for name, content := range map[string]string{"ie.yaml": "name: Ireland\n", "fr.yaml": "name: 123\n"} {
+		if err := os.WriteFile(filepath.Join(recordsDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("setup: write record: %v", err)
+		}
+	}
+	return &ingitdb.Definition{
+		Collections: map[string]*ingitdb.CollectionDef{
+			"countries": {
+				ID:      "countries",
+				DirPath: colDir,
+				RecordFile: &ingitdb.RecordFileDef{
+					Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord,
+				},
+				Columns: map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}},
+			},
+		},
+	}
+}
+
+func TestRunValidation_MatchesValidate(t *testing.T) {
+	t.Parallel()
+
+	def := taskTestDef(t)
+	reporter := &taskTestReporter{}
+	result, err := RunValidation(context.Background(), progress.NewDispatcher(reporter, nil), def, 0)
+	if err != nil {
+		t.Fatalf("RunValidation: %v", err)
+	}
+	expected, err := NewValidator().Validate(context.Background(), "", def)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if result.ErrorCount() != expected.ErrorCount() || result.ErrorCount() != 1 {
+		t.Errorf("error count = %d, Validate found %d", result.ErrorCount(), expected.ErrorCount())
+	}
+	if passed, total := result.GetRecordCounts("countries"); passed != 1 || total != 2 {
+		t.Errorf("record counts = %d/%d, want 1/2", passed, total)
+	}
+
+	var errEvents, doneEvents int
+	for _, e := range reporter.events {
+		switch e.Kind {
+		case ingitdb.ProgressKindError:
+			errEvents++
+			if e.Err == nil || e.Err.RecordKey != "fr" || e.Scope != "countries" {
+				t.Errorf("unexpected error event: %+v", e)
+			}
+		case ingitdb.ProgressKindItemDone:
+			doneEvents++
+			if e.Total != 2 || e.TaskName != ValidateTaskName {
+				t.Errorf("unexpected item event: %+v", e)
+			}
+		}
+	}
+	if errEvents != 1 || doneEvents != 2 {
+		t.Errorf("got %d error and %d item events, want 1 and 2", errEvents, doneEvents)
+	}
+}
+
+func TestCollectionValidationTask_SkipItem(t *testing.T) {
+	t.Parallel()
+
+	def := taskTestDef(t)
+	result := &ingitdb.ValidationResult{}
+	// Files are visited in glob order: fr.yaml (invalid) first.
+	steerer := &taskTestSteerer{signals: []progress.Signal{progress.SignalSkipItem}}
+	task := NewCollectionValidationTask("countries", def.Collections["countries"], result)
+	if err := task.Run(context.Background(), &taskTestReporter{}, steerer); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.HasErrors() {
+		t.Errorf("skipped invalid file should not be reported: %v", result.Errors())
+	}
+	if passed, total := result.GetRecordCounts("countries"); passed != 1 || total != 2 {
+		t.Errorf("record counts = %d/%d, want 1/2", passed, total)
+	}
+}
+
+func TestRunValidation_Abort(t *testing.T) {
+	t.Parallel()
+
+	def := taskTestDef(t)
+	steerer := &taskTestSteerer{signals: []progress.Signal{progress.SignalAbort}}
+	_, err := RunValidation(context.Background(), progress.NewDispatcher(nil, steerer), def, 1)
+	if !errors.Is(err, progress.ErrAborted) {
+		t.Errorf("err = %v, want ErrAborted", err)
+	}
+}
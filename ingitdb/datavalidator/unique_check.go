@@ -0,0 +1,126 @@
+package datavalidator
+
+// specscore: feature/column-validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	ingitdb "github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// validateUniqueConstraints checks every `unique` column and collection-level
+// `unique:` tuple (ingitdb.UniqueConstraints) across the records of each
+// collection.
+//
+// Like the FK pass it needs a collection's records all at once, so it runs
+// after the schema pass and reuses root records already parsed there, keyed by
+// collection ID; collections missing from loaded are read from disk. Each
+// subcollection instance is its own scope: two parents may each have a child
+// with the same value, but one parent may not have two.
+//
+// A record with any column of a tuple missing or nil is not compared, the
+// same rule SQL applies to NULL. Each group of colliding records is reported
+// once, naming every record key in the group.
+func (files recordFiles) validateUniqueConstraints(def *ingitdb.Definition, loaded map[string][]loadedRecord) []ingitdb.ValidationError {
+	if def == nil {
+		return nil
+	}
+	var errors []ingitdb.ValidationError
+	for _, id := range slices.Sorted(maps.Keys(def.Collections)) {
+		col := def.Collections[id]
+		errors = append(errors, checkCollectionUniqueness(id, "", col, func() ([]loadedRecord, error) {
+			if records, ok := loaded[id]; ok {
+				return records, nil
+			}
+			return files.loadCollectionRecords(col)
+		})...)
+		files.walkSubCollectionInstances(id, col, func(inst subCollectionInstance) {
+			errors = append(errors, checkCollectionUniqueness(inst.fullID, inst.parentKey, inst.colDef, func() ([]loadedRecord, error) {
+				return files.loadCollectionRecords(inst.colDef)
+			})...)
+		})
+	}
+	return errors
+}
+
+// checkCollectionUniqueness checks one collection, or one subcollection
+// instance owned by parentKey, against its unique constraints. load supplies
+// the records and is called only if the collection has a constraint.
+func checkCollectionUniqueness(
+	fullID, parentKey string,
+	col *ingitdb.CollectionDef,
+	load func() ([]loadedRecord, error),
+) []ingitdb.ValidationError {
+	constraints := ingitdb.UniqueConstraints(col)
+	if len(constraints) == 0 {
+		return nil
+	}
+	records, err := load()
+	if err != nil {
+		return nil // read/parse failure already reported by the schema pass
+	}
+
+	var errors []ingitdb.ValidationError
+	for _, columns := range constraints {
+		groups := make(map[string][]string) // tuple value -> record keys
+		var order []string
+		for _, r := range records {
+			value, ok := uniqueTupleValue(r.Data, columns)
+			if !ok {
+				continue
+			}
+			if _, seen := groups[value]; !seen {
+				order = append(order, value)
+			}
+			groups[value] = append(groups[value], r.Key)
+		}
+		for _, value := range order {
+			keys := groups[value]
+			if len(keys) < 2 {
+				continue
+			}
+			slices.Sort(keys)
+			quoted := make([]string, len(keys))
+			for i, key := range keys {
+				quoted[i] = fmt.Sprintf("%q", key)
+			}
+			message := fmt.Sprintf("unique constraint (%s) violated: value %s is shared by records %s",
+				strings.Join(columns, ", "), value, strings.Join(quoted, ", "))
+			if parentKey != "" {
+				message += fmt.Sprintf(" of parent record %q", parentKey)
+			}
+			errors = append(errors, newValidationError(fullID, "", keys[0], strings.Join(columns, ","), message, nil))
+		}
+	}
+	return errors
+}
+
+// uniqueTupleValue returns a canonical string for the values of columns in
+// data, or false when any of them is missing or nil. JSON keeps values of
+// different types apart ("1" vs 1) and makes an int and an equal whole float
+// collide, as they would in any numeric column.
+func uniqueTupleValue(data map[string]any, columns []string) (string, bool) {
+	values := make([]any, len(columns))
+	for i, name := range columns {
+		v, ok := data[name]
+		if !ok || v == nil {
+			return "", false
+		}
+		values[i] = v
+	}
+	var encoded []byte
+	var err error
+	if len(values) == 1 {
+		encoded, err = json.Marshal(values[0])
+	} else {
+		encoded, err = json.Marshal(values)
+	}
+	if err != nil {
+		return fmt.Sprintf("%v", values), true
+	}
+	return string(encoded), true
+}
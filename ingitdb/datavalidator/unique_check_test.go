@@ -0,0 +1,87 @@
+package datavalidator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ingitdb "github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func TestUniqueConstraints_ReportsEveryCollidingKey(t *testing.T) {
+	dir := t.TempDir()
+	cities := writeMapCollection(t, dir, "cities",
+		"c1:\n  country: ie\n  code: dub\n  slug: dublin\n"+
+			"c2:\n  country: ie\n  code: dub\n  slug: dublin-2\n"+
+			"c3:\n  country: ie\n  code: dub\n  slug: dublin-3\n"+
+			"c4:\n  country: us\n  code: dub\n  slug: dublin\n"+
+			"c5:\n  country: us\n  slug: none\n"+
+			"c6:\n  country: us\n  slug: none-2\n",
+		map[string]*ingitdb.ColumnDef{
+			"country": {Type: ingitdb.ColumnTypeString},
+			"code":    {Type: ingitdb.ColumnTypeString},
+			"slug":    {Type: ingitdb.ColumnTypeString, Unique: true},
+		})
+	cities.Unique = [][]string{{"country", "code"}}
+
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{"cities": cities}}
+	res, err := NewValidator().Validate(context.Background(), dir, def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := res.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 unique violations, got %d: %v", len(errs), errs)
+	}
+	byField := map[string]string{}
+	for _, e := range errs {
+		byField[e.FieldName] = e.Message
+	}
+	if msg := byField["country,code"]; !strings.Contains(msg, `"c1", "c2", "c3"`) || !strings.Contains(msg, `["ie","dub"]`) {
+		t.Errorf("tuple violation must name the value and every key, got: %q", msg)
+	}
+	if msg := byField["slug"]; !strings.Contains(msg, `"c1", "c4"`) {
+		t.Errorf("column violation must name both keys, got: %q", msg)
+	}
+}
+
+// The same value under two different parents is fine; under one parent it is not.
+func TestUniqueConstraints_ScopedPerSubCollectionInstance(t *testing.T) {
+	dir := t.TempDir()
+	owners := writeMapCollection(t, dir, "owners", "o1:\n  name: A\no2:\n  name: B\n",
+		map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}})
+	owners.RecordFile = &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord}
+	for key, pets := range map[string]string{
+		"o1": "p1:\n  name: Rex\np2:\n  name: Rex\n",
+		"o2": "p1:\n  name: Rex\n",
+	} {
+		recDir := filepath.Join(owners.DirPath, "$records")
+		if err := os.MkdirAll(filepath.Join(recDir, key, "pets"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(recDir, key+".yaml"), []byte("name: x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(recDir, key, "pets", "data.yaml"), []byte(pets), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	owners.SubCollections = map[string]*ingitdb.CollectionDef{"pets": {
+		ID: "pets",
+		RecordFile: &ingitdb.RecordFileDef{
+			Name: "data.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords,
+		},
+		Columns: map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString, Unique: true}},
+	}}
+
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{"owners": owners}}
+	errs := osFiles.validateUniqueConstraints(def, nil)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 unique violation, got %d: %v", len(errs), errs)
+	}
+	if msg := errs[0].Message; !strings.Contains(msg, `"p1", "p2"`) || !strings.Contains(msg, `parent record "o1"`) {
+		t.Errorf("violation must name both keys and the parent, got: %q", msg)
+	}
+}
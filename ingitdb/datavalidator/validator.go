@@ -5,84 +5,137 @@ package datavalidator
 // specscore: feature/subcollection-record-validation
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"io"
+	"maps"
 	"math"
 	"os"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
 	ingitdb "github.com/ingitdb/ingitdb-go/ingitdb"
 )
 
-const listRecordsKey = "$records"
-
 // NewValidator creates a data validator that parses records and checks basic schema constraints.
-func NewValidator() DataValidator {
-	return &simpleValidator{}
+// Record files are validated concurrently (see WithConcurrency); findings are
+// sorted, so the result does not depend on scheduling.
+func NewValidator(opts ...ValidatorOption) DataValidator {
+	sv := &simpleValidator{files: osFiles}
+	for _, opt := range opts {
+		opt(sv)
+	}
+	return sv
 }
 
-type simpleValidator struct{}
+type simpleValidator struct {
+	concurrency int    // <= 0 means runtime.NumCPU()
+	cache       bool   // reuse per-file findings across runs, see validationCache
+	cacheDir    string // "" means DefaultCacheDir under the database root
+	files       recordFiles
+}
 
 // Validate performs basic validation of records against their collection schemas.
 // Returns a ValidationResult with any errors found.
-func (sv *simpleValidator) Validate(_ context.Context, _ string, def *ingitdb.Definition) (*ingitdb.ValidationResult, error) {
+func (sv *simpleValidator) Validate(ctx context.Context, dbPath string, def *ingitdb.Definition) (*ingitdb.ValidationResult, error) {
+	result, _, err := sv.validate(ctx, dbPath, def, false)
+	return result, err
+}
+
+// validate is Validate that, with collect set, also returns the records the
+// schema pass parsed, keyed by root collection ID, and has the foreign key
+// and unique passes reuse them instead of reading those collections again.
+func (sv *simpleValidator) validate(
+	ctx context.Context,
+	dbPath string,
+	def *ingitdb.Definition,
+	collect bool,
+) (*ingitdb.ValidationResult, map[string][]ParsedRecord, error) {
 	result := &ingitdb.ValidationResult{}
+	files := sv.files
 
-	for collectionKey, colDef := range def.Collections {
-		passed, total, errors := validateCollectionRecords(collectionKey, colDef)
-		for _, validationErr := range errors {
-			result.Append(validationErr)
-		}
-		// Record-count bounds are a collection-level invariant checked against
-		// the record count the schema pass just produced. Only root collections
-		// are walked here (this loop, like the schema pass, iterates
-		// def.Collections, never subcollections) — see the Feature's
-		// root-vs-subcollection note.
-		for _, validationErr := range checkRecordCountConstraints(collectionKey, colDef, total) {
-			result.Append(validationErr)
+	var cache *validationCache
+	if sv.cache {
+		cacheDir := sv.cacheDir
+		if cacheDir == "" {
+			cacheDir = filepath.Join(dbPath, DefaultCacheDir)
 		}
-		result.SetRecordCounts(collectionKey, passed, total)
-		result.SetRecordCount(collectionKey, total)
+		cache = loadValidationCache(cacheDir, files)
+	}
+	parsed, err := files.validateRootCollectionsParallel(ctx, def, result, sv.concurrency, cache, collect)
+	if err != nil {
+		return result, nil, err
+	}
+	if cache != nil {
+		_ = cache.save() // best effort: the next run just validates more files
 	}
 
-	// Subcollection records are not reached by the root loop above (it iterates
+	// Subcollection records are not reached by the root pass above (it iterates
 	// def.Collections only). This additive pass walks every collection's
 	// SubCollections recursively, resolving each subcollection's on-disk data
 	// per parent record, and validates their records with the same per-record
 	// checks and record-count enforcement. See
 	// spec/features/subcollection-record-validation.
-	validateSubCollections(def, result)
+	files.validateSubCollections(def, result)
 
 	// Referential integrity is a whole-definition concern — a value must exist
 	// as a key in the resolved target collection — so it runs after the
 	// per-collection schema pass, once every collection's keys are known. It
 	// covers both root and subcollection records (shared walk).
-	for _, validationErr := range validateForeignKeyReferences(def) {
+	loaded := make(map[string][]loadedRecord, len(parsed))
+	for id, records := range parsed {
+		converted := make([]loadedRecord, len(records))
+		for i, r := range records {
+			converted[i] = loadedRecord{Key: r.Key, Data: r.Data}
+		}
+		loaded[id] = converted
+	}
+	for _, validationErr := range files.validateForeignKeyReferencesLoaded(def, loaded) {
+		result.Append(validationErr)
+	}
+	// Uniqueness likewise compares records with each other, so it can only be
+	// checked once a collection's records have all been read.
+	for _, validationErr := range files.validateUniqueConstraints(def, loaded) {
 		result.Append(validationErr)
 	}
 
-	return result, nil
+	result.SortErrors()
+	return result, parsed, nil
 }
 
-func validateCollectionRecords(collectionKey string, colDef *ingitdb.CollectionDef) (int, int, []ingitdb.ValidationError) {
-	total, err := countRecords(colDef)
-	if err != nil {
-		total = 0
+// recordSink receives each record the schema pass parses, whether or not it
+// then passes validation. A nil sink is allowed. It lets one read of a
+// collection serve both validation and a later consumer (the Scanner's view
+// building) without parsing the files twice.
+type recordSink func(filePath, recordKey string, data map[string]any)
+
+func (sink recordSink) add(filePath, recordKey string, data map[string]any) {
+	if sink != nil {
+		sink(filePath, recordKey, data)
 	}
+}
+
+func (files recordFiles) validateCollectionRecords(collectionKey string, colDef *ingitdb.CollectionDef, sink recordSink) (int, int, []ingitdb.ValidationError) {
 	if shouldSkipRecordParsing(colDef) {
+		total, err := files.countRecords(colDef)
+		if err != nil {
+			total = 0
+		}
 		return total, total, nil
 	}
 	switch colDef.RecordFile.RecordType {
 	case ingitdb.SingleRecord:
-		return validateSingleRecordFiles(collectionKey, colDef)
+		return files.validateSingleRecordFiles(collectionKey, colDef, sink)
 	case ingitdb.MapOfRecords:
-		return validateMapOfRecordsFile(collectionKey, colDef)
+		return files.validateMapOfRecordsFile(collectionKey, colDef, sink)
 	case ingitdb.ListOfRecords:
-		return validateListOfRecordsFile(collectionKey, colDef)
+		return files.validateListOfRecordsFile(collectionKey, colDef, sink)
 	default:
 		validationErr := newValidationError(collectionKey, "", "", "", "unsupported record type", nil)
 		return 0, 0, []ingitdb.ValidationError{validationErr}
@@ -99,13 +152,13 @@ func shouldSkipRecordParsing(colDef *ingitdb.CollectionDef) bool {
 	return false
 }
 
-func validateSingleRecordFiles(collectionKey string, colDef *ingitdb.CollectionDef) (int, int, []ingitdb.ValidationError) {
+func (files recordFiles) validateSingleRecordFiles(collectionKey string, colDef *ingitdb.CollectionDef, sink recordSink) (int, int, []ingitdb.ValidationError) {
 	pattern, err := singleRecordGlobPattern(colDef)
 	if err != nil {
 		validationErr := newValidationError(collectionKey, "", "", "", "invalid record file pattern", err)
 		return 0, 0, []ingitdb.ValidationError{validationErr}
 	}
-	matches, err := filepath.Glob(pattern)
+	matches, err := files.glob(pattern)
 	if err != nil {
 		validationErr := newValidationError(collectionKey, pattern, "", "", "failed to glob record files", err)
 		return 0, 0, []ingitdb.ValidationError{validationErr}
@@ -114,7 +167,7 @@ func validateSingleRecordFiles(collectionKey string, colDef *ingitdb.CollectionD
 	total := 0
 	var errors []ingitdb.ValidationError
 	for _, filePath := range matches {
-		filePassed, fileTotal, fileErrors := validateSingleRecordFile(collectionKey, colDef, filePath)
+		filePassed, fileTotal, fileErrors := files.validateSingleRecordFile(collectionKey, colDef, filePath, sink)
 		passed += filePassed
 		total += fileTotal
 		errors = append(errors, fileErrors...)
@@ -125,11 +178,11 @@ func validateSingleRecordFiles(collectionKey string, colDef *ingitdb.CollectionD
 // validateSingleRecordFile validates one single-record file: it stats, reads,
 // parses, and schema-validates the record. It returns the per-file passed/total
 // counts (a skipped or directory path counts as 0/0) and any errors found.
-func validateSingleRecordFile(collectionKey string, colDef *ingitdb.CollectionDef, filePath string) (int, int, []ingitdb.ValidationError) {
+func (files recordFiles) validateSingleRecordFile(collectionKey string, colDef *ingitdb.CollectionDef, filePath string, sink recordSink) (int, int, []ingitdb.ValidationError) {
 	if skipRecordPath(filePath, colDef.RecordFile) {
 		return 0, 0, nil
 	}
-	info, statErr := os.Stat(filePath)
+	info, statErr := files.stat(filePath)
 	if statErr != nil {
 		validationErr := newValidationError(collectionKey, filePath, "", "", "failed to stat record file", statErr)
 		return 0, 1, []ingitdb.ValidationError{validationErr}
@@ -137,7 +190,7 @@ func validateSingleRecordFile(collectionKey string, colDef *ingitdb.CollectionDe
 	if info.IsDir() {
 		return 0, 0, nil
 	}
-	content, readErr := os.ReadFile(filePath)
+	content, readErr := files.readFile(filePath)
 	if readErr != nil {
 		validationErr := newValidationError(collectionKey, filePath, "", "", "failed to read record file", readErr)
 		return 0, 1, []ingitdb.ValidationError{validationErr}
@@ -148,6 +201,7 @@ func validateSingleRecordFile(collectionKey string, colDef *ingitdb.CollectionDe
 		return 0, 1, []ingitdb.ValidationError{validationErr}
 	}
 	recordKey := recordKeyFromFilePath(filePath)
+	sink.add(filePath, recordKey, data)
 	recordErrors := validateRecordData(collectionKey, filePath, recordKey, colDef, data)
 	if len(recordErrors) > 0 {
 		return 0, 1, recordErrors
@@ -179,65 +233,66 @@ func recordKeyFromFilePath(filePath string) string {
 	return strings.TrimSuffix(name, ext)
 }
 
-func validateMapOfRecordsFile(collectionKey string, colDef *ingitdb.CollectionDef) (int, int, []ingitdb.ValidationError) {
+func (files recordFiles) validateMapOfRecordsFile(collectionKey string, colDef *ingitdb.CollectionDef, sink recordSink) (int, int, []ingitdb.ValidationError) {
 	filePath := collectionRecordFilePath(colDef)
-	content, ok, validationErr := readRecordsFile(collectionKey, filePath)
+	f, ok, validationErr := files.openRecordsFile(collectionKey, filePath)
 	if !ok {
 		if validationErr.Message == "" {
 			return 0, 0, nil
 		}
 		return 0, 1, []ingitdb.ValidationError{validationErr}
 	}
-	records, err := ingitdb.ParseMapOfRecordsContent(content, colDef.RecordFile.Format)
-	if err != nil {
-		validationErr = newValidationError(collectionKey, filePath, "", "", "failed to parse records file", err)
-		return 0, 1, []ingitdb.ValidationError{validationErr}
-	}
-	passed := 0
+	defer func() { _ = f.Close() }()
+	passed, total := 0, 0
 	var errors []ingitdb.ValidationError
-	for recordKey, data := range records {
+	err := ingitdb.StreamMapOfRecords(context.Background(), f, colDef.RecordFile.Format, func(recordKey string, data map[string]any) error {
+		total++
+		sink.add(filePath, recordKey, data)
 		recordErrors := validateRecordData(collectionKey, filePath, recordKey, colDef, data)
 		if len(recordErrors) > 0 {
 			errors = append(errors, recordErrors...)
-			continue
+			return nil
 		}
 		passed++
+		return nil
+	})
+	if err != nil {
+		return passed, total + 1, append(errors, newValidationError(collectionKey, filePath, "", "", "failed to parse records file", err))
 	}
-	total := len(records)
 	return passed, total, errors
 }
 
-func validateListOfRecordsFile(collectionKey string, colDef *ingitdb.CollectionDef) (int, int, []ingitdb.ValidationError) {
+func (files recordFiles) validateListOfRecordsFile(collectionKey string, colDef *ingitdb.CollectionDef, sink recordSink) (int, int, []ingitdb.ValidationError) {
 	filePath := collectionRecordFilePath(colDef)
-	content, ok, validationErr := readRecordsFile(collectionKey, filePath)
+	f, ok, validationErr := files.openRecordsFile(collectionKey, filePath)
 	if !ok {
 		if validationErr.Message == "" {
 			return 0, 0, nil
 		}
 		return 0, 1, []ingitdb.ValidationError{validationErr}
 	}
-	rows, err := parseListRows(content, colDef)
-	if err != nil {
-		validationErr = newValidationError(collectionKey, filePath, "", "", "failed to parse records file", err)
-		return 0, 1, []ingitdb.ValidationError{validationErr}
-	}
-	passed := 0
+	defer func() { _ = f.Close() }()
+	passed, total := 0, 0
 	var errors []ingitdb.ValidationError
-	for _, row := range rows {
+	err := ingitdb.StreamListOfRecords(context.Background(), f, colDef, func(row map[string]any) error {
+		total++
 		recordKey, keyOK := ingitdb.ResolveListRecordKey(row, colDef)
 		if !keyOK {
-			validationErr = newValidationError(collectionKey, filePath, "", "", "list record has no resolvable key", nil)
-			errors = append(errors, validationErr)
-			continue
+			errors = append(errors, newValidationError(collectionKey, filePath, "", "", "list record has no resolvable key", nil))
+			return nil
 		}
+		sink.add(filePath, recordKey, row)
 		recordErrors := validateRecordData(collectionKey, filePath, recordKey, colDef, row)
 		if len(recordErrors) > 0 {
 			errors = append(errors, recordErrors...)
-			continue
+			return nil
 		}
 		passed++
+		return nil
+	})
+	if err != nil {
+		return passed, total + 1, append(errors, newValidationError(collectionKey, filePath, "", "", "failed to parse records file", err))
 	}
-	total := len(rows)
 	return passed, total, errors
 }
 
@@ -246,10 +301,14 @@ func collectionRecordFilePath(colDef *ingitdb.CollectionDef) string {
 	return filepath.Join(baseDir, colDef.RecordFile.Name)
 }
 
-func readRecordsFile(collectionKey, filePath string) ([]byte, bool, ingitdb.ValidationError) {
-	content, err := os.ReadFile(filePath)
+// openRecordsFile opens a map or list records file for streaming. ok is false
+// when the file is absent (no error) or cannot be opened (validationErr set).
+// Records are decoded as they are read, so a large file is never loaded whole;
+// a parse error part way through still reports the records before it.
+func (files recordFiles) openRecordsFile(collectionKey, filePath string) (io.ReadCloser, bool, ingitdb.ValidationError) {
+	f, err := files.open(filePath)
 	if err == nil {
-		return content, true, ingitdb.ValidationError{}
+		return f, true, ingitdb.ValidationError{}
 	}
 	if os.IsNotExist(err) {
 		return nil, false, ingitdb.ValidationError{}
@@ -258,45 +317,13 @@ func readRecordsFile(collectionKey, filePath string) ([]byte, bool, ingitdb.Vali
 	return nil, false, validationErr
 }
 
-func parseListRows(content []byte, colDef *ingitdb.CollectionDef) ([]map[string]any, error) {
-	switch colDef.RecordFile.Format {
-	case ingitdb.RecordFormatCSV:
-		data, err := ingitdb.ParseRecordContentForCollection(content, colDef)
-		if err != nil {
-			return nil, err
-		}
-		rawRows, ok := data[listRecordsKey]
-		if !ok {
-			return nil, fmt.Errorf("csv parser did not return %q rows", listRecordsKey)
-		}
-		rows, ok := rawRows.([]map[string]any)
-		if !ok {
-			return nil, fmt.Errorf("csv parser returned %q as %T", listRecordsKey, rawRows)
-		}
-		return rows, nil
-	case ingitdb.RecordFormatINGR:
-		records, err := ingitdb.ParseMapOfRecordsContent(content, ingitdb.RecordFormatINGR)
-		if err != nil {
-			return nil, err
-		}
-		rows := make([]map[string]any, 0, len(records))
-		for key, record := range records {
-			record["$ID"] = key
-			rows = append(rows, record)
-		}
-		return rows, nil
-	default:
-		return ingitdb.ParseListOfRecordsContent(content, colDef.RecordFile.Format)
-	}
-}
-
 // reservedFieldPrefix marks a key the library owns rather than the schema.
-// datavalidator sets record["$ID"] for INGR input and parse.go sets row["$ID"]
+// the INGR decoder keeps record["$ID"] in every row and parse.go sets row["$ID"]
 // for CSV, so these keys appear in records without ever being declared.
 const reservedFieldPrefix = "$"
 
 // reservedIDField is the synthetic per-record key the library supplies from the
-// record's identity: validator.go sets it for INGR, parse.go for CSV, and
+// record's identity: the INGR decoder supplies it, parse.go for CSV, and
 // validateRecordData binds it from recordKey for per-file records.
 const reservedIDField = "$ID"
 
@@ -453,10 +480,89 @@ func validateRecordData(
 			errors = append(errors, newValidationError(collectionKey, filePath, recordKey, fieldName, err.Error(), nil))
 			continue
 		}
+		if err := checkPattern(fieldName, value, columnDef.Pattern); err != nil {
+			errors = append(errors, newValidationError(collectionKey, filePath, recordKey, fieldName, err.Error(), nil))
+			continue
+		}
+		if columnDef.ValidateFormat {
+			if err := checkFormat(fieldName, value, columnDef.Format); err != nil {
+				errors = append(errors, newValidationError(collectionKey, filePath, recordKey, fieldName, err.Error(), nil))
+				continue
+			}
+		}
+	}
+	errors = append(errors, recordCheckErrors(collectionKey, filePath, recordKey, colDef, data)...)
+	return errors
+}
+
+// recordCheckErrors evaluates the collection's `checks:` against one record, in
+// name order, and reports each one that does not hold.
+//
+// A check is bound to the same environment as required_when (storedFields), so
+// a column the record omits is None. An expression that cannot be evaluated
+// for this record — comparing None with a number, say — is reported rather
+// than treated as passing.
+func recordCheckErrors(
+	collectionKey string,
+	filePath string,
+	recordKey string,
+	colDef *ingitdb.CollectionDef,
+	data map[string]any,
+) []ingitdb.ValidationError {
+	if len(colDef.Checks) == 0 {
+		return nil
+	}
+	fields := checkFields(colDef, data)
+	var errors []ingitdb.ValidationError
+	for _, name := range slices.Sorted(maps.Keys(colDef.Checks)) {
+		check := colDef.Checks[name]
+		result, err := ingitdb.EvaluateFormula(check.Expr, fields)
+		if err != nil {
+			message := fmt.Sprintf("check %q could not be evaluated", name)
+			errors = append(errors, newValidationError(collectionKey, filePath, recordKey, "", message, err))
+			continue
+		}
+		passed, ok := result.(bool)
+		if !ok {
+			message := fmt.Sprintf("check %q must evaluate to True or False, got %T", name, result)
+			errors = append(errors, newValidationError(collectionKey, filePath, recordKey, "", message, nil))
+			continue
+		}
+		if !passed {
+			message := cmp.Or(check.Message, check.Expr)
+			errors = append(errors, newValidationError(collectionKey, filePath, recordKey, "",
+				fmt.Sprintf("check %q failed: %s", name, message), nil))
+		}
 	}
 	return errors
 }
 
+// checkTimeLayout renders a time in UTC with a fixed-width fraction, so two
+// rendered times order as strings the way they order in time.
+const checkTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// checkFields is storedFields for a check expression, with the time.Time
+// values YAML makes of unquoted dates and timestamps turned into strings a
+// check can compare: a date column's value becomes its ISO date, as a JSON
+// record stores it, and any other time a checkTimeLayout timestamp, so
+// `end >= start` holds whatever offsets the two were written with. Formulas,
+// required_when and view where expressions get no such conversion.
+func checkFields(colDef *ingitdb.CollectionDef, data map[string]any) map[string]any {
+	fields := storedFields(colDef, data)
+	for name, value := range fields {
+		t, ok := value.(time.Time)
+		if !ok {
+			continue
+		}
+		if colDef.Columns[name].Type == ingitdb.ColumnTypeDate {
+			fields[name] = t.Format(time.DateOnly)
+		} else {
+			fields[name] = t.UTC().Format(checkTimeLayout)
+		}
+	}
+	return fields
+}
+
 // checkEnum reports whether value is one of the column's permitted members.
 // An empty enum means the column is unconstrained by this rule.
 func checkEnum(fieldName string, value any, enum []any) error {
@@ -646,14 +752,19 @@ func checkRecordCountConstraints(collectionKey string, colDef *ingitdb.Collectio
 // countRecords counts the number of record keys in a collection directory.
 // When a $records/ subdirectory exists (used for per-key record files), it
 // counts entries inside that directory instead of at the collection root.
-func countRecords(colDef *ingitdb.CollectionDef) (int, error) {
+// A collection stored in one map/list file counts the records in that file.
+func (files recordFiles) countRecords(colDef *ingitdb.CollectionDef) (int, error) {
+	if !shouldSkipRecordParsing(colDef) && colDef.RecordFile.RecordType != ingitdb.SingleRecord {
+		records, err := files.loadCollectionRecords(colDef)
+		return len(records), err
+	}
 	collectionPath := colDef.DirPath
 	exts := expectedRecordExtensions(colDef)
 	recordsSubDir := filepath.Join(collectionPath, "$records")
-	if info, err := os.Stat(recordsSubDir); err == nil && info.IsDir() {
-		return countEntries(recordsSubDir, exts, colDef.RecordFile)
+	if info, err := files.stat(recordsSubDir); err == nil && info.IsDir() {
+		return files.countEntries(recordsSubDir, exts, colDef.RecordFile)
 	}
-	return countEntries(collectionPath, exts, colDef.RecordFile)
+	return files.countEntries(collectionPath, exts, colDef.RecordFile)
 }
 
 // expectedRecordExtensions returns the file extensions that count as record
@@ -677,8 +788,8 @@ func expectedRecordExtensions(colDef *ingitdb.CollectionDef) map[string]struct{}
 	return map[string]struct{}{".yaml": {}, ".yml": {}, ".json": {}}
 }
 
-func countEntries(dirPath string, exts map[string]struct{}, rfd *ingitdb.RecordFileDef) (int, error) {
-	entries, err := os.ReadDir(dirPath)
+func (files recordFiles) countEntries(dirPath string, exts map[string]struct{}, rfd *ingitdb.RecordFileDef) (int, error) {
+	entries, err := files.readDir(dirPath)
 	if err != nil {
 		return 0, err
 	}
@@ -770,6 +881,71 @@ func checkLength(fieldName string, value any, exact, minLen, maxLen *int) error
 	return nil
 }
 
+// patternCache holds compiled column patterns, keyed by source. A pattern is
+// compiled once per run rather than once per record.
+var patternCache sync.Map // string -> *regexp.Regexp
+
+// checkPattern enforces a column's pattern on a string value or on every
+// element of a list value. Definition load has already rejected a pattern that
+// does not compile, so a compile error here only reaches a caller that built
+// its CollectionDef by hand; it is reported rather than ignored.
+func checkPattern(fieldName string, value any, pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+	re, ok := patternCache.Load(pattern)
+	if !ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern for field %q: %w", fieldName, err)
+		}
+		re, _ = patternCache.LoadOrStore(pattern, compiled)
+	}
+	return forEachStringElement(fieldName, value, func(label, s string) error {
+		if !re.(*regexp.Regexp).MatchString(s) {
+			return fmt.Errorf("value %q for %s does not match pattern %q", s, label, pattern)
+		}
+		return nil
+	})
+}
+
+// checkFormat enforces a column's format (see ingitdb.CheckFormat) on a
+// string value or on every element of a list value.
+func checkFormat(fieldName string, value any, format string) error {
+	return forEachStringElement(fieldName, value, func(label, s string) error {
+		if err := ingitdb.CheckFormat(format, s); err != nil {
+			return fmt.Errorf("value for %s is not a valid %s: %w", label, format, err)
+		}
+		return nil
+	})
+}
+
+// forEachStringElement calls check with a string value, or with each string
+// element of a list value, labelled for the error message: `field "tags"` or
+// `field "tags"[2]`. Values of other kinds have already failed the type check.
+func forEachStringElement(fieldName string, value any, check func(label, s string) error) error {
+	label := fmt.Sprintf("field %q", fieldName)
+	switch v := value.(type) {
+	case string:
+		return check(label, v)
+	case []string:
+		for i, s := range v {
+			if err := check(fmt.Sprintf("%s[%d]", label, i), s); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, e := range v {
+			if s, ok := e.(string); ok {
+				if err := check(fmt.Sprintf("%s[%d]", label, i), s); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // valueLength reports a value's length and whether length is defined for it.
 // Strings count runes rather than bytes, so a multi-byte character counts once.
 func valueLength(value any) (int, bool) {
@@ -3,11 +3,189 @@ package ingitdb
 // specscore: feature/column-validation
 
 import (
+	"errors"
 	"fmt"
+	"maps"
 	"slices"
 	"strings"
 )
 
+// ForeignKeyDef declares a composite foreign key under a collection's
+// `foreign_keys:` list:
+//
+//	foreign_keys:
+//	  - columns: [country, city_code]
+//	    references: cities
+//
+// The values of Columns, in order, must together equal the primary_key of a
+// record in References, which is therefore a list-of-records collection whose
+// primary_key has exactly as many columns; its record keys are those values
+// joined (ResolveListRecordKey). References resolves like a column's
+// foreign_key (ResolveForeignKey). A record missing any of the columns does
+// not reference anything and is not checked.
+type ForeignKeyDef struct {
+	Columns    []string       `yaml:"columns"`
+	References string         `yaml:"references"`
+	OnDelete   OnDeleteAction `yaml:"on_delete,omitempty"`
+}
+
+// OnDeleteAction is what happens to a referencing record when the record it
+// references is deleted. It is declared as `on_delete` next to a foreign key.
+type OnDeleteAction string
+
+const (
+	// OnDeleteRestrict refuses the deletion while the record is referenced.
+	// It is the default when on_delete is not declared.
+	OnDeleteRestrict OnDeleteAction = "restrict"
+	// OnDeleteCascade deletes the referencing record as well.
+	OnDeleteCascade OnDeleteAction = "cascade"
+	// OnDeleteSetNull clears the reference: the column (every column, for a
+	// composite key) is set to null, and a list column drops the element.
+	OnDeleteSetNull OnDeleteAction = "set_null"
+)
+
+// validateOnDelete checks an on_delete declaration. set_null cannot apply to a
+// required column: the referencing record would become invalid.
+func validateOnDelete(action OnDeleteAction, columns map[string]*ColumnDef, names []string) error {
+	switch action {
+	case "", OnDeleteRestrict, OnDeleteCascade:
+	case OnDeleteSetNull:
+		for _, name := range names {
+			if col := columns[name]; col != nil && col.Required {
+				return fmt.Errorf("on_delete: set_null cannot clear required column '%s'", name)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown on_delete %q, must be one of: restrict, cascade, set_null", action)
+	}
+	return nil
+}
+
+// validate checks what a composite foreign key can check without seeing the
+// rest of the definition; the target is checked by ValidateForeignKeys.
+func (fk ForeignKeyDef) validate(columns map[string]*ColumnDef) error {
+	if fk.References == "" {
+		return errors.New("missing 'references'")
+	}
+	if len(fk.Columns) == 0 {
+		return errors.New("missing 'columns'")
+	}
+	for i, name := range fk.Columns {
+		col, ok := columns[name]
+		if !ok {
+			return fmt.Errorf("columns[%d] references unspecified column: %s", i, name)
+		}
+		if col.Formula != "" {
+			return fmt.Errorf("column '%s' is computed: a foreign key may reference only stored columns", name)
+		}
+		if slices.Contains(fk.Columns[:i], name) {
+			return fmt.Errorf("column '%s' is listed twice", name)
+		}
+	}
+	return validateOnDelete(fk.OnDelete, columns, fk.Columns)
+}
+
+// ForeignKeyRef is one foreign key of a collection, declared either on a
+// column or under `foreign_keys:`, with its target resolved.
+type ForeignKeyRef struct {
+	Columns   []string // the referencing column, or a composite key's columns
+	Composite bool     // declared under foreign_keys
+	Target    string   // resolved root collection id
+	OnDelete  OnDeleteAction
+}
+
+// ForeignKeyRefs returns the foreign keys of col whose targets resolve, column
+// foreign keys first in column name order, then composite ones as declared.
+// fullID is col's full id, used for module-relative resolution.
+func ForeignKeyRefs(fullID string, col *CollectionDef, collections map[string]*CollectionDef) []ForeignKeyRef {
+	var refs []ForeignKeyRef
+	for _, name := range slices.Sorted(maps.Keys(col.Columns)) {
+		colDef := col.Columns[name]
+		if colDef.ForeignKey == "" {
+			continue
+		}
+		if target, ok := ResolveForeignKey(fullID, colDef.ForeignKey, collections); ok {
+			refs = append(refs, ForeignKeyRef{Columns: []string{name}, Target: target, OnDelete: colDef.OnDelete})
+		}
+	}
+	for _, fk := range col.ForeignKeys {
+		if target, ok := ResolveForeignKey(fullID, fk.References, collections); ok {
+			refs = append(refs, ForeignKeyRef{Columns: fk.Columns, Composite: true, Target: target, OnDelete: fk.OnDelete})
+		}
+	}
+	return refs
+}
+
+// Keys returns the keys of Target that a record's data references through
+// this foreign key (see ForeignKeyValues and CompositeForeignKeyValue).
+func (r ForeignKeyRef) Keys(data map[string]any) []string {
+	if !r.Composite {
+		return ForeignKeyValues(data[r.Columns[0]])
+	}
+	if key, ok := CompositeForeignKeyValue(data, r.Columns); ok {
+		return []string{key}
+	}
+	return nil
+}
+
+// Action returns the on_delete action, with the restrict default applied.
+func (r ForeignKeyRef) Action() OnDeleteAction {
+	if r.OnDelete == "" {
+		return OnDeleteRestrict
+	}
+	return r.OnDelete
+}
+
+// ForeignKeyValues returns the record keys a foreign_key column's value refers
+// to: the value itself for a scalar column, every element for a list column
+// such as `tags: [a, b]`. Nil and empty values and elements are skipped — an
+// absent reference is a required/optional concern, not referential integrity.
+func ForeignKeyValues(value any) []string {
+	var elems []any
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		elems = v
+	case []string:
+		elems = make([]any, len(v))
+		for i, s := range v {
+			elems[i] = s
+		}
+	default:
+		elems = []any{v}
+	}
+	keys := make([]string, 0, len(elems))
+	for _, e := range elems {
+		if e == nil {
+			continue
+		}
+		if key := fmt.Sprintf("%v", e); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// CompositeForeignKeyValue returns the record key a composite foreign key of
+// a record refers to: the values of columns joined the way
+// ResolveListRecordKey joins a composite primary key. ok is false when any of
+// the values is missing, nil or empty, in which case nothing is referenced.
+func CompositeForeignKeyValue(data map[string]any, columns []string) (string, bool) {
+	parts := make([]string, len(columns))
+	for i, name := range columns {
+		v := data[name]
+		if v == nil {
+			return "", false
+		}
+		parts[i] = fmt.Sprintf("%v", v)
+		if parts[i] == "" {
+			return "", false
+		}
+	}
+	return strings.Join(parts, listKeySeparator), true
+}
+
 // collectionModule returns the module prefix of a collection's full id: the
 // segment before the first ".". Root collections register module-namespaced as
 // `<module>.<name>` (`commerce.countries`, `geo.countries`), and a
@@ -107,6 +285,21 @@ func ValidateForeignKeys(def *Definition) error {
 						full, name, fk, strings.Join(targets, ", ")))
 				}
 			}
+			for i, fk := range col.ForeignKeys {
+				target, ok := ResolveForeignKey(full, fk.References, def.Collections)
+				if !ok {
+					problems = append(problems, fmt.Sprintf(
+						"collection '%s': foreign_keys[%d] references '%s', which does not resolve to any collection in this definition (known collections: %s)",
+						full, i, fk.References, strings.Join(targets, ", ")))
+					continue
+				}
+				targetDef := def.Collections[target]
+				if targetDef.RecordFile == nil || targetDef.RecordFile.RecordType != ListOfRecords || len(targetDef.PrimaryKey) != len(fk.Columns) {
+					problems = append(problems, fmt.Sprintf(
+						"collection '%s': foreign_keys[%d] lists %d columns, but '%s' is not a list-of-records collection with a %d-column primary_key",
+						full, i, len(fk.Columns), target, len(fk.Columns)))
+				}
+			}
 			walk(full, col.SubCollections)
 		}
 	}
@@ -180,3 +180,181 @@ func TestValidateForeignKeys_NoFKsIsClean(t *testing.T) {
 		t.Errorf("a definition with no foreign keys must be clean, got: %v", err)
 	}
 }
+
+func TestForeignKeyValues(t *testing.T) {
+	cases := []struct {
+		name  string
+		value any
+		want  []string
+	}{
+		{"nil", nil, nil},
+		{"scalar", "ie", []string{"ie"}},
+		{"empty scalar", "", []string{}},
+		{"number", 7, []string{"7"}},
+		{"decoded list", []any{"a", nil, "", "b"}, []string{"a", "b"}},
+		{"string list", []string{"a", "b"}, []string{"a", "b"}},
+	}
+	for _, tc := range cases {
+		got := ForeignKeyValues(tc.value)
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") || len(got) != len(tc.want) {
+			t.Errorf("%s: ForeignKeyValues(%v) = %q, want %q", tc.name, tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestCompositeForeignKeyValue(t *testing.T) {
+	key, ok := CompositeForeignKeyValue(map[string]any{"country": "ie", "city": "dub"}, []string{"country", "city"})
+	if !ok || key != "ie"+listKeySeparator+"dub" {
+		t.Errorf("got %q, %v; want the values joined like a composite primary key", key, ok)
+	}
+	listKey, _ := ResolveListRecordKey(map[string]any{"country": "ie", "city": "dub"}, &CollectionDef{PrimaryKey: []string{"country", "city"}})
+	if key != listKey {
+		t.Errorf("composite FK value %q must equal the list record key %q", key, listKey)
+	}
+	for _, data := range []map[string]any{{"country": "ie"}, {"country": "ie", "city": nil}, {"country": "", "city": "dub"}} {
+		if _, ok = CompositeForeignKeyValue(data, []string{"country", "city"}); ok {
+			t.Errorf("%v: a missing or empty part must reference nothing", data)
+		}
+	}
+}
+
+func TestValidateForeignKeys_Composite(t *testing.T) {
+	cities := &CollectionDef{
+		ID:         "cities",
+		PrimaryKey: []string{"country", "code"},
+		RecordFile: &RecordFileDef{Name: "cities.yaml", Format: RecordFormatYAML, RecordType: ListOfRecords},
+	}
+	offices := func(fk ForeignKeyDef) *Definition {
+		return &Definition{Collections: map[string]*CollectionDef{
+			"cities":  cities,
+			"offices": {ID: "offices", ForeignKeys: []ForeignKeyDef{fk}},
+		}}
+	}
+	if err := ValidateForeignKeys(offices(ForeignKeyDef{Columns: []string{"country", "city"}, References: "cities"})); err != nil {
+		t.Errorf("a composite FK matching the target primary_key must be accepted, got: %v", err)
+	}
+	err := ValidateForeignKeys(offices(ForeignKeyDef{Columns: []string{"city"}, References: "cities"}))
+	if err == nil || !strings.Contains(err.Error(), "not a list-of-records collection with a 1-column primary_key") {
+		t.Errorf("a column-count mismatch must be rejected, got: %v", err)
+	}
+	err = ValidateForeignKeys(offices(ForeignKeyDef{Columns: []string{"country", "city"}, References: "towns"}))
+	if err == nil || !strings.Contains(err.Error(), "foreign_keys[0] references 'towns'") {
+		t.Errorf("an unresolved composite FK must be rejected, got: %v", err)
+	}
+}
+
+func TestCollectionDef_Validate_CompositeForeignKeys(t *testing.T) {
+	cases := []struct {
+		fk      ForeignKeyDef
+		wantErr string
+	}{
+		{ForeignKeyDef{Columns: []string{"country", "city"}, References: "cities"}, ""},
+		{ForeignKeyDef{Columns: []string{"country", "city"}}, "missing 'references'"},
+		{ForeignKeyDef{References: "cities"}, "missing 'columns'"},
+		{ForeignKeyDef{Columns: []string{"country", "town"}, References: "cities"}, "unspecified column: town"},
+		{ForeignKeyDef{Columns: []string{"country", "country"}, References: "cities"}, "listed twice"},
+		{ForeignKeyDef{Columns: []string{"country", "label"}, References: "cities"}, "is computed"},
+	}
+	for _, tc := range cases {
+		def := &CollectionDef{
+			ID:         "offices",
+			RecordFile: &RecordFileDef{Name: "{key}.json", Format: RecordFormatJSON, RecordType: "map[string]any"},
+			Columns: map[string]*ColumnDef{
+				"country": {Type: ColumnTypeString},
+				"city":    {Type: ColumnTypeString},
+				"label":   {Type: ColumnTypeString, Formula: "country"},
+			},
+			ForeignKeys: []ForeignKeyDef{tc.fk},
+		}
+		err := def.Validate()
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("%v: unexpected error: %v", tc.fk, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%v: error = %v, want it to contain %q", tc.fk, err, tc.wantErr)
+		}
+	}
+}
+
+func TestCollectionDef_Validate_OnDelete(t *testing.T) {
+	cases := []struct {
+		name    string
+		col     *ColumnDef
+		fk      *ForeignKeyDef
+		wantErr string
+	}{
+		{"cascade", &ColumnDef{Type: ColumnTypeString, ForeignKey: "countries", OnDelete: OnDeleteCascade}, nil, ""},
+		{"set_null on optional column", &ColumnDef{Type: ColumnTypeString, ForeignKey: "countries", OnDelete: OnDeleteSetNull}, nil, ""},
+		{"unknown action", &ColumnDef{Type: ColumnTypeString, ForeignKey: "countries", OnDelete: "nullify"}, nil, `unknown on_delete "nullify"`},
+		{"without foreign_key", &ColumnDef{Type: ColumnTypeString, OnDelete: OnDeleteCascade}, nil, "on_delete requires foreign_key"},
+		{"set_null on required column", &ColumnDef{Type: ColumnTypeString, Required: true, ForeignKey: "countries", OnDelete: OnDeleteSetNull}, nil, "cannot clear required column 'country'"},
+		{"composite set_null on required column", &ColumnDef{Type: ColumnTypeString, Required: true},
+			&ForeignKeyDef{Columns: []string{"country", "city"}, References: "cities", OnDelete: OnDeleteSetNull}, "cannot clear required column 'country'"},
+	}
+	for _, tc := range cases {
+		def := &CollectionDef{
+			ID:         "offices",
+			RecordFile: &RecordFileDef{Name: "{key}.json", Format: RecordFormatJSON, RecordType: "map[string]any"},
+			Columns: map[string]*ColumnDef{
+				"country": tc.col,
+				"city":    {Type: ColumnTypeString},
+			},
+		}
+		if tc.fk != nil {
+			def.ForeignKeys = []ForeignKeyDef{*tc.fk}
+		}
+		err := def.Validate()
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tc.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: error = %v, want it to contain %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestForeignKeyRefs(t *testing.T) {
+	collections := map[string]*CollectionDef{
+		"countries": {ID: "countries"},
+		"cities":    {ID: "cities"},
+		"offices": {ID: "offices",
+			Columns: map[string]*ColumnDef{
+				"tags":    {Type: "[]string", ForeignKey: "countries", OnDelete: OnDeleteSetNull},
+				"country": {Type: ColumnTypeString, ForeignKey: "countries", OnDelete: OnDeleteCascade},
+				"city":    {Type: ColumnTypeString},
+				"note":    {Type: ColumnTypeString},
+			},
+			ForeignKeys: []ForeignKeyDef{{Columns: []string{"country", "city"}, References: "cities"}},
+		},
+	}
+	refs := ForeignKeyRefs("offices", collections["offices"], collections)
+	if len(refs) != 3 {
+		t.Fatalf("want 3 refs, got %+v", refs)
+	}
+	if refs[0].Columns[0] != "country" || refs[0].Action() != OnDeleteCascade {
+		t.Errorf("refs[0] = %+v, want country with cascade", refs[0])
+	}
+	if refs[1].Columns[0] != "tags" || refs[1].Action() != OnDeleteSetNull {
+		t.Errorf("refs[1] = %+v, want tags with set_null", refs[1])
+	}
+	if !refs[2].Composite || refs[2].Target != "cities" || refs[2].Action() != OnDeleteRestrict {
+		t.Errorf("refs[2] = %+v, want composite cities with the restrict default", refs[2])
+	}
+
+	data := map[string]any{"tags": []any{"ie", "fr"}, "country": "ie", "city": "dub"}
+	if got := refs[1].Keys(data); len(got) != 2 || got[0] != "ie" || got[1] != "fr" {
+		t.Errorf("list keys = %v", got)
+	}
+	if got := refs[2].Keys(data); len(got) != 1 || got[0] != "ie\x1fdub" {
+		t.Errorf("composite keys = %q", got)
+	}
+	if got := refs[2].Keys(map[string]any{"country": "ie"}); len(got) != 0 {
+		t.Errorf("a partial composite key references nothing, got %q", got)
+	}
+}
@@ -0,0 +1,65 @@
+package gitdiff
+
+// specscore: feature/cli/validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitFileReader reads a file's content as of a git ref.
+type GitFileReader interface {
+	// ReadFile returns the content of path, relative to the repository root,
+	// at ref. An empty ref reads the working tree and IndexRef the staged
+	// blob, matching DiffFiles' toRef. ok is false when the file does not
+	// exist at ref.
+	ReadFile(ctx context.Context, repoPath, ref, path string) (content []byte, ok bool, err error)
+}
+
+// NewGitFileReader returns the default GitFileReader, which shells out to git.
+func NewGitFileReader() GitFileReader {
+	return cmdGitFileReader{}
+}
+
+type cmdGitFileReader struct{}
+
+// ReadFile resolves `<ref>:<path>`, or `:<path>` for IndexRef, with
+// `git rev-parse --verify` — which fails quietly when the path is absent at
+// ref — and prints the blob with `git cat-file blob`.
+func (cmdGitFileReader) ReadFile(ctx context.Context, repoPath, ref, path string) ([]byte, bool, error) {
+	if ref == "" {
+		content, err := os.ReadFile(filepath.Join(repoPath, filepath.FromSlash(path)))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, false, nil
+		}
+		return content, err == nil, err
+	}
+	object := ref + ":" + filepath.ToSlash(path)
+	if ref == IndexRef {
+		object = ":" + filepath.ToSlash(path) // stage 0 of the index
+	}
+	revParse := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", object)
+	revParse.Dir = repoPath
+	out, err := revParse.Output()
+	if err != nil {
+		// --quiet exits 1 for a name that does not resolve, and 128 for a
+		// real failure such as repoPath not being a repository.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("git rev-parse %s failed: %w", object, err)
+	}
+	catFile := exec.CommandContext(ctx, "git", "cat-file", "blob", strings.TrimSpace(string(out)))
+	catFile.Dir = repoPath
+	content, err := catFile.Output()
+	if err != nil {
+		return nil, false, fmt.Errorf("git cat-file %s failed: %w", object, err)
+	}
+	return content, true, nil
+}
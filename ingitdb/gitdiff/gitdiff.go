@@ -17,6 +17,10 @@ type GitDiffer interface {
 	DiffFiles(ctx context.Context, repoPath, fromRef, toRef string) ([]ingitdb.ChangedFile, error)
 }
 
+// IndexRef, passed to DiffFiles as toRef, diffs fromRef against the index —
+// the changes staged for the next commit — instead of the working tree.
+const IndexRef = ":index"
+
 // NewGitDiffer returns the default GitDiffer, which shells out to git.
 func NewGitDiffer() GitDiffer {
 	return cmdGitDiffer{}
@@ -25,14 +29,20 @@ func NewGitDiffer() GitDiffer {
 type cmdGitDiffer struct{}
 
 // DiffFiles runs `git diff --name-status <fromRef> [<toRef>]` in repoPath and
-// parses the result. An empty toRef diffs fromRef against the working tree.
+// parses the result. An empty toRef diffs fromRef against the working tree,
+// and IndexRef against the index (`--cached`).
 func (cmdGitDiffer) DiffFiles(ctx context.Context, repoPath, fromRef, toRef string) ([]ingitdb.ChangedFile, error) {
 	if fromRef == "" {
 		return nil, fmt.Errorf("from ref is required")
 	}
-	args := []string{"diff", "--name-status", fromRef}
-	if toRef != "" {
-		args = append(args, toRef)
+	args := []string{"diff", "--name-status"}
+	switch toRef {
+	case "":
+		args = append(args, fromRef)
+	case IndexRef:
+		args = append(args, "--cached", fromRef)
+	default:
+		args = append(args, fromRef, toRef)
 	}
 	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = repoPath
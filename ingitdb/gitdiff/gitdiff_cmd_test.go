@@ -95,3 +95,62 @@ func TestCmdGitDiffer_DiffFiles_RealRepo(t *testing.T) {
 		t.Errorf("b.yaml kind = %q, want added", kinds["b.yaml"])
 	}
 }
+
+func TestCmdGitFileReader_ReadFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		c := exec.Command("git", args...)
+		c.Dir = dir
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init")
+	git("config", "user.email", "t@example.com")
+	git("config", "user.name", "T")
+	if err := os.MkdirAll(filepath.Join(dir, "countries"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "countries", "ie.yaml"), []byte("v: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "-m", "base")
+	if err := os.WriteFile(filepath.Join(dir, "countries", "ie.yaml"), []byte("v: 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	if err := os.WriteFile(filepath.Join(dir, "countries", "ie.yaml"), []byte("v: 3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := NewGitFileReader()
+	ctx := context.Background()
+	content, ok, err := reader.ReadFile(ctx, dir, "HEAD", "countries/ie.yaml")
+	if err != nil || !ok || string(content) != "v: 1\n" {
+		t.Errorf("at HEAD: content=%q ok=%v err=%v, want committed content", content, ok, err)
+	}
+	content, ok, err = reader.ReadFile(ctx, dir, IndexRef, "countries/ie.yaml")
+	if err != nil || !ok || string(content) != "v: 2\n" {
+		t.Errorf("index: content=%q ok=%v err=%v, want staged content", content, ok, err)
+	}
+	content, ok, err = reader.ReadFile(ctx, dir, "", "countries/ie.yaml")
+	if err != nil || !ok || string(content) != "v: 3\n" {
+		t.Errorf("working tree: content=%q ok=%v err=%v, want edited content", content, ok, err)
+	}
+	for _, ref := range []string{"HEAD", IndexRef, ""} {
+		if _, ok, err = reader.ReadFile(ctx, dir, ref, "countries/fr.yaml"); ok || err != nil {
+			t.Errorf("ref %q: a missing file must report ok=false without error, got ok=%v err=%v", ref, ok, err)
+		}
+	}
+}
+
+func TestCmdGitFileReader_ReadFile_NotARepository(t *testing.T) {
+	t.Parallel()
+
+	if _, _, err := NewGitFileReader().ReadFile(context.Background(), t.TempDir(), "HEAD", "a.yaml"); err == nil {
+		t.Fatal("reading at a ref outside a git repository must fail, not report the file absent")
+	}
+}
@@ -0,0 +1,427 @@
+package gitdiff
+
+// specscore: feature/cli/validate
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/internal/gitobject"
+)
+
+// NewInProcessGitDiffer returns a GitDiffer that reads the repository's refs,
+// object database and index itself, so it works where there is no git binary.
+// Its results match `git diff --name-status` with default settings, except
+// that content filters (core.autocrlf, clean filters) are not applied to
+// working tree files. Only SHA-1 repositories are supported.
+func NewInProcessGitDiffer() GitDiffer {
+	return inProcessGitDiffer{}
+}
+
+type inProcessGitDiffer struct{}
+
+// DiffFiles diffs the tree of fromRef against the tree of toRef, the index
+// when toRef is IndexRef, or the working tree when toRef is empty. As git does
+// for a working tree diff, files that are not tracked are left out.
+func (inProcessGitDiffer) DiffFiles(ctx context.Context, repoPath, fromRef, toRef string) ([]ingitdb.ChangedFile, error) {
+	if fromRef == "" {
+		return nil, fmt.Errorf("from ref is required")
+	}
+	repo, err := gitobject.Open(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	fromID, err := repo.ResolveRevision(fromRef)
+	if err != nil {
+		return nil, err
+	}
+	from, err := repo.TreeFiles(fromID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", fromRef, err)
+	}
+	side := diffSide{repo: repo}
+	switch toRef {
+	case "":
+		err = side.loadWorkTree(ctx)
+	case IndexRef:
+		err = side.loadIndex()
+	default:
+		var toID gitobject.Hash
+		if toID, err = repo.ResolveRevision(toRef); err == nil {
+			side.files, err = repo.TreeFiles(toID)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return diffTrees(repo, from, side)
+}
+
+// NewInProcessGitFileReader returns a GitFileReader that reads blobs from the
+// repository's object database itself, so it works where there is no git
+// binary. It keeps each repository it opens, with its pack indexes, for the
+// reads that follow. Only SHA-1 repositories are supported.
+func NewInProcessGitFileReader() GitFileReader {
+	return &inProcessGitFileReader{repos: map[string]*gitobject.Repo{}}
+}
+
+type inProcessGitFileReader struct {
+	mu    sync.Mutex
+	repos map[string]*gitobject.Repo // by repoPath
+}
+
+// ReadFile reads path from the working tree for an empty ref, from the
+// index's stage 0 for IndexRef, and otherwise from the tree of ref. A
+// submodule is not a file.
+func (r *inProcessGitFileReader) ReadFile(_ context.Context, repoPath, ref, path string) ([]byte, bool, error) {
+	if ref == "" {
+		content, err := os.ReadFile(filepath.Join(repoPath, filepath.FromSlash(path)))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, false, nil
+		}
+		return content, err == nil, err
+	}
+	repo, err := r.open(repoPath)
+	if err != nil {
+		return nil, false, err
+	}
+	path = filepath.ToSlash(path)
+	var entry gitobject.Entry
+	var ok bool
+	if ref == IndexRef {
+		index, indexErr := repo.ReadIndex()
+		if indexErr != nil {
+			return nil, false, indexErr
+		}
+		// Entries are sorted by path, then stage: stage 0 comes first.
+		i, found := slices.BinarySearchFunc(index.Entries, path, func(e gitobject.IndexEntry, p string) int {
+			return cmp.Compare(e.Path, p)
+		})
+		if found && index.Entries[i].Stage == 0 {
+			entry, ok = index.Entries[i].Entry, true
+		}
+	} else {
+		id, resolveErr := repo.ResolveRevision(ref)
+		if resolveErr != nil {
+			return nil, false, resolveErr
+		}
+		if entry, ok, err = repo.TreeFile(id, path); err != nil {
+			return nil, false, fmt.Errorf("failed to read %s at %s: %w", path, ref, err)
+		}
+	}
+	if !ok || entry.Mode == gitobject.ModeGitlink {
+		return nil, false, nil
+	}
+	content, err := readBlob(repo, entry.Hash)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s at %s: %w", path, ref, err)
+	}
+	return content, true, nil
+}
+
+func (r *inProcessGitFileReader) open(repoPath string) (*gitobject.Repo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if repo, ok := r.repos[repoPath]; ok {
+		return repo, nil
+	}
+	repo, err := gitobject.Open(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	r.repos[repoPath] = repo
+	return repo, nil
+}
+
+// diffSide is the files being compared with fromRef's tree.
+type diffSide struct {
+	repo  *gitobject.Repo
+	files map[string]gitobject.Entry
+	// conflicted holds paths with an unresolved merge conflict in the index;
+	// git reports them as unmerged, which reads as modified.
+	conflicted map[string]bool
+	// worktree holds the content of working tree files whose ids are not
+	// known to be in the object database.
+	worktree map[string][]byte
+}
+
+func (s *diffSide) loadIndex() error {
+	index, err := s.repo.ReadIndex()
+	if err != nil {
+		return err
+	}
+	s.files = make(map[string]gitobject.Entry, len(index.Entries))
+	s.conflicted = make(map[string]bool)
+	for _, e := range index.Entries {
+		if e.Stage != 0 {
+			s.conflicted[e.Path] = true
+			continue
+		}
+		s.files[e.Path] = e.Entry
+	}
+	return nil
+}
+
+// loadWorkTree reads the tracked files of the working tree. A file whose size
+// and mtime still match its index entry is taken to hold the staged blob,
+// unless it was modified as late as the index itself was written; any other
+// file is read and hashed.
+func (s *diffSide) loadWorkTree(ctx context.Context) error {
+	workTree := s.repo.WorkTree()
+	if workTree == "" {
+		return fmt.Errorf("a bare repository has no working tree to diff against")
+	}
+	index, err := s.repo.ReadIndex()
+	if err != nil {
+		return err
+	}
+	s.files = make(map[string]gitobject.Entry, len(index.Entries))
+	s.conflicted = make(map[string]bool)
+	s.worktree = make(map[string][]byte)
+	for _, e := range index.Entries {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if e.Mode == gitobject.ModeGitlink {
+			s.files[e.Path] = e.Entry // a submodule's own changes are not looked into
+			continue
+		}
+		fullPath := filepath.Join(workTree, filepath.FromSlash(e.Path))
+		info, statErr := os.Lstat(fullPath)
+		if statErr != nil || info.IsDir() {
+			continue // deleted from the working tree
+		}
+		if e.Stage != 0 {
+			s.conflicted[e.Path] = true
+			continue
+		}
+		mode := gitobject.ModeRegular
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			mode = gitobject.ModeSymlink
+		case info.Mode()&0o111 != 0:
+			mode = gitobject.ModeExecutable
+		}
+		if mode == e.Mode && int64(e.Size) == info.Size()&0xffffffff &&
+			e.MTime.Equal(info.ModTime()) && info.ModTime().Before(index.ModTime) {
+			s.files[e.Path] = e.Entry
+			continue
+		}
+		var content []byte
+		if mode == gitobject.ModeSymlink {
+			target, linkErr := os.Readlink(fullPath)
+			if linkErr != nil {
+				return linkErr
+			}
+			content = []byte(filepath.ToSlash(target))
+		} else if content, err = os.ReadFile(fullPath); err != nil {
+			return err
+		}
+		id := gitobject.HashObject(gitobject.TypeBlob, content)
+		s.files[e.Path] = gitobject.Entry{Hash: id, Mode: mode}
+		if id != e.Hash {
+			s.worktree[e.Path] = content
+		}
+	}
+	return nil
+}
+
+// content returns the blob of a side's file, for rename scoring.
+func (s *diffSide) content(path string, e gitobject.Entry) ([]byte, error) {
+	if content, ok := s.worktree[path]; ok {
+		return content, nil
+	}
+	return readBlob(s.repo, e.Hash)
+}
+
+func readBlob(repo *gitobject.Repo, id gitobject.Hash) ([]byte, error) {
+	_, content, err := repo.ReadObject(id)
+	return content, err
+}
+
+// diffTrees compares the two sides path by path, then pairs deleted and added
+// files into renames.
+func diffTrees(repo *gitobject.Repo, from map[string]gitobject.Entry, to diffSide) ([]ingitdb.ChangedFile, error) {
+	var changed []ingitdb.ChangedFile
+	var deleted, added []string
+	for path, before := range from {
+		after, ok := to.files[path]
+		switch {
+		case to.conflicted[path]:
+			changed = append(changed, ingitdb.ChangedFile{Kind: ingitdb.ChangeKindModified, Path: path})
+		case !ok:
+			deleted = append(deleted, path)
+		case after != before:
+			changed = append(changed, ingitdb.ChangedFile{Kind: ingitdb.ChangeKindModified, Path: path})
+		}
+	}
+	for path := range to.files {
+		if _, ok := from[path]; !ok {
+			added = append(added, path)
+		}
+	}
+	for path := range to.conflicted {
+		if _, ok := from[path]; !ok {
+			changed = append(changed, ingitdb.ChangedFile{Kind: ingitdb.ChangeKindModified, Path: path})
+		}
+	}
+	slices.Sort(deleted)
+	slices.Sort(added)
+
+	renames, err := detectRenames(repo, from, to, deleted, added)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range deleted {
+		if _, renamed := renames.from[path]; !renamed {
+			changed = append(changed, ingitdb.ChangedFile{Kind: ingitdb.ChangeKindDeleted, Path: path})
+		}
+	}
+	for _, path := range added {
+		if oldPath, renamed := renames.to[path]; renamed {
+			changed = append(changed, ingitdb.ChangedFile{Kind: ingitdb.ChangeKindRenamed, OldPath: oldPath, Path: path})
+		} else {
+			changed = append(changed, ingitdb.ChangedFile{Kind: ingitdb.ChangeKindAdded, Path: path})
+		}
+	}
+	slices.SortFunc(changed, func(a, b ingitdb.ChangedFile) int { return cmp.Compare(a.Path, b.Path) })
+	return changed, nil
+}
+
+// renamePairs maps renamed files' old paths to new ones and back.
+type renamePairs struct {
+	from map[string]string
+	to   map[string]string
+}
+
+func (p renamePairs) add(oldPath, newPath string) {
+	p.from[oldPath] = newPath
+	p.to[newPath] = oldPath
+}
+
+// renameThreshold is the similarity, in percent, at which git's default
+// rename detection pairs a deleted file with an added one.
+const renameThreshold = 50
+
+// renameLimit caps inexact rename detection at this many candidate pairs,
+// like git's default diff.renameLimit of 1000 files on each side.
+const renameLimit = 1000 * 1000
+
+// detectRenames pairs deleted files with added ones: first those with
+// identical content, then, best match first, those at least renameThreshold
+// percent similar. As in git, empty files are never paired.
+func detectRenames(repo *gitobject.Repo, from map[string]gitobject.Entry, to diffSide, deleted, added []string) (renamePairs, error) {
+	pairs := renamePairs{from: map[string]string{}, to: map[string]string{}}
+	emptyBlob := gitobject.HashObject(gitobject.TypeBlob, nil)
+	byID := map[gitobject.Hash][]string{}
+	for _, path := range deleted {
+		if id := from[path].Hash; id != emptyBlob {
+			byID[id] = append(byID[id], path)
+		}
+	}
+	for _, path := range added {
+		id := to.files[path].Hash
+		if candidates := byID[id]; len(candidates) > 0 {
+			pairs.add(candidates[0], path)
+			byID[id] = candidates[1:]
+		}
+	}
+
+	var sources, targets []string
+	for _, path := range deleted {
+		if _, ok := pairs.from[path]; !ok && from[path].Hash != emptyBlob {
+			sources = append(sources, path)
+		}
+	}
+	for _, path := range added {
+		if _, ok := pairs.to[path]; !ok && to.files[path].Hash != emptyBlob {
+			targets = append(targets, path)
+		}
+	}
+	if len(sources) == 0 || len(targets) == 0 || len(sources)*len(targets) > renameLimit {
+		return pairs, nil
+	}
+	sourceContent := make([][]byte, len(sources))
+	for i, path := range sources {
+		content, err := readBlob(repo, from[path].Hash)
+		if err != nil {
+			return pairs, err
+		}
+		sourceContent[i] = content
+	}
+	type candidate struct {
+		score          int
+		source, target int
+	}
+	var candidates []candidate
+	for j, path := range targets {
+		content, err := to.content(path, to.files[path])
+		if err != nil {
+			return pairs, err
+		}
+		for i := range sources {
+			if score := similarity(sourceContent[i], content); score >= renameThreshold {
+				candidates = append(candidates, candidate{score: score, source: i, target: j})
+			}
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int { return cmp.Compare(b.score, a.score) })
+	for _, c := range candidates {
+		oldPath, newPath := sources[c.source], targets[c.target]
+		if _, taken := pairs.from[oldPath]; taken {
+			continue
+		}
+		if _, taken := pairs.to[newPath]; taken {
+			continue
+		}
+		pairs.add(oldPath, newPath)
+	}
+	return pairs, nil
+}
+
+// similarity scores, in percent, how much of the larger of two contents the
+// other shares with it, comparing lines as git's rename detection compares
+// its line-or-64-byte chunks.
+func similarity(a, b []byte) int {
+	larger := max(len(a), len(b))
+	if larger == 0 {
+		return 100
+	}
+	if min(len(a), len(b))*100 < larger*renameThreshold {
+		return 0 // too different in size to reach the threshold
+	}
+	counts := map[string]int{}
+	for _, chunk := range chunks(a) {
+		counts[string(chunk)]++
+	}
+	shared := 0
+	for _, chunk := range chunks(b) {
+		if counts[string(chunk)] > 0 {
+			counts[string(chunk)]--
+			shared += len(chunk)
+		}
+	}
+	return shared * 100 / larger
+}
+
+// chunks splits content after each newline and every 64 bytes.
+func chunks(content []byte) [][]byte {
+	var out [][]byte
+	for len(content) > 0 {
+		n := min(len(content), 64)
+		if i := bytes.IndexByte(content[:n], '\n'); i >= 0 {
+			n = i + 1
+		}
+		out = append(out, content[:n])
+		content = content[n:]
+	}
+	return out
+}
@@ -0,0 +1,287 @@
+package gitdiff
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// fixtureRepo is a repository in a temp dir with helpers to change it.
+type fixtureRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func newFixtureRepo(t *testing.T) *fixtureRepo {
+	t.Helper()
+	r := &fixtureRepo{t: t, dir: t.TempDir()}
+	r.git("init", "-q")
+	r.git("config", "user.email", "test@example.com")
+	r.git("config", "user.name", "Test")
+	return r
+}
+
+func (r *fixtureRepo) git(args ...string) string {
+	r.t.Helper()
+	c := exec.Command("git", args...)
+	c.Dir = r.dir
+	out, err := c.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func (r *fixtureRepo) write(name, content string) {
+	r.t.Helper()
+	full := filepath.Join(r.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+func (r *fixtureRepo) commit(message string) {
+	r.t.Helper()
+	r.git("add", "-A")
+	r.git("commit", "-q", "-m", message)
+}
+
+// lines returns n numbered lines, the i-th tagged with tag when i%7 == 0, so
+// that versions of a file are similar but not identical.
+func lines(n int, tag string) string {
+	var b strings.Builder
+	for i := range n {
+		if i%7 == 0 {
+			fmt.Fprintf(&b, "record %d: %s\n", i, tag)
+		} else {
+			fmt.Fprintf(&b, "record %d: unchanged value\n", i)
+		}
+	}
+	return b.String()
+}
+
+// assertSameAsGit diffs with both differs and requires identical results.
+func assertSameAsGit(t *testing.T, dir, fromRef, toRef string) []ingitdb.ChangedFile {
+	t.Helper()
+	ctx := context.Background()
+	want, err := NewGitDiffer().DiffFiles(ctx, dir, fromRef, toRef)
+	if err != nil {
+		t.Fatalf("git diff %s %s: %v", fromRef, toRef, err)
+	}
+	got, err := NewInProcessGitDiffer().DiffFiles(ctx, dir, fromRef, toRef)
+	if err != nil {
+		t.Fatalf("in-process diff %s %s: %v", fromRef, toRef, err)
+	}
+	byPath := func(a, b ingitdb.ChangedFile) int { return cmp.Compare(a.Path, b.Path) }
+	slices.SortFunc(want, byPath)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diff %s..%q:\n got %+v\nwant %+v", fromRef, toRef, got, want)
+	}
+	return got
+}
+
+// historyFixture commits a base and a second version that modifies, adds,
+// deletes and renames files, exactly and with edits, and changes a mode.
+func historyFixture(t *testing.T) *fixtureRepo {
+	r := newFixtureRepo(t)
+	r.write("countries/ie.yaml", lines(40, "Ireland"))
+	r.write("countries/fr.yaml", lines(40, "France"))
+	r.write("countries/de.yaml", lines(40, "Germany"))
+	r.write("cities/dub.yaml", lines(30, "Dublin"))
+	r.write("cities/cork.yaml", lines(30, "Cork"))
+	r.write("scripts/run.sh", "#!/bin/sh\necho run\n")
+	r.write("README.md", "# fixture\n")
+	r.commit("base")
+	r.git("tag", "v1")
+
+	r.write("countries/ie.yaml", lines(40, "Éire"))
+	r.git("rm", "-q", "countries/fr.yaml")
+	r.git("mv", "countries/de.yaml", "countries/germany.yaml")
+	r.git("mv", "cities/dub.yaml", "cities/dublin.yaml")
+	r.write("cities/dublin.yaml", lines(30, "Dublin")+"extra: line\n")
+	r.write("cities/galway.yaml", "name: Galway\n")
+	if err := os.Chmod(filepath.Join(r.dir, "scripts", "run.sh"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	r.commit("second")
+	r.git("branch", "second")
+	return r
+}
+
+func TestInProcessGitDiffer_CommitToCommit(t *testing.T) {
+	t.Parallel()
+
+	r := historyFixture(t)
+	got := assertSameAsGit(t, r.dir, "v1", "HEAD")
+	want := []ingitdb.ChangedFile{
+		{Kind: ingitdb.ChangeKindRenamed, OldPath: "cities/dub.yaml", Path: "cities/dublin.yaml"},
+		{Kind: ingitdb.ChangeKindAdded, Path: "cities/galway.yaml"},
+		{Kind: ingitdb.ChangeKindDeleted, Path: "countries/fr.yaml"},
+		{Kind: ingitdb.ChangeKindRenamed, OldPath: "countries/de.yaml", Path: "countries/germany.yaml"},
+		{Kind: ingitdb.ChangeKindModified, Path: "countries/ie.yaml"},
+		{Kind: ingitdb.ChangeKindModified, Path: "scripts/run.sh"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffFiles = %+v\nwant %+v", got, want)
+	}
+	assertSameAsGit(t, r.dir, "HEAD", "v1")
+	assertSameAsGit(t, r.dir, "HEAD~1", "HEAD^{commit}")
+	assertSameAsGit(t, r.dir, r.git("rev-parse", "--short", "v1"), "second")
+}
+
+// After gc, objects are read from a pack — many as deltas — and refs from
+// packed-refs.
+func TestInProcessGitDiffer_PackedRepository(t *testing.T) {
+	t.Parallel()
+
+	r := historyFixture(t)
+	for i := range 5 {
+		r.write("countries/ie.yaml", lines(40, fmt.Sprintf("Ireland v%d", i)))
+		r.commit(fmt.Sprintf("edit %d", i))
+	}
+	r.git("gc", "-q", "--aggressive")
+	if _, err := os.Stat(filepath.Join(r.dir, ".git", "packed-refs")); err != nil {
+		t.Fatalf("expected packed refs: %v", err)
+	}
+	assertSameAsGit(t, r.dir, "v1", "HEAD")
+	assertSameAsGit(t, r.dir, "HEAD~3", "HEAD~1")
+	assertSameAsGit(t, r.dir, "v1", "")
+}
+
+func TestInProcessGitDiffer_WorkTreeAndIndex(t *testing.T) {
+	t.Parallel()
+
+	r := historyFixture(t)
+	// Staged: a modification and an addition. Unstaged on top: further
+	// edits, a deletion, and an untracked file git does not report.
+	r.write("countries/ie.yaml", lines(40, "Ireland, staged"))
+	r.write("countries/es.yaml", lines(40, "Spain"))
+	r.git("add", "-A")
+	r.write("countries/ie.yaml", lines(40, "Ireland, unstaged"))
+	r.write("countries/es.yaml", lines(40, "España"))
+	if err := os.Remove(filepath.Join(r.dir, "README.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(r.dir, "scripts", "run.sh"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r.write("notes.txt", "untracked\n")
+
+	worktree := assertSameAsGit(t, r.dir, "HEAD", "")
+	if len(worktree) != 4 {
+		t.Errorf("worktree diff = %+v, want ie, es, README.md and run.sh", worktree)
+	}
+	staged := assertSameAsGit(t, r.dir, "HEAD", IndexRef)
+	if len(staged) != 2 {
+		t.Errorf("index diff = %+v, want ie and es only", staged)
+	}
+	assertSameAsGit(t, r.dir, "v1", IndexRef)
+
+	// Version 4 indexes compress paths.
+	r.git("update-index", "--index-version", "4")
+	assertSameAsGit(t, r.dir, "HEAD", IndexRef)
+	assertSameAsGit(t, r.dir, "HEAD", "")
+}
+
+func TestInProcessGitDiffer_LinkedWorktreeAndBareClone(t *testing.T) {
+	t.Parallel()
+
+	r := historyFixture(t)
+	linked := filepath.Join(t.TempDir(), "linked")
+	r.git("worktree", "add", "-q", linked, "v1")
+	if err := os.WriteFile(filepath.Join(linked, "README.md"), []byte("# changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	assertSameAsGit(t, linked, "HEAD", "")
+	assertSameAsGit(t, linked, "HEAD", "second")
+	// A subdirectory opens the enclosing repository.
+	assertSameAsGit(t, filepath.Join(linked, "countries"), "HEAD", "second")
+
+	bare := filepath.Join(t.TempDir(), "clone.git")
+	r.git("clone", "-q", "--bare", r.dir, bare)
+	assertSameAsGit(t, bare, "v1", "HEAD")
+	if _, err := NewInProcessGitDiffer().DiffFiles(context.Background(), bare, "HEAD", ""); err == nil {
+		t.Error("expected an error diffing a bare repository against a working tree")
+	}
+}
+
+func TestInProcessGitDiffer_Errors(t *testing.T) {
+	t.Parallel()
+
+	r := historyFixture(t)
+	differ := NewInProcessGitDiffer()
+	for _, tc := range []struct{ repo, from, to string }{
+		{r.dir, "", "HEAD"},
+		{r.dir, "no-such-ref", "HEAD"},
+		{r.dir, "HEAD~9", "HEAD"},
+		{r.dir, "HEAD", "v1^{blob}"},
+		{t.TempDir(), "HEAD", ""},
+	} {
+		if _, err := differ.DiffFiles(context.Background(), tc.repo, tc.from, tc.to); err == nil {
+			t.Errorf("DiffFiles(%q, %q): expected an error", tc.from, tc.to)
+		}
+	}
+}
+
+func TestInProcessGitFileReader_ReadFile(t *testing.T) {
+	t.Parallel()
+
+	r := historyFixture(t)
+	r.write("countries/ie.yaml", lines(40, "Ireland, staged"))
+	r.git("add", "-A")
+	r.write("countries/ie.yaml", lines(40, "Ireland, unstaged"))
+	r.git("gc", "-q") // read packed objects as well as loose ones
+	r.write("cities/cork.yaml", "name: Cork, staged\n")
+	r.git("add", "-A")
+
+	ctx := context.Background()
+	want, got := NewGitFileReader(), NewInProcessGitFileReader()
+	for _, ref := range []string{"v1", "HEAD", "second~1", IndexRef, ""} {
+		for _, path := range []string{"countries/ie.yaml", "countries/fr.yaml", "cities/cork.yaml", "scripts/run.sh", "missing.yaml", "countries/missing.yaml"} {
+			wantContent, wantOK, err := want.ReadFile(ctx, r.dir, ref, path)
+			if err != nil {
+				t.Fatalf("git: ReadFile(%q, %q): %v", ref, path, err)
+			}
+			content, ok, err := got.ReadFile(ctx, r.dir, ref, path)
+			if err != nil || ok != wantOK || string(content) != string(wantContent) {
+				t.Errorf("ReadFile(%q, %q) = %q, %v, %v; want %q, %v", ref, path, content, ok, err, wantContent, wantOK)
+			}
+		}
+	}
+	if _, ok, err := got.ReadFile(ctx, r.dir, "HEAD", "countries"); ok || err != nil {
+		t.Errorf("a directory must read as no file, got ok=%v err=%v", ok, err)
+	}
+	if _, _, err := got.ReadFile(ctx, r.dir, "no-such-ref", "README.md"); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+	if _, _, err := got.ReadFile(ctx, t.TempDir(), "HEAD", "a.yaml"); err == nil {
+		t.Error("reading at a ref outside a git repository must fail, not report the file absent")
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	t.Parallel()
+
+	base := lines(20, "a")
+	if got := similarity([]byte(base), []byte(base)); got != 100 {
+		t.Errorf("identical = %d, want 100", got)
+	}
+	if got := similarity([]byte(base), []byte(lines(20, "b"))); got < renameThreshold {
+		t.Errorf("lightly edited = %d, want at least %d", got, renameThreshold)
+	}
+	if got := similarity([]byte(base), []byte("something else entirely\n")); got != 0 {
+		t.Errorf("unrelated = %d, want 0", got)
+	}
+}
@@ -0,0 +1,215 @@
+package githooks
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testRepo is a git repository holding a one-collection inGitDB database:
+// widgets, a map of records whose size must be an int.
+type testRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	r := &testRepo{t: t, dir: t.TempDir()}
+	r.git("init", "-q")
+	r.git("config", "user.email", "t@example.com")
+	r.git("config", "user.name", "T")
+	r.write(".ingitdb/settings.yaml", "languages:\n  - required: en\n")
+	r.write(".ingitdb/root-collections.yaml", "widgets: ./widgets\n")
+	r.write("widgets/.collection/definition.yaml", "record_file:\n  name: widgets.yaml\n"+
+		"  type: \"map[$record_id]map[$field_name]any\"\n  format: yaml\n"+
+		"columns:\n  name:\n    type: string\n  size:\n    type: int\n")
+	return r
+}
+
+func (r *testRepo) git(args ...string) string {
+	r.t.Helper()
+	c := exec.Command("git", args...)
+	c.Dir = r.dir
+	out, err := c.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func (r *testRepo) write(rel, content string) {
+	r.t.Helper()
+	full := filepath.Join(r.dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+const (
+	validWidgets   = "w1:\n  name: Bolt\n  size: 3\nw2:\n  name: Nut\n  size: 1\n"
+	invalidWidgets = "w1:\n  name: Bolt\n  size: big\nw2:\n  name: Nut\n  size: 1\n"
+)
+
+func TestValidateStaged(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRepo(t)
+	r.write("widgets/widgets.yaml", validWidgets)
+	r.git("add", "-A")
+	r.git("commit", "-q", "-m", "base")
+	ctx := context.Background()
+
+	// An invalid edit is staged, then fixed in the working tree only: the
+	// commit would still carry the invalid record.
+	r.write("widgets/widgets.yaml", invalidWidgets)
+	r.git("add", "-A")
+	r.write("widgets/widgets.yaml", validWidgets)
+	result, err := ValidateStaged(ctx, r.dir)
+	if err != nil {
+		t.Fatalf("ValidateStaged: %v", err)
+	}
+	errs := result.Errors()
+	if len(errs) != 1 || errs[0].RecordKey != "w1" || errs[0].FieldName != "size" {
+		t.Fatalf("findings = %v, want one for w1.size", errs)
+	}
+	if want := filepath.Join(r.dir, "widgets", "widgets.yaml"); errs[0].FilePath != want {
+		t.Errorf("FilePath = %q, want %q", errs[0].FilePath, want)
+	}
+	if ExitCode(result) != 1 {
+		t.Errorf("ExitCode = %d, want 1", ExitCode(result))
+	}
+	if passed, total := result.GetRecordCounts("widgets"); passed != 0 || total != 1 {
+		t.Errorf("record counts = %d/%d, want 0/1: only the changed record is validated", passed, total)
+	}
+
+	// The reverse: a valid edit is staged and the working tree is broken.
+	r.write("widgets/widgets.yaml", strings.Replace(validWidgets, "size: 3", "size: 4", 1))
+	r.git("add", "-A")
+	r.write("widgets/widgets.yaml", invalidWidgets)
+	if result, err = ValidateStaged(ctx, r.dir); err != nil || ExitCode(result) != 0 {
+		t.Errorf("valid staged edit: findings %v, err %v", result.Errors(), err)
+	}
+}
+
+func TestValidateStaged_FirstCommit(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRepo(t)
+	r.write("widgets/widgets.yaml", invalidWidgets)
+	r.git("add", "-A")
+	r.write("widgets/widgets.yaml", validWidgets)
+	result, err := ValidateStaged(context.Background(), r.dir)
+	if err != nil {
+		t.Fatalf("ValidateStaged: %v", err)
+	}
+	if errs := result.Errors(); len(errs) != 1 || errs[0].FilePath != filepath.Join(r.dir, "widgets", "widgets.yaml") {
+		t.Errorf("findings = %v, want one in widgets.yaml", errs)
+	}
+}
+
+func TestValidateStaged_NotARepository(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ValidateStaged(context.Background(), t.TempDir()); err == nil {
+		t.Error("expected an error outside a git repository")
+	}
+}
+
+func TestInstall(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRepo(t)
+	r.write("widgets/widgets.yaml", validWidgets)
+	r.write(".gitattributes", "*.png binary")
+	ctx := context.Background()
+	if err := Install(ctx, r.dir); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	hookPath := filepath.Join(r.dir, ".git", "hooks", "pre-commit")
+	info, err := os.Stat(hookPath)
+	if err != nil || info.Mode()&0o111 == 0 {
+		t.Fatalf("hook is not an executable file: %v %v", info, err)
+	}
+	hook, _ := os.ReadFile(hookPath)
+	if !strings.Contains(string(hook), "exec "+DefaultHookCommand+"\n") {
+		t.Errorf("hook script:\n%s", hook)
+	}
+	if attributes, _ := os.ReadFile(filepath.Join(r.dir, ".gitattributes")); string(attributes) != "*.png binary" {
+		t.Errorf(".gitattributes changed without WithLFLineEndings:\n%s", attributes)
+	}
+
+	// Installing again replaces the hook in place; line endings are opt-in.
+	if err = Install(ctx, r.dir, WithHookCommand("go run ./cmd/ingitdb validate --staged"), WithLFLineEndings()); err != nil {
+		t.Fatalf("second Install: %v", err)
+	}
+	hook, _ = os.ReadFile(hookPath)
+	if !strings.Contains(string(hook), "exec go run ./cmd/ingitdb validate --staged\n") {
+		t.Errorf("hook script after reinstall:\n%s", hook)
+	}
+	if got := r.git("check-attr", "eol", "widgets/widgets.yaml"); got != "widgets/widgets.yaml: eol: lf" {
+		t.Errorf("check-attr = %q", got)
+	}
+	attributes, _ := os.ReadFile(filepath.Join(r.dir, ".gitattributes"))
+	want := "*.png binary\n# BEGIN ingitdb\n/widgets/widgets.yaml text eol=lf\n# END ingitdb\n"
+	if string(attributes) != want {
+		t.Errorf(".gitattributes =\n%s\nwant\n%s", attributes, want)
+	}
+}
+
+func TestInstall_HooksPathAndForeignHook(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRepo(t)
+	r.git("config", "core.hooksPath", "githooks")
+	if err := Install(context.Background(), r.dir); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(r.dir, "githooks", "pre-commit")); err != nil {
+		t.Errorf("hook not written to core.hooksPath: %v", err)
+	}
+
+	r.write("githooks/pre-commit", "#!/bin/sh\nmake lint\n")
+	if err := Install(context.Background(), r.dir); err == nil {
+		t.Error("expected Install to refuse to overwrite a hook it did not write")
+	}
+	if content, _ := os.ReadFile(filepath.Join(r.dir, "githooks", "pre-commit")); string(content) != "#!/bin/sh\nmake lint\n" {
+		t.Errorf("foreign hook was changed:\n%s", content)
+	}
+}
+
+func TestWriteAttributes(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".gitattributes")
+	for _, tc := range []struct{ before, want string }{
+		{"", "# BEGIN ingitdb\n/a text eol=lf\n# END ingitdb\n"},
+		{"*.png binary", "*.png binary\n# BEGIN ingitdb\n/a text eol=lf\n# END ingitdb\n"},
+		{"x\n# BEGIN ingitdb\n/old\n# END ingitdb\ny\n", "x\n# BEGIN ingitdb\n/a text eol=lf\n# END ingitdb\ny\n"},
+		{"# BEGIN ingitdb\n/old\n# END ingitdb", "# BEGIN ingitdb\n/a text eol=lf\n# END ingitdb\n"},
+		// Another block is left alone, even one whose name starts the same.
+		{"# BEGIN ingitdb merge\n/m\n# END ingitdb merge\n", "# BEGIN ingitdb merge\n/m\n# END ingitdb merge\n# BEGIN ingitdb\n/a text eol=lf\n# END ingitdb\n"},
+	} {
+		if err := os.WriteFile(path, []byte(tc.before), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeAttributes(path, hookAttributesBlock, []string{"/a text eol=lf"}); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := os.ReadFile(path); string(got) != tc.want {
+			t.Errorf("from %q: got %q, want %q", tc.before, got, tc.want)
+		}
+	}
+	if err := os.WriteFile(path, []byte("# BEGIN ingitdb\n/old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeAttributes(path, hookAttributesBlock, nil); err == nil {
+		t.Error("expected an error for a block with no end marker")
+	}
+}
@@ -0,0 +1,166 @@
+package githooks
+
+// specscore: feature/cli/validate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb/datavalidator"
+	"github.com/ingitdb/ingitdb-go/ingitdb/validator"
+)
+
+// DefaultHookCommand is the command the installed pre-commit hook runs. It is
+// expected to call ValidateStaged and exit with ExitCode.
+const DefaultHookCommand = "ingitdb validate --staged"
+
+// hookMarker identifies a hook script written by Install, which Install may
+// overwrite.
+const hookMarker = "# Installed by ingitdb."
+
+// hookAttributesBlock names the block of .gitattributes lines Install
+// manages; see writeAttributes.
+const hookAttributesBlock = "ingitdb"
+
+// InstallOption configures Install.
+type InstallOption func(*installOptions)
+
+type installOptions struct {
+	command string
+	eolLF   bool
+}
+
+// WithHookCommand sets the command the pre-commit hook runs instead of
+// DefaultHookCommand.
+func WithHookCommand(command string) InstallOption {
+	return func(o *installOptions) {
+		o.command = command
+	}
+}
+
+// WithLFLineEndings makes Install also write, in .gitattributes, a block with
+// a "text eol=lf" line for each collection's record files, so they are
+// checked out with LF line endings on every platform.
+func WithLFLineEndings() InstallOption {
+	return func(o *installOptions) {
+		o.eolLF = true
+	}
+}
+
+// Install sets up the repository at dbPath, which must be its root, to
+// validate staged records before each commit. It writes a pre-commit hook
+// where git looks for it, honouring core.hooksPath, and leaves line endings
+// to the repository's own .gitattributes unless WithLFLineEndings is given.
+//
+// Running Install again updates what it wrote. It does not overwrite a
+// pre-commit hook it did not write.
+func Install(ctx context.Context, dbPath string, opts ...InstallOption) error {
+	o := installOptions{command: DefaultHookCommand}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	root, err := filepath.Abs(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path for %q: %w", dbPath, err)
+	}
+	def, err := validator.ReadDefinition(root)
+	if err != nil {
+		return fmt.Errorf("failed to read definition: %w", err)
+	}
+	if err = installHook(ctx, root, o.command); err != nil {
+		return err
+	}
+	if !o.eolLF {
+		return nil
+	}
+	patterns := datavalidator.RecordFilePatterns(def)
+	var lines []string
+	for _, id := range slices.Sorted(maps.Keys(patterns)) {
+		pattern, relErr := attributePattern(root, patterns[id])
+		if relErr != nil {
+			return fmt.Errorf("collection %s: %w", id, relErr)
+		}
+		lines = append(lines, pattern+" text eol=lf")
+	}
+	return writeAttributes(filepath.Join(root, ".gitattributes"), hookAttributesBlock, lines)
+}
+
+// installHook writes the pre-commit hook script running command.
+func installHook(ctx context.Context, root, command string) error {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--git-path", "hooks/pre-commit")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("git rev-parse --git-path failed: %w", err)
+	}
+	hookPath := filepath.FromSlash(strings.TrimSpace(string(out)))
+	if !filepath.IsAbs(hookPath) {
+		hookPath = filepath.Join(root, hookPath)
+	}
+	existing, err := os.ReadFile(hookPath)
+	if err == nil && !bytes.Contains(existing, []byte(hookMarker)) {
+		return fmt.Errorf("a pre-commit hook not installed by ingitdb already exists at %s", hookPath)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(hookPath), 0o755); err != nil {
+		return err
+	}
+	script := "#!/bin/sh\n" +
+		hookMarker + " It validates the staged records and aborts the commit on findings.\n" +
+		"exec " + command + "\n"
+	if err = os.WriteFile(hookPath, []byte(script), 0o755); err != nil {
+		return err
+	}
+	return os.Chmod(hookPath, 0o755) // WriteFile keeps the mode of an existing file
+}
+
+// attributePattern turns an absolute record file pattern into a .gitattributes
+// pattern anchored at the repository root, C-quoted when it has spaces.
+func attributePattern(root, pattern string) (string, error) {
+	rel, err := filepath.Rel(root, pattern)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("record files %s are outside the repository", pattern)
+	}
+	anchored := "/" + filepath.ToSlash(rel)
+	if strings.ContainsAny(anchored, " \t\"") {
+		anchored = strconv.Quote(anchored)
+	}
+	return anchored, nil
+}
+
+// writeAttributes replaces the named block of lines Install or
+// InstallMergeDriver manages in the .gitattributes file at path, appending the
+// block when there is none yet, and leaves every other line as it is.
+func writeAttributes(path, block string, lines []string) error {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	begin, end := "# BEGIN "+block, "# END "+block
+	replacement := append(append([]string{begin}, lines...), end)
+	existing := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	if len(content) == 0 {
+		existing = nil
+	}
+	var out []string
+	if i := slices.Index(existing, begin); i >= 0 {
+		j := slices.Index(existing[i:], end)
+		if j < 0 {
+			return fmt.Errorf("%s: %q has no matching %q", path, begin, end)
+		}
+		out = slices.Concat(existing[:i], replacement, existing[i+j+1:])
+	} else {
+		out = append(existing, replacement...)
+	}
+	return os.WriteFile(path, []byte(strings.Join(out, "\n")+"\n"), 0o644)
+}
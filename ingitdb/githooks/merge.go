@@ -0,0 +1,230 @@
+package githooks
+
+// specscore: feature/cli/resolve/auto-resolve/record-merge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/datavalidator"
+	"github.com/ingitdb/ingitdb-go/ingitdb/recordmerge"
+	"github.com/ingitdb/ingitdb-go/ingitdb/validator"
+)
+
+// MergeDriverName is the name the merge driver is configured under, as in the
+// "merge=ingitdb" attribute and the merge.ingitdb.driver git config.
+const MergeDriverName = "ingitdb"
+
+// DefaultMergeDriverCommand is the command git runs as the merge driver, with
+// the ancestor, current and other versions of the file and its path appended.
+// It is expected to call Merge and exit with MergeResult.ExitCode.
+const DefaultMergeDriverCommand = "ingitdb merge-driver"
+
+// mergeAttributesBlock names the block of .gitattributes lines
+// InstallMergeDriver manages; see writeAttributes.
+const mergeAttributesBlock = "ingitdb merge"
+
+// MergeResult is the outcome of Merge.
+type MergeResult struct {
+	// Conflicted is true when the file was left with conflict markers.
+	Conflicted bool
+	// Reason says why the records could not be merged. It is empty when they
+	// were, and when the file was merged as text because it is not a record
+	// file or record merging is disabled for its collection. It is set when
+	// the definition could not be read and the file was merged as text.
+	Reason string
+}
+
+// ExitCode returns the status the merge driver exits with: 1, which tells git
+// the file still has conflicts, when Conflicted, and 0 otherwise.
+func (r MergeResult) ExitCode() int {
+	if r.Conflicted {
+		return 1
+	}
+	return 0
+}
+
+// Merge is the merge driver for record files of the database at dbPath, the
+// repository root. ancestor, current and other are the files git passes as
+// %O, %A and %B, and path is the file's path in the repository, %P. The
+// result is written to current, as git expects.
+//
+// The owning collection is looked up from path in the definition as it is in
+// the working tree. When the collection's effective record-merge settings
+// (see ingitdb.ResolveRecordMerge) enable it, the three versions are merged
+// record by record with recordmerge.MergeFiles, and a clean merge is written
+// in canonical format. A merge that escalates leaves standard conflict
+// markers, labelled with the Reason. Files that are not records of a
+// collection with record merging enabled are merged as text, like git's
+// default driver, and so is every file when the definition cannot be read —
+// a merge that breaks the definition must still complete — with the Reason
+// saying why.
+func Merge(ctx context.Context, dbPath, ancestor, current, other, path string) (MergeResult, error) {
+	root, err := filepath.Abs(dbPath)
+	if err != nil {
+		return MergeResult{}, fmt.Errorf("failed to get absolute path for %q: %w", dbPath, err)
+	}
+	def, err := validator.ReadDefinition(root)
+	if err != nil {
+		// Without a definition no file is known to hold records; git still
+		// needs a merged %A, so merge as text rather than fail the merge.
+		reason := fmt.Sprintf("failed to read definition: %v", err)
+		conflicts, mergeErr := mergeText(ctx, root, ancestor, current, other, "ours")
+		return MergeResult{Conflicted: conflicts, Reason: reason}, mergeErr
+	}
+	_, col := datavalidator.CollectionForRecordFile(def, filepath.Join(root, filepath.FromSlash(path)))
+	if col == nil || !ingitdb.ResolveRecordMerge(def, col).Enabled {
+		conflicts, mergeErr := mergeText(ctx, root, ancestor, current, other, "ours")
+		return MergeResult{Conflicted: conflicts}, mergeErr
+	}
+	var sides [3][]byte
+	for i, file := range []string{ancestor, current, other} {
+		if sides[i], err = os.ReadFile(file); err != nil {
+			return MergeResult{}, err
+		}
+	}
+	opts := recordmerge.Options{SameRecord: ingitdb.ResolveRecordMerge(def, col).SameRecord}
+	outcome := recordmerge.MergeFiles(sides[0], sides[1], sides[2], col, opts)
+	if !outcome.Escalate {
+		content, encodeErr := recordmerge.EncodeMerged(outcome.Merged, col)
+		if encodeErr == nil {
+			return MergeResult{}, os.WriteFile(current, content, 0o644)
+		}
+		outcome.Reason = fmt.Sprintf("failed to encode the merged records: %v", encodeErr)
+	}
+	conflicts, err := mergeText(ctx, root, ancestor, current, other, "ours ("+outcome.Reason+")")
+	if err != nil || conflicts {
+		return MergeResult{Conflicted: true, Reason: outcome.Reason}, err
+	}
+	// The lines merged cleanly, but the records did not: the merged text may
+	// hold a record changed on both sides. Mark the whole file as conflicted.
+	whole := slices.Concat(
+		[]byte("<<<<<<< ours ("+outcome.Reason+")\n"), withNewline(sides[1]),
+		[]byte("=======\n"), withNewline(sides[2]),
+		[]byte(">>>>>>> theirs\n"))
+	return MergeResult{Conflicted: true, Reason: outcome.Reason}, os.WriteFile(current, whole, 0o644)
+}
+
+// mergeText merges the files line by line with `git merge-file`, writing the
+// result to current. It reports whether conflict markers were left; the
+// current side's marker is labelled oursLabel.
+func mergeText(ctx context.Context, root, ancestor, current, other, oursLabel string) (bool, error) {
+	cmd := exec.CommandContext(ctx, "git", "merge-file", "-L", oursLabel, "-L", "base", "-L", "theirs", current, ancestor, other)
+	cmd.Dir = root
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return false, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128:
+		return true, nil // the exit code is the number of conflicts
+	default:
+		return false, fmt.Errorf("git merge-file failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+}
+
+func withNewline(content []byte) []byte {
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		return append(slices.Clip(content), '\n')
+	}
+	return content
+}
+
+// MergeAttributes returns the .gitattributes lines that select the merge
+// driver for the record files of def, the definition of the database at
+// dbPath: one per collection, subcollections included, whose layout
+// recordmerge can merge and whose record merging is enabled.
+func MergeAttributes(dbPath string, def *ingitdb.Definition) ([]string, error) {
+	root, err := filepath.Abs(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for %q: %w", dbPath, err)
+	}
+	patterns := datavalidator.RecordFilePatterns(def)
+	var lines []string
+	for _, id := range slices.Sorted(maps.Keys(patterns)) {
+		col := collectionByID(def, id)
+		if !recordmerge.Mergeable(col) || !ingitdb.ResolveRecordMerge(def, col).Enabled {
+			continue
+		}
+		pattern, err := attributePattern(root, patterns[id])
+		if err != nil {
+			return nil, fmt.Errorf("collection %s: %w", id, err)
+		}
+		lines = append(lines, pattern+" merge="+MergeDriverName)
+	}
+	return lines, nil
+}
+
+// collectionByID returns the collection or subcollection with the full path
+// id, or nil.
+func collectionByID(def *ingitdb.Definition, id string) *ingitdb.CollectionDef {
+	parts := strings.Split(id, "/")
+	col := def.Collections[parts[0]]
+	for _, subID := range parts[1:] {
+		if col == nil {
+			return nil
+		}
+		col = col.SubCollections[subID]
+	}
+	return col
+}
+
+// MergeDriverOption configures InstallMergeDriver.
+type MergeDriverOption func(*mergeDriverOptions)
+
+type mergeDriverOptions struct {
+	command string
+}
+
+// WithMergeDriverCommand sets the command git runs as the merge driver instead
+// of DefaultMergeDriverCommand.
+func WithMergeDriverCommand(command string) MergeDriverOption {
+	return func(o *mergeDriverOptions) {
+		o.command = command
+	}
+}
+
+// InstallMergeDriver sets up the repository at dbPath, which must be its root,
+// to merge record files with Merge: it declares the driver in the
+// repository's git config and writes MergeAttributes to a block of
+// .gitattributes. Running it again updates both.
+func InstallMergeDriver(ctx context.Context, dbPath string, opts ...MergeDriverOption) error {
+	o := mergeDriverOptions{command: DefaultMergeDriverCommand}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	root, err := filepath.Abs(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path for %q: %w", dbPath, err)
+	}
+	def, err := validator.ReadDefinition(root)
+	if err != nil {
+		return fmt.Errorf("failed to read definition: %w", err)
+	}
+	lines, err := MergeAttributes(root, def)
+	if err != nil {
+		return err
+	}
+	for key, value := range map[string]string{
+		"name":   "inGitDB record merge",
+		"driver": o.command + " %O %A %B %P",
+	} {
+		cmd := exec.CommandContext(ctx, "git", "config", "merge."+MergeDriverName+"."+key, value)
+		cmd.Dir = root
+		if out, cmdErr := cmd.CombinedOutput(); cmdErr != nil {
+			return fmt.Errorf("git config failed: %w: %s", cmdErr, strings.TrimSpace(string(out)))
+		}
+	}
+	return writeAttributes(filepath.Join(root, ".gitattributes"), mergeAttributesBlock, lines)
+}
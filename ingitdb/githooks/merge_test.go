@@ -0,0 +1,197 @@
+package githooks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestMergeDriverProcess is not a test: it is the merge driver git runs in
+// TestInstallMergeDriver, which re-executes the test binary with the driver's
+// arguments after "--".
+func TestMergeDriverProcess(t *testing.T) {
+	if os.Getenv("INGITDB_TEST_MERGE_DRIVER") != "1" {
+		t.Skip("run by git as a merge driver only")
+	}
+	args := os.Args[slices.Index(os.Args, "--")+1:]
+	result, err := Merge(context.Background(), ".", args[0], args[1], args[2], args[3])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	os.Exit(result.ExitCode())
+}
+
+// mergeTestRepo adds to the test repository a notes collection whose record
+// merging is disabled, and commits both collections' records.
+func mergeTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	r := newTestRepo(t)
+	r.write(".ingitdb/root-collections.yaml", "widgets: ./widgets\nnotes: ./notes\n")
+	r.write("notes/.collection/definition.yaml", "record_file:\n  name: notes.yaml\n"+
+		"  type: \"map[$record_id]map[$field_name]any\"\n  format: yaml\n"+
+		"columns:\n  text:\n    type: string\n"+
+		"conflict_resolution:\n  record_merge:\n    enabled: false\n")
+	r.write("widgets/widgets.yaml", validWidgets)
+	r.write("notes/notes.yaml", "n1:\n  text: hello\n")
+	r.git("add", "-A")
+	r.git("commit", "-q", "-m", "base")
+	return r
+}
+
+func TestMergeAttributes(t *testing.T) {
+	t.Parallel()
+
+	r := mergeTestRepo(t)
+	if err := InstallMergeDriver(context.Background(), r.dir, WithMergeDriverCommand("driver")); err != nil {
+		t.Fatalf("InstallMergeDriver: %v", err)
+	}
+	attributes, _ := os.ReadFile(filepath.Join(r.dir, ".gitattributes"))
+	want := "# BEGIN ingitdb merge\n/widgets/widgets.yaml merge=ingitdb\n# END ingitdb merge\n"
+	if string(attributes) != want {
+		t.Errorf(".gitattributes =\n%s\nwant\n%s", attributes, want)
+	}
+	if got := r.git("config", "merge.ingitdb.driver"); got != "driver %O %A %B %P" {
+		t.Errorf("merge.ingitdb.driver = %q", got)
+	}
+	if got := r.git("check-attr", "merge", "widgets/widgets.yaml", "notes/notes.yaml"); got !=
+		"widgets/widgets.yaml: merge: ingitdb\nnotes/notes.yaml: merge: unspecified" {
+		t.Errorf("check-attr:\n%s", got)
+	}
+	// Both blocks live side by side.
+	if err := Install(context.Background(), r.dir, WithLFLineEndings()); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if attributes, _ = os.ReadFile(filepath.Join(r.dir, ".gitattributes")); !strings.HasPrefix(string(attributes), want) ||
+		!strings.Contains(string(attributes), "# BEGIN ingitdb\n") {
+		t.Errorf(".gitattributes after Install:\n%s", attributes)
+	}
+}
+
+func TestInstallMergeDriver(t *testing.T) {
+	t.Parallel()
+
+	r := mergeTestRepo(t)
+	command := os.Args[0] + " -test.run=^TestMergeDriverProcess$ --"
+	if err := InstallMergeDriver(context.Background(), r.dir, WithMergeDriverCommand(command)); err != nil {
+		t.Fatalf("InstallMergeDriver: %v", err)
+	}
+	r.git("add", "-A")
+	r.git("commit", "-q", "-m", "merge driver")
+	base := r.git("rev-parse", "HEAD")
+	merge := func(branch string) (string, error) {
+		c := exec.Command("git", "merge", "--no-edit", branch)
+		c.Dir = r.dir
+		c.Env = append(os.Environ(), "INGITDB_TEST_MERGE_DRIVER=1")
+		out, err := c.CombinedOutput()
+		return string(out), err
+	}
+	branches := func(name, theirs, ours string) {
+		r.git("checkout", "-q", "-B", name, base)
+		r.write("widgets/widgets.yaml", theirs)
+		r.git("commit", "-q", "-am", name)
+		r.git("checkout", "-q", "-B", name+"-target", base)
+		r.write("widgets/widgets.yaml", ours)
+		r.git("commit", "-q", "-am", name+" target")
+	}
+
+	// Both sides append a record after the same line: a text conflict that
+	// merges cleanly record by record.
+	branches("append", validWidgets+"w3:\n  name: Washer\n  size: 2\n", validWidgets+"w4:\n  name: Pin\n  size: 5\n")
+	if out, err := merge("append"); err != nil {
+		t.Fatalf("merge: %v\n%s", err, out)
+	}
+	content, _ := os.ReadFile(filepath.Join(r.dir, "widgets", "widgets.yaml"))
+	want := "w1:\n    name: Bolt\n    size: 3\nw2:\n    name: Nut\n    size: 1\n" +
+		"w3:\n    name: Washer\n    size: 2\nw4:\n    name: Pin\n    size: 5\n"
+	if string(content) != want {
+		t.Errorf("merged widgets.yaml =\n%s\nwant\n%s", content, want)
+	}
+
+	// Both sides change the same field: the merge stops with markers.
+	branches("clash", strings.Replace(validWidgets, "size: 3", "size: 4", 1), strings.Replace(validWidgets, "size: 3", "size: 5", 1))
+	if out, err := merge("clash"); err == nil {
+		t.Fatalf("merge succeeded:\n%s", out)
+	}
+	content, _ = os.ReadFile(filepath.Join(r.dir, "widgets", "widgets.yaml"))
+	if !strings.Contains(string(content), "<<<<<<< ours (") || !strings.Contains(string(content), "  size: 5\n=======\n  size: 4\n>>>>>>> theirs\n") {
+		t.Errorf("conflicted widgets.yaml:\n%s", content)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	r := mergeTestRepo(t)
+	ctx := context.Background()
+	files := func(base, ours, theirs string) (string, string, string) {
+		dir := t.TempDir()
+		paths := []string{filepath.Join(dir, "O"), filepath.Join(dir, "A"), filepath.Join(dir, "B")}
+		for i, content := range []string{base, ours, theirs} {
+			if err := os.WriteFile(paths[i], []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return paths[0], paths[1], paths[2]
+	}
+
+	// Different fields of one record: the lines merge, the records do not,
+	// as same-record merging is off by default.
+	base := "w1:\n  name: Bolt\n  colour: red\n  size: 3\n"
+	ancestor, current, other := files(base, strings.Replace(base, "Bolt", "Big bolt", 1), strings.Replace(base, "size: 3", "size: 4", 1))
+	result, err := Merge(ctx, r.dir, ancestor, current, other, "widgets/widgets.yaml")
+	if err != nil || !result.Conflicted || result.Reason == "" || result.ExitCode() != 1 {
+		t.Fatalf("Merge = %+v, %v; want a conflict with a reason", result, err)
+	}
+	content, _ := os.ReadFile(current)
+	want := "<<<<<<< ours (" + result.Reason + ")\n" + strings.Replace(base, "Bolt", "Big bolt", 1) +
+		"=======\n" + strings.Replace(base, "size: 3", "size: 4", 1) + ">>>>>>> theirs\n"
+	if string(content) != want {
+		t.Errorf("whole-file conflict =\n%s\nwant\n%s", content, want)
+	}
+
+	// With record merging disabled, and outside any collection, files merge
+	// as text.
+	for _, path := range []string{"notes/notes.yaml", "README.md"} {
+		ancestor, current, other = files("a\nb\nc\n", "A\nb\nc\n", "a\nb\nC\n")
+		result, err = Merge(ctx, r.dir, ancestor, current, other, path)
+		content, _ = os.ReadFile(current)
+		if err != nil || !reflect.DeepEqual(result, MergeResult{}) || string(content) != "A\nb\nC\n" {
+			t.Errorf("%s: Merge = %+v, %v, content %q", path, result, err, content)
+		}
+	}
+	ancestor, current, other = files("a\n", "b\n", "c\n")
+	if result, err = Merge(ctx, r.dir, ancestor, current, other, "README.md"); err != nil || !result.Conflicted || result.Reason != "" {
+		t.Errorf("text conflict: Merge = %+v, %v", result, err)
+	}
+	if _, err = Merge(ctx, r.dir, filepath.Join(t.TempDir(), "missing"), current, other, "widgets/widgets.yaml"); err == nil {
+		t.Error("expected an error for a missing ancestor file")
+	}
+}
+
+func TestMerge_DefinitionUnreadable(t *testing.T) {
+	t.Parallel()
+
+	r := mergeTestRepo(t)
+	r.write(".ingitdb/root-collections.yaml", "widgets: [\n")
+	dir := t.TempDir()
+	ancestor, current, other := filepath.Join(dir, "O"), filepath.Join(dir, "A"), filepath.Join(dir, "B")
+	for path, content := range map[string]string{ancestor: "a\nb\nc\n", current: "A\nb\nc\n", other: "a\nb\nC\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err := Merge(context.Background(), r.dir, ancestor, current, other, "widgets/widgets.yaml")
+	if err != nil || result.Conflicted || !strings.Contains(result.Reason, "failed to read definition") {
+		t.Fatalf("Merge = %+v, %v; want a clean text merge with a reason", result, err)
+	}
+	if content, _ := os.ReadFile(current); string(content) != "A\nb\nC\n" {
+		t.Errorf("merged content = %q", content)
+	}
+}
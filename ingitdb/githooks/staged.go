@@ -0,0 +1,103 @@
+// Package githooks connects inGitDB to git: a pre-commit hook that validates
+// the staged records, and a merge driver that merges record files record by
+// record. Each comes with an installer.
+package githooks
+
+// specscore: feature/cli/validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/datavalidator"
+	"github.com/ingitdb/ingitdb-go/ingitdb/gitdiff"
+	"github.com/ingitdb/ingitdb-go/ingitdb/gittree"
+	"github.com/ingitdb/ingitdb-go/ingitdb/internal/gitobject"
+	"github.com/ingitdb/ingitdb-go/ingitdb/validator"
+)
+
+// Option configures ValidateStaged.
+type Option func(*options)
+
+type options struct {
+	validatorOpts []datavalidator.ValidatorOption
+}
+
+// WithValidatorOptions adds opts to those of the validator that reads the
+// staged records, for example datavalidator.WithConcurrency. They apply to the
+// full pass, before the first commit and when a staged change touches a
+// definition file, and to the incremental one.
+func WithValidatorOptions(opts ...datavalidator.ValidatorOption) Option {
+	return func(o *options) {
+		o.validatorOpts = append(o.validatorOpts, opts...)
+	}
+}
+
+// ValidateStaged validates what is about to be committed in the repository at
+// dbPath, which must be its root: the blobs staged in the git index, not the
+// working tree files, so a partially staged edit is validated as it will be
+// committed. It is meant for a pre-commit hook; see ExitCode and Install.
+//
+// The definition and the records changed between HEAD and the index are read
+// from the staged blobs, and only those records are validated. Before the
+// first commit every staged record is validated. Findings name files under
+// dbPath, where the staged content will be once committed.
+func ValidateStaged(ctx context.Context, dbPath string, opts ...Option) (*ingitdb.ValidationResult, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	root, err := filepath.Abs(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for %q: %w", dbPath, err)
+	}
+	tree, err := gittree.Open(ctx, root, gitdiff.IndexRef)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tree.Close() }()
+	def, err := validator.NewGitCollectionsReader(tree).ReadDefinition(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the staged definition: %w", err)
+	}
+
+	validatorOpts := append([]datavalidator.ValidatorOption{datavalidator.WithGitTree(tree)}, o.validatorOpts...)
+	full := datavalidator.NewValidator(validatorOpts...)
+	hasHead, err := hasCommit(root, "HEAD")
+	switch {
+	case err != nil:
+		return nil, err
+	case !hasHead:
+		return full.Validate(ctx, root, def)
+	default:
+		resolver := datavalidator.NewChangeSetResolver(
+			datavalidator.WithGitFileReader(gitdiff.NewInProcessGitFileReader()))
+		incremental := datavalidator.NewIncrementalValidator(gitdiff.NewInProcessGitDiffer(), resolver, full, validatorOpts...)
+		return incremental.ValidateChanges(ctx, root, def, "HEAD", gitdiff.IndexRef)
+	}
+}
+
+// ExitCode returns the status a pre-commit hook exits with for result: 1, which
+// makes git abort the commit, when validation found anything, and 0 otherwise.
+func ExitCode(result *ingitdb.ValidationResult) int {
+	if result.HasErrors() {
+		return 1
+	}
+	return 0
+}
+
+// hasCommit reports whether rev names a commit in the repository at
+// repoPath; it does not for HEAD before the first commit.
+func hasCommit(repoPath, rev string) (bool, error) {
+	repo, err := gitobject.Open(repoPath)
+	if err != nil {
+		return false, err
+	}
+	if _, err = repo.ResolveRevision(rev + "^{commit}"); errors.Is(err, gitobject.ErrUnknownRevision) {
+		return false, nil
+	}
+	return err == nil, err
+}
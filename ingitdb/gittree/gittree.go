@@ -0,0 +1,299 @@
+// Package gittree reads the files of a git commit without checking it out. A
+// Tree lists a ref's tree once and reads blobs on demand, so a database
+// definition and its records can be loaded as of any commit, tag or branch —
+// including from a bare clone, which has no working tree at all. The
+// repository is read in process; no git binary is needed.
+package gittree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb/gitdiff"
+	"github.com/ingitdb/ingitdb-go/ingitdb/internal/gitobject"
+)
+
+// Tree is a read-only view of the files of one git tree. It implements
+// fs.ReadFileFS, fs.ReadDirFS and fs.StatFS over slash-separated paths
+// relative to the repository root; Paths adapts it to OS paths. A Tree is safe
+// for concurrent use.
+type Tree struct {
+	repo  *gitobject.Repo
+	root  string
+	ref   string
+	files map[string]blob
+	dirs  map[string][]fs.DirEntry
+
+	mu    sync.Mutex
+	sizes map[gitobject.Hash]int64 // blob sizes, learnt as blobs are read
+}
+
+// blob is one file of the tree.
+type blob struct {
+	id   gitobject.Hash
+	mode fs.FileMode
+}
+
+// Open lists the tree of ref — a commit, tag, branch or tree id, or
+// gitdiff.IndexRef for the files staged in the index — in the repository at
+// repoPath. repoPath is the repository's top-level directory, or for a bare
+// repository the repository directory itself; Paths resolves OS paths against
+// it. Submodules, and paths with an unresolved merge conflict in the index,
+// are left out.
+func Open(ctx context.Context, repoPath, ref string) (*Tree, error) {
+	if ref == "" {
+		return nil, fmt.Errorf("ref is required")
+	}
+	root, err := filepath.Abs(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for %q: %w", repoPath, err)
+	}
+	repo, err := gitobject.Open(root)
+	if err != nil {
+		return nil, err
+	}
+	var entries map[string]gitobject.Entry
+	if ref == gitdiff.IndexRef {
+		index, indexErr := repo.ReadIndex()
+		if indexErr != nil {
+			return nil, indexErr
+		}
+		entries = make(map[string]gitobject.Entry, len(index.Entries))
+		for _, e := range index.Entries {
+			if e.Stage == 0 {
+				entries[e.Path] = e.Entry
+			}
+		}
+	} else {
+		id, resolveErr := repo.ResolveRevision(ref)
+		if resolveErr != nil {
+			return nil, resolveErr
+		}
+		if entries, err = repo.TreeFiles(id); err != nil {
+			return nil, fmt.Errorf("failed to list the tree of %s: %w", ref, err)
+		}
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	t := &Tree{repo: repo, root: root, ref: ref, sizes: map[gitobject.Hash]int64{}}
+	t.index(entries)
+	return t, nil
+}
+
+// Ref returns the ref the tree was opened at.
+func (t *Tree) Ref() string { return t.ref }
+
+// index builds the file and directory maps from the tree's entries.
+func (t *Tree) index(entries map[string]gitobject.Entry) {
+	t.files = make(map[string]blob, len(entries))
+	children := map[string]map[string]bool{".": {}} // dir → child name → is a directory
+	for name, e := range entries {
+		mode := fs.FileMode(0o444)
+		switch e.Mode {
+		case gitobject.ModeGitlink:
+			continue // a submodule commit has no content in this repository
+		case gitobject.ModeExecutable:
+			mode = 0o555
+		case gitobject.ModeSymlink:
+			mode = fs.ModeSymlink | 0o777
+		}
+		t.files[name] = blob{id: e.Hash, mode: mode}
+		for child, isDir := name, false; child != "."; child, isDir = path.Dir(child), true {
+			dir := path.Dir(child)
+			if children[dir] == nil {
+				children[dir] = map[string]bool{}
+			}
+			children[dir][path.Base(child)] = isDir
+		}
+	}
+	t.dirs = make(map[string][]fs.DirEntry, len(children))
+	for dir, names := range children {
+		dirEntries := make([]fs.DirEntry, 0, len(names))
+		for name, isDir := range names {
+			if isDir {
+				dirEntries = append(dirEntries, fs.FileInfoToDirEntry(dirInfo(name)))
+			} else {
+				dirEntries = append(dirEntries, fileEntry{t: t, name: path.Join(dir, name)})
+			}
+		}
+		slices.SortFunc(dirEntries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+		t.dirs[dir] = dirEntries
+	}
+}
+
+// Open implements fs.FS.
+func (t *Tree) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if entries, ok := t.dirs[name]; ok {
+		return &openDir{info: dirInfo(path.Base(name)), entries: entries}, nil
+	}
+	if _, ok := t.files[name]; !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	content, err := t.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	info := fileInfo{name: path.Base(name), size: int64(len(content)), mode: t.files[name].mode}
+	return &openFile{info: info, Reader: bytes.NewReader(content)}, nil
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (t *Tree) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	b, ok := t.files[name]
+	if !ok {
+		err := fs.ErrNotExist
+		if _, isDir := t.dirs[name]; isDir {
+			err = errors.New("is a directory")
+		}
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	content, err := t.readBlob(b)
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	return content, nil
+}
+
+// ReadDir implements fs.ReadDirFS. Entries are sorted by name.
+func (t *Tree) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	entries, ok := t.dirs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return slices.Clone(entries), nil
+}
+
+// Stat implements fs.StatFS.
+func (t *Tree) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if _, ok := t.dirs[name]; ok {
+		return dirInfo(path.Base(name)), nil
+	}
+	if _, ok := t.files[name]; ok {
+		return t.fileInfo(name)
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// Close releases nothing: blobs are read in process. It is kept so that
+// callers need not care how a Tree reads.
+func (t *Tree) Close() error { return nil }
+
+// readBlob reads one blob from the object database and notes its size.
+func (t *Tree) readBlob(b blob) ([]byte, error) {
+	_, content, err := t.repo.ReadObject(b.id)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	t.sizes[b.id] = int64(len(content))
+	t.mu.Unlock()
+	return content, nil
+}
+
+// fileInfo describes the file name. Git trees record no sizes, so a blob not
+// read yet is read for its size.
+func (t *Tree) fileInfo(name string) (fileInfo, error) {
+	b := t.files[name]
+	t.mu.Lock()
+	size, ok := t.sizes[b.id]
+	t.mu.Unlock()
+	if !ok {
+		content, err := t.readBlob(b)
+		if err != nil {
+			return fileInfo{}, &fs.PathError{Op: "stat", Path: name, Err: err}
+		}
+		size = int64(len(content))
+	}
+	return fileInfo{name: path.Base(name), size: size, mode: b.mode}, nil
+}
+
+// fileEntry is a file's fs.DirEntry; its size is only looked up by Info.
+type fileEntry struct {
+	t    *Tree
+	name string // slash-separated path in the tree
+}
+
+func (e fileEntry) Name() string               { return path.Base(e.name) }
+func (e fileEntry) IsDir() bool                { return false }
+func (e fileEntry) Type() fs.FileMode          { return e.t.files[e.name].mode.Type() }
+func (e fileEntry) Info() (fs.FileInfo, error) { return e.t.fileInfo(e.name) }
+
+func dirInfo(name string) fileInfo {
+	return fileInfo{name: name, mode: fs.ModeDir | 0o555}
+}
+
+// fileInfo describes a tree entry. Git records no modification times, so
+// ModTime is the zero time.
+type fileInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi fileInfo) ModTime() time.Time { return time.Time{} }
+func (fi fileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi fileInfo) Sys() any           { return nil }
+
+// openFile is a file opened with Tree.Open; its content is read in full.
+type openFile struct {
+	info fileInfo
+	*bytes.Reader
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *openFile) Close() error               { return nil }
+
+// openDir is a directory opened with Tree.Open.
+type openDir struct {
+	info    fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *openDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *openDir) Close() error               { return nil }
+
+func (d *openDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *openDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return slices.Clone(rest), nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	d.offset += n
+	return slices.Clone(rest[:n]), nil
+}
@@ -0,0 +1,240 @@
+package gittree
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb/gitdiff"
+	"github.com/ingitdb/ingitdb-go/ingitdb/internal/gitobject"
+)
+
+// testRepo creates a repository in a temp dir and returns it with helpers to
+// write files and run git in it.
+func testRepo(t *testing.T) (dir string, write func(name, content string), git func(args ...string) string) {
+	t.Helper()
+	dir = t.TempDir()
+	git = func(args ...string) string {
+		t.Helper()
+		c := exec.Command("git", args...)
+		c.Dir = dir
+		out, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+	write = func(name, content string) {
+		t.Helper()
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	git("init", "-q")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test")
+	return dir, write, git
+}
+
+func openTree(t *testing.T, repoPath, ref string) *Tree {
+	t.Helper()
+	tree, err := Open(context.Background(), repoPath, ref)
+	if err != nil {
+		t.Fatalf("Open(%s): %v", ref, err)
+	}
+	t.Cleanup(func() { _ = tree.Close() })
+	return tree
+}
+
+func TestTree_ReadsFilesAtRef(t *testing.T) {
+	t.Parallel()
+
+	dir, write, git := testRepo(t)
+	write("countries/ie.yaml", "name: Ireland\n")
+	write("countries/fr.yaml", "name: France\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "v1")
+	git("tag", "v1")
+	write("countries/ie.yaml", "name: Éire\n")
+	git("rm", "-q", "countries/fr.yaml")
+	git("commit", "-q", "-am", "v2")
+	write("countries/ie.yaml", "name: uncommitted\n")
+
+	v1 := openTree(t, dir, "v1")
+	content, err := v1.ReadFile("countries/ie.yaml")
+	if err != nil || string(content) != "name: Ireland\n" {
+		t.Errorf("ReadFile at v1 = %q, %v; want the v1 content", content, err)
+	}
+	if _, err = v1.ReadFile("countries/fr.yaml"); err != nil {
+		t.Errorf("fr.yaml exists at v1: %v", err)
+	}
+
+	head := openTree(t, dir, "HEAD")
+	content, err = head.ReadFile("countries/ie.yaml")
+	if err != nil || string(content) != "name: Éire\n" {
+		t.Errorf("ReadFile at HEAD = %q, %v; want the committed content, not the working tree", content, err)
+	}
+	if _, err = head.ReadFile("countries/fr.yaml"); !os.IsNotExist(err) {
+		t.Errorf("fr.yaml was deleted at HEAD, got err %v", err)
+	}
+	if got := head.Ref(); got != "HEAD" {
+		t.Errorf("Ref() = %q", got)
+	}
+}
+
+func TestTree_FS(t *testing.T) {
+	t.Parallel()
+
+	dir, write, git := testRepo(t)
+	write("a.txt", "a")
+	write("dir/b.txt", "bb")
+	write("dir/sub/c.txt", "")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+
+	tree := openTree(t, dir, "HEAD")
+	if err := fstest.TestFS(tree, "a.txt", "dir/b.txt", "dir/sub/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if content, err := tree.ReadFile("dir/b.txt"); err != nil || string(content) != "bb" {
+		t.Errorf("ReadFile after Close = %q, %v; want a fresh reader", content, err)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	t.Parallel()
+
+	dir, write, git := testRepo(t)
+	write("db/people/$records/alice.yaml", "name: Alice\n")
+	write("db/people/$records/bob.yaml", "name: Bob\n")
+	write("db/people/readme.md", "")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+
+	paths := openTree(t, dir, "HEAD").Paths()
+	records := filepath.Join(dir, "db", "people", "$records")
+
+	content, err := paths.ReadFile(filepath.Join(records, "alice.yaml"))
+	if err != nil || string(content) != "name: Alice\n" {
+		t.Errorf("ReadFile = %q, %v", content, err)
+	}
+	matches, err := paths.Glob(filepath.Join(records, "*.yaml"))
+	want := []string{filepath.Join(records, "alice.yaml"), filepath.Join(records, "bob.yaml")}
+	if err != nil || !slices.Equal(matches, want) {
+		t.Errorf("Glob = %v, %v; want %v", matches, err, want)
+	}
+	info, err := paths.Stat(records)
+	if err != nil || !info.IsDir() {
+		t.Errorf("Stat(records dir) = %v, %v; want a directory", info, err)
+	}
+	entries, err := paths.ReadDir(filepath.Join(dir, "db", "people"))
+	if err != nil || len(entries) != 2 || entries[0].Name() != "$records" || !entries[0].IsDir() {
+		t.Errorf("ReadDir = %v, %v", entries, err)
+	}
+	f, err := paths.Open(filepath.Join(records, "bob.yaml"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	_ = f.Close()
+
+	for _, missing := range []string{
+		filepath.Join(records, "carol.yaml"),
+		filepath.Join(filepath.Dir(dir), "elsewhere.yaml"), // outside the repository
+	} {
+		if _, err = paths.ReadFile(missing); !os.IsNotExist(err) {
+			t.Errorf("ReadFile(%s) err = %v, want not-exist", missing, err)
+		}
+		if _, err = paths.Stat(missing); !os.IsNotExist(err) {
+			t.Errorf("Stat(%s) err = %v, want not-exist", missing, err)
+		}
+	}
+	if matches, err = paths.Glob(filepath.Join(filepath.Dir(dir), "*")); err != nil || len(matches) != 0 {
+		t.Errorf("Glob outside the repository = %v, %v; want no matches", matches, err)
+	}
+}
+
+// A bare clone has no working tree; its files are read from the object
+// database alone.
+func TestOpen_BareRepository(t *testing.T) {
+	t.Parallel()
+
+	dir, write, git := testRepo(t)
+	write("db/tags.yaml", "go: {}\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+	bare := filepath.Join(t.TempDir(), "clone.git")
+	git("clone", "-q", "--bare", dir, bare)
+
+	paths := openTree(t, bare, "HEAD").Paths()
+	content, err := paths.ReadFile(filepath.Join(bare, "db", "tags.yaml"))
+	if err != nil || string(content) != "go: {}\n" {
+		t.Errorf("ReadFile = %q, %v", content, err)
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	t.Parallel()
+
+	dir, write, git := testRepo(t)
+	write("a.txt", "a")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+
+	if _, err := Open(context.Background(), dir, ""); err == nil {
+		t.Error("expected an error for an empty ref")
+	}
+	if _, err := Open(context.Background(), dir, "no-such-ref"); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+	if _, err := Open(context.Background(), t.TempDir(), "HEAD"); err == nil {
+		t.Error("expected an error outside a repository")
+	}
+}
+
+// The index side: staged content, not the working tree or HEAD.
+func TestOpen_Index(t *testing.T) {
+	t.Parallel()
+
+	dir, write, git := testRepo(t)
+	write("countries/ie.yaml", "name: Ireland\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+	write("countries/ie.yaml", "name: Éire\n")
+	write("countries/fr.yaml", "name: France\n")
+	git("add", "-A")
+	write("countries/ie.yaml", "name: unstaged\n")
+
+	tree := openTree(t, dir, gitdiff.IndexRef)
+	for name, want := range map[string]string{"countries/ie.yaml": "name: Éire\n", "countries/fr.yaml": "name: France\n"} {
+		if content, err := tree.ReadFile(name); err != nil || string(content) != want {
+			t.Errorf("ReadFile(%s) = %q, %v; want %q", name, content, err, want)
+		}
+	}
+}
+
+func TestIndex_LeavesOutSubmodules(t *testing.T) {
+	t.Parallel()
+
+	tree := &Tree{sizes: map[gitobject.Hash]int64{}}
+	tree.index(map[string]gitobject.Entry{
+		"vendor/lib": {Mode: gitobject.ModeGitlink},
+		"a.txt":      {Mode: gitobject.ModeRegular},
+	})
+	if _, err := tree.Stat("vendor/lib"); !os.IsNotExist(err) {
+		t.Errorf("a submodule must be left out, got %v", err)
+	}
+	if _, err := tree.Stat("vendor"); !os.IsNotExist(err) {
+		t.Errorf("a directory holding only a submodule must be left out, got %v", err)
+	}
+}
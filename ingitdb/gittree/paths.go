@@ -0,0 +1,108 @@
+package gittree
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Paths adapts a Tree to OS paths, for readers written against os.ReadFile,
+// os.ReadDir, os.Stat and filepath.Glob. A path resolves relative to the
+// tree's repository directory, as if the tree were checked out there; a path
+// outside it does not exist. Errors for missing paths satisfy os.IsNotExist.
+type Paths struct {
+	t *Tree
+}
+
+// Paths returns the tree's OS-path adapter.
+func (t *Tree) Paths() Paths { return Paths{t: t} }
+
+// Root returns the directory OS paths resolve against.
+func (p Paths) Root() string { return p.t.root }
+
+// ReadFile mirrors os.ReadFile.
+func (p Paths) ReadFile(name string) ([]byte, error) {
+	rel, ok := p.rel(name)
+	if !ok {
+		return nil, notExist("read", name)
+	}
+	content, err := p.t.ReadFile(rel)
+	return content, withPath(err, name)
+}
+
+// ReadDir mirrors os.ReadDir.
+func (p Paths) ReadDir(name string) ([]os.DirEntry, error) {
+	rel, ok := p.rel(name)
+	if !ok {
+		return nil, notExist("readdir", name)
+	}
+	entries, err := p.t.ReadDir(rel)
+	return entries, withPath(err, name)
+}
+
+// Stat mirrors os.Stat.
+func (p Paths) Stat(name string) (os.FileInfo, error) {
+	rel, ok := p.rel(name)
+	if !ok {
+		return nil, notExist("stat", name)
+	}
+	info, err := p.t.Stat(rel)
+	return info, withPath(err, name)
+}
+
+// Open mirrors os.Open.
+func (p Paths) Open(name string) (fs.File, error) {
+	rel, ok := p.rel(name)
+	if !ok {
+		return nil, notExist("open", name)
+	}
+	f, err := p.t.Open(rel)
+	return f, withPath(err, name)
+}
+
+// Glob mirrors filepath.Glob. Matches are OS paths under Root.
+func (p Paths) Glob(pattern string) ([]string, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	rel, ok := p.rel(pattern)
+	if !ok {
+		return nil, nil
+	}
+	matches, err := fs.Glob(p.t, rel)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range matches {
+		matches[i] = filepath.Join(p.t.root, filepath.FromSlash(m))
+	}
+	return matches, nil
+}
+
+// rel maps an OS path to the tree's slash-separated path, reporting false for
+// a path outside the repository directory.
+func (p Paths) rel(name string) (string, bool) {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(p.t.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	return rel, fs.ValidPath(rel)
+}
+
+func notExist(op, name string) error {
+	return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+// withPath reports err, a tree error, under the OS path the caller gave.
+func withPath(err error, name string) error {
+	if pathErr, ok := err.(*fs.PathError); ok {
+		return &fs.PathError{Op: pathErr.Op, Path: name, Err: pathErr.Err}
+	}
+	return err
+}
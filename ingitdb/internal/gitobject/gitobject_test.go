@@ -0,0 +1,254 @@
+package gitobject
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func gitIn(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	out, err := c.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+// packedRepo builds a repository with several versions of a file, an
+// annotated tag and a branch, then packs it so most objects are deltas.
+func packedRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	gitIn(t, dir, "init", "-q")
+	gitIn(t, dir, "config", "user.email", "test@example.com")
+	gitIn(t, dir, "config", "user.name", "Test")
+	for i := range 6 {
+		var b strings.Builder
+		for line := range 200 {
+			fmt.Fprintf(&b, "line %d of version %d\n", line, min(line, i))
+		}
+		if err := os.MkdirAll(filepath.Join(dir, "data"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "data", "records.txt"), []byte(b.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		gitIn(t, dir, "add", "-A")
+		gitIn(t, dir, "commit", "-q", "-m", fmt.Sprintf("version %d", i))
+		if i == 2 {
+			gitIn(t, dir, "tag", "-a", "-m", "release", "v1")
+			gitIn(t, dir, "branch", "maintenance")
+		}
+	}
+	gitIn(t, dir, "gc", "-q", "--aggressive")
+	return dir
+}
+
+func TestReadObject_MatchesGit(t *testing.T) {
+	t.Parallel()
+
+	dir := packedRepo(t)
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	all := gitIn(t, dir, "cat-file", "--batch-all-objects", "--batch-check")
+	checked := 0
+	for _, line := range strings.Split(strings.TrimSpace(all), "\n") {
+		fields := strings.Fields(line) // <id> <type> <size>
+		id, err := ParseHash(fields[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		typ, content, err := repo.ReadObject(id)
+		if err != nil {
+			t.Fatalf("ReadObject(%s): %v", id, err)
+		}
+		want := gitIn(t, dir, "cat-file", fields[1], fields[0])
+		if typ.String() != fields[1] || !bytes.Equal(content, []byte(want)) {
+			t.Errorf("object %s: got %s of %d bytes, want %s of %d bytes", id, typ, len(content), fields[1], len(want))
+		}
+		if HashObject(typ, content) != id {
+			t.Errorf("object %s hashes to %s", id, HashObject(typ, content))
+		}
+		checked++
+	}
+	if checked < 20 {
+		t.Errorf("checked only %d objects", checked)
+	}
+}
+
+func TestResolveRevision_MatchesGit(t *testing.T) {
+	t.Parallel()
+
+	dir := packedRepo(t)
+	repo, err := Open(filepath.Join(dir, "data")) // found from a subdirectory
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	head := strings.TrimSpace(gitIn(t, dir, "rev-parse", "HEAD"))
+	for _, rev := range []string{
+		"HEAD", "HEAD~2", "HEAD^", "HEAD^^", "HEAD~1^1", "v1", "v1^{commit}", "v1^0", "v1~1", "v1^{tree}",
+		"maintenance", "heads/maintenance", "refs/heads/maintenance", "tags/v1", head[:7], head,
+	} {
+		want := strings.TrimSpace(gitIn(t, dir, "rev-parse", rev))
+		got, err := repo.ResolveRevision(rev)
+		if err != nil {
+			t.Errorf("ResolveRevision(%q): %v", rev, err)
+			continue
+		}
+		if got.String() != want {
+			t.Errorf("ResolveRevision(%q) = %s, want %s", rev, got, want)
+		}
+	}
+	for _, rev := range []string{"", "~1", "nope", "HEAD~9", "HEAD^2", "v1^{blob}", "HEAD^{commit"} {
+		if _, err := repo.ResolveRevision(rev); err == nil {
+			t.Errorf("ResolveRevision(%q): expected an error", rev)
+		}
+	}
+}
+
+func TestTreeFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := packedRepo(t)
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	id, err := repo.ResolveRevision("v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := repo.TreeFiles(id)
+	if err != nil {
+		t.Fatalf("TreeFiles: %v", err)
+	}
+	entry, ok := files["data/records.txt"]
+	want := strings.TrimSpace(gitIn(t, dir, "rev-parse", "v1:data/records.txt"))
+	if len(files) != 1 || !ok || entry.Hash.String() != want || entry.Mode != ModeRegular {
+		t.Errorf("TreeFiles = %+v, want data/records.txt at %s", files, want)
+	}
+}
+
+func TestTreeFile(t *testing.T) {
+	t.Parallel()
+
+	dir := packedRepo(t)
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	id, err := repo.ResolveRevision("v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok, err := repo.TreeFile(id, "data/records.txt")
+	want := strings.TrimSpace(gitIn(t, dir, "rev-parse", "v1:data/records.txt"))
+	if err != nil || !ok || entry.Hash.String() != want || entry.Mode != ModeRegular {
+		t.Errorf("TreeFile = %+v, %v, %v; want data/records.txt at %s", entry, ok, err, want)
+	}
+	for _, name := range []string{"data", "data/missing.txt", "data/records.txt/x", "missing/records.txt"} {
+		if _, ok, err = repo.TreeFile(id, name); ok || err != nil {
+			t.Errorf("TreeFile(%q) = %v, %v; want no file and no error", name, ok, err)
+		}
+	}
+}
+
+func TestReadIndex(t *testing.T) {
+	t.Parallel()
+
+	dir := packedRepo(t)
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for _, version := range []string{"2", "3", "4"} {
+		gitIn(t, dir, "update-index", "--index-version", version)
+		index, err := repo.ReadIndex()
+		if err != nil {
+			t.Fatalf("v%s: ReadIndex: %v", version, err)
+		}
+		want := strings.TrimSpace(gitIn(t, dir, "rev-parse", ":data/records.txt"))
+		if len(index.Entries) != 1 || index.Entries[0].Path != "data/records.txt" || index.Entries[0].Hash.String() != want {
+			t.Errorf("v%s: entries = %+v", version, index.Entries)
+		}
+	}
+
+	// A v4 entry strips its prefix from the previous path; 128 bytes or
+	// more takes a two-byte varint.
+	long := filepath.Join("a", strings.Repeat("d", 150), "x.txt")
+	if err = os.MkdirAll(filepath.Join(dir, filepath.Dir(long)), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{long, "b.txt"} {
+		if err = os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitIn(t, dir, "add", "-A")
+	gitIn(t, dir, "update-index", "--index-version", "4")
+	index, err := repo.ReadIndex()
+	if err != nil {
+		t.Fatalf("v4 with a long strip: ReadIndex: %v", err)
+	}
+	var paths []string
+	for _, e := range index.Entries {
+		paths = append(paths, e.Path)
+	}
+	if want := []string{filepath.ToSlash(long), "b.txt", "data/records.txt"}; !slices.Equal(paths, want) {
+		t.Errorf("v4 paths = %q, want %q", paths, want)
+	}
+
+	if _, err = Open(t.TempDir()); err == nil {
+		t.Error("Open of a directory outside any repository: expected an error")
+	}
+	for _, tc := range []struct {
+		in   []byte
+		want uint64
+		n    int
+	}{
+		{[]byte{0x7f}, 127, 1},
+		{[]byte{0x80, 0x00}, 128, 2},
+		{[]byte{0x80, 0x16, 'x'}, 150, 2},
+		{[]byte{0x80}, 0, 0},
+	} {
+		if v, n := offsetVarint(tc.in); v != tc.want || n != tc.n {
+			t.Errorf("offsetVarint(%x) = %d, %d; want %d, %d", tc.in, v, n, tc.want, tc.n)
+		}
+	}
+	if _, err = parseIndex([]byte("DIRC not an index at all, far too short")); err == nil {
+		t.Error("parseIndex: expected a checksum error")
+	}
+}
+
+func TestApplyDelta(t *testing.T) {
+	t.Parallel()
+
+	base := []byte("hello, world\n")
+	// base size 13, result size 18; copy 7 bytes at 0, insert "there, ",
+	// copy 4 bytes at 7.
+	delta := []byte{13, 18, 0x90, 7, 7, 't', 'h', 'e', 'r', 'e', ',', ' ', 0x91, 7, 4}
+	got, err := applyDelta(base, delta)
+	if err != nil || string(got) != "hello, there, worl" {
+		t.Errorf("applyDelta = %q, %v", got, err)
+	}
+	for _, corrupt := range [][]byte{
+		{12, 18},             // wrong base size
+		{13, 5, 0x91, 10, 9}, // copy past the end of the base
+		{13, 5, 3, 'a'},      // insert past the end of the delta
+		{13, 1, 0},           // zero-length insert
+	} {
+		if _, err = applyDelta(base, corrupt); err == nil {
+			t.Errorf("applyDelta(%v): expected an error", corrupt)
+		}
+	}
+}
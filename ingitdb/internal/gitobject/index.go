@@ -0,0 +1,138 @@
+package gitobject
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"time"
+)
+
+// IndexEntry is one path staged in the index.
+type IndexEntry struct {
+	Path  string
+	Entry        // staged blob and mode
+	Stage int    // 0, or 1-3 for the sides of an unresolved merge conflict
+	Size  uint32 // worktree file size when staged, truncated to 32 bits
+	MTime time.Time
+}
+
+// Index is the parsed index file.
+type Index struct {
+	Entries []IndexEntry // sorted by path, then stage
+	// ModTime is when the index file was written. A worktree file modified
+	// at or after it may have changed without its size or mtime showing it.
+	ModTime time.Time
+}
+
+// ReadIndex reads the repository's index. A repository without an index, e.g.
+// a bare one or one with nothing staged yet, has an empty Index.
+func (r *Repo) ReadIndex() (*Index, error) {
+	data, err := os.ReadFile(r.IndexPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Index{}, nil
+		}
+		return nil, err
+	}
+	info, err := os.Stat(r.IndexPath())
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseIndex(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", r.IndexPath(), err)
+	}
+	return &Index{Entries: entries, ModTime: info.ModTime()}, nil
+}
+
+// parseIndex parses index format versions 2 to 4: a "DIRC" header with the
+// version and entry count, then the entries, then extensions (ignored) and a
+// trailing checksum. An entry is stat data, the object id, flags holding the
+// stage and path length, extended flags (version 3 and up, when flagged),
+// and the path — NUL-padded to a multiple of 8 bytes in versions 2 and 3, and
+// in version 4 prefix-compressed against the previous entry's path.
+func parseIndex(data []byte) ([]IndexEntry, error) {
+	if len(data) < 12+sha1.Size || !bytes.Equal(data[:4], []byte("DIRC")) {
+		return nil, fmt.Errorf("not an index file")
+	}
+	sum := sha1.Sum(data[:len(data)-sha1.Size])
+	if !bytes.Equal(sum[:], data[len(data)-sha1.Size:]) {
+		return nil, fmt.Errorf("index checksum mismatch")
+	}
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("index version %d is not supported", version)
+	}
+	count := int(binary.BigEndian.Uint32(data[8:12]))
+	entries := make([]IndexEntry, 0, count)
+	pos := 12
+	prevPath := ""
+	const fixedLen = 62 // stat data (40), object id (20), flags (2)
+	for range count {
+		start := pos
+		if len(data) < pos+fixedLen {
+			return nil, fmt.Errorf("index is truncated")
+		}
+		e := IndexEntry{
+			MTime: time.Unix(int64(binary.BigEndian.Uint32(data[pos+8:])), int64(binary.BigEndian.Uint32(data[pos+12:]))),
+			Size:  binary.BigEndian.Uint32(data[pos+36:]),
+		}
+		e.Mode = binary.BigEndian.Uint32(data[pos+24:])
+		copy(e.Hash[:], data[pos+40:pos+60])
+		flags := binary.BigEndian.Uint16(data[pos+60:])
+		e.Stage = int(flags>>12) & 3
+		pos += fixedLen
+		if flags&0x4000 != 0 && version >= 3 {
+			pos += 2 // extended flags: skip-worktree, intent-to-add
+		}
+		if version == 4 {
+			strip, n := offsetVarint(data[pos:])
+			if n <= 0 || strip > uint64(len(prevPath)) {
+				return nil, fmt.Errorf("index is malformed")
+			}
+			pos += n
+			end := bytes.IndexByte(data[pos:], 0)
+			if end < 0 {
+				return nil, fmt.Errorf("index is truncated")
+			}
+			e.Path = prevPath[:len(prevPath)-int(strip)] + string(data[pos:pos+end])
+			pos += end + 1
+		} else {
+			end := bytes.IndexByte(data[pos:], 0)
+			if end < 0 {
+				return nil, fmt.Errorf("index is truncated")
+			}
+			e.Path = string(data[pos : pos+end])
+			pos = start + (pos+end-start+8)&^7
+		}
+		if e.Mode&0o170000 == modeTree {
+			return nil, fmt.Errorf("sparse index directory entries are not supported")
+		}
+		prevPath = e.Path
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// offsetVarint decodes the varint git writes for the index v4 path prefix
+// length, the same encoding as an OFS_DELTA base offset: each continuation
+// adds one before shifting, so it differs from LEB128 from 128 on. n is 0
+// when b ends before the last byte, or the value overflows.
+func offsetVarint(b []byte) (v uint64, n int) {
+	for i, c := range b {
+		if i > 0 {
+			if v > (1<<57)-2 {
+				return 0, 0
+			}
+			v++
+			v <<= 7
+		}
+		v |= uint64(c & 0x7f)
+		if c&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
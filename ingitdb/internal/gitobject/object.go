@@ -0,0 +1,454 @@
+package gitobject
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Hash is a SHA-1 object id.
+type Hash [20]byte
+
+// ParseHash parses a 40-digit hex object id.
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+	if len(s) != 2*len(h) {
+		return h, fmt.Errorf("invalid object id %q", s)
+	}
+	if _, err := hex.Decode(h[:], []byte(s)); err != nil {
+		return h, fmt.Errorf("invalid object id %q", s)
+	}
+	return h, nil
+}
+
+func (h Hash) String() string { return hex.EncodeToString(h[:]) }
+
+// IsZero reports whether h is the all-zero id.
+func (h Hash) IsZero() bool { return h == Hash{} }
+
+// HashObject returns the id git gives content stored as an object of typ.
+func HashObject(typ ObjectType, content []byte) Hash {
+	d := sha1.New()
+	_, _ = fmt.Fprintf(d, "%s %d\x00", typ, len(content))
+	_, _ = d.Write(content)
+	var h Hash
+	copy(h[:], d.Sum(nil))
+	return h
+}
+
+// ObjectType is the type of a git object.
+type ObjectType int
+
+// Object types, numbered as in pack files.
+const (
+	TypeCommit ObjectType = 1
+	TypeTree   ObjectType = 2
+	TypeBlob   ObjectType = 3
+	TypeTag    ObjectType = 4
+
+	typeOfsDelta ObjectType = 6
+	typeRefDelta ObjectType = 7
+)
+
+func (t ObjectType) String() string {
+	switch t {
+	case TypeCommit:
+		return "commit"
+	case TypeTree:
+		return "tree"
+	case TypeBlob:
+		return "blob"
+	case TypeTag:
+		return "tag"
+	}
+	return "unknown"
+}
+
+func parseObjectType(s string) (ObjectType, bool) {
+	for _, t := range []ObjectType{TypeCommit, TypeTree, TypeBlob, TypeTag} {
+		if t.String() == s {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// ErrNotFound reports an object id the repository does not hold.
+var ErrNotFound = errors.New("object not found")
+
+// ReadObject returns an object's type and content.
+func (r *Repo) ReadObject(h Hash) (ObjectType, []byte, error) {
+	typ, data, err := r.readLoose(h)
+	if err == nil || !errors.Is(err, ErrNotFound) {
+		return typ, data, err
+	}
+	packs, err := r.loadPacks()
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, p := range packs {
+		if offset, ok := p.find(h); ok {
+			return p.readAt(r, offset, 0)
+		}
+	}
+	return 0, nil, fmt.Errorf("%w: %s", ErrNotFound, h)
+}
+
+func (r *Repo) looseObjectPath(h Hash) string {
+	s := h.String()
+	return filepath.Join(r.commonDir, "objects", s[:2], s[2:])
+}
+
+func (r *Repo) readLoose(h Hash) (ObjectType, []byte, error) {
+	f, err := os.Open(r.looseObjectPath(h))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil, fmt.Errorf("%w: %s", ErrNotFound, h)
+		}
+		return 0, nil, err
+	}
+	defer func() { _ = f.Close() }()
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return 0, nil, fmt.Errorf("loose object %s: %w", h, err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return 0, nil, fmt.Errorf("loose object %s: %w", h, err)
+	}
+	hdr, data, ok := bytes.Cut(raw, []byte{0})
+	typeName, size, _ := strings.Cut(string(hdr), " ")
+	typ, known := parseObjectType(typeName)
+	if !ok || !known || size != strconv.Itoa(len(data)) {
+		return 0, nil, fmt.Errorf("loose object %s: malformed header %q", h, hdr)
+	}
+	return typ, data, nil
+}
+
+// expandPrefix resolves an abbreviated hex id to the one object it names.
+func (r *Repo) expandPrefix(prefix string) (Hash, error) {
+	prefix = strings.ToLower(prefix)
+	if len(prefix) == 2*len(Hash{}) {
+		h, err := ParseHash(prefix)
+		if err != nil {
+			return Hash{}, err
+		}
+		if _, _, err = r.ReadObject(h); err != nil {
+			return Hash{}, err
+		}
+		return h, nil
+	}
+	var found []Hash
+	entries, err := os.ReadDir(filepath.Join(r.commonDir, "objects", prefix[:2]))
+	if err != nil && !os.IsNotExist(err) {
+		return Hash{}, err
+	}
+	for _, e := range entries {
+		if name := prefix[:2] + e.Name(); strings.HasPrefix(name, prefix) {
+			if h, parseErr := ParseHash(name); parseErr == nil {
+				found = append(found, h)
+			}
+		}
+	}
+	packs, err := r.loadPacks()
+	if err != nil {
+		return Hash{}, err
+	}
+	for _, p := range packs {
+		found = append(found, p.withPrefix(prefix)...)
+	}
+	slices.SortFunc(found, func(a, b Hash) int { return bytes.Compare(a[:], b[:]) })
+	found = slices.Compact(found)
+	switch len(found) {
+	case 0:
+		return Hash{}, fmt.Errorf("%w: %s", ErrNotFound, prefix)
+	case 1:
+		return found[0], nil
+	}
+	return Hash{}, fmt.Errorf("object id %s is ambiguous", prefix)
+}
+
+// pack is one pack file and its version 2 index.
+type pack struct {
+	path    string
+	hashes  []Hash
+	offsets []int64
+	fanout  [256]uint32
+}
+
+func (r *Repo) loadPacks() ([]*pack, error) {
+	r.packsOnce.Do(func() {
+		matches, err := filepath.Glob(filepath.Join(r.commonDir, "objects", "pack", "*.idx"))
+		if err != nil {
+			r.packsErr = err
+			return
+		}
+		for _, idx := range matches {
+			p, err := readPackIndex(idx)
+			if err != nil {
+				r.packsErr = err
+				return
+			}
+			r.packs = append(r.packs, p)
+		}
+	})
+	return r.packs, r.packsErr
+}
+
+// readPackIndex reads a version 2 pack index: a magic number and version, a
+// 256-entry fan-out table, the sorted object ids, their CRCs, their 4-byte
+// offsets and, for offsets with the high bit set, 8-byte offsets.
+func readPackIndex(idxPath string) (*pack, error) {
+	data, err := os.ReadFile(idxPath)
+	if err != nil {
+		return nil, err
+	}
+	const headerLen = 8 + 256*4
+	if len(data) < headerLen || !bytes.Equal(data[:4], []byte{0xff, 't', 'O', 'c'}) || binary.BigEndian.Uint32(data[4:8]) != 2 {
+		return nil, fmt.Errorf("%s: unsupported pack index format", idxPath)
+	}
+	p := &pack{path: strings.TrimSuffix(idxPath, ".idx") + ".pack"}
+	for i := range p.fanout {
+		p.fanout[i] = binary.BigEndian.Uint32(data[8+4*i:])
+	}
+	n := int(p.fanout[255])
+	hashesAt := headerLen
+	offsetsAt := hashesAt + n*20 + n*4
+	largeAt := offsetsAt + n*4
+	if len(data) < largeAt {
+		return nil, fmt.Errorf("%s: truncated pack index", idxPath)
+	}
+	p.hashes = make([]Hash, n)
+	p.offsets = make([]int64, n)
+	for i := range n {
+		copy(p.hashes[i][:], data[hashesAt+20*i:])
+		offset := binary.BigEndian.Uint32(data[offsetsAt+4*i:])
+		if offset&0x80000000 == 0 {
+			p.offsets[i] = int64(offset)
+			continue
+		}
+		at := largeAt + 8*int(offset&0x7fffffff)
+		if len(data) < at+8 {
+			return nil, fmt.Errorf("%s: truncated pack index", idxPath)
+		}
+		p.offsets[i] = int64(binary.BigEndian.Uint64(data[at:]))
+	}
+	return p, nil
+}
+
+func (p *pack) find(h Hash) (int64, bool) {
+	lo := 0
+	if h[0] > 0 {
+		lo = int(p.fanout[h[0]-1])
+	}
+	hi := int(p.fanout[h[0]])
+	i, ok := slices.BinarySearchFunc(p.hashes[lo:hi], h, func(a, b Hash) int { return bytes.Compare(a[:], b[:]) })
+	if !ok {
+		return 0, false
+	}
+	return p.offsets[lo+i], true
+}
+
+func (p *pack) withPrefix(prefix string) []Hash {
+	var found []Hash
+	for _, h := range p.hashes {
+		if strings.HasPrefix(h.String(), prefix) {
+			found = append(found, h)
+		}
+	}
+	return found
+}
+
+// maxDeltaDepth bounds delta chains; git's default maximum is 50.
+const maxDeltaDepth = 1000
+
+// readAt reads the object at offset, resolving deltas. Each entry starts with
+// a header holding its type and inflated size; a delta entry then names its
+// base — by a relative offset in this pack or by id — and its content is the
+// zlib-compressed delta.
+func (p *pack) readAt(r *Repo, offset int64, depth int) (ObjectType, []byte, error) {
+	if depth > maxDeltaDepth {
+		return 0, nil, fmt.Errorf("%s: delta chain too deep at offset %d", p.path, offset)
+	}
+	f, err := os.Open(p.path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() { _ = f.Close() }()
+	br := &byteReader{r: io.NewSectionReader(f, offset, 1<<62)}
+
+	c, err := br.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	typ := ObjectType((c >> 4) & 7)
+	size := int64(c & 0x0f)
+	for shift := 4; c&0x80 != 0; shift += 7 {
+		if c, err = br.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+		size |= int64(c&0x7f) << shift
+	}
+
+	var baseType ObjectType
+	var base []byte
+	switch typ {
+	case TypeCommit, TypeTree, TypeBlob, TypeTag:
+	case typeOfsDelta:
+		if c, err = br.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+		rel := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = br.ReadByte(); err != nil {
+				return 0, nil, err
+			}
+			rel = ((rel + 1) << 7) | int64(c&0x7f)
+		}
+		if baseType, base, err = p.readAt(r, offset-rel, depth+1); err != nil {
+			return 0, nil, err
+		}
+	case typeRefDelta:
+		var baseID Hash
+		if _, err = io.ReadFull(br, baseID[:]); err != nil {
+			return 0, nil, err
+		}
+		if baseType, base, err = r.ReadObject(baseID); err != nil {
+			return 0, nil, err
+		}
+	default:
+		return 0, nil, fmt.Errorf("%s: unknown object type %d at offset %d", p.path, typ, offset)
+	}
+
+	zr, err := zlib.NewReader(br)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: offset %d: %w", p.path, offset, err)
+	}
+	data := make([]byte, size)
+	if _, err = io.ReadFull(zr, data); err != nil {
+		return 0, nil, fmt.Errorf("%s: offset %d: %w", p.path, offset, err)
+	}
+	if typ != typeOfsDelta && typ != typeRefDelta {
+		return typ, data, nil
+	}
+	out, err := applyDelta(base, data)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: offset %d: %w", p.path, offset, err)
+	}
+	return baseType, out, nil
+}
+
+// applyDelta rebuilds an object from its base and a delta: the base and result
+// sizes as varints, then instructions that either copy a range of the base
+// (high bit set; flag bits select which offset and size bytes follow) or
+// insert the next 1-127 literal bytes.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	errCorrupt := errors.New("corrupt delta")
+	pos := 0
+	varint := func() (int, bool) {
+		n, shift := 0, 0
+		for pos < len(delta) {
+			c := delta[pos]
+			pos++
+			n |= int(c&0x7f) << shift
+			shift += 7
+			if c&0x80 == 0 {
+				return n, true
+			}
+		}
+		return 0, false
+	}
+	baseSize, ok1 := varint()
+	resultSize, ok2 := varint()
+	if !ok1 || !ok2 || baseSize != len(base) {
+		return nil, errCorrupt
+	}
+	out := make([]byte, 0, resultSize)
+	for pos < len(delta) {
+		op := delta[pos]
+		pos++
+		if op&0x80 == 0 {
+			n := int(op)
+			if n == 0 || pos+n > len(delta) {
+				return nil, errCorrupt
+			}
+			out = append(out, delta[pos:pos+n]...)
+			pos += n
+			continue
+		}
+		var offset, size int
+		for i := range 4 {
+			if op&(1<<i) != 0 {
+				if pos >= len(delta) {
+					return nil, errCorrupt
+				}
+				offset |= int(delta[pos]) << (8 * i)
+				pos++
+			}
+		}
+		for i := range 3 {
+			if op&(0x10<<i) != 0 {
+				if pos >= len(delta) {
+					return nil, errCorrupt
+				}
+				size |= int(delta[pos]) << (8 * i)
+				pos++
+			}
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if offset+size > len(base) {
+			return nil, errCorrupt
+		}
+		out = append(out, base[offset:offset+size]...)
+	}
+	if len(out) != resultSize {
+		return nil, errCorrupt
+	}
+	return out, nil
+}
+
+// byteReader buffers a pack entry for reading byte by byte; being an
+// io.ByteReader, zlib reads the compressed data through the same buffer.
+type byteReader struct {
+	r   io.Reader
+	buf [4096]byte
+	pos int
+	end int
+}
+
+func (b *byteReader) ReadByte() (byte, error) {
+	if b.pos == b.end {
+		n, err := b.r.Read(b.buf[:])
+		if n == 0 {
+			if err == nil {
+				err = io.ErrNoProgress
+			}
+			return 0, err
+		}
+		b.pos, b.end = 0, n
+	}
+	c := b.buf[b.pos]
+	b.pos++
+	return c, nil
+}
+
+func (b *byteReader) Read(p []byte) (int, error) {
+	if b.pos == b.end {
+		return b.r.Read(p)
+	}
+	n := copy(p, b.buf[b.pos:b.end])
+	b.pos += n
+	return n, nil
+}
@@ -0,0 +1,325 @@
+// Package gitobject reads a git repository's refs, object database and index
+// directly, without the git binary. It covers what diffing needs — resolving
+// revisions, reading commits and trees, listing the index — for SHA-1
+// repositories with loose and packed objects.
+package gitobject
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Repo is an opened repository. It is safe for concurrent use.
+type Repo struct {
+	gitDir    string // HEAD and index live here
+	commonDir string // objects and refs live here; gitDir unless a linked worktree
+	workTree  string // empty for a bare repository
+
+	packsOnce sync.Once
+	packs     []*pack
+	packsErr  error
+}
+
+// Open opens the repository containing path: the nearest directory at or
+// above it that is a working tree — whose .git is a directory or a "gitdir:"
+// file — or a bare repository.
+func Open(path string) (*Repo, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for %q: %w", path, err)
+	}
+	r := &Repo{}
+	for dir := abs; r.gitDir == ""; {
+		dotGit := filepath.Join(dir, ".git")
+		info, statErr := os.Stat(dotGit)
+		switch {
+		case statErr == nil && info.IsDir():
+			r.gitDir, r.workTree = dotGit, dir
+		case statErr == nil:
+			gitDir, linkErr := readGitDirLink(dotGit)
+			if linkErr != nil {
+				return nil, linkErr
+			}
+			r.gitDir, r.workTree = gitDir, dir
+		case isGitDir(dir):
+			r.gitDir = dir
+		default:
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return nil, fmt.Errorf("%s is not in a git repository", abs)
+			}
+			dir = parent
+		}
+	}
+	r.commonDir = r.gitDir
+	if content, readErr := os.ReadFile(filepath.Join(r.gitDir, "commondir")); readErr == nil {
+		common := strings.TrimSpace(string(content))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(r.gitDir, common)
+		}
+		r.commonDir = filepath.Clean(common)
+	}
+	if err = r.checkFormat(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// readGitDirLink follows a ".git" file of the form "gitdir: <path>".
+func readGitDirLink(dotGit string) (string, error) {
+	content, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(content))
+	gitDir, ok := strings.CutPrefix(line, "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("%s: expected a gitdir: line", dotGit)
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(dotGit), gitDir)
+	}
+	return filepath.Clean(gitDir), nil
+}
+
+// isGitDir reports whether dir looks like a git directory.
+func isGitDir(dir string) bool {
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// checkFormat rejects repositories whose objects are not SHA-1 addressed.
+func (r *Repo) checkFormat() error {
+	f, err := os.Open(filepath.Join(r.commonDir, "config"))
+	if err != nil {
+		return nil // no config: defaults apply
+	}
+	defer func() { _ = f.Close() }()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "objectformat") && strings.TrimSpace(value) != "sha1" {
+			return fmt.Errorf("object format %q is not supported", strings.TrimSpace(value))
+		}
+	}
+	return scanner.Err()
+}
+
+// WorkTree returns the working tree directory, or "" for a bare repository.
+func (r *Repo) WorkTree() string { return r.workTree }
+
+// IndexPath returns the path of the repository's index file.
+func (r *Repo) IndexPath() string { return filepath.Join(r.gitDir, "index") }
+
+// ResolveRevision resolves rev to an object id. It accepts a full or
+// abbreviated hex id, HEAD and the other names in the git directory, a full
+// ref or a branch, tag or remote name, each optionally followed by ~N, ^N or
+// ^{type} suffixes.
+func (r *Repo) ResolveRevision(rev string) (Hash, error) {
+	base, suffix := rev, ""
+	if i := strings.IndexAny(rev, "~^"); i >= 0 {
+		base, suffix = rev[:i], rev[i:]
+	}
+	if base == "" {
+		return Hash{}, fmt.Errorf("invalid revision %q", rev)
+	}
+	h, err := r.resolveName(base)
+	if err != nil {
+		return Hash{}, fmt.Errorf("unknown revision %q: %w", rev, err)
+	}
+	for suffix != "" {
+		op := suffix[0]
+		suffix = suffix[1:]
+		if op == '^' && strings.HasPrefix(suffix, "{") {
+			end := strings.IndexByte(suffix, '}')
+			if end < 0 {
+				return Hash{}, fmt.Errorf("invalid revision %q", rev)
+			}
+			if h, err = r.peel(h, suffix[1:end]); err != nil {
+				return Hash{}, fmt.Errorf("revision %q: %w", rev, err)
+			}
+			suffix = suffix[end+1:]
+			continue
+		}
+		digits := len(suffix) - len(strings.TrimLeft(suffix, "0123456789"))
+		n := 1
+		if digits > 0 {
+			n, _ = strconv.Atoi(suffix[:digits])
+			suffix = suffix[digits:]
+		}
+		if h, err = r.ancestor(h, op, n); err != nil {
+			return Hash{}, fmt.Errorf("revision %q: %w", rev, err)
+		}
+	}
+	return h, nil
+}
+
+// ancestor applies one ~n (n-th first-parent ancestor) or ^n (n-th parent)
+// step.
+func (r *Repo) ancestor(h Hash, op byte, n int) (Hash, error) {
+	steps, parent := n, 1
+	if op == '^' {
+		steps, parent = 1, n
+		if n == 0 {
+			return r.peel(h, "commit")
+		}
+	}
+	for range steps {
+		c, err := r.Commit(h)
+		if err != nil {
+			return Hash{}, err
+		}
+		if len(c.Parents) < parent {
+			return Hash{}, fmt.Errorf("commit %s has no parent %d", h, parent)
+		}
+		h = c.Parents[parent-1]
+	}
+	return h, nil
+}
+
+// ErrUnknownRevision reports a revision naming no ref or object, such as HEAD
+// before the first commit.
+var ErrUnknownRevision = errors.New("no such ref or object")
+
+// resolveName resolves a name without suffixes: a ref name first, as git does,
+// then a hex object id.
+func (r *Repo) resolveName(name string) (Hash, error) {
+	candidates := []string{name, "refs/" + name, "refs/tags/" + name, "refs/heads/" + name, "refs/remotes/" + name, "refs/remotes/" + name + "/HEAD"}
+	for _, ref := range candidates {
+		h, ok, err := r.readRef(ref, 0)
+		if err != nil {
+			return Hash{}, err
+		}
+		if ok {
+			return h, nil
+		}
+	}
+	if isHex(name) && len(name) >= 4 {
+		return r.expandPrefix(name)
+	}
+	return Hash{}, ErrUnknownRevision
+}
+
+// readRef reads a loose or packed ref, following symbolic refs.
+func (r *Repo) readRef(name string, depth int) (Hash, bool, error) {
+	if depth > 5 {
+		return Hash{}, false, fmt.Errorf("symbolic ref %s nests too deeply", name)
+	}
+	if strings.Contains(name, "..") {
+		return Hash{}, false, nil
+	}
+	dir := r.commonDir
+	if !strings.HasPrefix(name, "refs/") {
+		dir = r.gitDir // HEAD, ORIG_HEAD, MERGE_HEAD... are per worktree
+	}
+	content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err == nil {
+		line := strings.TrimSpace(string(content))
+		if target, ok := strings.CutPrefix(line, "ref:"); ok {
+			return r.readRef(strings.TrimSpace(target), depth+1)
+		}
+		h, parseErr := ParseHash(firstField(line))
+		if parseErr != nil {
+			return Hash{}, false, nil // not a ref file, e.g. "config"
+		}
+		return h, true, nil
+	}
+	if !strings.HasPrefix(name, "refs/") {
+		return Hash{}, false, nil
+	}
+	return r.packedRef(name)
+}
+
+func (r *Repo) packedRef(name string) (Hash, bool, error) {
+	content, err := os.ReadFile(filepath.Join(r.commonDir, "packed-refs"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Hash{}, false, nil
+		}
+		return Hash{}, false, err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		hex, ref, ok := strings.Cut(line, " ")
+		if ok && ref == name {
+			h, parseErr := ParseHash(hex)
+			return h, parseErr == nil, parseErr
+		}
+	}
+	return Hash{}, false, nil
+}
+
+// peel dereferences tags until an object of type typ ("commit", "tree", or ""
+// for any non-tag) is reached.
+func (r *Repo) peel(h Hash, typ string) (Hash, error) {
+	for {
+		objType, data, err := r.ReadObject(h)
+		if err != nil {
+			return Hash{}, err
+		}
+		switch {
+		case typ == "" && objType != TypeTag, objType.String() == typ:
+			return h, nil
+		case objType == TypeTag:
+			target, ok := header(data, "object")
+			if !ok {
+				return Hash{}, fmt.Errorf("tag %s has no object", h)
+			}
+			if h, err = ParseHash(target); err != nil {
+				return Hash{}, err
+			}
+		case objType == TypeCommit && typ == "tree":
+			c, parseErr := parseCommit(data)
+			if parseErr != nil {
+				return Hash{}, parseErr
+			}
+			return c.Tree, nil
+		default:
+			return Hash{}, fmt.Errorf("object %s is a %s, not a %s", h, objType, typ)
+		}
+	}
+}
+
+// header returns the value of the first "<name> <value>" header line of a
+// commit or tag.
+func header(data []byte, name string) (string, bool) {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(line) == 0 {
+			break
+		}
+		if value, ok := bytes.CutPrefix(line, []byte(name+" ")); ok {
+			return string(value), true
+		}
+	}
+	return "", false
+}
+
+func firstField(s string) string {
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,158 @@
+package gitobject
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Commit is the part of a commit object diffing needs.
+type Commit struct {
+	Tree    Hash
+	Parents []Hash
+}
+
+// Commit reads the commit h, peeling tags.
+func (r *Repo) Commit(h Hash) (Commit, error) {
+	h, err := r.peel(h, "commit")
+	if err != nil {
+		return Commit{}, err
+	}
+	_, data, err := r.ReadObject(h)
+	if err != nil {
+		return Commit{}, err
+	}
+	return parseCommit(data)
+}
+
+func parseCommit(data []byte) (Commit, error) {
+	var c Commit
+	hasTree := false
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(line) == 0 {
+			break // end of headers
+		}
+		name, value, _ := bytes.Cut(line, []byte(" "))
+		switch string(name) {
+		case "tree":
+			h, err := ParseHash(string(value))
+			if err != nil {
+				return Commit{}, err
+			}
+			c.Tree, hasTree = h, true
+		case "parent":
+			h, err := ParseHash(string(value))
+			if err != nil {
+				return Commit{}, err
+			}
+			c.Parents = append(c.Parents, h)
+		}
+	}
+	if !hasTree {
+		return Commit{}, fmt.Errorf("commit has no tree")
+	}
+	return c, nil
+}
+
+// File modes as git records them.
+const (
+	ModeRegular    uint32 = 0o100644
+	ModeExecutable uint32 = 0o100755
+	ModeSymlink    uint32 = 0o120000
+	ModeGitlink    uint32 = 0o160000
+	modeTree       uint32 = 0o040000
+)
+
+// Entry is a file of a tree or the index: its id and mode.
+type Entry struct {
+	Hash Hash
+	Mode uint32
+}
+
+// TreeFiles lists the files under tree-ish h (a tree, or a commit or tag
+// leading to one) by slash-separated path.
+func (r *Repo) TreeFiles(h Hash) (map[string]Entry, error) {
+	tree, err := r.peel(h, "tree")
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]Entry)
+	return files, r.walkTree(tree, "", files)
+}
+
+// TreeFile looks up the file at slash-separated path under tree-ish h,
+// reading only the trees along the path. ok is false when there is no file
+// there.
+func (r *Repo) TreeFile(h Hash, name string) (entry Entry, ok bool, err error) {
+	tree, err := r.peel(h, "tree")
+	if err != nil {
+		return Entry{}, false, err
+	}
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		var found bool
+		var next Entry
+		err = r.readTree(tree, func(entryName string, mode uint32, id Hash) error {
+			if entryName == part {
+				found, next = true, Entry{Hash: id, Mode: mode}
+			}
+			return nil
+		})
+		if err != nil || !found {
+			return Entry{}, false, err
+		}
+		if i == len(parts)-1 {
+			return next, next.Mode != modeTree, nil
+		}
+		if next.Mode != modeTree {
+			return Entry{}, false, nil
+		}
+		tree = next.Hash
+	}
+	return Entry{}, false, nil
+}
+
+// walkTree lists the files of tree h under prefix, recursing into subtrees.
+func (r *Repo) walkTree(h Hash, prefix string, files map[string]Entry) error {
+	return r.readTree(h, func(name string, mode uint32, id Hash) error {
+		name = path.Join(prefix, name)
+		if mode == modeTree {
+			return r.walkTree(id, name, files)
+		}
+		files[name] = Entry{Hash: id, Mode: mode}
+		return nil
+	})
+}
+
+// readTree reads a tree object — entries of "<octal mode> <name>\0<20-byte
+// id>" — calling fn for each entry.
+func (r *Repo) readTree(h Hash, fn func(name string, mode uint32, id Hash) error) error {
+	typ, data, err := r.ReadObject(h)
+	if err != nil {
+		return err
+	}
+	if typ != TypeTree {
+		return fmt.Errorf("object %s is a %s, not a tree", h, typ)
+	}
+	for len(data) > 0 {
+		sp := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if sp < 0 || nul < sp || len(data) < nul+21 {
+			return fmt.Errorf("tree %s is malformed", h)
+		}
+		mode, err := strconv.ParseUint(string(data[:sp]), 8, 32)
+		if err != nil {
+			return fmt.Errorf("tree %s: malformed mode %q", h, data[:sp])
+		}
+		name := string(data[sp+1 : nul])
+		var id Hash
+		copy(id[:], data[nul+1:nul+21])
+		data = data[nul+21:]
+		if err = fn(name, uint32(mode), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
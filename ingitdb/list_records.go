@@ -20,6 +20,7 @@ const listKeySeparator = "\x1f"
 // It handles a top-level YAML sequence, a top-level JSON array, and a JSONL
 // stream (one JSON object per non-empty line). Empty content yields no rows.
 // csv and ingr keep their dedicated parsers and are not handled here.
+// StreamListOfRecords decodes the same formats without collecting the rows.
 func ParseListOfRecordsContent(content []byte, format RecordFormat) ([]map[string]any, error) {
 	switch format {
 	case RecordFormatYAML, RecordFormatYML:
@@ -0,0 +1,40 @@
+package materializer
+
+import (
+	"context"
+
+	ingitdb "github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// ComputedColumnsReader decorates a RecordsReader so that every record it
+// yields carries its computed (formula) columns, evaluated and coerced by
+// ingitdb.EvaluateComputedColumns.
+//
+// A failed evaluation is passed to OnError as a ValidationError and the record
+// is still yielded, with that column nil. Without OnError the first failure
+// stops the read and is returned, so a value is never dropped silently.
+type ComputedColumnsReader struct {
+	Reader  ingitdb.RecordsReader
+	OnError func(ingitdb.ValidationError)
+}
+
+func (r ComputedColumnsReader) ReadRecords(
+	ctx context.Context,
+	dbPath string,
+	col *ingitdb.CollectionDef,
+	yield func(ingitdb.IRecordEntry) error,
+) error {
+	if !ingitdb.HasComputedColumns(col) {
+		return r.Reader.ReadRecords(ctx, dbPath, col, yield)
+	}
+	return r.Reader.ReadRecords(ctx, dbPath, col, func(entry ingitdb.IRecordEntry) error {
+		data, errs := ingitdb.EvaluateComputedColumns(col, entry.GetID(), entry.GetData())
+		for _, validationErr := range errs {
+			if r.OnError == nil {
+				return validationErr
+			}
+			r.OnError(validationErr)
+		}
+		return yield(ingitdb.NewMapRecordEntry(entry.GetID(), data))
+	})
+}
@@ -0,0 +1,86 @@
+package materializer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func computedTestCollection() *ingitdb.CollectionDef {
+	return &ingitdb.CollectionDef{
+		ID: "orders",
+		Columns: map[string]*ingitdb.ColumnDef{
+			"qty":   {Type: ingitdb.ColumnTypeInt},
+			"price": {Type: ingitdb.ColumnTypeInt},
+			"total": {Type: ingitdb.ColumnTypeInt, Formula: "qty * price"},
+		},
+	}
+}
+
+func computedTestRecords() []ingitdb.IRecordEntry {
+	return []ingitdb.IRecordEntry{
+		ingitdb.NewMapRecordEntry("a", map[string]any{"qty": 2, "price": 3}),
+		ingitdb.NewMapRecordEntry("b", map[string]any{"qty": 2, "price": "x"}),
+	}
+}
+
+func TestComputedColumnsReader_OnError(t *testing.T) {
+	t.Parallel()
+
+	var failures []ingitdb.ValidationError
+	reader := ComputedColumnsReader{
+		Reader:  fakeRecordsReader{records: computedTestRecords()},
+		OnError: func(e ingitdb.ValidationError) { failures = append(failures, e) },
+	}
+	totals := map[string]any{}
+	err := reader.ReadRecords(context.Background(), "/db", computedTestCollection(), func(e ingitdb.IRecordEntry) error {
+		totals[e.GetID()] = e.GetData()["total"]
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if totals["a"] != int64(6) || totals["b"] != nil {
+		t.Errorf("totals = %v, want a=6 and b=nil", totals)
+	}
+	if len(failures) != 1 || failures[0].RecordKey != "b" || failures[0].FieldName != "total" {
+		t.Errorf("failures = %+v, want one for b.total", failures)
+	}
+}
+
+func TestComputedColumnsReader_FailsWithoutOnError(t *testing.T) {
+	t.Parallel()
+
+	reader := ComputedColumnsReader{Reader: fakeRecordsReader{records: computedTestRecords()}}
+	err := reader.ReadRecords(context.Background(), "/db", computedTestCollection(), func(ingitdb.IRecordEntry) error {
+		return nil
+	})
+	var validationErr ingitdb.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.RecordKey != "b" {
+		t.Fatalf("expected a ValidationError for record b, got %v", err)
+	}
+}
+
+func TestSimpleViewBuilder_BuildView_ComputesFormulaColumns(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writer := &capturingWriter{}
+	builder := SimpleViewBuilder{
+		RecordsReader: fakeRecordsReader{records: computedTestRecords()},
+		Writer:        writer,
+	}
+	view := &ingitdb.ViewDef{ID: "all", FileName: "all.md", Template: "md-table"}
+	result, err := builder.BuildView(context.Background(), dir, dir, computedTestCollection(), &ingitdb.Definition{}, view)
+	if err != nil {
+		t.Fatalf("BuildView: %v", err)
+	}
+	if len(result.Errors) != 1 {
+		t.Fatalf("expected the failed formula to be reported, got %v", result.Errors)
+	}
+	if len(writer.lastRecords) != 2 || writer.lastRecords[0].GetData()["total"] != int64(6) {
+		t.Errorf("expected the computed total in the view, got %v", writer.lastRecords)
+	}
+}
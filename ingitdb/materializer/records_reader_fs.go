@@ -3,11 +3,13 @@ package materializer
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
 	ingitdb "github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/gittree"
 )
 
 // filepathRel is a seam over filepath.Rel used by recordPatternForKey's key
@@ -16,8 +18,11 @@ import (
 var filepathRel = filepath.Rel
 
 // FileRecordsReader loads records from collection files on disk.
+// Map and list files are decoded as a stream, one record at a time, so a
+// large file is never held in memory whole.
 type FileRecordsReader struct {
 	readFile func(string) ([]byte, error)
+	openFile func(string) (io.ReadCloser, error)
 	statFile func(string) (os.FileInfo, error)
 	glob     func(string) ([]string, error)
 }
@@ -25,18 +30,32 @@ type FileRecordsReader struct {
 func NewFileRecordsReader() FileRecordsReader {
 	return FileRecordsReader{
 		readFile: os.ReadFile,
+		openFile: func(name string) (io.ReadCloser, error) { return os.Open(name) },
 		statFile: os.Stat,
 		glob:     filepath.Glob,
 	}
 }
 
+// NewGitRecordsReader returns a FileRecordsReader that reads records as of
+// tree's ref rather than from the working tree. Collection DirPaths are OS
+// paths under the tree's repository directory, as validator's
+// NewGitCollectionsReader produces them.
+func NewGitRecordsReader(tree *gittree.Tree) FileRecordsReader {
+	paths := tree.Paths()
+	return FileRecordsReader{
+		readFile: paths.ReadFile,
+		openFile: func(name string) (io.ReadCloser, error) { return paths.Open(name) },
+		statFile: paths.Stat,
+		glob:     paths.Glob,
+	}
+}
+
 func (r FileRecordsReader) ReadRecords(
 	ctx context.Context,
 	dbPath string,
 	col *ingitdb.CollectionDef,
 	yield func(ingitdb.IRecordEntry) error,
 ) error {
-	_ = ctx
 	_ = dbPath
 	if col.RecordFile == nil {
 		return fmt.Errorf("collection %q has no record file definition", col.ID)
@@ -52,23 +71,13 @@ func (r FileRecordsReader) ReadRecords(
 			}
 			return fmt.Errorf("failed to stat %s: %w", path, err)
 		}
-		content, err := r.readFile(path)
-		if err != nil {
-			return fmt.Errorf("failed to read records file %s: %w", path, err)
-		}
-		records, err := ingitdb.ParseMapOfRecordsContent(content, col.RecordFile.Format)
-		if err != nil {
-			return fmt.Errorf("failed to parse records file %s: %w", path, err)
-		}
-		for key, data := range records {
-			d := ingitdb.ApplyLocaleToRead(data, col.Columns)
-			d["$ID"] = key
-			entry := ingitdb.NewMapRecordEntry(key, d)
-			if err := yield(entry); err != nil {
-				return err
-			}
-		}
-		return nil
+		return r.streamFile(ctx, path, func(f io.Reader, yield func(ingitdb.IRecordEntry) error) error {
+			return ingitdb.StreamMapOfRecords(ctx, f, col.RecordFile.Format, func(key string, data map[string]any) error {
+				d := ingitdb.ApplyLocaleToRead(data, col.Columns)
+				d["$ID"] = key
+				return yield(ingitdb.NewMapRecordEntry(key, d))
+			})
+		}, yield)
 	case ingitdb.SingleRecord:
 		patternPath, extractKey, err := recordPatternForKey(fileName, filepath.Join(col.DirPath, recordsBase))
 		if err != nil {
@@ -79,6 +88,9 @@ func (r FileRecordsReader) ReadRecords(
 			return fmt.Errorf("failed to glob records: %w", err)
 		}
 		for _, filePath := range matches {
+			if err = ctx.Err(); err != nil {
+				return err
+			}
 			if col.RecordFile.IsExcluded(filepath.Base(filePath)) {
 				continue
 			}
@@ -109,32 +121,52 @@ func (r FileRecordsReader) ReadRecords(
 			}
 			return fmt.Errorf("failed to stat %s: %w", path, err)
 		}
-		content, err := r.readFile(path)
-		if err != nil {
-			return fmt.Errorf("failed to read records file %s: %w", path, err)
-		}
-		rows, err := ingitdb.ParseListOfRecordsContent(content, col.RecordFile.Format)
-		if err != nil {
-			return fmt.Errorf("failed to parse records file %s: %w", path, err)
-		}
-		for _, row := range rows {
-			key, ok := ingitdb.ResolveListRecordKey(row, col)
-			if !ok {
-				return fmt.Errorf("list record in %s has no resolvable key (set primary_key or a $id/id field)", path)
-			}
-			d := ingitdb.ApplyLocaleToRead(row, col.Columns)
-			d["$ID"] = key
-			entry := ingitdb.NewMapRecordEntry(key, d)
-			if err := yield(entry); err != nil {
-				return err
-			}
-		}
-		return nil
+		return r.streamFile(ctx, path, func(f io.Reader, yield func(ingitdb.IRecordEntry) error) error {
+			return ingitdb.StreamListOfRecords(ctx, f, col, func(row map[string]any) error {
+				key, ok := ingitdb.ResolveListRecordKey(row, col)
+				if !ok {
+					return fmt.Errorf("list record in %s has no resolvable key (set primary_key or a $id/id field)", path)
+				}
+				d := ingitdb.ApplyLocaleToRead(row, col.Columns)
+				d["$ID"] = key
+				return yield(ingitdb.NewMapRecordEntry(key, d))
+			})
+		}, yield)
 	default:
 		return fmt.Errorf("record type %q is not supported", col.RecordFile.RecordType)
 	}
 }
 
+// streamFile opens path and runs decode over it. Errors from yield and from ctx
+// are returned unchanged; anything else is reported as a parse failure of path.
+func (r FileRecordsReader) streamFile(
+	ctx context.Context,
+	path string,
+	decode func(f io.Reader, yield func(ingitdb.IRecordEntry) error) error,
+	yield func(ingitdb.IRecordEntry) error,
+) error {
+	f, err := r.openFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read records file %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+	var yieldErr error
+	err = decode(f, func(entry ingitdb.IRecordEntry) error {
+		yieldErr = yield(entry)
+		return yieldErr
+	})
+	switch {
+	case err == nil:
+		return nil
+	case yieldErr != nil:
+		return yieldErr
+	case ctx.Err() != nil:
+		return ctx.Err()
+	default:
+		return fmt.Errorf("failed to parse records file %s: %w", path, err)
+	}
+}
+
 func recordPatternForKey(name, dirPath string) (patternPath string, extractKey func(string) string, err error) {
 	const placeholder = "{key}"
 	if !strings.Contains(name, placeholder) {
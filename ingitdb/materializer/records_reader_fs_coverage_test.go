@@ -49,9 +49,9 @@ func TestFileRecordsReader_ReadRecords_MapOfRecords_ReadError(t *testing.T) {
 		statFile: func(path string) (os.FileInfo, error) {
 			return nil, nil
 		},
-		readFile: func(path string) ([]byte, error) {
+		openFile: openBytes(func(path string) ([]byte, error) {
 			return nil, readErr
-		},
+		}),
 	}
 	col := &ingitdb.CollectionDef{
 		ID:      "test",
@@ -82,9 +82,9 @@ func TestFileRecordsReader_ReadRecords_MapOfRecords_ParseError(t *testing.T) {
 		statFile: func(path string) (os.FileInfo, error) {
 			return nil, nil
 		},
-		readFile: func(path string) ([]byte, error) {
+		openFile: openBytes(func(path string) ([]byte, error) {
 			return []byte("invalid json"), nil
-		},
+		}),
 	}
 	col := &ingitdb.CollectionDef{
 		ID:      "test",
@@ -113,9 +113,9 @@ func TestFileRecordsReader_ReadRecords_MapOfRecords_YieldError(t *testing.T) {
 		statFile: func(path string) (os.FileInfo, error) {
 			return nil, nil
 		},
-		readFile: func(path string) ([]byte, error) {
+		openFile: openBytes(func(path string) ([]byte, error) {
 			return []byte(`{"key1": {"title": "Test"}}`), nil
-		},
+		}),
 	}
 	col := &ingitdb.CollectionDef{
 		ID:      "test",
@@ -284,9 +284,9 @@ func TestFileRecordsReader_ReadRecords_MapOfRecords_Success(t *testing.T) {
 		statFile: func(path string) (os.FileInfo, error) {
 			return nil, nil // file exists
 		},
-		readFile: func(path string) ([]byte, error) {
+		openFile: openBytes(func(path string) ([]byte, error) {
 			return []byte(`{"key1": {"title": "Item 1"}, "key2": {"title": "Item 2"}}`), nil
-		},
+		}),
 	}
 	col := &ingitdb.CollectionDef{
 		ID:      "test",
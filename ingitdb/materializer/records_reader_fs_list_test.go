@@ -37,9 +37,9 @@ func TestReadRecords_List_SuccessInOrder(t *testing.T) {
 	t.Parallel()
 	reader := FileRecordsReader{
 		statFile: func(string) (os.FileInfo, error) { return nil, nil },
-		readFile: func(string) ([]byte, error) {
+		openFile: openBytes(func(string) ([]byte, error) {
 			return []byte(`[{"$id":"a","v":1},{"$id":"b","v":2},{"$id":"c"}]`), nil
-		},
+		}),
 	}
 	ids, err := collectIDs(reader, listCol())
 	if err != nil {
@@ -55,7 +55,7 @@ func TestReadRecords_List_KeylessFails(t *testing.T) {
 	t.Parallel()
 	reader := FileRecordsReader{
 		statFile: func(string) (os.FileInfo, error) { return nil, nil },
-		readFile: func(string) ([]byte, error) { return []byte(`[{"name":"Alex"}]`), nil },
+		openFile: openBytes(func(string) ([]byte, error) { return []byte(`[{"name":"Alex"}]`), nil }),
 	}
 	_, err := collectIDs(reader, listCol())
 	if err == nil || !strings.Contains(err.Error(), "no resolvable key") {
@@ -93,7 +93,7 @@ func TestReadRecords_List_ReadError(t *testing.T) {
 	readErr := errors.New("read failed")
 	reader := FileRecordsReader{
 		statFile: func(string) (os.FileInfo, error) { return nil, nil },
-		readFile: func(string) ([]byte, error) { return nil, readErr },
+		openFile: openBytes(func(string) ([]byte, error) { return nil, readErr }),
 	}
 	_, err := collectIDs(reader, listCol())
 	if !errors.Is(err, readErr) {
@@ -105,7 +105,7 @@ func TestReadRecords_List_ParseError(t *testing.T) {
 	t.Parallel()
 	reader := FileRecordsReader{
 		statFile: func(string) (os.FileInfo, error) { return nil, nil },
-		readFile: func(string) ([]byte, error) { return []byte("[not json"), nil },
+		openFile: openBytes(func(string) ([]byte, error) { return []byte("[not json"), nil }),
 	}
 	_, err := collectIDs(reader, listCol())
 	if err == nil || !strings.Contains(err.Error(), "failed to parse") {
@@ -118,7 +118,7 @@ func TestReadRecords_List_YieldError(t *testing.T) {
 	yieldErr := errors.New("yield boom")
 	reader := FileRecordsReader{
 		statFile: func(string) (os.FileInfo, error) { return nil, nil },
-		readFile: func(string) ([]byte, error) { return []byte(`[{"$id":"a"}]`), nil },
+		openFile: openBytes(func(string) ([]byte, error) { return []byte(`[{"$id":"a"}]`), nil }),
 	}
 	err := reader.ReadRecords(context.Background(), "/tmp", listCol(), func(ingitdb.IRecordEntry) error {
 		return yieldErr
@@ -1,9 +1,12 @@
 package materializer
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/ingitdb/ingitdb-go/ingitdb"
@@ -16,6 +19,9 @@ func TestNewFileRecordsReader(t *testing.T) {
 	if reader.readFile == nil {
 		t.Error("readFile should not be nil")
 	}
+	if reader.openFile == nil {
+		t.Error("openFile should not be nil")
+	}
 	if reader.statFile == nil {
 		t.Error("statFile should not be nil")
 	}
@@ -24,6 +30,17 @@ func TestNewFileRecordsReader(t *testing.T) {
 	}
 }
 
+// openBytes adapts a whole-file read stub to the openFile seam.
+func openBytes(read func(string) ([]byte, error)) func(string) (io.ReadCloser, error) {
+	return func(name string) (io.ReadCloser, error) {
+		content, err := read(name)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+}
+
 func TestRecordPatternForKey(t *testing.T) {
 	t.Parallel()
 
@@ -226,3 +243,84 @@ func TestFileRecordsReader_ReadRecords_SingleRecord_NoPlaceholder(t *testing.T)
 		t.Error("expected error for record file name without {key} placeholder")
 	}
 }
+
+func TestFileRecordsReader_ReadRecords_StreamsListFormats(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		format  ingitdb.RecordFormat
+		content string
+	}{
+		{name: "jsonl", format: ingitdb.RecordFormatJSONL, content: "{\"id\":\"a\"}\n\n{\"id\":\"b\"}\n"},
+		{name: "csv", format: ingitdb.RecordFormatCSV, content: "id,title\na,A\nb,B\n"},
+		{name: "ingr", format: ingitdb.RecordFormatINGR, content: "# INGR.io | test: $ID, title\n\"a\"\n\"A\"\n\"b\"\n\"B\"\n# 2 records\n"},
+		{name: "yaml", format: ingitdb.RecordFormatYAML, content: "# items\n- id: a\n- id: b\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			fileName := "items." + string(tt.format)
+			if err := os.WriteFile(filepath.Join(dir, fileName), []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			col := &ingitdb.CollectionDef{
+				ID:           "items",
+				DirPath:      dir,
+				ColumnsOrder: []string{"id", "title"},
+				RecordFile: &ingitdb.RecordFileDef{
+					Name:       fileName,
+					RecordType: ingitdb.ListOfRecords,
+					Format:     tt.format,
+				},
+			}
+			if tt.format == ingitdb.RecordFormatCSV {
+				col.PrimaryKey = []string{"id"}
+			}
+			var ids []string
+			err := NewFileRecordsReader().ReadRecords(context.Background(), dir, col, func(e ingitdb.IRecordEntry) error {
+				ids = append(ids, e.GetID())
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+				t.Errorf("ids = %v, want [a b]", ids)
+			}
+		})
+	}
+}
+
+func TestFileRecordsReader_ReadRecords_HonorsContext(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "items.jsonl"), []byte("{\"id\":\"a\"}\n{\"id\":\"b\"}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	col := &ingitdb.CollectionDef{
+		ID:      "items",
+		DirPath: dir,
+		RecordFile: &ingitdb.RecordFileDef{
+			Name:       "items.jsonl",
+			RecordType: ingitdb.ListOfRecords,
+			Format:     ingitdb.RecordFormatJSONL,
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var ids []string
+	err := NewFileRecordsReader().ReadRecords(ctx, dir, col, func(e ingitdb.IRecordEntry) error {
+		ids = append(ids, e.GetID())
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(ids) != 1 {
+		t.Errorf("expected the stream to stop after the first record, got %v", ids)
+	}
+}
@@ -0,0 +1,96 @@
+package materializer
+
+import (
+	"context"
+	"maps"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/gittree"
+	"github.com/ingitdb/ingitdb-go/ingitdb/validator"
+)
+
+// A definition and its records read at an older ref reflect that commit: a
+// collection added later is absent, and neither later commits nor uncommitted
+// edits leak into the records.
+func TestGitRecordsReader_ReadsCollectionAtRef(t *testing.T) {
+	t.Parallel()
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		c := exec.Command("git", args...)
+		c.Dir = repo
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test")
+
+	// The database lives below the repository root.
+	dbPath := filepath.Join(repo, "db")
+	mustMkdir(t, filepath.Join(dbPath, ".ingitdb"))
+	mustWrite(t, filepath.Join(dbPath, ".ingitdb", "root-collections.yaml"), "tasks: ./tasks\ncountries: ./countries\n")
+	for _, dir := range []string{"tasks/.collection", "tasks/$records", "countries/.collection", "planets/.collection"} {
+		mustMkdir(t, filepath.Join(dbPath, dir))
+	}
+	mustWrite(t, filepath.Join(dbPath, "tasks", ".collection", "definition.yaml"),
+		"record_file:\n  name: \"{key}.json\"\n  type: \"map[string]any\"\n  format: json\n"+
+			"columns:\n  title:\n    type: string\n")
+	mustWrite(t, filepath.Join(dbPath, "tasks", "$records", "t1.json"), `{"title":"A"}`)
+	mustWrite(t, filepath.Join(dbPath, "countries", ".collection", "definition.yaml"),
+		"record_file:\n  name: countries.yaml\n  type: \"map[$record_id]map[$field_name]any\"\n  format: yaml\n"+
+			"columns:\n  name:\n    type: string\n")
+	mustWrite(t, filepath.Join(dbPath, "countries", "countries.yaml"), "ie:\n  name: Ireland\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "v1")
+	git("tag", "v1")
+
+	mustWrite(t, filepath.Join(dbPath, ".ingitdb", "root-collections.yaml"), "tasks: ./tasks\ncountries: ./countries\nplanets: ./planets\n")
+	mustWrite(t, filepath.Join(dbPath, "planets", ".collection", "definition.yaml"),
+		"record_file:\n  name: planets.yaml\n  type: \"map[$record_id]map[$field_name]any\"\n  format: yaml\n")
+	mustWrite(t, filepath.Join(dbPath, "tasks", "$records", "t2.json"), `{"title":"B"}`)
+	mustWrite(t, filepath.Join(dbPath, "countries", "countries.yaml"), "ie:\n  name: Éire\nfr:\n  name: France\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "v2")
+	mustWrite(t, filepath.Join(dbPath, "tasks", "$records", "t1.json"), `{"title":"uncommitted"}`)
+
+	tree, err := gittree.Open(context.Background(), repo, "v1")
+	if err != nil {
+		t.Fatalf("gittree.Open: %v", err)
+	}
+	defer func() { _ = tree.Close() }()
+
+	def, err := validator.NewGitCollectionsReader(tree).ReadDefinition(dbPath, ingitdb.Validate())
+	if err != nil {
+		t.Fatalf("ReadDefinition: %v", err)
+	}
+	if ids := slices.Sorted(maps.Keys(def.Collections)); !slices.Equal(ids, []string{"countries", "tasks"}) {
+		t.Fatalf("collections at v1 = %v, want countries and tasks", ids)
+	}
+
+	reader := NewGitRecordsReader(tree)
+	read := func(id, field string) map[string]any {
+		t.Helper()
+		got := map[string]any{}
+		err := reader.ReadRecords(context.Background(), dbPath, def.Collections[id], func(entry ingitdb.IRecordEntry) error {
+			got[entry.GetID()] = entry.GetData()[field]
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ReadRecords(%s): %v", id, err)
+		}
+		return got
+	}
+	if got := read("tasks", "title"); len(got) != 1 || got["t1"] != "A" {
+		t.Errorf("tasks at v1 = %v, want only t1 with its committed title", got)
+	}
+	if got := read("countries", "name"); len(got) != 1 || got["ie"] != "Ireland" {
+		t.Errorf("countries at v1 = %v, want only ie: Ireland", got)
+	}
+}
@@ -0,0 +1,71 @@
+package materializer
+
+import (
+	"context"
+	"maps"
+	"path/filepath"
+	"strings"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/datavalidator"
+)
+
+// StaticRecordsReader is a RecordsReader over records already in memory. It
+// yields the same entries for whichever collection it is asked about, so use
+// one per collection. The Scanner uses it to build views from the records it
+// parsed while validating.
+type StaticRecordsReader []ingitdb.IRecordEntry
+
+func (r StaticRecordsReader) ReadRecords(
+	ctx context.Context,
+	_ string,
+	_ *ingitdb.CollectionDef,
+	yield func(ingitdb.IRecordEntry) error,
+) error {
+	for _, entry := range r {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := yield(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EntriesFromParsed turns records parsed by the validator into the entries
+// FileRecordsReader would yield for the same files: single-record keys are
+// taken from the record-file name pattern (so `{key}/record.yaml` layouts get
+// the directory name), locale columns are applied and `$ID` is set.
+func EntriesFromParsed(col *ingitdb.CollectionDef, parsed []datavalidator.ParsedRecord) ([]ingitdb.IRecordEntry, error) {
+	var extractKey func(string) string
+	if col.RecordFile != nil && col.RecordFile.RecordType == ingitdb.SingleRecord {
+		var err error
+		_, extractKey, err = recordPatternForKey(col.RecordFile.Name, filepath.Join(col.DirPath, col.RecordFile.RecordsBasePath()))
+		if err != nil {
+			return nil, err
+		}
+	}
+	entries := make([]ingitdb.IRecordEntry, 0, len(parsed))
+	for _, p := range parsed {
+		key := p.Key
+		if extractKey != nil {
+			key = extractKey(p.FilePath)
+			if strings.HasPrefix(key, ".") {
+				continue // hidden directories like .collection
+			}
+		}
+		d := ingitdb.ApplyLocaleToRead(p.Data, col.Columns)
+		// ApplyLocaleToRead returns data itself when the collection declares
+		// no columns; copy so setting $ID never writes into the parsed map.
+		if len(col.Columns) == 0 {
+			d = maps.Clone(d)
+		}
+		if d == nil {
+			d = make(map[string]any, 1)
+		}
+		d["$ID"] = key
+		entries = append(entries, ingitdb.NewMapRecordEntry(key, d))
+	}
+	return entries, nil
+}
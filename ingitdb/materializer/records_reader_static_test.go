@@ -0,0 +1,80 @@
+package materializer
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/datavalidator"
+)
+
+func TestEntriesFromParsed_SingleRecordKeysFollowPattern(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	col := &ingitdb.CollectionDef{
+		ID:      "people",
+		DirPath: dir,
+		RecordFile: &ingitdb.RecordFileDef{
+			Name: "{key}/record.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord,
+		},
+	}
+	base := filepath.Join(dir, col.RecordFile.RecordsBasePath())
+	data := map[string]any{"name": "Alice"}
+	parsed := []datavalidator.ParsedRecord{
+		{FilePath: filepath.Join(base, "alice", "record.yaml"), Key: "record", Data: data},
+		{FilePath: filepath.Join(base, ".collection", "record.yaml"), Key: "record", Data: map[string]any{}},
+	}
+	entries, err := EntriesFromParsed(col, parsed)
+	if err != nil {
+		t.Fatalf("EntriesFromParsed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].GetID() != "alice" || entries[0].GetData()["$ID"] != "alice" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if _, ok := data["$ID"]; ok {
+		t.Error("EntriesFromParsed must not modify the parsed data")
+	}
+}
+
+func TestEntriesFromParsed_MapRecordsKeepKeys(t *testing.T) {
+	t.Parallel()
+
+	col := &ingitdb.CollectionDef{
+		ID:         "tags",
+		RecordFile: &ingitdb.RecordFileDef{Name: "tags.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords},
+		Columns:    map[string]*ingitdb.ColumnDef{"title": {Type: ingitdb.ColumnTypeString, Locale: "en"}},
+	}
+	parsed := []datavalidator.ParsedRecord{{Key: "go", Data: map[string]any{"titles": map[string]any{"en": "Go"}}}}
+	entries, err := EntriesFromParsed(col, parsed)
+	if err != nil {
+		t.Fatalf("EntriesFromParsed: %v", err)
+	}
+	got := entries[0].GetData()
+	if entries[0].GetID() != "go" || got["title"] != "Go" || got["$ID"] != "go" {
+		t.Errorf("unexpected entry data: %v", got)
+	}
+}
+
+func TestStaticRecordsReader(t *testing.T) {
+	t.Parallel()
+
+	reader := StaticRecordsReader{
+		ingitdb.NewMapRecordEntry("a", map[string]any{}),
+		ingitdb.NewMapRecordEntry("b", map[string]any{}),
+	}
+	var ids []string
+	err := reader.ReadRecords(context.Background(), "", nil, func(e ingitdb.IRecordEntry) error {
+		ids = append(ids, e.GetID())
+		return nil
+	})
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("ReadRecords: ids=%v err=%v", ids, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := reader.ReadRecords(ctx, "", nil, func(ingitdb.IRecordEntry) error { return nil }); err == nil {
+		t.Error("expected cancelled context to stop reading")
+	}
+}
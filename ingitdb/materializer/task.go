@@ -0,0 +1,103 @@
+package materializer
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/progress"
+)
+
+// MaterializeTaskName is the ProgressEvent.TaskName of materialization tasks.
+const MaterializeTaskName = "materialize"
+
+// CollectionMaterializeTask is a progress.Task that builds every view of one
+// collection, one view per item, so SignalSkipItem skips a single view.
+type CollectionMaterializeTask struct {
+	builder  SimpleViewBuilder
+	dbPath   string
+	repoRoot string
+	col      *ingitdb.CollectionDef
+	def      *ingitdb.Definition
+	result   ingitdb.MaterializeResult
+}
+
+// NewCollectionMaterializeTask returns a task building col's views with b.
+func NewCollectionMaterializeTask(b SimpleViewBuilder, dbPath, repoRoot string, col *ingitdb.CollectionDef, def *ingitdb.Definition) *CollectionMaterializeTask {
+	return &CollectionMaterializeTask{builder: b, dbPath: dbPath, repoRoot: repoRoot, col: col, def: def}
+}
+
+func (t *CollectionMaterializeTask) Name() string {
+	return MaterializeTaskName + " " + t.col.ID
+}
+
+// Result returns what the task has materialized so far. Read it only after Run
+// has returned.
+func (t *CollectionMaterializeTask) Result() *ingitdb.MaterializeResult {
+	return &t.result
+}
+
+func (t *CollectionMaterializeTask) Run(ctx context.Context, reporter progress.ProgressReporter, steerer progress.Steerer) error {
+	if t.builder.DefReader == nil {
+		return fmt.Errorf("view definition reader is required")
+	}
+	views, err := t.builder.collectionViews(t.col)
+	if err != nil {
+		return err
+	}
+	return progress.RunItems(ctx, reporter, steerer, MaterializeTaskName, t.col.ID, slices.Sorted(maps.Keys(views)),
+		func(ctx context.Context, viewID string) error {
+			res, err := t.builder.BuildView(ctx, t.dbPath, t.repoRoot, t.col, t.def, views[viewID])
+			if err != nil {
+				return err
+			}
+			mergeMaterializeResult(&t.result, res)
+			// View errors are collected in the result, like BuildViews does;
+			// they are reported here so they show up while the run is going.
+			for _, viewErr := range res.Errors {
+				reporter.Report(ingitdb.ProgressEvent{
+					Kind: ingitdb.ProgressKindError, TaskName: MaterializeTaskName, Scope: t.col.ID, ItemKey: viewID,
+					Message: viewErr.Error(),
+				})
+			}
+			return nil
+		})
+}
+
+func mergeMaterializeResult(dst, src *ingitdb.MaterializeResult) {
+	dst.FilesCreated += src.FilesCreated
+	dst.FilesUpdated += src.FilesUpdated
+	dst.FilesUnchanged += src.FilesUnchanged
+	dst.FilesDeleted += src.FilesDeleted
+	dst.Errors = append(dst.Errors, src.Errors...)
+}
+
+// RunMaterialization builds the views of every root collection of def, one
+// task per collection scheduled on d with the given concurrency
+// (0 = runtime.NumCPU()), and returns the combined result. View errors are
+// collected in the result rather than returned; the returned error is for
+// aborts, cancellation and collections whose views could not be listed.
+func RunMaterialization(
+	ctx context.Context,
+	d progress.Dispatcher,
+	b SimpleViewBuilder,
+	dbPath, repoRoot string,
+	def *ingitdb.Definition,
+	concurrency int,
+) (*ingitdb.MaterializeResult, error) {
+	ids := slices.Sorted(maps.Keys(def.Collections))
+	tasks := make([]*CollectionMaterializeTask, len(ids))
+	scheduled := make([]progress.Task, len(ids))
+	for i, id := range ids {
+		tasks[i] = NewCollectionMaterializeTask(b, dbPath, repoRoot, def.Collections[id], def)
+		scheduled[i] = tasks[i]
+	}
+	err := d.RunParallel(ctx, scheduled, concurrency)
+	result := &ingitdb.MaterializeResult{}
+	for _, task := range tasks {
+		mergeMaterializeResult(result, task.Result())
+	}
+	return result, err
+}
@@ -0,0 +1,104 @@
+package materializer
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/progress"
+)
+
+type skipFirstSteerer struct{ used bool }
+
+func (s *skipFirstSteerer) Steer() progress.Signal {
+	if s.used {
+		return progress.SignalNone
+	}
+	s.used = true
+	return progress.SignalSkipItem
+}
+
+func taskTestBuilder(writer ViewWriter) SimpleViewBuilder {
+	return SimpleViewBuilder{
+		DefReader: fakeViewDefReader{},
+		RecordsReader: fakeRecordsReader{records: []ingitdb.IRecordEntry{
+			ingitdb.NewMapRecordEntry("1", map[string]any{"title": "A"}),
+		}},
+		Writer: writer,
+	}
+}
+
+func taskTestDefinition(dir string) *ingitdb.Definition {
+	return &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"items": {
+			ID:      "items",
+			DirPath: filepath.Join(dir, "items"),
+			Views: map[string]*ingitdb.ViewDef{
+				"a": {ID: "a", Columns: []string{"title"}},
+				"b": {ID: "b", Columns: []string{"title"}},
+			},
+		},
+	}}
+}
+
+func TestRunMaterialization(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writer := &allCallsCapturingWriter{}
+	result, err := RunMaterialization(context.Background(), progress.NewDispatcher(nil, nil), taskTestBuilder(writer), dir, dir, taskTestDefinition(dir), 0)
+	if err != nil {
+		t.Fatalf("RunMaterialization: %v", err)
+	}
+	if result.FilesCreated != 2 || len(writer.calls) != 2 {
+		t.Errorf("expected 2 views written, got created=%d calls=%d", result.FilesCreated, len(writer.calls))
+	}
+}
+
+func TestCollectionMaterializeTask_SkipView(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writer := &allCallsCapturingWriter{}
+	def := taskTestDefinition(dir)
+	task := NewCollectionMaterializeTask(taskTestBuilder(writer), dir, dir, def.Collections["items"], def)
+	var events []ingitdb.ProgressEvent
+	reporter := progress.ReporterFunc(func(e ingitdb.ProgressEvent) { events = append(events, e) })
+	if err := task.Run(context.Background(), reporter, &skipFirstSteerer{}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if task.Result().FilesCreated != 1 || len(writer.calls) != 1 {
+		t.Fatalf("expected only view b to be written, got %d calls", len(writer.calls))
+	}
+	if events[1].Kind != ingitdb.ProgressKindSkipped || events[1].ItemKey != "a" {
+		t.Errorf("expected view a to be skipped, got %+v", events[1])
+	}
+}
+
+type failingViewWriter struct{}
+
+func (failingViewWriter) WriteView(context.Context, *ingitdb.CollectionDef, *ingitdb.ViewDef, []ingitdb.IRecordEntry, string) (WriteOutcome, error) {
+	return WriteOutcomeUnchanged, errors.New("disk full")
+}
+
+func TestCollectionMaterializeTask_ViewErrorsInResult(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	def := taskTestDefinition(dir)
+	task := NewCollectionMaterializeTask(taskTestBuilder(failingViewWriter{}), dir, dir, def.Collections["items"], def)
+	var errEvents int
+	reporter := progress.ReporterFunc(func(e ingitdb.ProgressEvent) {
+		if e.Kind == ingitdb.ProgressKindError && e.Message == "disk full" {
+			errEvents++
+		}
+	})
+	if err := task.Run(context.Background(), reporter, &skipFirstSteerer{used: true}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(task.Result().Errors) != 2 || errEvents != 2 {
+		t.Errorf("expected 2 view errors in result and events, got %d and %d", len(task.Result().Errors), errEvents)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 
@@ -77,35 +78,32 @@ func (b SimpleViewBuilder) BuildViews(
 	if b.Writer == nil {
 		return nil, fmt.Errorf("view writer is required")
 	}
-	// Use pre-loaded views from the collection definition when available (both
-	// layouts).  Fall back to reading from disk for callers that construct a
-	// CollectionDef without going through ReadDefinition (e.g. GitHub path).
-	var views map[string]*ingitdb.ViewDef
-	if col.Views != nil {
-		views = col.Views
-	} else {
-		var err error
-		views, err = b.DefReader.ReadViewDefs(col.DirPath)
-		if err != nil {
-			return nil, err
-		}
-		// Inject the inline default_view when it was not already injected.
-		if col.DefaultView != nil {
-			if _, exists := views[ingitdb.DefaultViewID]; !exists {
-				dv := *col.DefaultView
-				dv.ID = ingitdb.DefaultViewID
-				dv.IsDefault = true
-				views[ingitdb.DefaultViewID] = &dv
-			}
-		}
+	views, err := b.collectionViews(col)
+	if err != nil {
+		return nil, err
 	}
 	fs := b.fsOpsOrDefault()
 	result := &ingitdb.MaterializeResult{}
+	computeErrsReported := false
 	for _, view := range views {
-		records, err := readAllRecords(ctx, b.RecordsReader, dbPath, col)
+		records, computeErrs, err := readAllRecords(ctx, b.RecordsReader, dbPath, col)
 		if err != nil {
 			return nil, err
 		}
+		if !computeErrsReported {
+			// Every view re-reads the same records; report their formula
+			// failures once per collection, not once per view.
+			result.Errors = append(result.Errors, computeErrs...)
+			computeErrsReported = true
+		}
+		// `where` narrows the record set before anything else looks at it, so
+		// order_by, top, parameterized partitions and FK groups all see only
+		// the matching records.
+		records, err = filterRecordsByWhere(col, view, records)
+		if err != nil {
+			result.Errors = append(result.Errors, err)
+			continue
+		}
 
 		if view.IsDefault {
 			// Handle default view export
@@ -157,6 +155,30 @@ func (b SimpleViewBuilder) BuildViews(
 	return result, nil
 }
 
+// collectionViews returns the views to materialize for col. It uses the views
+// pre-loaded on the collection definition when available (both layouts), and
+// falls back to reading from disk for callers that construct a CollectionDef
+// without going through ReadDefinition (e.g. GitHub path).
+func (b SimpleViewBuilder) collectionViews(col *ingitdb.CollectionDef) (map[string]*ingitdb.ViewDef, error) {
+	if col.Views != nil {
+		return col.Views, nil
+	}
+	views, err := b.DefReader.ReadViewDefs(col.DirPath)
+	if err != nil {
+		return nil, err
+	}
+	// Inject the inline default_view when it was not already injected.
+	if col.DefaultView != nil {
+		if _, exists := views[ingitdb.DefaultViewID]; !exists {
+			dv := *col.DefaultView
+			dv.ID = ingitdb.DefaultViewID
+			dv.IsDefault = true
+			views[ingitdb.DefaultViewID] = &dv
+		}
+	}
+	return views, nil
+}
+
 func (b SimpleViewBuilder) BuildView(
 	ctx context.Context,
 	dbPath string,
@@ -175,10 +197,16 @@ func (b SimpleViewBuilder) BuildView(
 
 	result := &ingitdb.MaterializeResult{}
 
-	records, err := readAllRecords(ctx, b.RecordsReader, dbPath, col)
+	records, computeErrs, err := readAllRecords(ctx, b.RecordsReader, dbPath, col)
 	if err != nil {
 		return nil, err
 	}
+	result.Errors = append(result.Errors, computeErrs...)
+	records, err = filterRecordsByWhere(col, view, records)
+	if err != nil {
+		result.Errors = append(result.Errors, err)
+		return result, nil
+	}
 
 	if view.IsDefault {
 		fs := b.fsOpsOrDefault()
@@ -231,21 +259,52 @@ func (b SimpleViewBuilder) BuildView(
 	return result, nil
 }
 
+// readAllRecords reads a collection's records with their computed columns
+// evaluated (ComputedColumnsReader). Formula failures do not stop the read:
+// they are returned as computeErrs for the caller to report, and the affected
+// column is left empty.
 func readAllRecords(
 	ctx context.Context,
 	reader ingitdb.RecordsReader,
 	dbPath string,
 	col *ingitdb.CollectionDef,
-) ([]ingitdb.IRecordEntry, error) {
-	var records []ingitdb.IRecordEntry
-	err := reader.ReadRecords(ctx, dbPath, col, func(entry ingitdb.IRecordEntry) error {
+) (records []ingitdb.IRecordEntry, computeErrs []error, err error) {
+	computed := ComputedColumnsReader{
+		Reader: reader,
+		OnError: func(validationErr ingitdb.ValidationError) {
+			computeErrs = append(computeErrs, validationErr)
+		},
+	}
+	err = computed.ReadRecords(ctx, dbPath, col, func(entry ingitdb.IRecordEntry) error {
 		records = append(records, entry)
 		return nil
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	return records, computeErrs, nil
+}
+
+// filterRecordsByWhere keeps only the records for which the view's `where`
+// expression evaluates to True (ingitdb.MatchesWhere). A view without `where`
+// gets records back unchanged. An evaluation failure on any record fails the
+// whole view rather than dropping that record: a view that silently omits
+// records it could not judge is worse than a view that is not written.
+func filterRecordsByWhere(col *ingitdb.CollectionDef, view *ingitdb.ViewDef, records []ingitdb.IRecordEntry) ([]ingitdb.IRecordEntry, error) {
+	if view.Where == "" {
+		return records, nil
+	}
+	filtered := make([]ingitdb.IRecordEntry, 0, len(records))
+	for _, record := range records {
+		matched, err := ingitdb.MatchesWhere(view.Where, col.Columns, record.GetData())
+		if err != nil {
+			return nil, fmt.Errorf("view %s/%s: record %q: %w", col.ID, view.ID, record.GetID(), err)
+		}
+		if matched {
+			filtered = append(filtered, record)
+		}
 	}
-	return records, nil
+	return filtered, nil
 }
 
 func filterColumns(records []ingitdb.IRecordEntry, cols []string) []ingitdb.IRecordEntry {
@@ -373,8 +432,9 @@ func buildDefaultView(dbPath string, repoRoot string, col *ingitdb.CollectionDef
 }
 
 // buildFKViews generates one filtered output file per unique FK value for every FK column
-// in the collection. It is called immediately after buildDefaultView when the view is the
-// default view and the collection has at least one FK column.
+// and composite foreign key in the collection. It is called immediately after
+// buildDefaultView when the view is the default view and the collection has at least one
+// FK column.
 func buildFKViews(
 	dbPath string, repoRoot string,
 	col *ingitdb.CollectionDef, def *ingitdb.Definition,
@@ -419,6 +479,18 @@ func buildFKViews(
 		exportOpts = append(exportOpts, WithRecordsDelimiter())
 	}
 
+	// fkView is one foreign key's share of the output: the records grouped by
+	// the key they reference, written under
+	// $fk/<collection>/<name>/<key path>.<ext> in the referenced collection.
+	type fkView struct {
+		name    string   // FK column, or composite columns joined with "+"
+		target  string   // resolved referenced collection
+		exclude []string // columns left out of the export
+		groups  map[string][]ingitdb.IRecordEntry
+		paths   map[string][]string // group key -> path segments under name
+	}
+	var fkViews []*fkView
+
 	for colName, colDef := range columns {
 		if colDef.ForeignKey == "" {
 			continue
@@ -434,48 +506,95 @@ func buildFKViews(
 			errs = append(errs, fmt.Errorf("buildFKViews: FK for column %q resolves to no collection (foreign_key %q, from %q)", colName, colDef.ForeignKey, col.ID))
 			continue
 		}
-		referredColDef := def.Collections[resolvedFK]
-		referredRelColPath, _ := filepath.Rel(outputRoot, referredColDef.DirPath)
+		fv := &fkView{name: colName, target: resolvedFK, groups: map[string][]ingitdb.IRecordEntry{}, paths: map[string][]string{}}
+		// A scalar FK column is excluded from the export — its value is
+		// constant for every record in the file (it equals the key), so
+		// including it wastes space and bandwidth. A list column is kept: a
+		// record is filed under each key it lists, and the other keys matter.
+		if !strings.HasPrefix(string(colDef.Type), "[]") {
+			fv.exclude = []string{colName}
+		}
 
-		// Exclude the FK column itself — its value is constant for every record in
-		// the file (it equals fkValue), so including it wastes space and bandwidth.
-		fkExportColumns := make([]string, 0, len(exportColumns))
-		for _, c := range exportColumns {
-			if c != colName {
-				fkExportColumns = append(fkExportColumns, c)
+		// Group records by FK value; skip nil/empty. A list column files the
+		// record under every distinct element.
+		for _, rec := range records {
+			d := rec.GetData()
+			if d == nil {
+				continue
+			}
+			seen := make(map[string]bool)
+			for _, fkVal := range ingitdb.ForeignKeyValues(d[colName]) {
+				if seen[fkVal] {
+					continue
+				}
+				seen[fkVal] = true
+				fv.groups[fkVal] = append(fv.groups[fkVal], rec)
+				fv.paths[fkVal] = []string{fkVal}
 			}
 		}
+		fkViews = append(fkViews, fv)
+	}
 
-		// Group records by FK value; skip nil/empty.
-		groups := make(map[string][]ingitdb.IRecordEntry)
+	// Composite foreign keys are grouped by their referenced key, one path
+	// segment per column: $fk/<collection>/country+code/ie/dub.<ext>.
+	for i, fk := range col.ForeignKeys {
+		resolvedFK, ok := ingitdb.ResolveForeignKey(col.ID, fk.References, def.Collections)
+		if !ok {
+			errs = append(errs, fmt.Errorf("buildFKViews: foreign_keys[%d] resolves to no collection (references %q, from %q)", i, fk.References, col.ID))
+			continue
+		}
+		fv := &fkView{
+			name:    strings.Join(fk.Columns, "+"),
+			target:  resolvedFK,
+			exclude: fk.Columns,
+			groups:  map[string][]ingitdb.IRecordEntry{},
+			paths:   map[string][]string{},
+		}
 		for _, rec := range records {
 			d := rec.GetData()
 			if d == nil {
 				continue
 			}
-			raw := d[colName]
-			if raw == nil {
+			key, ok := ingitdb.CompositeForeignKeyValue(d, fk.Columns)
+			if !ok {
 				continue
 			}
-			fkVal := fmt.Sprintf("%v", raw)
-			if fkVal == "" {
-				continue
+			if _, known := fv.paths[key]; !known {
+				segments := make([]string, len(fk.Columns))
+				for j, name := range fk.Columns {
+					segments[j] = fmt.Sprintf("%v", d[name])
+				}
+				fv.paths[key] = segments
+			}
+			fv.groups[key] = append(fv.groups[key], rec)
+		}
+		fkViews = append(fkViews, fv)
+	}
+
+	for _, fv := range fkViews {
+		referredColDef := def.Collections[fv.target]
+		referredRelColPath, _ := filepath.Rel(outputRoot, referredColDef.DirPath)
+
+		fkExportColumns := make([]string, 0, len(exportColumns))
+		for _, c := range exportColumns {
+			if !slices.Contains(fv.exclude, c) {
+				fkExportColumns = append(fkExportColumns, c)
 			}
-			groups[fkVal] = append(groups[fkVal], rec)
 		}
 
-		for fkValue, fkRecords := range groups {
-			viewName := resolvedFK + "/$fk/" + col.ID + "/" + colName + "/" + fkValue
+		for key, fkRecords := range fv.groups {
+			fkValue := strings.Join(fv.paths[key], "/")
+			viewName := fv.target + "/$fk/" + col.ID + "/" + fv.name + "/" + fkValue
 			content, err := formatExportBatch(format, viewName, fkExportColumns, fkRecords, exportOpts...)
 			if err != nil {
-				errs = append(errs, fmt.Errorf("buildFKViews %s/%s: format: %w", colName, fkValue, err))
+				errs = append(errs, fmt.Errorf("buildFKViews %s/%s: format: %w", fv.name, fkValue, err))
 				continue
 			}
 
-			outPath := filepath.Join(outputRoot, ingitdb.IngitdbDir, referredRelColPath, "$fk", col.ID, colName, fkValue+"."+ext)
+			outPath := filepath.Join(outputRoot, ingitdb.IngitdbDir, referredRelColPath, "$fk", col.ID, fv.name, filepath.FromSlash(fkValue)+"."+ext)
 
 			if err := fs.mkdirAll(filepath.Dir(outPath), 0o755); err != nil {
-				errs = append(errs, fmt.Errorf("buildFKViews %s/%s: mkdir: %w", colName, fkValue, err))
+				errs = append(errs, fmt.Errorf("buildFKViews %s/%s: mkdir: %w", fv.name, fkValue, err))
 				continue
 			}
 
@@ -490,7 +609,7 @@ func buildFKViews(
 			}
 
 			if err := fs.writeFile(outPath, content, 0o644); err != nil {
-				errs = append(errs, fmt.Errorf("buildFKViews %s/%s: write: %w", colName, fkValue, err))
+				errs = append(errs, fmt.Errorf("buildFKViews %s/%s: write: %w", fv.name, fkValue, err))
 				continue
 			}
 			if readErr == nil {
@@ -247,7 +247,7 @@ func TestReadAllRecords_YieldError(t *testing.T) {
 	reader := errorRecordsReader{err: yieldErr}
 	col := &ingitdb.CollectionDef{}
 
-	_, err := readAllRecords(context.Background(), reader, "/db", col)
+	_, _, err := readAllRecords(context.Background(), reader, "/db", col)
 	if err == nil {
 		t.Fatal("expected error from yield")
 	}
@@ -1045,3 +1045,101 @@ func TestBuildFKViews_EmptyFormat(t *testing.T) {
 		t.Errorf("expected FK view file at %s, got error: %v", gbPath, err)
 	}
 }
+
+// TestBuildFKViews_ListColumnFilesRecordUnderEachElement verifies that a list
+// FK column files a record under every distinct key it lists, and keeps the
+// column in the export since its other elements are not implied by the file.
+func TestBuildFKViews_ListColumnFilesRecordUnderEachElement(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	col := &ingitdb.CollectionDef{
+		ID:           "posts",
+		DirPath:      filepath.Join(tmpDir, "posts"),
+		ColumnsOrder: []string{"$ID", "title", "tags"},
+		Columns: map[string]*ingitdb.ColumnDef{
+			"title": {Type: ingitdb.ColumnTypeString},
+			"tags":  {Type: "[]string", ForeignKey: "tags"},
+		},
+	}
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"tags": {ID: "tags", DirPath: filepath.Join(tmpDir, "tags")},
+	}}
+	records := []ingitdb.IRecordEntry{
+		ingitdb.NewMapRecordEntry("p1", map[string]any{"$ID": "p1", "title": "One", "tags": []any{"go", "db", "go"}}),
+		ingitdb.NewMapRecordEntry("p2", map[string]any{"$ID": "p2", "title": "Two", "tags": []any{"db"}}),
+	}
+
+	created, _, _, errs := buildFKViews(tmpDir, "", col, def, makeDefaultView("json"), records, nil, defaultFSops())
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if created != 2 {
+		t.Errorf("expected 2 files created, got %d", created)
+	}
+	goContent, err := os.ReadFile(fkFilePath(tmpDir, "tags", "posts", "tags", "go", "json"))
+	if err != nil {
+		t.Fatalf("go.json not found: %v", err)
+	}
+	if !strings.Contains(string(goContent), "p1") || strings.Contains(string(goContent), "p2") {
+		t.Errorf("go.json should contain only p1, got: %s", goContent)
+	}
+	if strings.Count(string(goContent), `"One"`) != 1 {
+		t.Errorf("a repeated element must not duplicate the record, got: %s", goContent)
+	}
+	if !strings.Contains(string(goContent), `"tags"`) {
+		t.Errorf("a list FK column must stay in the export, got: %s", goContent)
+	}
+	dbContent, err := os.ReadFile(fkFilePath(tmpDir, "tags", "posts", "tags", "db", "json"))
+	if err != nil {
+		t.Fatalf("db.json not found: %v", err)
+	}
+	if !strings.Contains(string(dbContent), "p1") || !strings.Contains(string(dbContent), "p2") {
+		t.Errorf("db.json should contain p1 and p2, got: %s", dbContent)
+	}
+}
+
+// TestBuildFKViews_CompositeForeignKey verifies that a composite foreign key
+// groups records by the referenced key, nesting one directory per column.
+func TestBuildFKViews_CompositeForeignKey(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	col := &ingitdb.CollectionDef{
+		ID:           "offices",
+		DirPath:      filepath.Join(tmpDir, "offices"),
+		ColumnsOrder: []string{"$ID", "name", "country", "city"},
+		Columns: map[string]*ingitdb.ColumnDef{
+			"name":    {Type: ingitdb.ColumnTypeString},
+			"country": {Type: ingitdb.ColumnTypeString},
+			"city":    {Type: ingitdb.ColumnTypeString},
+		},
+		ForeignKeys: []ingitdb.ForeignKeyDef{{Columns: []string{"country", "city"}, References: "cities"}},
+	}
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"cities": {ID: "cities", DirPath: filepath.Join(tmpDir, "cities")},
+	}}
+	records := []ingitdb.IRecordEntry{
+		ingitdb.NewMapRecordEntry("o1", map[string]any{"$ID": "o1", "name": "HQ", "country": "ie", "city": "dub"}),
+		ingitdb.NewMapRecordEntry("o2", map[string]any{"$ID": "o2", "name": "Lab", "country": "ie", "city": "cork"}),
+		ingitdb.NewMapRecordEntry("o3", map[string]any{"$ID": "o3", "name": "Remote", "country": "ie"}),
+	}
+
+	created, _, _, errs := buildFKViews(tmpDir, "", col, def, makeDefaultView("json"), records, nil, defaultFSops())
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if created != 2 {
+		t.Errorf("expected 2 files created (o3 references nothing), got %d", created)
+	}
+	content, err := os.ReadFile(fkFilePath(tmpDir, "cities", "offices", "country+city", filepath.Join("ie", "dub"), "json"))
+	if err != nil {
+		t.Fatalf("ie/dub.json not found: %v", err)
+	}
+	if !strings.Contains(string(content), "HQ") || strings.Contains(string(content), "Lab") {
+		t.Errorf("ie/dub.json should contain only o1, got: %s", content)
+	}
+	if strings.Contains(string(content), `"country"`) || strings.Contains(string(content), `"city"`) {
+		t.Errorf("composite FK columns must be excluded from the export, got: %s", content)
+	}
+}
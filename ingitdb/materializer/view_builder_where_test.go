@@ -0,0 +1,122 @@
+package materializer
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func whereTestCollection(dir string) *ingitdb.CollectionDef {
+	return &ingitdb.CollectionDef{
+		ID:      "countries",
+		DirPath: filepath.Join(dir, "countries"),
+		Columns: map[string]*ingitdb.ColumnDef{
+			"title":      {Type: ingitdb.ColumnTypeString},
+			"continent":  {Type: ingitdb.ColumnTypeString},
+			"population": {Type: ingitdb.ColumnTypeInt},
+		},
+	}
+}
+
+func whereTestRecords() []ingitdb.IRecordEntry {
+	return []ingitdb.IRecordEntry{
+		ingitdb.NewMapRecordEntry("fr", map[string]any{"title": "France", "continent": "europe", "population": 68}),
+		ingitdb.NewMapRecordEntry("de", map[string]any{"title": "Germany", "continent": "europe", "population": 84}),
+		ingitdb.NewMapRecordEntry("mt", map[string]any{"title": "Malta", "continent": "europe", "population": 1}),
+		ingitdb.NewMapRecordEntry("jp", map[string]any{"title": "Japan", "continent": "asia", "population": 124}),
+	}
+}
+
+// where is applied before order_by and top: the top-N is taken from the
+// matching records, not from the whole collection.
+func TestSimpleViewBuilder_BuildView_WhereAppliedBeforeTop(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	view := &ingitdb.ViewDef{
+		ID:       "big_europe",
+		Where:    `continent == "europe" and population > 10`,
+		OrderBy:  "population desc",
+		Top:      1,
+		FileName: "big_europe.md",
+		Template: "md-table",
+	}
+	writer := &capturingWriter{}
+	builder := SimpleViewBuilder{
+		RecordsReader: fakeRecordsReader{records: whereTestRecords()},
+		Writer:        writer,
+	}
+	result, err := builder.BuildView(context.Background(), dir, dir, whereTestCollection(dir), &ingitdb.Definition{}, view)
+	if err != nil {
+		t.Fatalf("BuildView: %v", err)
+	}
+	if len(result.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+	if len(writer.lastRecords) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(writer.lastRecords))
+	}
+	if got := writer.lastRecords[0].GetID(); got != "de" {
+		t.Errorf("expected top matching record de, got %s", got)
+	}
+}
+
+func TestSimpleViewBuilder_BuildViews_WhereFiltersParameterizedView(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	view := &ingitdb.ViewDef{
+		ID:    "by_continent_{continent}",
+		Where: `population >= 68`,
+	}
+	writer := &allCallsCapturingWriter{}
+	builder := SimpleViewBuilder{
+		DefReader:     fakeViewDefReader{views: map[string]*ingitdb.ViewDef{view.ID: view}},
+		RecordsReader: fakeRecordsReader{records: whereTestRecords()},
+		Writer:        writer,
+	}
+	result, err := builder.BuildViews(context.Background(), dir, dir, whereTestCollection(dir), &ingitdb.Definition{})
+	if err != nil {
+		t.Fatalf("BuildViews: %v", err)
+	}
+	if len(result.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+	total := 0
+	for _, call := range writer.calls {
+		for _, rec := range call.records {
+			if rec.GetID() == "mt" {
+				t.Errorf("record mt does not match where but was written to %s", call.outPath)
+			}
+		}
+		total += len(call.records)
+	}
+	if total != 3 {
+		t.Errorf("expected 3 matching records across partitions, got %d", total)
+	}
+}
+
+func TestSimpleViewBuilder_BuildView_WhereEvaluationErrorFailsView(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	view := &ingitdb.ViewDef{ID: "broken", Where: `title`, Template: "md-table"}
+	writer := &capturingWriter{}
+	builder := SimpleViewBuilder{
+		RecordsReader: fakeRecordsReader{records: whereTestRecords()},
+		Writer:        writer,
+	}
+	result, err := builder.BuildView(context.Background(), dir, dir, whereTestCollection(dir), &ingitdb.Definition{}, view)
+	if err != nil {
+		t.Fatalf("BuildView: %v", err)
+	}
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Error(), "True or False") {
+		t.Fatalf("expected one where evaluation error, got %v", result.Errors)
+	}
+	if writer.called != 0 {
+		t.Errorf("view with a failing where must not be written")
+	}
+}
@@ -277,31 +277,13 @@ func resolveINGRColumns(data map[string]map[string]any, columnsOrder []string) [
 // parseINGRAsMap decodes an INGR file into map[string]map[string]any keyed
 // by the reserved `$ID` column.
 func parseINGRAsMap(content []byte) (map[string]map[string]any, error) {
-	var rows []map[string]any
-	if err := ingr.Unmarshal(content, &rows); err != nil {
-		return nil, fmt.Errorf("failed to parse INGR records: %w", err)
-	}
-	result := make(map[string]map[string]any, len(rows))
-	for i, row := range rows {
-		raw, ok := row["$ID"]
-		if !ok {
-			return nil, fmt.Errorf("INGR record at index %d is missing required $ID column", i)
-		}
-		id, ok := raw.(string)
-		if !ok {
-			return nil, fmt.Errorf("INGR record at index %d has non-string $ID value (%T)", i, raw)
-		}
-		if _, dup := result[id]; dup {
-			return nil, fmt.Errorf("INGR record has duplicate $ID %q", id)
-		}
-		fields := make(map[string]any, len(row)-1)
-		for k, v := range row {
-			if k == "$ID" {
-				continue
-			}
-			fields[k] = v
-		}
-		result[id] = fields
+	result := make(map[string]map[string]any)
+	err := streamINGRRows(bytes.NewReader(content), func(id string, row map[string]any) error {
+		result[id] = ingrRecordFields(row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return result, nil
 }
@@ -0,0 +1,129 @@
+package progress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// ErrAborted is returned by a Dispatcher, and by RunItems, when the user
+// aborted the operation with SignalAbort.
+var ErrAborted = errors.New("aborted by user")
+
+// ReporterFunc adapts a function, such as a tui.Screen's Update method, to
+// ProgressReporter.
+type ReporterFunc func(event ingitdb.ProgressEvent)
+
+func (f ReporterFunc) Report(event ingitdb.ProgressEvent) { f(event) }
+
+type nopReporter struct{}
+
+func (nopReporter) Report(ingitdb.ProgressEvent) {}
+
+type nopSteerer struct{}
+
+func (nopSteerer) Steer() Signal { return SignalNone }
+
+// NewDispatcher returns a Dispatcher that hands every task the given reporter
+// and steerer. A nil reporter discards events; a nil steerer never signals.
+func NewDispatcher(reporter ProgressReporter, steerer Steerer) Dispatcher {
+	if reporter == nil {
+		reporter = nopReporter{}
+	}
+	if steerer == nil {
+		steerer = nopSteerer{}
+	}
+	return &dispatcher{reporter: reporter, steerer: steerer}
+}
+
+type dispatcher struct {
+	reporter ProgressReporter
+	steerer  Steerer
+}
+
+// RunSequential runs tasks one after another, in order.
+func (d *dispatcher) RunSequential(ctx context.Context, tasks []Task) error {
+	return d.RunParallel(ctx, tasks, 1)
+}
+
+// RunParallel runs at most concurrency tasks at a time; concurrency <= 0 uses
+// runtime.NumCPU(). Tasks are started in slice order.
+//
+// A failing task does not stop the others: their errors are joined, each
+// prefixed with the task name. A SignalAbort read by any task cancels the
+// context shared by all of them, no further tasks are started, and
+// RunParallel returns ErrAborted.
+func (d *dispatcher) RunParallel(ctx context.Context, tasks []Task, concurrency int) error {
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	steerer := &abortingSteerer{inner: d.steerer, cancel: cancel}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	sem := make(chan struct{}, concurrency)
+launch:
+	for _, task := range tasks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break launch
+		}
+		if ctx.Err() != nil {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(task Task) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := task.Run(ctx, d.reporter, steerer); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", task.Name(), err))
+				mu.Unlock()
+			}
+		}(task)
+	}
+	wg.Wait()
+
+	if steerer.aborted.Load() {
+		return ErrAborted
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// abortingSteerer passes signals through from the user's Steerer and cancels
+// the dispatch context the first time any task reads SignalAbort, so tasks
+// that are blocked on ctx rather than polling the steerer stop too.
+type abortingSteerer struct {
+	inner   Steerer
+	cancel  context.CancelFunc
+	aborted atomic.Bool
+}
+
+func (s *abortingSteerer) Steer() Signal {
+	if s.aborted.Load() {
+		return SignalAbort
+	}
+	sig := s.inner.Steer()
+	if sig == SignalAbort {
+		s.aborted.Store(true)
+		s.cancel()
+	}
+	return sig
+}
@@ -0,0 +1,192 @@
+package progress_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/progress"
+)
+
+// funcTask is a Task backed by a function.
+type funcTask struct {
+	name string
+	run  func(ctx context.Context, reporter progress.ProgressReporter, steerer progress.Steerer) error
+}
+
+func (f funcTask) Name() string { return f.name }
+
+func (f funcTask) Run(ctx context.Context, reporter progress.ProgressReporter, steerer progress.Steerer) error {
+	return f.run(ctx, reporter, steerer)
+}
+
+// syncReporter is a goroutine-safe event recorder.
+type syncReporter struct {
+	mu     sync.Mutex
+	events []ingitdb.ProgressEvent
+}
+
+func (r *syncReporter) Report(e ingitdb.ProgressEvent) {
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+}
+
+func (r *syncReporter) kinds() []ingitdb.ProgressKind {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kinds := make([]ingitdb.ProgressKind, len(r.events))
+	for i, e := range r.events {
+		kinds[i] = e.Kind
+	}
+	return kinds
+}
+
+// queueSteerer returns queued signals in order, then SignalNone.
+type queueSteerer struct {
+	mu      sync.Mutex
+	signals []progress.Signal
+}
+
+func (s *queueSteerer) Steer() progress.Signal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.signals) == 0 {
+		return progress.SignalNone
+	}
+	sig := s.signals[0]
+	s.signals = s.signals[1:]
+	return sig
+}
+
+func TestDispatcher_RunSequential_RunsInOrder(t *testing.T) {
+	t.Parallel()
+
+	var order []string
+	var tasks []progress.Task
+	for _, name := range []string{"a", "b", "c"} {
+		tasks = append(tasks, funcTask{name: name, run: func(context.Context, progress.ProgressReporter, progress.Steerer) error {
+			order = append(order, name)
+			return nil
+		}})
+	}
+	if err := progress.NewDispatcher(nil, nil).RunSequential(context.Background(), tasks); err != nil {
+		t.Fatalf("RunSequential: %v", err)
+	}
+	if strings.Join(order, ",") != "a,b,c" {
+		t.Errorf("order = %v, want a,b,c", order)
+	}
+}
+
+func TestDispatcher_RunParallel_BoundsConcurrency(t *testing.T) {
+	t.Parallel()
+
+	var running, peak atomic.Int32
+	var tasks []progress.Task
+	for range 8 {
+		tasks = append(tasks, funcTask{name: "t", run: func(context.Context, progress.ProgressReporter, progress.Steerer) error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			return nil
+		}})
+	}
+	if err := progress.NewDispatcher(nil, nil).RunParallel(context.Background(), tasks, 3); err != nil {
+		t.Fatalf("RunParallel: %v", err)
+	}
+	if got := peak.Load(); got > 3 || got < 2 {
+		t.Errorf("peak concurrency = %d, want 2..3", got)
+	}
+}
+
+func TestDispatcher_RunParallel_JoinsTaskErrors(t *testing.T) {
+	t.Parallel()
+
+	boom := errors.New("boom")
+	ran := atomic.Int32{}
+	tasks := []progress.Task{
+		funcTask{name: "bad", run: func(context.Context, progress.ProgressReporter, progress.Steerer) error { ran.Add(1); return boom }},
+		funcTask{name: "good", run: func(context.Context, progress.ProgressReporter, progress.Steerer) error { ran.Add(1); return nil }},
+	}
+	err := progress.NewDispatcher(nil, nil).RunParallel(context.Background(), tasks, 0)
+	if !errors.Is(err, boom) || !strings.Contains(err.Error(), "bad: boom") {
+		t.Errorf("err = %v, want wrapped boom", err)
+	}
+	if ran.Load() != 2 {
+		t.Errorf("expected both tasks to run, ran %d", ran.Load())
+	}
+}
+
+func TestDispatcher_RunParallel_AbortCancelsContext(t *testing.T) {
+	t.Parallel()
+
+	steerer := &queueSteerer{signals: []progress.Signal{progress.SignalAbort}}
+	started := make(chan struct{})
+	var lateRuns atomic.Int32
+	tasks := []progress.Task{
+		funcTask{name: "waiter", run: func(ctx context.Context, _ progress.ProgressReporter, _ progress.Steerer) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+		funcTask{name: "aborter", run: func(_ context.Context, _ progress.ProgressReporter, s progress.Steerer) error {
+			<-started
+			if s.Steer() != progress.SignalAbort {
+				t.Error("expected SignalAbort to pass through")
+			}
+			return nil
+		}},
+		funcTask{name: "late", run: func(context.Context, progress.ProgressReporter, progress.Steerer) error {
+			lateRuns.Add(1)
+			return nil
+		}},
+	}
+	err := progress.NewDispatcher(nil, steerer).RunParallel(context.Background(), tasks, 2)
+	if !errors.Is(err, progress.ErrAborted) {
+		t.Errorf("err = %v, want ErrAborted", err)
+	}
+	if lateRuns.Load() != 0 {
+		t.Error("no task should start after an abort")
+	}
+}
+
+func TestDispatcher_RunParallel_ParentCancellation(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ran := false
+	tasks := []progress.Task{funcTask{name: "t", run: func(context.Context, progress.ProgressReporter, progress.Steerer) error {
+		ran = true
+		return nil
+	}}}
+	err := progress.NewDispatcher(nil, nil).RunParallel(ctx, tasks, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if ran {
+		t.Error("no task should start on a cancelled context")
+	}
+}
+
+func TestReporterFunc(t *testing.T) {
+	t.Parallel()
+
+	var got ingitdb.ProgressEvent
+	var r progress.ProgressReporter = progress.ReporterFunc(func(e ingitdb.ProgressEvent) { got = e })
+	r.Report(ingitdb.ProgressEvent{TaskName: "x"})
+	if got.TaskName != "x" {
+		t.Errorf("ReporterFunc did not forward the event: %+v", got)
+	}
+}
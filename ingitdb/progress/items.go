@@ -0,0 +1,71 @@
+package progress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// RunItems is the loop shared by tasks that process a known list of items
+// (records, files or views). It reports ProgressKindStarted, then one event
+// per item, then ProgressKindCompleted, with Done/Total counting items.
+//
+// Before each item the steerer is consulted: SignalSkipItem skips that item
+// (reported as ProgressKindSkipped and counted as done) and SignalAbort stops
+// the loop with ErrAborted. Cancellation of ctx stops the loop the same way
+// with ctx.Err(); both report ProgressKindAborted.
+//
+// An error from run is reported as ProgressKindError and does not stop the
+// loop; all item errors are returned joined, each prefixed with its key.
+func RunItems(
+	ctx context.Context,
+	reporter ProgressReporter,
+	steerer Steerer,
+	taskName, scope string,
+	keys []string,
+	run func(ctx context.Context, key string) error,
+) error {
+	total := len(keys)
+	done := 0
+	reporter.Report(ingitdb.ProgressEvent{Kind: ingitdb.ProgressKindStarted, TaskName: taskName, Scope: scope, Total: total})
+	aborted := func(err error) error {
+		reporter.Report(ingitdb.ProgressEvent{Kind: ingitdb.ProgressKindAborted, TaskName: taskName, Scope: scope, Done: done, Total: total})
+		return err
+	}
+	var errs []error
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return aborted(err)
+		}
+		switch steerer.Steer() {
+		case SignalAbort:
+			return aborted(ErrAborted)
+		case SignalSkipItem:
+			done++
+			reporter.Report(ingitdb.ProgressEvent{
+				Kind: ingitdb.ProgressKindSkipped, TaskName: taskName, Scope: scope, ItemKey: key, Done: done, Total: total,
+			})
+			continue
+		}
+		err := run(ctx, key)
+		done++
+		if err != nil {
+			if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+				return aborted(err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			reporter.Report(ingitdb.ProgressEvent{
+				Kind: ingitdb.ProgressKindError, TaskName: taskName, Scope: scope, ItemKey: key, Done: done, Total: total,
+				Message: err.Error(),
+			})
+			continue
+		}
+		reporter.Report(ingitdb.ProgressEvent{
+			Kind: ingitdb.ProgressKindItemDone, TaskName: taskName, Scope: scope, ItemKey: key, Done: done, Total: total,
+		})
+	}
+	reporter.Report(ingitdb.ProgressEvent{Kind: ingitdb.ProgressKindCompleted, TaskName: taskName, Scope: scope, Done: done, Total: total})
+	return errors.Join(errs...)
+}
@@ -0,0 +1,121 @@
+package progress_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/progress"
+)
+
+func TestRunItems_ReportsDoneAndTotal(t *testing.T) {
+	t.Parallel()
+
+	reporter := &syncReporter{}
+	var processed []string
+	err := progress.RunItems(context.Background(), reporter, &queueSteerer{}, "validate", "users", []string{"a", "b"},
+		func(_ context.Context, key string) error {
+			processed = append(processed, key)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("RunItems: %v", err)
+	}
+	want := []ingitdb.ProgressKind{
+		ingitdb.ProgressKindStarted, ingitdb.ProgressKindItemDone, ingitdb.ProgressKindItemDone, ingitdb.ProgressKindCompleted,
+	}
+	if !slices.Equal(reporter.kinds(), want) {
+		t.Fatalf("kinds = %v, want %v", reporter.kinds(), want)
+	}
+	last := reporter.events[len(reporter.events)-1]
+	if last.Done != 2 || last.Total != 2 || last.Scope != "users" || last.TaskName != "validate" {
+		t.Errorf("unexpected completion event: %+v", last)
+	}
+	if reporter.events[1].ItemKey != "a" || reporter.events[1].Done != 1 {
+		t.Errorf("unexpected first item event: %+v", reporter.events[1])
+	}
+	if !slices.Equal(processed, []string{"a", "b"}) {
+		t.Errorf("processed = %v", processed)
+	}
+}
+
+func TestRunItems_SkipItem(t *testing.T) {
+	t.Parallel()
+
+	reporter := &syncReporter{}
+	steerer := &queueSteerer{signals: []progress.Signal{progress.SignalSkipItem}}
+	var processed []string
+	err := progress.RunItems(context.Background(), reporter, steerer, "validate", "users", []string{"a", "b"},
+		func(_ context.Context, key string) error {
+			processed = append(processed, key)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("RunItems: %v", err)
+	}
+	if !slices.Equal(processed, []string{"b"}) {
+		t.Errorf("processed = %v, want [b]", processed)
+	}
+	if reporter.events[1].Kind != ingitdb.ProgressKindSkipped || reporter.events[1].ItemKey != "a" {
+		t.Errorf("expected a skipped event for a, got %+v", reporter.events[1])
+	}
+}
+
+func TestRunItems_Abort(t *testing.T) {
+	t.Parallel()
+
+	reporter := &syncReporter{}
+	steerer := &queueSteerer{signals: []progress.Signal{progress.SignalNone, progress.SignalAbort}}
+	err := progress.RunItems(context.Background(), reporter, steerer, "validate", "users", []string{"a", "b", "c"},
+		func(context.Context, string) error { return nil })
+	if !errors.Is(err, progress.ErrAborted) {
+		t.Fatalf("err = %v, want ErrAborted", err)
+	}
+	last := reporter.events[len(reporter.events)-1]
+	if last.Kind != ingitdb.ProgressKindAborted || last.Done != 1 || last.Total != 3 {
+		t.Errorf("unexpected final event: %+v", last)
+	}
+}
+
+func TestRunItems_ItemErrorsContinue(t *testing.T) {
+	t.Parallel()
+
+	reporter := &syncReporter{}
+	err := progress.RunItems(context.Background(), reporter, &queueSteerer{}, "materialize", "users", []string{"a", "b"},
+		func(_ context.Context, key string) error {
+			if key == "a" {
+				return errors.New("bad view")
+			}
+			return nil
+		})
+	if err == nil || !strings.Contains(err.Error(), "a: bad view") {
+		t.Fatalf("err = %v, want a: bad view", err)
+	}
+	want := []ingitdb.ProgressKind{
+		ingitdb.ProgressKindStarted, ingitdb.ProgressKindError, ingitdb.ProgressKindItemDone, ingitdb.ProgressKindCompleted,
+	}
+	if !slices.Equal(reporter.kinds(), want) {
+		t.Errorf("kinds = %v, want %v", reporter.kinds(), want)
+	}
+	if reporter.events[1].Message != "bad view" {
+		t.Errorf("error event message = %q", reporter.events[1].Message)
+	}
+}
+
+func TestRunItems_ContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := progress.RunItems(ctx, &syncReporter{}, &queueSteerer{}, "validate", "users", []string{"a"},
+		func(context.Context, string) error {
+			t.Error("no item should run on a cancelled context")
+			return nil
+		})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
@@ -15,10 +15,11 @@ const (
 // ProgressEvent carries one progress update from a running task.
 type ProgressEvent struct {
 	Kind     ProgressKind
-	TaskName string           // "validate" or "materialize"
+	TaskName string           // "validate", "materialize" or "trigger"
 	Scope    string           // collection or view ID
 	ItemKey  string           // record key or output file name
 	Done     int              // items completed so far
 	Total    int              // total items; 0 = unknown
 	Err      *ValidationError // non-nil only for ProgressKindError
+	Message  string           // free-form detail, e.g. a trigger step's output
 }
@@ -0,0 +1,285 @@
+package recorddiff
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/gitdiff"
+)
+
+// Commit identifies the commit that made a record change.
+type Commit struct {
+	Hash    string    `json:"hash"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	Date    time.Time `json:"date"`
+	Message string    `json:"message"`
+}
+
+// Revision is one commit's change to a record: the record was added, deleted
+// or modified relative to the commit's first parent. Fields is nil when the
+// record file does not parse at the commit or its parent, so the change cannot
+// be itemized.
+type Revision struct {
+	Commit
+	ChangeKind ingitdb.ChangeKind `json:"change"`
+	Fields     []FieldChange      `json:"fields"`
+}
+
+// FieldBlame names the last commit that changed one field of a record.
+type FieldBlame struct {
+	Field  string `json:"field"`
+	Value  any    `json:"value"`
+	Commit Commit `json:"commit"`
+}
+
+// History returns the commits reachable from ref (HEAD when empty) that
+// changed record key of the root collection collectionID, newest first. The
+// database directory dbPath is the git repository root, and the record file is
+// located with def as it stands, not as it stood at each commit.
+//
+// For a map or list layout the record is isolated inside the shared file: a
+// commit that only touched the file's other records is not part of its
+// history. A record renamed to another key starts a new history.
+func History(ctx context.Context, dbPath string, def *ingitdb.Definition, collectionID, key, ref string) ([]Revision, error) {
+	h, err := newRecordHistory(dbPath, def, collectionID, key)
+	if err != nil {
+		return nil, err
+	}
+	return h.revisions(ctx, cmp.Or(ref, "HEAD"))
+}
+
+// Blame returns, for each field of record key as it stands at ref (HEAD when
+// empty), the last commit that changed the field's value, sorted by field. A
+// field whose history cannot be traced, e.g. through an unparseable version of
+// the file, has a zero Commit. It is an error for the record not to exist at
+// ref.
+func Blame(ctx context.Context, dbPath string, def *ingitdb.Definition, collectionID, key, ref string) ([]FieldBlame, error) {
+	h, err := newRecordHistory(dbPath, def, collectionID, key)
+	if err != nil {
+		return nil, err
+	}
+	ref = cmp.Or(ref, "HEAD")
+	current, ok, err := h.recordAt(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("record %q of collection %q does not exist at %s", key, collectionID, ref)
+	}
+	revisions, err := h.revisions(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	blame := make([]FieldBlame, 0, len(current))
+	for _, field := range slices.Sorted(maps.Keys(current)) {
+		fb := FieldBlame{Field: field, Value: current[field]}
+	search:
+		for _, rev := range revisions { // newest first
+			if rev.Fields == nil && rev.ChangeKind == ingitdb.ChangeKindModified {
+				break // an unparseable version hides what changed
+			}
+			for _, fc := range rev.Fields {
+				if fc.Field == field {
+					fb.Commit = rev.Commit
+					break search
+				}
+			}
+		}
+		blame = append(blame, fb)
+	}
+	return blame, nil
+}
+
+// recordHistory reads one record's versions from git.
+type recordHistory struct {
+	dbPath string
+	colDef *ingitdb.CollectionDef
+	key    string
+	path   string // record file, relative to dbPath, slash-separated
+	reader gitdiff.GitFileReader
+	cache  map[string]readResult // commit → file content
+}
+
+type readResult struct {
+	content []byte
+	ok      bool
+}
+
+func newRecordHistory(dbPath string, def *ingitdb.Definition, collectionID, key string) (*recordHistory, error) {
+	colDef := def.Collections[collectionID]
+	if colDef == nil {
+		return nil, fmt.Errorf("collection %q is not defined", collectionID)
+	}
+	if colDef.RecordFile == nil {
+		return nil, fmt.Errorf("collection %q has no record file definition", collectionID)
+	}
+	if key == "" {
+		return nil, errors.New("record key is required")
+	}
+	name := colDef.RecordFile.Name
+	if colDef.RecordFile.RecordType == ingitdb.SingleRecord {
+		name = strings.ReplaceAll(name, "{key}", key)
+		if name == colDef.RecordFile.Name || strings.ContainsAny(name, "{}") {
+			return nil, fmt.Errorf("record file name %q of collection %q cannot be derived from the record key alone", colDef.RecordFile.Name, collectionID)
+		}
+	}
+	absPath := filepath.Join(colDef.DirPath, colDef.RecordFile.RecordsBasePath(), name)
+	rel, err := filepath.Rel(dbPath, absPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil, fmt.Errorf("record file %s is outside the database directory %s", absPath, dbPath)
+	}
+	return &recordHistory{
+		dbPath: dbPath,
+		colDef: colDef,
+		key:    key,
+		path:   filepath.ToSlash(rel),
+		reader: gitdiff.NewInProcessGitFileReader(),
+		cache:  make(map[string]readResult),
+	}, nil
+}
+
+// logFormat prints a commit's hash, parents, author name, email, date and
+// message separated by unit separators; -z ends each commit with a NUL.
+const logFormat = "%H%x1f%P%x1f%an%x1f%ae%x1f%aI%x1f%B"
+
+// revisions walks the commits that touched the record file and keeps those
+// that changed the record.
+func (h *recordHistory) revisions(ctx context.Context, ref string) ([]Revision, error) {
+	cmd := exec.CommandContext(ctx, "git", "log", "-z", "--format="+logFormat, ref, "--", h.path)
+	cmd.Dir = h.dbPath
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log %s -- %s failed: %w", ref, h.path, err)
+	}
+	var revisions []Revision
+	for _, entry := range strings.Split(string(out), "\x00") {
+		entry = strings.TrimPrefix(entry, "\n")
+		if entry == "" {
+			continue
+		}
+		fields := strings.SplitN(entry, "\x1f", 6)
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("unexpected git log entry %q", entry)
+		}
+		commit := Commit{Hash: fields[0], Author: fields[2], Email: fields[3], Message: strings.TrimSpace(fields[5])}
+		if commit.Date, err = time.Parse(time.RFC3339, fields[4]); err != nil {
+			return nil, fmt.Errorf("unexpected date in git log entry %q: %w", entry, err)
+		}
+		parent := strings.Fields(fields[1])
+		rev, changed, err := h.compare(ctx, parent, commit.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			rev.Commit = commit
+			revisions = append(revisions, rev)
+		}
+	}
+	return revisions, nil
+}
+
+// compare diffs the record between a commit and its first parent; a root
+// commit is compared with an empty tree.
+func (h *recordHistory) compare(ctx context.Context, parents []string, commit string) (Revision, bool, error) {
+	var before map[string]any
+	inBefore, parsed := false, true
+	if len(parents) > 0 {
+		data, ok, err := h.recordAt(ctx, parents[0])
+		if err != nil && !errors.Is(err, errUnparseable) {
+			return Revision{}, false, err
+		}
+		before, inBefore, parsed = data, ok, err == nil
+	}
+	after, inAfter, err := h.recordAt(ctx, commit)
+	if err != nil && !errors.Is(err, errUnparseable) {
+		return Revision{}, false, err
+	}
+	if !parsed || err != nil {
+		return Revision{ChangeKind: ingitdb.ChangeKindModified}, true, nil
+	}
+	switch {
+	case !inBefore && !inAfter:
+		return Revision{}, false, nil
+	case !inBefore:
+		return Revision{ChangeKind: ingitdb.ChangeKindAdded, Fields: fieldChanges(nil, after)}, true, nil
+	case !inAfter:
+		return Revision{ChangeKind: ingitdb.ChangeKindDeleted, Fields: fieldChanges(before, nil)}, true, nil
+	}
+	fields := fieldChanges(before, after)
+	return Revision{ChangeKind: ingitdb.ChangeKindModified, Fields: fields}, len(fields) > 0, nil
+}
+
+// errUnparseable reports a record file that does not parse at a commit.
+var errUnparseable = errors.New("record file does not parse")
+
+// recordAt returns the record's fields at commit; ok is false when the record
+// does not exist there.
+func (h *recordHistory) recordAt(ctx context.Context, commit string) (map[string]any, bool, error) {
+	read, cached := h.cache[commit]
+	if !cached {
+		content, ok, err := h.reader.ReadFile(ctx, h.dbPath, commit, h.path)
+		if err != nil {
+			return nil, false, err
+		}
+		read = readResult{content: content, ok: ok}
+		h.cache[commit] = read
+	}
+	if !read.ok {
+		return nil, false, nil
+	}
+	if h.colDef.RecordFile.RecordType == ingitdb.SingleRecord {
+		data, err := ingitdb.ParseRecordContentForCollection(read.content, h.colDef)
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: %s at %s: %v", errUnparseable, h.path, commit, err)
+		}
+		return data, true, nil
+	}
+	data, ok, err := findSharedRecord(read.content, h.colDef, h.key)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %s at %s: %v", errUnparseable, h.path, commit, err)
+	}
+	return data, ok, nil
+}
+
+// errFound stops streaming a shared file once the record is found.
+var errFound = errors.New("found")
+
+// findSharedRecord finds key's record in a map/list file's content.
+func findSharedRecord(content []byte, colDef *ingitdb.CollectionDef, key string) (record map[string]any, ok bool, err error) {
+	switch colDef.RecordFile.RecordType {
+	case ingitdb.MapOfRecords:
+		err = ingitdb.StreamMapOfRecords(context.Background(), bytes.NewReader(content), colDef.RecordFile.Format,
+			func(k string, data map[string]any) error {
+				if k != key {
+					return nil
+				}
+				record = data
+				return errFound
+			})
+	case ingitdb.ListOfRecords:
+		err = ingitdb.StreamListOfRecords(context.Background(), bytes.NewReader(content), colDef, func(row map[string]any) error {
+			if k, resolved := ingitdb.ResolveListRecordKey(row, colDef); !resolved || k != key {
+				return nil
+			}
+			record = row
+			return errFound
+		})
+	default:
+		return nil, false, fmt.Errorf("unsupported record type %q", colDef.RecordFile.RecordType)
+	}
+	if errors.Is(err, errFound) {
+		return record, true, nil
+	}
+	return nil, false, err
+}
@@ -0,0 +1,230 @@
+package recorddiff
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// historyTestRepo commits a sequence of edits by different authors to a map
+// file, a list file and a single-record file.
+func historyTestRepo(t *testing.T) (string, *ingitdb.Definition) {
+	t.Helper()
+	dir := t.TempDir()
+	git := func(args ...string) {
+		c := exec.Command("git", args...)
+		c.Dir = dir
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(rel, content string) {
+		full := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit := func(author, message string) {
+		git("add", "-A")
+		git("commit", "-q", "--author", author+" <"+strings.ToLower(author)+"@example.com>", "-m", message)
+	}
+	git("init", "-q")
+	git("config", "user.email", "t@example.com")
+	git("config", "user.name", "T")
+
+	write("countries/countries.yaml", "fr:\n  name: France\n  population: 67\nie:\n  name: Ireland\n  population: 5\n")
+	write("cities/cities.csv", "id,name\ndub,Dublin\ncork,Cork\n")
+	write("people/$records/ada.yaml", "name: Ada\n")
+	commit("Alice", "Add data")
+
+	write("countries/countries.yaml", "fr:\n  name: France\n  population: 68\nie:\n  name: Ireland\n  population: 5\n")
+	write("cities/cities.csv", "id,name\ndub,Baile Átha Cliath\ncork,Cork\n")
+	commit("Bob", "Update France's population")
+
+	// fr is only reformatted: not a change to it.
+	write("countries/countries.yaml", "fr:\n  population: 68\n  name: \"France\"\nie:\n  name: Ireland\n  population: 6\n")
+	write("people/$records/ada.yaml", "name: Ada Lovelace\n")
+	commit("Carol", "Update Ireland's population\n\nCensus 2022.")
+
+	write("countries/countries.yaml", "fr:\n  population: 68\n  name: France\n  capital: Paris\nie:\n  name: Ireland\n  population: 6\n")
+	write("cities/cities.csv", "id,name\ndub,Baile Átha Cliath\n")
+	commit("Dave", "Add capitals")
+
+	if err := os.Remove(filepath.Join(dir, "people", "$records", "ada.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	commit("Erin", "Remove Ada")
+
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"countries": {
+			ID: "countries", DirPath: filepath.Join(dir, "countries"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "countries.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords},
+		},
+		"cities": {
+			ID: "cities", DirPath: filepath.Join(dir, "cities"),
+			RecordFile:   &ingitdb.RecordFileDef{Name: "cities.csv", Format: ingitdb.RecordFormatCSV, RecordType: ingitdb.ListOfRecords},
+			Columns:      map[string]*ingitdb.ColumnDef{"id": {Type: ingitdb.ColumnTypeString}, "name": {Type: ingitdb.ColumnTypeString}},
+			ColumnsOrder: []string{"id", "name"},
+		},
+		"people": {
+			ID: "people", DirPath: filepath.Join(dir, "people"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord},
+		},
+	}}
+	return dir, def
+}
+
+// revisionSummary is what a test compares of a Revision.
+type revisionSummary struct {
+	Author  string
+	Kind    ingitdb.ChangeKind
+	Changed []string
+}
+
+func summarize(revisions []Revision) []revisionSummary {
+	summaries := make([]revisionSummary, 0, len(revisions))
+	for _, rev := range revisions {
+		s := revisionSummary{Author: rev.Author, Kind: rev.ChangeKind}
+		for _, fc := range rev.Fields {
+			s.Changed = append(s.Changed, fc.Field)
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	dir, def := historyTestRepo(t)
+	cases := []struct {
+		collection, key string
+		want            []revisionSummary
+	}{
+		// Carol's reformatting of fr and changes to ie are not fr's history.
+		{"countries", "fr", []revisionSummary{
+			{"Dave", ingitdb.ChangeKindModified, []string{"capital"}},
+			{"Bob", ingitdb.ChangeKindModified, []string{"population"}},
+			{"Alice", ingitdb.ChangeKindAdded, []string{"name", "population"}},
+		}},
+		{"countries", "ie", []revisionSummary{
+			{"Carol", ingitdb.ChangeKindModified, []string{"population"}},
+			{"Alice", ingitdb.ChangeKindAdded, []string{"name", "population"}},
+		}},
+		{"cities", "cork", []revisionSummary{
+			{"Dave", ingitdb.ChangeKindDeleted, []string{"id", "name"}},
+			{"Alice", ingitdb.ChangeKindAdded, []string{"id", "name"}},
+		}},
+		{"people", "ada", []revisionSummary{
+			{"Erin", ingitdb.ChangeKindDeleted, []string{"name"}},
+			{"Carol", ingitdb.ChangeKindModified, []string{"name"}},
+			{"Alice", ingitdb.ChangeKindAdded, []string{"name"}},
+		}},
+		{"countries", "de", []revisionSummary{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.collection+"/"+tc.key, func(t *testing.T) {
+			t.Parallel()
+			revisions, err := History(context.Background(), dir, def, tc.collection, tc.key, "")
+			if err != nil {
+				t.Fatalf("History: %v", err)
+			}
+			if got := summarize(revisions); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("History = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHistory_RevisionDetails(t *testing.T) {
+	t.Parallel()
+
+	dir, def := historyTestRepo(t)
+	revisions, err := History(context.Background(), dir, def, "countries", "ie", "")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	rev := revisions[0]
+	if rev.Email != "carol@example.com" || rev.Message != "Update Ireland's population\n\nCensus 2022." ||
+		rev.Date.IsZero() || len(rev.Hash) != 40 {
+		t.Errorf("commit = %+v", rev.Commit)
+	}
+	want := []FieldChange{{Field: "population", Before: 5, After: 6}}
+	if !reflect.DeepEqual(rev.Fields, want) {
+		t.Errorf("Fields = %+v, want %+v", rev.Fields, want)
+	}
+}
+
+func TestBlame(t *testing.T) {
+	t.Parallel()
+
+	dir, def := historyTestRepo(t)
+	blame, err := Blame(context.Background(), dir, def, "countries", "fr", "")
+	if err != nil {
+		t.Fatalf("Blame: %v", err)
+	}
+	got := map[string]string{}
+	for _, fb := range blame {
+		got[fb.Field] = fb.Commit.Author
+	}
+	want := map[string]string{"capital": "Dave", "name": "Alice", "population": "Bob"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Blame authors = %v, want %v", got, want)
+	}
+	if blame[0].Field != "capital" || blame[0].Value != "Paris" {
+		t.Errorf("blame[0] = %+v, want capital: Paris first", blame[0])
+	}
+
+	// ada no longer exists at HEAD; it does one commit earlier.
+	if _, err = Blame(context.Background(), dir, def, "people", "ada", ""); err == nil {
+		t.Error("expected an error for a record deleted at HEAD")
+	}
+	blame, err = Blame(context.Background(), dir, def, "people", "ada", "HEAD~1")
+	if err != nil {
+		t.Fatalf("Blame at HEAD~1: %v", err)
+	}
+	if len(blame) != 1 || blame[0].Value != "Ada Lovelace" || blame[0].Commit.Author != "Carol" {
+		t.Errorf("Blame at HEAD~1 = %+v", blame)
+	}
+}
+
+func TestHistory_Errors(t *testing.T) {
+	t.Parallel()
+
+	dir, def := historyTestRepo(t)
+	def.Collections["tagged"] = &ingitdb.CollectionDef{
+		ID: "tagged", DirPath: filepath.Join(dir, "tagged"),
+		RecordFile: &ingitdb.RecordFileDef{Name: "{key}-{lang}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord},
+	}
+	for name, call := range map[string]func() error{
+		"unknown collection": func() error {
+			_, err := History(context.Background(), dir, def, "planets", "earth", "")
+			return err
+		},
+		"empty key": func() error {
+			_, err := History(context.Background(), dir, def, "countries", "", "")
+			return err
+		},
+		"name beyond the key": func() error {
+			_, err := History(context.Background(), dir, def, "tagged", "x", "")
+			return err
+		},
+		"unknown ref": func() error {
+			_, err := History(context.Background(), dir, def, "countries", "fr", "no-such-ref")
+			return err
+		},
+	} {
+		if err := call(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// Package recorddiff reports what changed in a database's records between two
+// git refs: per collection, the records added, removed and changed, with
+// field-level before/after values for the changed ones.
+//
+// Records are compared as parsed values, not as text, so representation-only
+// edits — key order, quoting, indentation, moving a record within its file —
+// are not changes. This is the equality recordmerge uses.
+//
+// History and Blame apply the same comparison along a record's git history.
+package recorddiff
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/datavalidator"
+	"github.com/ingitdb/ingitdb-go/ingitdb/gitdiff"
+)
+
+// Diff is the record-level difference between two refs.
+type Diff struct {
+	FromRef     string           `json:"from"`
+	ToRef       string           `json:"to,omitempty"`
+	Collections []CollectionDiff `json:"collections"`
+}
+
+// CollectionDiff is one collection's changes. For a subcollection,
+// CollectionID is its full path (e.g. "orders/order_details") and each record
+// names its parent chain.
+type CollectionDiff struct {
+	CollectionID string         `json:"collection"`
+	Added        []Record       `json:"added,omitempty"`
+	Removed      []Record       `json:"removed,omitempty"`
+	Changed      []RecordChange `json:"changed,omitempty"`
+	// Unresolved lists changed record files that could not be parsed into
+	// records at one of the refs, so their changes are not itemized.
+	Unresolved []string `json:"unresolved_files,omitempty"`
+}
+
+// Parent is one link of a subcollection record's parent chain.
+type Parent struct {
+	CollectionID string `json:"collection"`
+	Key          string `json:"key"`
+}
+
+// Record is an added or removed record with its field values.
+type Record struct {
+	Key     string         `json:"key"`
+	Parents []Parent       `json:"parents,omitempty"`
+	Fields  map[string]any `json:"fields"`
+}
+
+// RecordChange is a record present at both refs whose fields differ.
+type RecordChange struct {
+	Key     string        `json:"key"`
+	Parents []Parent      `json:"parents,omitempty"`
+	Fields  []FieldChange `json:"fields"`
+}
+
+// FieldChange is one field's value at each ref; nil where the field is absent.
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// IsEmpty reports whether no record changed.
+func (d *Diff) IsEmpty() bool {
+	return len(d.Collections) == 0
+}
+
+// Option configures Compute.
+type Option func(*options)
+
+type options struct {
+	differ gitdiff.GitDiffer
+	reader gitdiff.GitFileReader
+}
+
+// WithGitDiffer sets how the files changed between the refs are listed. It
+// defaults to gitdiff.NewInProcessGitDiffer(), which needs no git binary.
+func WithGitDiffer(differ gitdiff.GitDiffer) Option {
+	return func(o *options) {
+		o.differ = differ
+	}
+}
+
+// WithGitFileReader sets how a record file is read at a ref. It defaults to
+// gitdiff.NewInProcessGitFileReader().
+func WithGitFileReader(reader gitdiff.GitFileReader) Option {
+	return func(o *options) {
+		o.reader = reader
+	}
+}
+
+// Compute diffs the records of def's collections between fromRef and toRef in
+// the git repository at dbPath; an empty toRef is the working tree. Record
+// files at both refs are parsed with def, so a definition change between the
+// refs is not itself reported.
+//
+// Collections are sorted by ID and records by key. A record removed and added
+// back under the same key, e.g. by a file rename, is compared as one record.
+func Compute(ctx context.Context, dbPath string, def *ingitdb.Definition, fromRef, toRef string, opts ...Option) (*Diff, error) {
+	o := options{differ: gitdiff.NewInProcessGitDiffer(), reader: gitdiff.NewInProcessGitFileReader()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	changed, err := o.differ.DiffFiles(ctx, dbPath, fromRef, toRef)
+	if err != nil {
+		return nil, err
+	}
+	resolver := datavalidator.NewChangeSetResolver(
+		datavalidator.WithGitFileReader(o.reader),
+		datavalidator.WithSingleRecordFields(),
+	).(datavalidator.RecordChangeResolver)
+	affected, err := resolver.ResolveRecords(ctx, dbPath, def, changed, fromRef, toRef)
+	if err != nil {
+		return nil, err
+	}
+	return build(fromRef, toRef, dbPath, affected), nil
+}
+
+// recordID identifies a record across the two sides of a diff.
+type recordID struct {
+	collectionID string
+	parents      string
+	key          string
+}
+
+type recordSides struct {
+	parents           []Parent
+	before, after     map[string]any
+	inBefore, inAfter bool
+	// unparsed is set when the record's file did not parse at a ref; the
+	// record is then reported through its file in Unresolved.
+	unparsed bool
+}
+
+func build(fromRef, toRef, dbPath string, affected []datavalidator.AffectedRecord) *Diff {
+	records := make(map[recordID]*recordSides)
+	unresolved := make(map[string][]string)
+	for _, ar := range affected {
+		if ar.RecordKey == "" {
+			unresolved[ar.CollectionID] = append(unresolved[ar.CollectionID], relPath(dbPath, ar.FilePath))
+			continue
+		}
+		var parents []Parent
+		var chain []string
+		for _, p := range ar.Parents {
+			parents = append(parents, Parent{CollectionID: p.CollectionID, Key: p.RecordKey})
+			chain = append(chain, p.CollectionID+"/"+p.RecordKey)
+		}
+		id := recordID{collectionID: ar.CollectionID, parents: strings.Join(chain, "/"), key: ar.RecordKey}
+		sides := records[id]
+		if sides == nil {
+			sides = &recordSides{parents: parents}
+			records[id] = sides
+		}
+		if ar.Unparsed {
+			sides.unparsed = true
+			unresolved[ar.CollectionID] = append(unresolved[ar.CollectionID], relPath(dbPath, ar.FilePath))
+			continue
+		}
+		// A deletion contributes the before side; anything else the after
+		// side, and a modification both.
+		if ar.ChangeKind == ingitdb.ChangeKindDeleted || ar.ChangeKind == ingitdb.ChangeKindModified {
+			sides.inBefore = true
+			sides.before = mergeSide(sides.before, ar.Fields, func(fc datavalidator.FieldChange) any { return fc.Before })
+		}
+		if ar.ChangeKind != ingitdb.ChangeKindDeleted {
+			sides.inAfter = true
+			sides.after = mergeSide(sides.after, ar.Fields, func(fc datavalidator.FieldChange) any { return fc.After })
+		}
+	}
+
+	byCollection := make(map[string]*CollectionDiff)
+	collection := func(id string) *CollectionDiff {
+		cd := byCollection[id]
+		if cd == nil {
+			cd = &CollectionDiff{CollectionID: id}
+			byCollection[id] = cd
+		}
+		return cd
+	}
+	for id, sides := range records {
+		switch {
+		case sides.unparsed:
+			continue
+		case sides.inBefore && sides.inAfter:
+			fields := fieldChanges(sides.before, sides.after)
+			if len(fields) == 0 {
+				continue
+			}
+			cd := collection(id.collectionID)
+			cd.Changed = append(cd.Changed, RecordChange{Key: id.key, Parents: sides.parents, Fields: fields})
+		case sides.inAfter:
+			cd := collection(id.collectionID)
+			cd.Added = append(cd.Added, Record{Key: id.key, Parents: sides.parents, Fields: orEmpty(sides.after)})
+		default:
+			cd := collection(id.collectionID)
+			cd.Removed = append(cd.Removed, Record{Key: id.key, Parents: sides.parents, Fields: orEmpty(sides.before)})
+		}
+	}
+	for id, files := range unresolved {
+		cd := collection(id)
+		slices.Sort(files)
+		cd.Unresolved = slices.Compact(files)
+	}
+
+	diff := &Diff{FromRef: fromRef, ToRef: toRef}
+	for _, id := range slices.Sorted(maps.Keys(byCollection)) {
+		cd := byCollection[id]
+		slices.SortFunc(cd.Added, func(a, b Record) int { return compareRecords(a.Parents, a.Key, b.Parents, b.Key) })
+		slices.SortFunc(cd.Removed, func(a, b Record) int { return compareRecords(a.Parents, a.Key, b.Parents, b.Key) })
+		slices.SortFunc(cd.Changed, func(a, b RecordChange) int { return compareRecords(a.Parents, a.Key, b.Parents, b.Key) })
+		diff.Collections = append(diff.Collections, *cd)
+	}
+	return diff
+}
+
+// mergeSide adds one side of fields to a record's values. The resolver lists
+// every field of an added or deleted record, and only the differing fields of
+// a modified one; a modified record's unchanged fields are not needed to
+// compare it.
+func mergeSide(values map[string]any, fields []datavalidator.FieldChange, side func(datavalidator.FieldChange) any) map[string]any {
+	if values == nil {
+		values = make(map[string]any)
+	}
+	for _, fc := range fields {
+		if v := side(fc); v != nil {
+			values[fc.Field] = v
+		}
+	}
+	return values
+}
+
+// fieldChanges lists the fields whose values differ, sorted by name.
+func fieldChanges(before, after map[string]any) []FieldChange {
+	names := slices.Collect(maps.Keys(before))
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	var changes []FieldChange
+	for _, name := range names {
+		if b, a := before[name], after[name]; !reflect.DeepEqual(b, a) {
+			changes = append(changes, FieldChange{Field: name, Before: b, After: a})
+		}
+	}
+	return changes
+}
+
+func compareRecords(aParents []Parent, aKey string, bParents []Parent, bKey string) int {
+	for i := 0; i < len(aParents) && i < len(bParents); i++ {
+		if c := cmp.Compare(aParents[i].Key, bParents[i].Key); c != 0 {
+			return c
+		}
+	}
+	return cmp.Or(cmp.Compare(len(aParents), len(bParents)), cmp.Compare(aKey, bKey))
+}
+
+func orEmpty(values map[string]any) map[string]any {
+	if values == nil {
+		return map[string]any{}
+	}
+	return values
+}
@@ -0,0 +1,239 @@
+package recorddiff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func testRepo(t *testing.T) (string, *ingitdb.Definition, string) {
+	t.Helper()
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		c := exec.Command("git", args...)
+		c.Dir = dir
+		out, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(rel, content string) {
+		full := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init")
+	git("config", "user.email", "t@example.com")
+	git("config", "user.name", "T")
+	write("countries/countries.yaml", "fr:\n  name: France\ngb:\n  name: Britain\nie:\n  name: Ireland\n  population: 5000000\n")
+	write("cities/cities.csv", "id,name\ndub,Dublin\ncork,Cork\n")
+	write("people/$records/ada.yaml", "name: Ada\n")
+	write("people/$records/alan.yaml", "name: Alan\n")
+	git("add", ".")
+	git("commit", "-m", "base")
+	base := git("rev-parse", "HEAD")
+
+	// Key order and quoting change for fr (noise); ie's population changes;
+	// gb is removed and de added. Rows of cities are reordered (noise). ada is
+	// renamed, alan is deleted, and grace is added.
+	write("countries/countries.yaml", "ie:\n  population: 5200000\n  name: Ireland\nfr:\n  name: \"France\"\nde:\n  name: Germany\n")
+	write("cities/cities.csv", "id,name\ncork,Cork\ndub,Dublin\n")
+	write("people/$records/ada.yaml", "name: Ada Lovelace\n")
+	write("people/$records/grace.yaml", "name: Grace\n")
+	git("rm", "-q", "people/$records/alan.yaml")
+	git("add", ".")
+	git("commit", "-m", "second")
+
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"countries": {
+			ID: "countries", DirPath: filepath.Join(dir, "countries"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "countries.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords},
+			Columns: map[string]*ingitdb.ColumnDef{
+				"name":       {Type: ingitdb.ColumnTypeString},
+				"population": {Type: ingitdb.ColumnTypeInt},
+			},
+		},
+		"cities": {
+			ID: "cities", DirPath: filepath.Join(dir, "cities"),
+			RecordFile:   &ingitdb.RecordFileDef{Name: "cities.csv", Format: ingitdb.RecordFormatCSV, RecordType: ingitdb.ListOfRecords},
+			Columns:      map[string]*ingitdb.ColumnDef{"id": {Type: ingitdb.ColumnTypeString}, "name": {Type: ingitdb.ColumnTypeString}},
+			ColumnsOrder: []string{"id", "name"},
+		},
+		"people": {
+			ID: "people", DirPath: filepath.Join(dir, "people"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord},
+			Columns:    map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}},
+		},
+	}}
+	return dir, def, base
+}
+
+func TestCompute(t *testing.T) {
+	t.Parallel()
+
+	dir, def, base := testRepo(t)
+	diff, err := Compute(context.Background(), dir, def, base, "HEAD")
+	if err != nil {
+		t.Fatalf("Compute: %v", err)
+	}
+	want := []CollectionDiff{
+		{
+			CollectionID: "countries",
+			Added:        []Record{{Key: "de", Fields: map[string]any{"name": "Germany"}}},
+			Removed:      []Record{{Key: "gb", Fields: map[string]any{"name": "Britain"}}},
+			Changed: []RecordChange{{Key: "ie", Fields: []FieldChange{
+				{Field: "population", Before: 5000000, After: 5200000},
+			}}},
+		},
+		{
+			CollectionID: "people",
+			Added:        []Record{{Key: "grace", Fields: map[string]any{"name": "Grace"}}},
+			Removed:      []Record{{Key: "alan", Fields: map[string]any{"name": "Alan"}}},
+			Changed: []RecordChange{{Key: "ada", Fields: []FieldChange{
+				{Field: "name", Before: "Ada", After: "Ada Lovelace"},
+			}}},
+		},
+	}
+	if !reflect.DeepEqual(diff.Collections, want) {
+		t.Errorf("collections =\n%+v\nwant\n%+v", diff.Collections, want)
+	}
+}
+
+// Record files that do not parse at one of the refs are reported as
+// unresolved, never as records with no or empty fields.
+func TestCompute_UnparseableRecordFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		c := exec.Command("git", args...)
+		c.Dir = dir
+		out, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(rel, content string) {
+		full := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init")
+	git("config", "user.email", "t@example.com")
+	git("config", "user.name", "T")
+	write("people/$records/ada.yaml", "name: Alice\n")
+	write("people/$records/alan.yaml", "name: [unclosed\n")
+	git("add", ".")
+	git("commit", "-m", "base")
+	base := git("rev-parse", "HEAD")
+	write("people/$records/ada.yaml", "name: [unclosed\n")
+	write("people/$records/grace.yaml", "name: {unclosed\n")
+	git("rm", "-q", "people/$records/alan.yaml")
+	git("add", ".")
+	git("commit", "-m", "broken")
+
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"people": {
+			ID: "people", DirPath: filepath.Join(dir, "people"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord},
+			Columns:    map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}},
+		},
+	}}
+	diff, err := Compute(context.Background(), dir, def, base, "HEAD")
+	if err != nil {
+		t.Fatalf("Compute: %v", err)
+	}
+	if diff.IsEmpty() {
+		t.Fatal("unparseable changes must not leave the diff empty")
+	}
+	want := []CollectionDiff{{
+		CollectionID: "people",
+		Unresolved:   []string{"people/$records/ada.yaml", "people/$records/alan.yaml", "people/$records/grace.yaml"},
+	}}
+	if !reflect.DeepEqual(diff.Collections, want) {
+		t.Errorf("collections =\n%+v\nwant\n%+v", diff.Collections, want)
+	}
+}
+
+func TestDiff_WriteReport(t *testing.T) {
+	t.Parallel()
+
+	dir, def, base := testRepo(t)
+	diff, err := Compute(context.Background(), dir, def, base, "HEAD")
+	if err != nil {
+		t.Fatalf("Compute: %v", err)
+	}
+	var buf bytes.Buffer
+	if err = diff.WriteReport(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `countries: 1 added, 1 removed, 1 changed
+  + de
+  - gb
+  ~ ie
+      population: 5000000 → 5200000
+
+people: 1 added, 1 removed, 1 changed
+  + grace
+  - alan
+  ~ ada
+      name: "Ada" → "Ada Lovelace"
+`
+	if buf.String() != want {
+		t.Errorf("report =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err = (&Diff{FromRef: base}).WriteReport(&buf); err != nil || buf.String() != "No record changes.\n" {
+		t.Errorf("empty report = %q (%v)", buf.String(), err)
+	}
+}
+
+func TestDiff_WriteJSON(t *testing.T) {
+	t.Parallel()
+
+	diff := &Diff{FromRef: "main", ToRef: "HEAD", Collections: []CollectionDiff{{
+		CollectionID: "orders/lines",
+		Added:        []Record{{Key: "l1", Parents: []Parent{{CollectionID: "orders", Key: "o1"}}, Fields: map[string]any{"qty": 2}}},
+		Changed:      []RecordChange{{Key: "l2", Fields: []FieldChange{{Field: "qty", Before: 1}}}},
+	}}}
+	var buf bytes.Buffer
+	if err := diff.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	collections := got["collections"].([]any)
+	col := collections[0].(map[string]any)
+	if col["collection"] != "orders/lines" || col["removed"] != nil {
+		t.Errorf("unexpected collection JSON: %v", col)
+	}
+	added := col["added"].([]any)[0].(map[string]any)
+	if added["parents"].([]any)[0].(map[string]any)["key"] != "o1" {
+		t.Errorf("added record must carry its parent chain: %v", added)
+	}
+	field := col["changed"].([]any)[0].(map[string]any)["fields"].([]any)[0].(map[string]any)
+	if v, present := field["after"]; !present || v != nil {
+		t.Errorf("a removed field must be reported as after: null, got %v", field)
+	}
+}
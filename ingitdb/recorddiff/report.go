@@ -0,0 +1,81 @@
+package recorddiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// WriteJSON writes the diff as indented JSON.
+func (d *Diff) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// WriteReport writes the diff as a plain-text report meant for reviewing a
+// pull request: a summary line per collection, then one line per record —
+// "+" added, "-" removed, "~" changed — with a changed record's fields below
+// it as "field: before → after". Values are shown as JSON, so a string is
+// quoted and an absent value is null.
+func (d *Diff) WriteReport(w io.Writer) error {
+	var sb strings.Builder
+	if d.IsEmpty() {
+		sb.WriteString("No record changes.\n")
+	}
+	for i, cd := range d.Collections {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "%s: %d added, %d removed, %d changed\n",
+			cd.CollectionID, len(cd.Added), len(cd.Removed), len(cd.Changed))
+		for _, r := range cd.Added {
+			fmt.Fprintf(&sb, "  + %s\n", recordPath(r.Parents, r.Key))
+		}
+		for _, r := range cd.Removed {
+			fmt.Fprintf(&sb, "  - %s\n", recordPath(r.Parents, r.Key))
+		}
+		for _, r := range cd.Changed {
+			fmt.Fprintf(&sb, "  ~ %s\n", recordPath(r.Parents, r.Key))
+			for _, fc := range r.Fields {
+				fmt.Fprintf(&sb, "      %s: %s → %s\n", fc.Field, formatValue(fc.Before), formatValue(fc.After))
+			}
+		}
+		for _, file := range cd.Unresolved {
+			fmt.Fprintf(&sb, "  ? %s (could not be parsed at one of the refs)\n", file)
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// recordPath names a record by its key, prefixed for a subcollection record
+// with its parents' keys: "ord001/line1".
+func recordPath(parents []Parent, key string) string {
+	if len(parents) == 0 {
+		return key
+	}
+	keys := make([]string, 0, len(parents)+1)
+	for _, p := range parents {
+		keys = append(keys, p.Key)
+	}
+	return strings.Join(append(keys, key), "/")
+}
+
+func formatValue(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func relPath(dbPath, absPath string) string {
+	rel, err := filepath.Rel(dbPath, absPath)
+	if err != nil {
+		return absPath
+	}
+	return filepath.ToSlash(rel)
+}
@@ -17,8 +17,7 @@ import (
 // It returns an Outcome whose Merged holds the union of non-conflicting changes
 // on success, or Escalate=true (with a reason) when the conflict is not
 // auto-resolvable — including unsupported record layouts and parse failures.
-// Serialization of the merged records back to file bytes is the caller's
-// responsibility.
+// EncodeMerged serializes the merged records back to file bytes.
 func MergeFiles(base, ours, theirs []byte, col *ingitdb.CollectionDef, opts Options) Outcome {
 	if col == nil || col.RecordFile == nil {
 		return escalate("collection has no record-file definition")
@@ -0,0 +1,66 @@
+// specscore: feature/cli/resolve/auto-resolve/record-merge
+package recordmerge
+
+import (
+	"fmt"
+
+	ingitdb "github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// EncodeMerged serializes the Merged records of a successful MergeFiles
+// outcome back to the bytes of col's record file, in the canonical format the
+// library writes records in: a map file keyed and sorted by record key, a list
+// file in merged order, a single record on its own. Formatting and comments
+// of the merged sides are not preserved.
+func EncodeMerged(merged []Record, col *ingitdb.CollectionDef) ([]byte, error) {
+	if col == nil || col.RecordFile == nil {
+		return nil, fmt.Errorf("collection has no record-file definition")
+	}
+	rfd := col.RecordFile
+	switch {
+	case rfd.RecordType == ingitdb.SingleRecord:
+		if len(merged) != 1 {
+			return nil, fmt.Errorf("a single-record file holds one record, got %d", len(merged))
+		}
+		return ingitdb.EncodeRecordContentForCollection(merged[0].Fields, col)
+	case rfd.RecordType == ingitdb.MapOfRecords,
+		rfd.RecordType == ingitdb.ListOfRecords && rfd.Format == ingitdb.RecordFormatINGR:
+		// INGR lists are keyed by $ID and merge as maps.
+		data := make(map[string]map[string]any, len(merged))
+		for _, r := range merged {
+			data[r.Key] = r.Fields
+		}
+		return ingitdb.EncodeMapOfRecordsContent(data, rfd.Format, col.ID, col.ColumnsOrder)
+	case rfd.RecordType == ingitdb.ListOfRecords:
+		rows := make([]map[string]any, 0, len(merged))
+		for _, r := range merged {
+			rows = append(rows, r.Fields)
+		}
+		if rfd.Format == ingitdb.RecordFormatCSV {
+			return ingitdb.EncodeRecordContentForCollection(rows, col)
+		}
+		return ingitdb.EncodeListOfRecordsContent(rows, rfd.Format, col.ColumnsOrder)
+	default:
+		return nil, fmt.Errorf("record layout %q cannot be encoded", rfd.RecordType)
+	}
+}
+
+// Mergeable reports whether MergeFiles can merge col's record files at all,
+// rather than escalating every conflict for want of a parser for the layout.
+func Mergeable(col *ingitdb.CollectionDef) bool {
+	if col == nil || col.RecordFile == nil {
+		return false
+	}
+	switch col.RecordFile.RecordType {
+	case ingitdb.MapOfRecords, ingitdb.SingleRecord:
+		return true
+	case ingitdb.ListOfRecords:
+		switch col.RecordFile.Format {
+		case ingitdb.RecordFormatCSV, ingitdb.RecordFormatINGR,
+			ingitdb.RecordFormatYAML, ingitdb.RecordFormatYML,
+			ingitdb.RecordFormatJSON, ingitdb.RecordFormatJSONL:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package recordmerge
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	ingitdb "github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// The encoded merge parses back to the merged records, for every layout
+// MergeFiles supports.
+func TestEncodeMerged_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	ingr := func(ids ...string) []byte {
+		data := make(map[string]map[string]any, len(ids))
+		for _, id := range ids {
+			data[id] = map[string]any{"$ID": id, "v": "1"}
+		}
+		b, err := ingitdb.EncodeMapOfRecordsContent(data, ingitdb.RecordFormatINGR, "rs", []string{"v"})
+		if err != nil {
+			t.Fatalf("encode INGR: %v", err)
+		}
+		return b
+	}
+	ingrCol := &ingitdb.CollectionDef{
+		ID:           "rs",
+		ColumnsOrder: []string{"v"},
+		RecordFile:   &ingitdb.RecordFileDef{Name: "rs", Format: ingitdb.RecordFormatINGR, RecordType: ingitdb.ListOfRecords},
+	}
+	for _, tc := range []struct {
+		name               string
+		col                *ingitdb.CollectionDef
+		base, ours, theirs []byte
+		want               string // expected encoding, when checked
+	}{
+		{"map", mapCol(), []byte("x:\n  v: 0\n"), []byte("x:\n  v: 0\nb:\n  v: 1\n"), []byte("x:\n  v: 0\na:\n  v: 2\n"),
+			"a:\n    v: 2\nb:\n    v: 1\nx:\n    v: 0\n"},
+		{"single", singleCol(), []byte("name: A\nv: 0\n"), []byte("name: B\nv: 0\n"), []byte("name: A\nv: 1\n"), ""},
+		{"csv", csvCol([]string{"$id", "v"}, nil), []byte("$id,v\nx,0\n"), []byte("$id,v\nx,0\na,1\n"), []byte("$id,v\nx,0\nb,2\n"),
+			"$id,v\nx,0\na,1\nb,2\n"},
+		{"ingr", ingrCol, ingr("x"), ingr("x", "a"), ingr("x", "b"), ""},
+		{"yaml list", seqCol(ingitdb.RecordFormatYAML, nil),
+			[]byte("- $id: x\n  v: 0\n"), []byte("- $id: x\n  v: 0\n- $id: a\n  v: 1\n"), []byte("- $id: x\n  v: 0\n- $id: b\n  v: 2\n"), ""},
+		{"jsonl", seqCol(ingitdb.RecordFormatJSONL, nil),
+			[]byte("{\"$id\":\"x\"}\n"), []byte("{\"$id\":\"x\"}\n{\"$id\":\"a\"}\n"), []byte("{\"$id\":\"x\"}\n{\"$id\":\"b\"}\n"),
+			"{\"$id\":\"x\"}\n{\"$id\":\"a\"}\n{\"$id\":\"b\"}\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if !Mergeable(tc.col) {
+				t.Fatal("Mergeable = false")
+			}
+			opts := Options{SameRecord: true}
+			merged := MergeFiles(tc.base, tc.ours, tc.theirs, tc.col, opts)
+			if merged.Escalate {
+				t.Fatalf("MergeFiles escalated: %s", merged.Reason)
+			}
+			content, err := EncodeMerged(merged.Merged, tc.col)
+			if err != nil {
+				t.Fatalf("EncodeMerged: %v", err)
+			}
+			if tc.want != "" && string(content) != tc.want {
+				t.Errorf("EncodeMerged =\n%s\nwant\n%s", content, tc.want)
+			}
+			reparsed := MergeFiles(nil, content, nil, tc.col, opts)
+			if tc.col.RecordFile.RecordType == ingitdb.MapOfRecords || tc.col.RecordFile.Format == ingitdb.RecordFormatINGR {
+				byKey := func(a, b Record) int { return strings.Compare(a.Key, b.Key) }
+				slices.SortFunc(merged.Merged, byKey) // map files are written sorted by key
+			}
+			if reparsed.Escalate || !reflect.DeepEqual(reparsed.Merged, merged.Merged) {
+				t.Errorf("encoded merge parses to %v (%s), want %v", reparsed.Merged, reparsed.Reason, merged.Merged)
+			}
+		})
+	}
+}
+
+func TestEncodeMerged_Errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := EncodeMerged(nil, &ingitdb.CollectionDef{}); err == nil {
+		t.Error("expected an error without a record-file definition")
+	}
+	if _, err := EncodeMerged(nil, singleCol()); err == nil || !strings.Contains(err.Error(), "one record") {
+		t.Errorf("single record without a record: err = %v", err)
+	}
+	if Mergeable(seqCol(ingitdb.RecordFormatTOML, nil)) || Mergeable(nil) {
+		t.Error("Mergeable must be false for a toml list and for no collection")
+	}
+}
@@ -0,0 +1,451 @@
+package ingitdb
+
+// specscore: feature/record-format/list-of-records
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ingr-io/ingr-go/ingr"
+	"gopkg.in/yaml.v3"
+)
+
+// StreamListOfRecords decodes a list-of-records file from r and calls yield
+// once per row, in file order, without holding the whole file in memory.
+//
+// JSONL, CSV and INGR are decoded line by line; a JSON array is walked token by
+// token; a block-style YAML sequence is split into one chunk per top-level item.
+// A YAML file that is not a block sequence (flow style, a directive, a bare
+// scalar) falls back to decoding the whole document. INGR rows carry their key
+// under "$ID". CSV needs colDef.ColumnsOrder to check the header.
+//
+// ctx is checked before every row; a cancelled context stops the stream with
+// ctx.Err(). An error returned by yield is returned as is.
+func StreamListOfRecords(ctx context.Context, r io.Reader, colDef *CollectionDef, yield func(row map[string]any) error) error {
+	if colDef == nil || colDef.RecordFile == nil {
+		return fmt.Errorf("collection definition missing record_file")
+	}
+	yieldCtx := func(row map[string]any) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return yield(row)
+	}
+	switch format := colDef.RecordFile.Format; format {
+	case RecordFormatCSV:
+		return streamCSVRows(r, colDef, yieldCtx)
+	case RecordFormatINGR:
+		return streamINGRRows(r, func(_ string, row map[string]any) error {
+			return yieldCtx(row)
+		})
+	default:
+		return streamListRows(r, format, yieldCtx)
+	}
+}
+
+// StreamMapOfRecords decodes a map-of-records file from r and calls yield once
+// per record with the record's key and fields, in file order.
+//
+// INGR is decoded line by line, a JSON object token by token, and a block-style
+// YAML mapping one top-level key at a time. TOML has no streaming decoder and
+// is read whole. Keys must be unique; a repeated key is an error rather than
+// silently overriding the earlier record.
+//
+// ctx is checked before every record; a cancelled context stops the stream
+// with ctx.Err(). An error returned by yield is returned as is.
+func StreamMapOfRecords(ctx context.Context, r io.Reader, format RecordFormat, yield func(key string, fields map[string]any) error) error {
+	seen := make(map[string]bool)
+	yieldCtx := func(key string, fields map[string]any) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if seen[key] {
+			return fmt.Errorf("duplicate record key %q", key)
+		}
+		seen[key] = true
+		return yield(key, fields)
+	}
+	switch format {
+	case RecordFormatINGR:
+		return streamINGRRows(r, func(id string, row map[string]any) error {
+			return yieldCtx(id, ingrRecordFields(row))
+		})
+	case RecordFormatJSON:
+		return streamJSONObject(r, yieldCtx)
+	case RecordFormatYAML, RecordFormatYML:
+		return streamYAMLMapping(r, yieldCtx)
+	default:
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		records, err := ParseMapOfRecordsContent(content, format)
+		if err != nil {
+			return err
+		}
+		for key, fields := range records {
+			if err = yieldCtx(key, fields); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// streamListRows streams the list formats ParseListOfRecordsContent accepts.
+func streamListRows(r io.Reader, format RecordFormat, yield func(map[string]any) error) error {
+	switch format {
+	case RecordFormatYAML, RecordFormatYML:
+		return streamYAMLSequence(r, yield)
+	case RecordFormatJSON:
+		return streamJSONArray(r, yield)
+	case RecordFormatJSONL:
+		return streamJSONLRows(r, yield)
+	default:
+		return fmt.Errorf("format %q is not a list-of-records format", format)
+	}
+}
+
+func streamJSONLRows(r io.Reader, yield func(map[string]any) error) error {
+	br := bufio.NewReader(r)
+	for lineNum := 1; ; lineNum++ {
+		raw, readErr := br.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("failed to read JSONL line %d: %w", lineNum, readErr)
+		}
+		if line := bytes.TrimSpace(raw); len(line) > 0 {
+			var row map[string]any
+			if err := json.Unmarshal(line, &row); err != nil {
+				return fmt.Errorf("failed to parse JSONL line %d: %w", lineNum, err)
+			}
+			if err := yield(row); err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
+
+// streamJSONArray walks a top-level JSON array element by element. Empty input
+// and a top-level null yield no rows, as ParseListOfRecordsContent does.
+func streamJSONArray(r io.Reader, yield func(map[string]any) error) error {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err == io.EOF || (err == nil && tok == nil) {
+		return jsonExpectEnd(dec, "JSON list")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse JSON list: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("failed to parse JSON list: top-level value is not an array")
+	}
+	for dec.More() {
+		var row map[string]any
+		if err = dec.Decode(&row); err != nil {
+			return fmt.Errorf("failed to parse JSON list: %w", err)
+		}
+		if err = yield(row); err != nil {
+			return err
+		}
+	}
+	if _, err = dec.Token(); err != nil { // the closing ']'
+		return fmt.Errorf("failed to parse JSON list: %w", err)
+	}
+	return jsonExpectEnd(dec, "JSON list")
+}
+
+// streamJSONObject walks a top-level JSON object one member at a time; every
+// member value must itself be an object. A top-level null yields no records.
+func streamJSONObject(r io.Reader, yield func(string, map[string]any) error) error {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return fmt.Errorf("failed to parse JSON record: %w", io.ErrUnexpectedEOF)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse JSON record: %w", err)
+	}
+	if tok == nil {
+		return jsonExpectEnd(dec, "JSON record")
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("failed to parse JSON record: top-level value is not an object")
+	}
+	for dec.More() {
+		keyTok, keyErr := dec.Token()
+		if keyErr != nil {
+			return fmt.Errorf("failed to parse JSON record: %w", keyErr)
+		}
+		key, _ := keyTok.(string) // object keys are always strings
+		var value any
+		if err = dec.Decode(&value); err != nil {
+			return fmt.Errorf("failed to parse JSON record %q: %w", key, err)
+		}
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("record %q is not a map", key)
+		}
+		if err = yield(key, fields); err != nil {
+			return err
+		}
+	}
+	if _, err = dec.Token(); err != nil { // the closing '}'
+		return fmt.Errorf("failed to parse JSON record: %w", err)
+	}
+	return jsonExpectEnd(dec, "JSON record")
+}
+
+// jsonExpectEnd rejects data after the top-level value, as json.Unmarshal does.
+func jsonExpectEnd(dec *json.Decoder, what string) error {
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("invalid data after top-level value")
+		}
+		return fmt.Errorf("failed to parse %s: %w", what, err)
+	}
+	return nil
+}
+
+// streamINGRRows decodes INGR records one at a time and passes each with its
+// $ID. A record missing $ID, with a non-string $ID, or repeating an earlier
+// $ID is malformed.
+func streamINGRRows(r io.Reader, yield func(id string, row map[string]any) error) error {
+	dec := ingr.NewDecoder(r)
+	seen := make(map[string]bool)
+	for i := 0; dec.More(); i++ {
+		row := make(map[string]any)
+		if err := dec.Decode(&row); err != nil {
+			return fmt.Errorf("failed to parse INGR records: %w", err)
+		}
+		raw, ok := row["$ID"]
+		if !ok {
+			return fmt.Errorf("INGR record at index %d is missing required $ID column", i)
+		}
+		id, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("INGR record at index %d has non-string $ID value (%T)", i, raw)
+		}
+		if seen[id] {
+			return fmt.Errorf("INGR record has duplicate $ID %q", id)
+		}
+		seen[id] = true
+		if err := yield(id, row); err != nil {
+			return err
+		}
+	}
+	// More reports false both at the end of the stream and on a sticky error
+	// (bad header, footer count mismatch); Decode surfaces the latter.
+	if err := dec.Decode(nil); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to parse INGR records: %w", err)
+	}
+	return nil
+}
+
+// ingrRecordFields returns an INGR row's fields without its $ID key column.
+func ingrRecordFields(row map[string]any) map[string]any {
+	fields := make(map[string]any, len(row)-1)
+	for k, v := range row {
+		if k != "$ID" {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// streamYAMLSequence decodes a block-style top-level YAML sequence one item at
+// a time. From the first item that defines an anchor on, the rest of the
+// sequence is decoded together so later items can alias it.
+func streamYAMLSequence(r io.Reader, yield func(map[string]any) error) error {
+	return streamYAMLBlocks(r, isYAMLSequenceItem,
+		func(content []byte) error {
+			rows, err := parseYAMLList(content)
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				if err = yield(row); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func(chunk []byte, line int) error {
+			var rows []map[string]any
+			if err := yaml.Unmarshal(chunk, &rows); err != nil {
+				return fmt.Errorf("failed to parse YAML list item at line %d: %w", line, err)
+			}
+			for _, row := range rows {
+				if err := yield(row); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+// streamYAMLMapping decodes a block-style top-level YAML mapping one key at a
+// time, with the same anchor handling as streamYAMLSequence.
+func streamYAMLMapping(r io.Reader, yield func(string, map[string]any) error) error {
+	yieldRaw := func(raw map[string]any) error {
+		for key, value := range raw {
+			fields, ok := value.(map[string]any)
+			if !ok {
+				return fmt.Errorf("record %q is not a map", key)
+			}
+			if err := yield(key, fields); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return streamYAMLBlocks(r, isYAMLMappingKey,
+		func(content []byte) error {
+			raw, err := ParseRecordContent(content, RecordFormatYAML)
+			if err != nil {
+				return err
+			}
+			return yieldRaw(raw)
+		},
+		func(chunk []byte, line int) error {
+			var raw map[string]any
+			if err := yaml.Unmarshal(chunk, &raw); err != nil {
+				return fmt.Errorf("failed to parse YAML record at line %d: %w", line, err)
+			}
+			return yieldRaw(raw)
+		})
+}
+
+// streamYAMLBlocks splits a YAML document into the top-level blocks that begin
+// at column zero on lines accepted by isStart, calling decodeChunk for each
+// with the block's first line number. Comments and blank lines ride along with
+// the block they follow. If the first significant line does not start a block,
+// the document is not in the shape streaming supports and decodeAll gets the
+// whole content instead. A document end or second document stops the stream,
+// matching yaml.Unmarshal, which reads the first document only.
+//
+// An alias can only be resolved within the chunk that holds its anchor, so
+// once a block defines an anchor, that block and everything after it are
+// passed to decodeChunk as a single chunk.
+func streamYAMLBlocks(
+	r io.Reader,
+	isStart func(line []byte) bool,
+	decodeAll func(content []byte) error,
+	decodeChunk func(chunk []byte, line int) error,
+) error {
+	br := bufio.NewReader(r)
+	var chunk, head []byte
+	chunkLine := 0
+	started := false
+	for lineNum := 1; ; lineNum++ {
+		line, readErr := br.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+		trimmed := bytes.TrimRight(line, "\r\n")
+		switch {
+		case isYAMLDocumentMarker(trimmed, "---") && !started && len(bytes.TrimSpace(head)) == 0:
+			head = append(head, line...) // the document start before any content
+		case isYAMLDocumentMarker(trimmed, "---") || isYAMLDocumentMarker(trimmed, "..."):
+			if started {
+				return decodeChunk(chunk, chunkLine)
+			}
+			head = append(head, line...)
+		case len(trimmed) > 0 && trimmed[0] != ' ' && trimmed[0] != '\t' && trimmed[0] != '#' && isStart(trimmed):
+			if started {
+				if err := decodeChunk(chunk, chunkLine); err != nil {
+					return err
+				}
+			}
+			started = true
+			chunk, chunkLine = append(chunk[:0], line...), lineNum
+			if hasYAMLAnchor(trimmed) {
+				return decodeRest(br, chunk, chunkLine, decodeChunk)
+			}
+		case started:
+			chunk = append(chunk, line...)
+			if hasYAMLAnchor(trimmed) {
+				return decodeRest(br, chunk, chunkLine, decodeChunk)
+			}
+		default:
+			head = append(head, line...)
+			if isYAMLSignificant(trimmed) {
+				rest, err := io.ReadAll(br)
+				if err != nil {
+					return err
+				}
+				return decodeAll(append(head, rest...))
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+	}
+	if !started {
+		return decodeAll(head)
+	}
+	return decodeChunk(chunk, chunkLine)
+}
+
+// decodeRest appends the unread remainder of br to chunk and decodes it as one
+// chunk. A later document in the remainder is ignored by the decoder.
+func decodeRest(br *bufio.Reader, chunk []byte, line int, decodeChunk func(chunk []byte, line int) error) error {
+	rest, err := io.ReadAll(br)
+	if err != nil {
+		return err
+	}
+	return decodeChunk(append(chunk, rest...), line)
+}
+
+// hasYAMLAnchor reports whether a line may define an anchor: an "&" followed
+// by a name character. "&" inside a quoted or plain scalar ("AT&T") also
+// matches; that only costs streaming, never correctness.
+func hasYAMLAnchor(line []byte) bool {
+	for i := bytes.IndexByte(line, '&'); i >= 0 && i < len(line)-1; {
+		if c := line[i+1]; c != ' ' && c != '\t' {
+			return true
+		}
+		next := bytes.IndexByte(line[i+1:], '&')
+		if next < 0 {
+			return false
+		}
+		i += 1 + next
+	}
+	return false
+}
+
+func isYAMLDocumentMarker(line []byte, marker string) bool {
+	rest, ok := bytes.CutPrefix(line, []byte(marker))
+	return ok && len(bytes.TrimSpace(rest)) == 0
+}
+
+// isYAMLSignificant reports whether a line carries content, i.e. is neither
+// blank nor a comment.
+func isYAMLSignificant(line []byte) bool {
+	trimmed := bytes.TrimSpace(line)
+	return len(trimmed) > 0 && trimmed[0] != '#'
+}
+
+// isYAMLSequenceItem reports whether a column-zero line opens a block sequence
+// item ("-" followed by a space or nothing).
+func isYAMLSequenceItem(line []byte) bool {
+	return len(line) > 0 && line[0] == '-' && (len(line) == 1 || line[1] == ' ' || line[1] == '\t')
+}
+
+// isYAMLMappingKey reports whether a column-zero line opens a block mapping
+// entry. Flow collections, sequence items, explicit "?" keys and directives
+// are left to the whole-document decoder.
+func isYAMLMappingKey(line []byte) bool {
+	switch line[0] {
+	case '-', '[', '{', '?', ':', '%', '&', '*', '!', '|', '>':
+		return false
+	}
+	return bytes.Contains(line, []byte(":"))
+}
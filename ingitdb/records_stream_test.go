@@ -0,0 +1,215 @@
+package ingitdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func streamListIDs(t *testing.T, format RecordFormat, content string) ([]any, error) {
+	t.Helper()
+	col := &CollectionDef{ColumnsOrder: []string{"id"}, RecordFile: &RecordFileDef{Format: format}}
+	var ids []any
+	err := StreamListOfRecords(context.Background(), strings.NewReader(content), col, func(row map[string]any) error {
+		ids = append(ids, row["id"])
+		return nil
+	})
+	return ids, err
+}
+
+func TestStreamListOfRecords_Formats(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		format  RecordFormat
+		content string
+		want    []any
+	}{
+		{"json array", RecordFormatJSON, ` [ {"id":"a"}, {"id":"b"} ] `, []any{"a", "b"}},
+		{"json empty", RecordFormatJSON, "  \n", nil},
+		{"json null", RecordFormatJSON, "null", nil},
+		{"jsonl without trailing newline", RecordFormatJSONL, "{\"id\":\"a\"}\n\n{\"id\":\"b\"}", []any{"a", "b"}},
+		{"csv", RecordFormatCSV, "id\na\nb\n", []any{"a", "b"}},
+		{"yaml block sequence", RecordFormatYAML, "---\n# leading comment\n- id: a\n  tags:\n    - x\n\n# between\n- id: b\n", []any{"a", "b"}},
+		{"yaml multi-line scalar", RecordFormatYML, "- id: a\n  note: |\n    - not an item\n- id: b\n", []any{"a", "b"}},
+		{"yaml flow sequence falls back", RecordFormatYAML, "[{id: a}, {id: b}]\n", []any{"a", "b"}},
+		{"yaml stops at second document", RecordFormatYAML, "- id: a\n---\n- id: b\n", []any{"a"}},
+		{"yaml empty", RecordFormatYAML, "# nothing\n", nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ids, err := streamListIDs(t, tc.format, tc.content)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(ids, tc.want) {
+				t.Errorf("ids = %v, want %v", ids, tc.want)
+			}
+		})
+	}
+}
+
+func TestStreamListOfRecords_Errors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		format  RecordFormat
+		content string
+		wantErr string
+	}{
+		{"json trailing data", RecordFormatJSON, `[{"id":"a"}] x`, "failed to parse JSON list"},
+		{"json not an array", RecordFormatJSON, `{"id":"a"}`, "not an array"},
+		{"jsonl bad line", RecordFormatJSONL, "{\"id\":\"a\"}\n{bad\n", "JSONL line 2"},
+		{"yaml bad item", RecordFormatYAML, "- id: a\n- id: [b\n", "YAML list item at line 2"},
+		{"ingr duplicate $ID", RecordFormatINGR, "# INGR.io | test: $ID\n\"a\"\n\"a\"\n# 2 records\n", `duplicate $ID "a"`},
+		{"not a list format", RecordFormatTOML, "", "not a list-of-records format"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := streamListIDs(t, tc.format, tc.content)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestStreamListOfRecords_StopsOnYieldErrorAndCancel(t *testing.T) {
+	t.Parallel()
+
+	col := &CollectionDef{RecordFile: &RecordFileDef{Format: RecordFormatJSONL}}
+	content := "{\"id\":\"a\"}\n{\"id\":\"b\"}\n{\"id\":\"c\"}\n"
+
+	stop := errors.New("stop")
+	calls := 0
+	err := StreamListOfRecords(context.Background(), strings.NewReader(content), col, func(map[string]any) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("yield error: err=%v calls=%d, want stop after 1 call", err, calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = 0
+	err = StreamListOfRecords(ctx, strings.NewReader(content), col, func(map[string]any) error {
+		calls++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("cancel: err=%v calls=%d, want context.Canceled after 1 call", err, calls)
+	}
+}
+
+func TestStreamMapOfRecords(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		format   RecordFormat
+		content  string
+		wantKeys []string
+		wantErr  string
+	}{
+		{name: "json object", format: RecordFormatJSON, content: `{"b":{"v":1},"a":{"v":2}}`, wantKeys: []string{"b", "a"}},
+		{name: "json null", format: RecordFormatJSON, content: "null"},
+		{name: "json empty", format: RecordFormatJSON, content: "", wantErr: "unexpected EOF"},
+		{name: "json value not a map", format: RecordFormatJSON, content: `{"a":1}`, wantErr: `record "a" is not a map`},
+		{name: "json duplicate key", format: RecordFormatJSON, content: `{"a":{},"a":{}}`, wantErr: `duplicate record key "a"`},
+		{name: "yaml block mapping", format: RecordFormatYAML, content: "# c\nb:\n  v: 1\n\"a\":\n  v: 2\n", wantKeys: []string{"b", "a"}},
+		{name: "yaml flow mapping falls back", format: RecordFormatYAML, content: "{a: {v: 1}}\n", wantKeys: []string{"a"}},
+		{name: "yaml value not a map", format: RecordFormatYAML, content: "a: 1\n", wantErr: `record "a" is not a map`},
+		{name: "ingr strips $ID", format: RecordFormatINGR, content: "# INGR.io | test: $ID, v\n\"a\"\n1\n# 1 record\n", wantKeys: []string{"a"}},
+		{name: "toml read whole", format: RecordFormatTOML, content: "[a]\nv = 1\n", wantKeys: []string{"a"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var keys []string
+			err := StreamMapOfRecords(context.Background(), strings.NewReader(tc.content), tc.format, func(key string, fields map[string]any) error {
+				if _, hasID := fields["$ID"]; hasID {
+					t.Errorf("record %q: fields must not carry $ID", key)
+				}
+				keys = append(keys, key)
+				return nil
+			})
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(keys, tc.wantKeys) {
+				t.Errorf("keys = %v, want %v", keys, tc.wantKeys)
+			}
+		})
+	}
+}
+
+func TestYAMLAliasesAcrossRecords(t *testing.T) {
+	t.Parallel()
+
+	const list = "- id: z\n- &base\n  id: a\n  kind: x\n- <<: *base\n  id: b\n---\n- id: ignored\n"
+	const mapping = "z:\n  kind: w\na: &base\n  kind: x\nb:\n  <<: *base\n  size: 2\n"
+
+	t.Run("parse list", func(t *testing.T) {
+		t.Parallel()
+		rows, err := ParseListOfRecordsContent([]byte("- &base\n  id: a\n  kind: x\n- <<: *base\n  id: b\n"), RecordFormatYAML)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(rows) != 2 || rows[1]["id"] != "b" || rows[1]["kind"] != "x" {
+			t.Errorf("rows = %v, want b to inherit kind x", rows)
+		}
+	})
+	t.Run("stream list", func(t *testing.T) {
+		t.Parallel()
+		col := &CollectionDef{RecordFile: &RecordFileDef{Format: RecordFormatYAML}}
+		var got []string
+		err := StreamListOfRecords(context.Background(), strings.NewReader(list), col, func(row map[string]any) error {
+			got = append(got, fmt.Sprintf("%v:%v", row["id"], row["kind"]))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := []string{"z:<nil>", "a:x", "b:x"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("rows = %v, want %v", got, want)
+		}
+	})
+	t.Run("stream map", func(t *testing.T) {
+		t.Parallel()
+		got := make(map[string]string)
+		err := StreamMapOfRecords(context.Background(), strings.NewReader(mapping), RecordFormatYAML, func(key string, fields map[string]any) error {
+			got[key] = fmt.Sprintf("%v", fields["kind"])
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := map[string]string{"z": "w", "a": "x", "b": "x"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("records = %v, want %v", got, want)
+		}
+	})
+	t.Run("parse map", func(t *testing.T) {
+		t.Parallel()
+		records, err := ParseMapOfRecordsContent([]byte(mapping), RecordFormatYAML)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if records["b"]["kind"] != "x" || records["b"]["size"] != 2 {
+			t.Errorf("b = %v, want kind x and size 2", records["b"])
+		}
+	})
+}
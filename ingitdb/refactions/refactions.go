@@ -0,0 +1,405 @@
+// Package refactions applies foreign-key referential actions — the on_delete
+// declared next to a foreign key — when a record is deleted: referencing
+// records are deleted (cascade), have the reference cleared (set_null), or
+// make the deletion fail (restrict, the default).
+//
+// Root collections are the only ones read and written: a record that
+// references the deleted one from a subcollection is not reached. Incremental
+// validation still reports it as an orphan.
+package refactions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// Step is one change a deletion makes to the database.
+type Step struct {
+	CollectionID string
+	RecordKey    string
+	// Cleared lists the columns set_null cleared (or, for a list column,
+	// removed the key from). It is empty when the record is deleted.
+	Cleared []string
+}
+
+// Plan is the full effect of deleting a record: the record itself first,
+// then every cascaded deletion and cleared reference, in the order they were
+// found.
+type Plan struct {
+	Steps []Step
+}
+
+// Reference is a record that references another through a foreign key.
+type Reference struct {
+	CollectionID string
+	RecordKey    string
+	Columns      []string
+}
+
+// RestrictError is returned when a deletion would orphan a record whose
+// foreign key is on_delete: restrict. Nothing is changed.
+type RestrictError struct {
+	CollectionID string
+	RecordKey    string
+	References   []Reference
+}
+
+func (e *RestrictError) Error() string {
+	refs := make([]string, len(e.References))
+	for i, ref := range e.References {
+		refs[i] = fmt.Sprintf("%s/%s (%s)", ref.CollectionID, ref.RecordKey, strings.Join(ref.Columns, ", "))
+	}
+	return fmt.Sprintf("cannot delete %s/%s: still referenced by %s",
+		e.CollectionID, e.RecordKey, strings.Join(refs, ", "))
+}
+
+// PlanDelete works out what deleting recordKey from collectionID entails,
+// without changing anything. It fails with a *RestrictError when a restrict
+// foreign key would be left dangling, counting only references from records
+// that are not themselves deleted by the cascade.
+func PlanDelete(def *ingitdb.Definition, collectionID, recordKey string) (*Plan, error) {
+	plan, _, err := planDelete(def, collectionID, recordKey)
+	return plan, err
+}
+
+// DeleteRecord deletes recordKey from collectionID and applies every
+// referential action the deletion triggers, returning what it did. Affected
+// record files are rewritten in their declared formats; when a restrict
+// foreign key refuses the deletion nothing is written.
+//
+// Every affected file is encoded before any is written, so a collection that
+// cannot be encoded leaves the database as it was. Writing itself is not
+// atomic across files: an I/O error part way through can leave some files
+// rewritten. Run it in a clean git work tree to be able to undo.
+func DeleteRecord(ctx context.Context, def *ingitdb.Definition, collectionID, recordKey string) (*Plan, error) {
+	plan, st, err := planDelete(def, collectionID, recordKey)
+	if err != nil {
+		return nil, err
+	}
+	if err = st.write(ctx); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func planDelete(def *ingitdb.Definition, collectionID, recordKey string) (*Plan, *store, error) {
+	if def == nil || def.Collections[collectionID] == nil {
+		return nil, nil, fmt.Errorf("unknown collection %q", collectionID)
+	}
+	st := newStore(def)
+	target, err := st.collection(collectionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := target.records[recordKey]; !ok {
+		return nil, nil, fmt.Errorf("record %q not found in collection %q", recordKey, collectionID)
+	}
+
+	type pending struct{ collectionID, key string }
+	plan := &Plan{}
+	queue := []pending{{collectionID, recordKey}}
+	st.delete(collectionID, recordKey)
+	plan.Steps = append(plan.Steps, Step{CollectionID: collectionID, RecordKey: recordKey})
+	var restricted []Reference
+
+	ids := slices.Sorted(maps.Keys(def.Collections))
+	for len(queue) > 0 {
+		deleted := queue[0]
+		queue = queue[1:]
+		for _, id := range ids {
+			for _, ref := range ingitdb.ForeignKeyRefs(id, def.Collections[id], def.Collections) {
+				if ref.Target != deleted.collectionID {
+					continue
+				}
+				referencing, loadErr := st.collection(id)
+				if loadErr != nil {
+					return nil, nil, loadErr
+				}
+				for _, key := range referencing.order {
+					data, live := referencing.records[key]
+					if !live || !slices.Contains(ref.Keys(data), deleted.key) {
+						continue
+					}
+					switch ref.Action() {
+					case ingitdb.OnDeleteCascade:
+						st.delete(id, key)
+						plan.Steps = append(plan.Steps, Step{CollectionID: id, RecordKey: key})
+						queue = append(queue, pending{id, key})
+					case ingitdb.OnDeleteSetNull:
+						clearReference(data, ref, deleted.key)
+						st.touch(id, key)
+						plan.Steps = append(plan.Steps, Step{CollectionID: id, RecordKey: key, Cleared: ref.Columns})
+					default:
+						restricted = append(restricted, Reference{CollectionID: id, RecordKey: key, Columns: ref.Columns})
+					}
+				}
+			}
+		}
+	}
+
+	// A restrict reference from a record the cascade deletes anyway is moot.
+	var blocking []Reference
+	for _, ref := range restricted {
+		if _, live := st.collections[ref.CollectionID].records[ref.RecordKey]; live {
+			blocking = append(blocking, ref)
+		}
+	}
+	if len(blocking) > 0 {
+		return nil, nil, &RestrictError{CollectionID: collectionID, RecordKey: recordKey, References: blocking}
+	}
+	return plan, st, nil
+}
+
+// clearReference applies set_null to one record: a list column drops the
+// deleted key, any other foreign key has its column(s) set to null.
+func clearReference(data map[string]any, ref ingitdb.ForeignKeyRef, deletedKey string) {
+	if !ref.Composite {
+		switch v := data[ref.Columns[0]].(type) {
+		case []any:
+			data[ref.Columns[0]] = slices.DeleteFunc(slices.Clone(v), func(e any) bool {
+				return e != nil && fmt.Sprintf("%v", e) == deletedKey
+			})
+			return
+		case []string:
+			data[ref.Columns[0]] = slices.DeleteFunc(slices.Clone(v), func(e string) bool { return e == deletedKey })
+			return
+		}
+	}
+	for _, name := range ref.Columns {
+		data[name] = nil
+	}
+}
+
+// store holds the records of the collections a deletion touches, loaded on
+// first use and written back by write.
+type store struct {
+	def         *ingitdb.Definition
+	collections map[string]*collectionRecords
+}
+
+// collectionRecords is one collection's records. For a single-record layout
+// files maps each key to its file; deleted records are removed from records
+// but stay in order, so a rewritten list keeps its row order.
+type collectionRecords struct {
+	def     *ingitdb.CollectionDef
+	order   []string
+	records map[string]map[string]any
+	files   map[string]string
+	deleted []string
+	touched map[string]bool // single-record keys to rewrite
+	dirty   bool
+}
+
+func newStore(def *ingitdb.Definition) *store {
+	return &store{def: def, collections: map[string]*collectionRecords{}}
+}
+
+func (s *store) collection(id string) (*collectionRecords, error) {
+	if c, ok := s.collections[id]; ok {
+		return c, nil
+	}
+	colDef := s.def.Collections[id]
+	c := &collectionRecords{
+		def:     colDef,
+		records: map[string]map[string]any{},
+		files:   map[string]string{},
+		touched: map[string]bool{},
+	}
+	if err := c.load(); err != nil {
+		return nil, fmt.Errorf("collection %q: %w", id, err)
+	}
+	s.collections[id] = c
+	return c, nil
+}
+
+func (s *store) delete(id, key string) {
+	c := s.collections[id]
+	delete(c.records, key)
+	c.deleted = append(c.deleted, key)
+	c.dirty = true
+}
+
+// touch marks a record modified, so write rewrites its file.
+func (s *store) touch(id, key string) {
+	c := s.collections[id]
+	c.dirty = true
+	c.touched[key] = true
+}
+
+func (c *collectionRecords) add(key string, data map[string]any) {
+	c.order = append(c.order, key)
+	c.records[key] = data
+}
+
+func (c *collectionRecords) load() error {
+	rfd := c.def.RecordFile
+	if rfd == nil {
+		return nil
+	}
+	baseDir := filepath.Join(c.def.DirPath, rfd.RecordsBasePath())
+	switch rfd.RecordType {
+	case ingitdb.SingleRecord:
+		prefix, suffix, templated := strings.Cut(rfd.Name, "{key}")
+		if !templated {
+			return nil // a single fixed file holds no keyed records to reference
+		}
+		matches, err := filepath.Glob(filepath.Join(baseDir, prefix+"*"+suffix))
+		if err != nil {
+			return err
+		}
+		for _, path := range matches {
+			name := filepath.Base(path)
+			if strings.HasPrefix(name, ".") || rfd.IsExcluded(name) {
+				continue
+			}
+			content, readErr := os.ReadFile(path)
+			if readErr != nil {
+				return readErr
+			}
+			data, parseErr := ingitdb.ParseRecordContentForCollection(content, c.def)
+			if parseErr != nil {
+				return fmt.Errorf("%s: %w", path, parseErr)
+			}
+			key := strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix)
+			c.files[key] = path
+			c.add(key, data)
+		}
+	case ingitdb.MapOfRecords:
+		content, err := readIfExists(filepath.Join(baseDir, rfd.Name))
+		if err != nil || content == nil {
+			return err
+		}
+		records, err := ingitdb.ParseMapOfRecordsContent(content, rfd.Format)
+		if err != nil {
+			return err
+		}
+		for _, key := range slices.Sorted(maps.Keys(records)) {
+			c.add(key, records[key])
+		}
+	case ingitdb.ListOfRecords:
+		content, err := readIfExists(filepath.Join(baseDir, rfd.Name))
+		if err != nil || content == nil {
+			return err
+		}
+		return ingitdb.StreamListOfRecords(context.Background(), bytes.NewReader(content), c.def, func(row map[string]any) error {
+			if key, ok := ingitdb.ResolveListRecordKey(row, c.def); ok {
+				c.add(key, row)
+			}
+			return nil
+		})
+	}
+	return nil
+}
+
+func readIfExists(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return content, err
+}
+
+// write persists every modified collection. Every collection is encoded
+// before any file is touched, so a collection that cannot be encoded leaves
+// the database as it was; files are then written in collection id order.
+func (s *store) write(ctx context.Context) error {
+	var changes []fileChange
+	for _, id := range slices.Sorted(maps.Keys(s.collections)) {
+		c := s.collections[id]
+		if !c.dirty {
+			continue
+		}
+		collectionChanges, err := c.encode()
+		if err != nil {
+			return fmt.Errorf("collection %q: %w", id, err)
+		}
+		changes = append(changes, collectionChanges...)
+	}
+	for _, change := range changes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := change.apply(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileChange is one file a collection's changes rewrite or remove.
+type fileChange struct {
+	path    string
+	content []byte
+	remove  bool
+}
+
+func (f fileChange) apply() error {
+	if f.remove {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(f.path, f.content, 0o644)
+}
+
+// encode returns the file changes that persist c, encoded the way
+// recordmerge.EncodeMerged encodes each layout and format.
+func (c *collectionRecords) encode() ([]fileChange, error) {
+	rfd := c.def.RecordFile
+	path := filepath.Join(c.def.DirPath, rfd.RecordsBasePath(), rfd.Name)
+	switch {
+	case rfd.RecordType == ingitdb.SingleRecord:
+		changes := make([]fileChange, 0, len(c.deleted)+len(c.touched))
+		for _, key := range c.deleted {
+			changes = append(changes, fileChange{path: c.files[key], remove: true})
+		}
+		for _, key := range slices.Sorted(maps.Keys(c.touched)) {
+			data, live := c.records[key]
+			if !live {
+				continue
+			}
+			content, err := ingitdb.EncodeRecordContentForCollection(data, c.def)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, fileChange{path: c.files[key], content: content})
+		}
+		return changes, nil
+	case rfd.RecordType == ingitdb.MapOfRecords,
+		rfd.RecordType == ingitdb.ListOfRecords && rfd.Format == ingitdb.RecordFormatINGR:
+		// INGR lists are keyed by $ID and encode as maps.
+		content, err := ingitdb.EncodeMapOfRecordsContent(c.records, rfd.Format, c.def.ID, c.def.ColumnsOrder)
+		if err != nil {
+			return nil, err
+		}
+		return []fileChange{{path: path, content: content}}, nil
+	case rfd.RecordType == ingitdb.ListOfRecords:
+		rows := make([]map[string]any, 0, len(c.records))
+		for _, key := range c.order {
+			if data, live := c.records[key]; live {
+				rows = append(rows, data)
+			}
+		}
+		var content []byte
+		var err error
+		if rfd.Format == ingitdb.RecordFormatCSV {
+			content, err = ingitdb.EncodeRecordContentForCollection(rows, c.def)
+		} else {
+			content, err = ingitdb.EncodeListOfRecordsContent(rows, rfd.Format, c.def.ColumnsOrder)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []fileChange{{path: path, content: content}}, nil
+	}
+	return nil, nil
+}
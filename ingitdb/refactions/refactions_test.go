@@ -0,0 +1,207 @@
+package refactions
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testDef builds countries (single-record YAML files), cities (a map file
+// referencing countries), offices (a list referencing cities) and posts (a map
+// with a list column referencing countries).
+func testDef(t *testing.T, cityOnDelete, officeOnDelete, postOnDelete ingitdb.OnDeleteAction) (string, *ingitdb.Definition) {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "countries", "$records", "ie.yaml"), "name: Ireland\n")
+	writeFile(t, filepath.Join(dir, "countries", "$records", "fr.yaml"), "name: France\n")
+	writeFile(t, filepath.Join(dir, "cities", "cities.yaml"),
+		"dub:\n  country: ie\ncork:\n  country: ie\nparis:\n  country: fr\n")
+	writeFile(t, filepath.Join(dir, "offices", "offices.yaml"),
+		"- id: o1\n  city: dub\n- id: o2\n  city: paris\n")
+	writeFile(t, filepath.Join(dir, "posts", "posts.yaml"),
+		"p1:\n  countries: [ie, fr]\n")
+	str := ingitdb.ColumnTypeString
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"countries": {
+			ID: "countries", DirPath: filepath.Join(dir, "countries"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord},
+			Columns:    map[string]*ingitdb.ColumnDef{"name": {Type: str}},
+		},
+		"cities": {
+			ID: "cities", DirPath: filepath.Join(dir, "cities"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "cities.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords},
+			Columns:    map[string]*ingitdb.ColumnDef{"country": {Type: str, ForeignKey: "countries", OnDelete: cityOnDelete}},
+		},
+		"offices": {
+			ID: "offices", DirPath: filepath.Join(dir, "offices"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "offices.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.ListOfRecords},
+			Columns:    map[string]*ingitdb.ColumnDef{"id": {Type: str}, "city": {Type: str, ForeignKey: "cities", OnDelete: officeOnDelete}},
+		},
+		"posts": {
+			ID: "posts", DirPath: filepath.Join(dir, "posts"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "posts.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords},
+			Columns:    map[string]*ingitdb.ColumnDef{"countries": {Type: "[]string", ForeignKey: "countries", OnDelete: postOnDelete}},
+		},
+	}}
+	return dir, def
+}
+
+func TestDeleteRecord_CascadeAndSetNull(t *testing.T) {
+	t.Parallel()
+
+	dir, def := testDef(t, ingitdb.OnDeleteCascade, ingitdb.OnDeleteSetNull, ingitdb.OnDeleteSetNull)
+	plan, err := DeleteRecord(context.Background(), def, "countries", "ie")
+	if err != nil {
+		t.Fatalf("DeleteRecord: %v", err)
+	}
+	want := []Step{
+		{CollectionID: "countries", RecordKey: "ie"},
+		{CollectionID: "cities", RecordKey: "cork"},
+		{CollectionID: "cities", RecordKey: "dub"},
+		{CollectionID: "posts", RecordKey: "p1", Cleared: []string{"countries"}},
+		{CollectionID: "offices", RecordKey: "o1", Cleared: []string{"city"}},
+	}
+	if !reflect.DeepEqual(plan.Steps, want) {
+		t.Errorf("steps = %+v, want %+v", plan.Steps, want)
+	}
+
+	if _, statErr := os.Stat(filepath.Join(dir, "countries", "$records", "ie.yaml")); !os.IsNotExist(statErr) {
+		t.Errorf("ie.yaml must be removed, stat err = %v", statErr)
+	}
+	cities, _ := os.ReadFile(filepath.Join(dir, "cities", "cities.yaml"))
+	if strings.Contains(string(cities), "dub") || strings.Contains(string(cities), "cork") || !strings.Contains(string(cities), "paris") {
+		t.Errorf("cities must keep only paris, got:\n%s", cities)
+	}
+	offices, _ := os.ReadFile(filepath.Join(dir, "offices", "offices.yaml"))
+	rows, err := ingitdb.ParseListOfRecordsContent(offices, ingitdb.RecordFormatYAML)
+	if err != nil || len(rows) != 2 || rows[0]["city"] != nil || rows[1]["city"] != "paris" {
+		t.Errorf("o1's city must be cleared and o2 kept, got %v (%v)", rows, err)
+	}
+	posts, _ := os.ReadFile(filepath.Join(dir, "posts", "posts.yaml"))
+	byKey, err := ingitdb.ParseMapOfRecordsContent(posts, ingitdb.RecordFormatYAML)
+	if err != nil || !reflect.DeepEqual(ingitdb.ForeignKeyValues(byKey["p1"]["countries"]), []string{"fr"}) {
+		t.Errorf("set_null on a list column must drop only the deleted key, got %v (%v)", byKey, err)
+	}
+}
+
+// A cascade into and out of a CSV list rewrites the CSV file; nothing is
+// written until every changed collection has been encoded.
+func TestDeleteRecord_CSVList(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "countries", "countries.csv"), "id,name\nie,Ireland\nfr,France\n")
+	writeFile(t, filepath.Join(dir, "cities", "cities.yaml"), "dub:\n  country: ie\nparis:\n  country: fr\n")
+	str := ingitdb.ColumnTypeString
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"countries": {
+			ID: "countries", DirPath: filepath.Join(dir, "countries"),
+			RecordFile:   &ingitdb.RecordFileDef{Name: "countries.csv", Format: ingitdb.RecordFormatCSV, RecordType: ingitdb.ListOfRecords},
+			Columns:      map[string]*ingitdb.ColumnDef{"id": {Type: str}, "name": {Type: str}},
+			ColumnsOrder: []string{"id", "name"},
+		},
+		"cities": {
+			ID: "cities", DirPath: filepath.Join(dir, "cities"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "cities.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords},
+			Columns:    map[string]*ingitdb.ColumnDef{"country": {Type: str, ForeignKey: "countries", OnDelete: ingitdb.OnDeleteCascade}},
+		},
+	}}
+	if _, err := DeleteRecord(context.Background(), def, "countries", "ie"); err != nil {
+		t.Fatalf("DeleteRecord: %v", err)
+	}
+	countries, _ := os.ReadFile(filepath.Join(dir, "countries", "countries.csv"))
+	if string(countries) != "id,name\nfr,France\n" {
+		t.Errorf("countries.csv =\n%s", countries)
+	}
+	cities, _ := os.ReadFile(filepath.Join(dir, "cities", "cities.yaml"))
+	if strings.Contains(string(cities), "dub") || !strings.Contains(string(cities), "paris") {
+		t.Errorf("cities must keep only paris, got:\n%s", cities)
+	}
+}
+
+// An encode failure in one collection leaves every file as it was.
+func TestDeleteRecord_EncodeFailureWritesNothing(t *testing.T) {
+	t.Parallel()
+
+	dir, def := testDef(t, ingitdb.OnDeleteCascade, ingitdb.OnDeleteSetNull, ingitdb.OnDeleteSetNull)
+	_, st, err := planDelete(def, "countries", "ie")
+	if err != nil {
+		t.Fatalf("planDelete: %v", err)
+	}
+	// offices is written after cities and countries; make its rows
+	// impossible to encode once they have been read.
+	offices := st.collections["offices"]
+	bad := *offices.def
+	rfd := *bad.RecordFile
+	rfd.Format = "xml"
+	bad.RecordFile = &rfd
+	offices.def = &bad
+
+	before, _ := os.ReadFile(filepath.Join(dir, "cities", "cities.yaml"))
+	if err = st.write(context.Background()); err == nil {
+		t.Fatal("expected an encode error for offices")
+	}
+	if after, _ := os.ReadFile(filepath.Join(dir, "cities", "cities.yaml")); string(after) != string(before) {
+		t.Errorf("cities.yaml changed:\n%s", after)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "countries", "$records", "ie.yaml")); err != nil {
+		t.Errorf("ie.yaml must be kept: %v", err)
+	}
+}
+
+func TestPlanDelete_Restrict(t *testing.T) {
+	t.Parallel()
+
+	dir, def := testDef(t, ingitdb.OnDeleteCascade, "", ingitdb.OnDeleteSetNull)
+	_, err := PlanDelete(def, "countries", "ie")
+	var restrictErr *RestrictError
+	if !errors.As(err, &restrictErr) {
+		t.Fatalf("expected a RestrictError, got %v", err)
+	}
+	want := []Reference{{CollectionID: "offices", RecordKey: "o1", Columns: []string{"city"}}}
+	if !reflect.DeepEqual(restrictErr.References, want) {
+		t.Errorf("references = %+v, want %+v", restrictErr.References, want)
+	}
+	if _, err = DeleteRecord(context.Background(), def, "countries", "ie"); err == nil {
+		t.Fatal("DeleteRecord must refuse a restricted deletion")
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "countries", "$records", "ie.yaml")); statErr != nil {
+		t.Errorf("a refused deletion must not change anything: %v", statErr)
+	}
+
+	// A restrict reference from a record the cascade deletes is moot.
+	if _, err = PlanDelete(def, "countries", "fr"); err == nil {
+		t.Fatal("paris is referenced by o2 (restrict), deleting fr must be refused")
+	}
+	if _, err = PlanDelete(def, "cities", "cork"); err != nil {
+		t.Errorf("cork is not referenced, got: %v", err)
+	}
+}
+
+func TestPlanDelete_UnknownRecord(t *testing.T) {
+	t.Parallel()
+
+	_, def := testDef(t, "", "", "")
+	if _, err := PlanDelete(def, "countries", "de"); err == nil || !strings.Contains(err.Error(), `record "de" not found`) {
+		t.Errorf("expected a not-found error, got %v", err)
+	}
+	if _, err := PlanDelete(def, "planets", "earth"); err == nil || !strings.Contains(err.Error(), `unknown collection "planets"`) {
+		t.Errorf("expected an unknown-collection error, got %v", err)
+	}
+}
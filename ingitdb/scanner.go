@@ -5,5 +5,14 @@ import "context"
 // Scanner orchestrates the full pipeline: walk filesystem, invoke Validator and ViewBuilder.
 type Scanner interface {
 	// Scan walks dbPath, validates all records, and rebuilds all views.
-	Scan(ctx context.Context, dbPath string, def *Definition) error
+	Scan(ctx context.Context, dbPath string, def *Definition) (*ScanReport, error)
+}
+
+// ScanReport combines the outcomes of a Scan's validation and materialization.
+type ScanReport struct {
+	Validation  *ValidationResult
+	Materialize *MaterializeResult
+	// SkippedCollections lists, sorted, the collections whose views were not
+	// rebuilt because their records failed validation.
+	SkippedCollections []string
 }
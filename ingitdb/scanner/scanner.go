@@ -0,0 +1,132 @@
+// Package scanner provides the default ingitdb.Scanner: one pass over the
+// database that validates every record and rebuilds every view.
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/datavalidator"
+	"github.com/ingitdb/ingitdb-go/ingitdb/materializer"
+)
+
+// Option configures a scanner built by NewScanner.
+type Option func(*scanner)
+
+// SkipViewsOnInvalid makes Scan leave the views of a collection untouched when
+// any of its records failed validation, so views never publish data the
+// schema rejects. Without it, views are rebuilt from whatever records parsed.
+func SkipViewsOnInvalid() Option {
+	return func(s *scanner) { s.skipInvalid = true }
+}
+
+// WithRepoRoot sets the repository root used to resolve views' output paths
+// relative to the repository; it defaults to dbPath.
+func WithRepoRoot(repoRoot string) Option {
+	return func(s *scanner) { s.repoRoot = repoRoot }
+}
+
+// WithViewWriter replaces the ViewWriter, e.g. to capture output in tests.
+func WithViewWriter(w materializer.ViewWriter) Option {
+	return func(s *scanner) { s.writer = w }
+}
+
+// WithValidatorOptions adds opts to those Scan validates with, for example
+// datavalidator.WithConcurrency or datavalidator.WithCache.
+func WithValidatorOptions(opts ...datavalidator.ValidatorOption) Option {
+	return func(s *scanner) { s.validatorOpts = append(s.validatorOpts, opts...) }
+}
+
+// WithLogf sets the logger passed to the view builder.
+func WithLogf(logf func(format string, args ...any)) Option {
+	return func(s *scanner) { s.logf = logf }
+}
+
+// NewScanner returns the default Scanner.
+func NewScanner(opts ...Option) ingitdb.Scanner {
+	s := &scanner{
+		defReader: materializer.FileViewDefReader{},
+		writer:    materializer.NewFileViewWriter(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+type scanner struct {
+	defReader     materializer.ViewDefReader
+	writer        materializer.ViewWriter
+	logf          func(format string, args ...any)
+	repoRoot      string
+	skipInvalid   bool
+	validatorOpts []datavalidator.ValidatorOption
+}
+
+// Scan reads each root collection once. The records parsed for validation are
+// handed, as they are, to view building, so no record file is parsed twice.
+// Validation is the same as datavalidator.NewValidator's: record files are
+// validated in parallel, cached findings are reused when enabled, and findings
+// are sorted.
+//
+// Validation runs to completion — schema, subcollection and foreign-key
+// passes — before any view is built, so SkipViewsOnInvalid also accounts for
+// broken references. Subcollections have no views of their own and are read
+// only by the validation passes.
+func (s *scanner) Scan(ctx context.Context, dbPath string, def *ingitdb.Definition) (*ingitdb.ScanReport, error) {
+	report := &ingitdb.ScanReport{
+		Validation:  &ingitdb.ValidationResult{},
+		Materialize: &ingitdb.MaterializeResult{},
+	}
+	validation, parsed, err := datavalidator.ValidateParsed(ctx, dbPath, def, s.validatorOpts...)
+	if validation != nil {
+		report.Validation = validation
+	}
+	if err != nil {
+		return report, err
+	}
+
+	failed := make(map[string]bool)
+	for _, e := range report.Validation.Errors() {
+		failed[e.CollectionID] = true
+	}
+	repoRoot := s.repoRoot
+	if repoRoot == "" {
+		repoRoot = dbPath
+	}
+	for _, id := range slices.Sorted(maps.Keys(def.Collections)) {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if s.skipInvalid && failed[id] {
+			report.SkippedCollections = append(report.SkippedCollections, id)
+			continue
+		}
+		col := def.Collections[id]
+		entries, err := materializer.EntriesFromParsed(col, parsed[id])
+		if err != nil {
+			report.Materialize.Errors = append(report.Materialize.Errors, fmt.Errorf("collection %s: %w", id, err))
+			continue
+		}
+		builder := materializer.SimpleViewBuilder{
+			DefReader:     s.defReader,
+			RecordsReader: materializer.StaticRecordsReader(entries),
+			Writer:        s.writer,
+			Logf:          s.logf,
+		}
+		res, err := builder.BuildViews(ctx, dbPath, repoRoot, col, def)
+		if err != nil {
+			report.Materialize.Errors = append(report.Materialize.Errors, fmt.Errorf("collection %s: %w", id, err))
+			continue
+		}
+		report.Materialize.FilesCreated += res.FilesCreated
+		report.Materialize.FilesUpdated += res.FilesUpdated
+		report.Materialize.FilesUnchanged += res.FilesUnchanged
+		report.Materialize.FilesDeleted += res.FilesDeleted
+		report.Materialize.Errors = append(report.Materialize.Errors, res.Errors...)
+	}
+	return report, nil
+}
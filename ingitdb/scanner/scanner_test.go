@@ -0,0 +1,167 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/datavalidator"
+	"github.com/ingitdb/ingitdb-go/ingitdb/materializer"
+)
+
+type capturedView struct {
+	collection string
+	view       string
+	records    []ingitdb.IRecordEntry
+}
+
+type capturingWriter struct {
+	mu    sync.Mutex
+	views []capturedView
+}
+
+func (w *capturingWriter) WriteView(
+	_ context.Context,
+	col *ingitdb.CollectionDef,
+	view *ingitdb.ViewDef,
+	records []ingitdb.IRecordEntry,
+	_ string,
+) (materializer.WriteOutcome, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.views = append(w.views, capturedView{collection: col.ID, view: view.ID, records: records})
+	return materializer.WriteOutcomeCreated, nil
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("setup: mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("setup: write: %v", err)
+	}
+}
+
+// scanTestDef lays out two collections, each with one named view:
+// "countries" (all records valid) and "cities" (one record has a wrong type).
+func scanTestDef(t *testing.T) (string, *ingitdb.Definition) {
+	t.Helper()
+	dbPath := t.TempDir()
+	writeFile(t, filepath.Join(dbPath, "countries", "$records", "ie.yaml"), "name: Ireland\n")
+	writeFile(t, filepath.Join(dbPath, "countries", "$records", "fr.yaml"), "name: France\n")
+	writeFile(t, filepath.Join(dbPath, "cities", "cities.yaml"), "dublin:\n  name: Dublin\nparis:\n  name: 42\n")
+	view := func(id string) map[string]*ingitdb.ViewDef {
+		return map[string]*ingitdb.ViewDef{id: {ID: id, Columns: []string{"name"}, OrderBy: "name"}}
+	}
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"countries": {
+			ID:      "countries",
+			DirPath: filepath.Join(dbPath, "countries"),
+			RecordFile: &ingitdb.RecordFileDef{
+				Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord,
+			},
+			Columns: map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}},
+			Views:   view("all_countries"),
+		},
+		"cities": {
+			ID:      "cities",
+			DirPath: filepath.Join(dbPath, "cities"),
+			RecordFile: &ingitdb.RecordFileDef{
+				Name: "cities.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords,
+			},
+			Columns: map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}},
+			Views:   view("all_cities"),
+		},
+	}}
+	return dbPath, def
+}
+
+func TestScan_ValidatesAndMaterializes(t *testing.T) {
+	t.Parallel()
+
+	dbPath, def := scanTestDef(t)
+	writer := &capturingWriter{}
+	report, err := NewScanner(WithViewWriter(writer)).Scan(context.Background(), dbPath, def)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if report.Validation.ErrorCount() != 1 {
+		t.Errorf("expected 1 validation error, got %v", report.Validation.Errors())
+	}
+	if passed, total := report.Validation.GetRecordCounts("cities"); passed != 1 || total != 2 {
+		t.Errorf("cities counts = %d/%d, want 1/2", passed, total)
+	}
+	if report.Materialize.FilesCreated != 2 || len(report.Materialize.Errors) != 0 {
+		t.Errorf("unexpected materialize result: %+v", report.Materialize)
+	}
+	if len(report.SkippedCollections) != 0 {
+		t.Errorf("nothing should be skipped by default, got %v", report.SkippedCollections)
+	}
+	for _, v := range writer.views {
+		if v.view != "all_countries" {
+			continue
+		}
+		if len(v.records) != 2 || v.records[0].GetID() != "fr" || v.records[0].GetData()["name"] != "France" {
+			t.Errorf("unexpected countries view records: %+v", v.records)
+		}
+	}
+}
+
+func TestScan_SkipViewsOnInvalid(t *testing.T) {
+	t.Parallel()
+
+	dbPath, def := scanTestDef(t)
+	writer := &capturingWriter{}
+	report, err := NewScanner(WithViewWriter(writer), SkipViewsOnInvalid()).Scan(context.Background(), dbPath, def)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(report.SkippedCollections) != 1 || report.SkippedCollections[0] != "cities" {
+		t.Errorf("expected cities to be skipped, got %v", report.SkippedCollections)
+	}
+	if len(writer.views) != 1 || writer.views[0].view != "all_countries" {
+		t.Errorf("expected only the countries view to be written, got %+v", writer.views)
+	}
+}
+
+func TestScan_WithCache(t *testing.T) {
+	t.Parallel()
+
+	dbPath, def := scanTestDef(t)
+	s := NewScanner(WithValidatorOptions(datavalidator.WithCache(), datavalidator.WithConcurrency(2)))
+	for run := range 2 {
+		writer := &capturingWriter{}
+		s.(*scanner).writer = writer
+		report, err := s.Scan(context.Background(), dbPath, def)
+		if err != nil {
+			t.Fatalf("run %d: Scan: %v", run, err)
+		}
+		if report.Validation.ErrorCount() != 1 {
+			t.Errorf("run %d: expected 1 validation error, got %v", run, report.Validation.Errors())
+		}
+		// Files served from the cache still feed the views.
+		for _, v := range writer.views {
+			if len(v.records) != 2 {
+				t.Errorf("run %d: view %s has %d records, want 2", run, v.view, len(v.records))
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dbPath, datavalidator.DefaultCacheDir)); err != nil {
+		t.Errorf("cache not written: %v", err)
+	}
+}
+
+func TestScan_CancelledContext(t *testing.T) {
+	t.Parallel()
+
+	dbPath, def := scanTestDef(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewScanner(WithViewWriter(&capturingWriter{})).Scan(ctx, dbPath, def); err == nil {
+		t.Error("expected an error for a cancelled context")
+	}
+}
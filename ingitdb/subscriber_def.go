@@ -3,6 +3,7 @@ package ingitdb
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 )
 
 // SubscriberFor represents the selector for when a subscriber group should be triggered
@@ -258,25 +259,59 @@ func (d *SearchIndexDef) Validate() error {
 	return nil
 }
 
-// RSSDef represents an RSS or Atom feed generator subscriber handler
+// RSS feed formats accepted by RSSDef.Format.
+const (
+	RSSFormatRSS  = "rss" // RSS 2.0, the default
+	RSSFormatAtom = "atom"
+)
+
+// DefaultRSSMaxItems caps a feed when RSSDef.MaxItems is not set.
+const DefaultRSSMaxItems = 20
+
+// RSSDef represents an RSS or Atom feed generator subscriber handler.
+// The feed lists the latest records of Collection, newest first by DateColumn.
 type RSSDef struct {
-	HandlerBase `yaml:",inline"`
-	Output      string `yaml:"output"`
-	Title       string `yaml:"title"`
-	Link        string `yaml:"link"`
-	Format      string `yaml:"format,omitempty"`
+	HandlerBase       `yaml:",inline"`
+	Output            string `yaml:"output"` // feed file path, relative to the database root
+	Title             string `yaml:"title"`
+	Link              string `yaml:"link"`
+	Format            string `yaml:"format,omitempty"`
+	Description       string `yaml:"description,omitempty"`
+	Collection        string `yaml:"collection"`
+	TitleColumn       string `yaml:"title_column,omitempty"`       // defaults to "title"; the record key when empty
+	DescriptionColumn string `yaml:"description_column,omitempty"` // items have no description when unset
+	DateColumn        string `yaml:"date_column"`
+	ItemLink          string `yaml:"item_link,omitempty"` // URL template; "{key}" is replaced by the record key
+	MaxItems          int    `yaml:"max_items,omitempty"` // defaults to DefaultRSSMaxItems
 }
 
 func (d *RSSDef) Validate() error {
 	if d.Output == "" {
 		return errors.New("rss output is required")
 	}
+	if !filepath.IsLocal(filepath.FromSlash(d.Output)) {
+		return fmt.Errorf("rss output must be a path inside the database, got %q", d.Output)
+	}
 	if d.Title == "" {
 		return errors.New("rss title is required")
 	}
 	if d.Link == "" {
 		return errors.New("rss link is required")
 	}
+	if d.Collection == "" {
+		return errors.New("rss collection is required")
+	}
+	if d.DateColumn == "" {
+		return errors.New("rss date_column is required")
+	}
+	switch d.Format {
+	case "", RSSFormatRSS, RSSFormatAtom:
+	default:
+		return fmt.Errorf("rss format must be %q or %q, got %q", RSSFormatRSS, RSSFormatAtom, d.Format)
+	}
+	if d.MaxItems < 0 {
+		return fmt.Errorf("rss max_items must not be negative, got %d", d.MaxItems)
+	}
 	return nil
 }
 
@@ -185,6 +185,12 @@ func TestRSSDef_Validate(t *testing.T) {
 	d := &RSSDef{}
 	assert.ErrorContains(t, d.Validate(), "rss output is required")
 
+	d.Output = "../out.xml"
+	assert.ErrorContains(t, d.Validate(), "rss output must be a path inside the database")
+
+	d.Output = "/tmp/out.xml"
+	assert.ErrorContains(t, d.Validate(), "rss output must be a path inside the database")
+
 	d.Output = "out.xml"
 	assert.ErrorContains(t, d.Validate(), "rss title is required")
 
@@ -192,6 +198,22 @@ func TestRSSDef_Validate(t *testing.T) {
 	assert.ErrorContains(t, d.Validate(), "rss link is required")
 
 	d.Link = "https://example.com"
+	assert.ErrorContains(t, d.Validate(), "rss collection is required")
+
+	d.Collection = "posts"
+	assert.ErrorContains(t, d.Validate(), "rss date_column is required")
+
+	d.DateColumn = "published"
+	assert.NoError(t, d.Validate())
+
+	d.Format = "json"
+	assert.ErrorContains(t, d.Validate(), "rss format must be")
+
+	d.Format = RSSFormatAtom
+	d.MaxItems = -1
+	assert.ErrorContains(t, d.Validate(), "rss max_items must not be negative")
+
+	d.MaxItems = 0
 	assert.NoError(t, d.Validate())
 }
 
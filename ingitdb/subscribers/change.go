@@ -0,0 +1,167 @@
+package subscribers
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/datavalidator"
+)
+
+// Change is the normalized, transport-independent description of one changed
+// file that every handler receives. It is serialized as-is into webhook bodies
+// and email attachments, so its JSON shape is part of the subscriber contract.
+type Change struct {
+	Event        ingitdb.TriggerEventType `json:"event"`
+	CollectionID string                   `json:"collection,omitempty"` // empty when the file is not a record file
+	RecordKey    string                   `json:"key,omitempty"`        // empty for list/map files where key is unknown
+	Path         string                   `json:"path"`                 // repo-relative, slash-separated
+	OldPath      string                   `json:"old_path,omitempty"`   // set only for renames
+	// Record holds the record's current field values for a created or updated
+	// single-record file. It is what search-index handlers upsert.
+	Record map[string]any `json:"record,omitempty"`
+}
+
+// Payload is what one subscriber's handlers are sent: the subscriber's ID and
+// the changes that matched its `for` selector, in change-set order.
+type Payload struct {
+	Subscriber string   `json:"subscriber"`
+	Changes    []Change `json:"changes"`
+}
+
+// Summary renders the payload as plain text for chat-style handlers (Slack,
+// Discord, Telegram, ntfy, SMS, email): a count line followed by one
+// "<event> <collection>/<key>" line per change, falling back to the path when
+// the change is not a keyed record.
+func (p Payload) Summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "inGitDB: %d change(s)", len(p.Changes))
+	for _, c := range p.Changes {
+		target := c.Path
+		if c.CollectionID != "" && c.RecordKey != "" {
+			target = c.CollectionID + "/" + c.RecordKey
+		}
+		fmt.Fprintf(&sb, "\n%s %s", c.Event, target)
+	}
+	return sb.String()
+}
+
+// buildChanges normalizes a git change set into Changes. Record files are
+// attributed to their owning collection with datavalidator.CollectionForRecordFile,
+// the same lookup the incremental validator uses, so the two agree on what a
+// record file is. Files that are not record files are still reported (with no
+// collection) because a subscriber may watch arbitrary paths.
+func buildChanges(dbPath string, def *ingitdb.Definition, changed []ingitdb.ChangedFile) []Change {
+	changes := make([]Change, 0, len(changed))
+	for _, cf := range changed {
+		event, ok := ingitdb.TriggerEventForChange(cf.Kind)
+		if !ok {
+			continue
+		}
+		c := Change{
+			Event:   event,
+			Path:    filepath.ToSlash(cf.Path),
+			OldPath: filepath.ToSlash(cf.OldPath),
+		}
+		absPath := filepath.Clean(filepath.Join(dbPath, cf.Path))
+		colID, colDef := datavalidator.CollectionForRecordFile(def, absPath)
+		if colDef != nil {
+			c.CollectionID = colID
+			if colDef.RecordFile.RecordType == ingitdb.SingleRecord {
+				name := filepath.Base(absPath)
+				c.RecordKey = strings.TrimSuffix(name, filepath.Ext(name))
+				if event != ingitdb.TriggerEventDeleted {
+					c.Record = readRecord(absPath, colDef)
+				}
+			}
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// readRecord returns a single-record file's parsed fields, or nil when the file
+// cannot be read or parsed. Delivery does not fail on an unreadable record:
+// schema problems are the validator's to report, and the change itself still
+// happened.
+func readRecord(absPath string, colDef *ingitdb.CollectionDef) map[string]any {
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil
+	}
+	data, err := ingitdb.ParseRecordContentForCollection(content, colDef)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// matchChanges returns the changes a subscriber's `for` selector selects. An
+// empty Paths list matches every path and an empty Events list matches every
+// event.
+func matchChanges(sel *ingitdb.SubscriberFor, changes []Change) []Change {
+	var matched []Change
+	for _, c := range changes {
+		if sel != nil && len(sel.Events) > 0 && !slices.Contains(sel.Events, c.Event) {
+			continue
+		}
+		if sel != nil && len(sel.Paths) > 0 && !matchesAnyPath(sel.Paths, c) {
+			continue
+		}
+		matched = append(matched, c)
+	}
+	return matched
+}
+
+func matchesAnyPath(patterns []string, c Change) bool {
+	for _, pattern := range patterns {
+		if MatchPath(pattern, c.Path) || (c.OldPath != "" && MatchPath(pattern, c.OldPath)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPath reports whether a repo-relative, slash-separated path is selected
+// by a SubscriberFor path pattern.
+//
+// Each pattern segment is matched with path.Match, and a `**` segment matches
+// zero or more whole segments, so `todo/**/*.yaml` selects record files at any
+// depth. A pattern that selects a directory also selects everything beneath
+// it: `todo/tasks` matches `todo/tasks/$records/t1.yaml`.
+func MatchPath(pattern, p string) bool {
+	pattern = strings.Trim(filepath.ToSlash(pattern), "/")
+	p = strings.Trim(filepath.ToSlash(p), "/")
+	if pattern == "" {
+		return false
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(p, "/"))
+}
+
+// matchSegments matches pattern segments against path segments. Running out
+// of pattern while path segments remain is a match: the pattern selected an
+// ancestor directory.
+func matchSegments(pattern, segs []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segs); i++ {
+			if matchSegments(pattern[1:], segs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segs) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], segs[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segs[1:])
+}
@@ -0,0 +1,116 @@
+package subscribers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func TestMatchPath(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"todo/tasks", "todo/tasks/$records/t1.yaml", true},
+		{"todo/tasks/", "todo/tasks/$records/t1.yaml", true},
+		{"todo/tags", "todo/tasks/$records/t1.yaml", false},
+		{"todo/*/$records/*.yaml", "todo/tasks/$records/t1.yaml", true},
+		{"todo/**/*.yaml", "todo/tasks/$records/t1.yaml", true},
+		{"**/*.json", "todo/tasks/$records/t1.yaml", false},
+		{"**", "README.md", true},
+		{"todo/tasks/$records/t1.yaml", "todo/tasks/$records/t1.yaml", true},
+		{"todo/tasks/$records/t1.yaml/extra", "todo/tasks/$records/t1.yaml", false},
+		{"", "todo", false},
+		{"[", "todo", false},
+	}
+	for _, tc := range cases {
+		if got := MatchPath(tc.pattern, tc.path); got != tc.want {
+			t.Errorf("MatchPath(%q, %q) = %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
+
+func testDef(dbPath string) *ingitdb.Definition {
+	return &ingitdb.Definition{
+		Collections: map[string]*ingitdb.CollectionDef{
+			"tasks": {
+				ID:      "tasks",
+				DirPath: filepath.Join(dbPath, "tasks"),
+				RecordFile: &ingitdb.RecordFileDef{
+					Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord,
+				},
+				Columns: map[string]*ingitdb.ColumnDef{"title": {Type: ingitdb.ColumnTypeString}},
+			},
+		},
+	}
+}
+
+func TestBuildChanges(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	writeTask(t, dbPath, "t1", "title: Buy milk\n")
+	changed := []ingitdb.ChangedFile{
+		{Kind: ingitdb.ChangeKindAdded, Path: "tasks/$records/t1.yaml"},
+		{Kind: ingitdb.ChangeKindDeleted, Path: "tasks/$records/t2.yaml"},
+		{Kind: ingitdb.ChangeKindModified, Path: "README.md"},
+	}
+
+	got := buildChanges(dbPath, testDef(dbPath), changed)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 changes, got %d: %+v", len(got), got)
+	}
+	if got[0].Event != ingitdb.TriggerEventCreated || got[0].CollectionID != "tasks" || got[0].RecordKey != "t1" {
+		t.Errorf("unexpected created change: %+v", got[0])
+	}
+	if got[0].Record["title"] != "Buy milk" {
+		t.Errorf("expected record data to be loaded, got %v", got[0].Record)
+	}
+	if got[1].Event != ingitdb.TriggerEventDeleted || got[1].RecordKey != "t2" || got[1].Record != nil {
+		t.Errorf("unexpected deleted change: %+v", got[1])
+	}
+	if got[2].CollectionID != "" || got[2].Event != ingitdb.TriggerEventUpdated {
+		t.Errorf("unexpected non-record change: %+v", got[2])
+	}
+}
+
+func TestMatchChanges(t *testing.T) {
+	t.Parallel()
+
+	changes := []Change{
+		{Event: ingitdb.TriggerEventCreated, Path: "tasks/$records/t1.yaml"},
+		{Event: ingitdb.TriggerEventDeleted, Path: "tasks/$records/t2.yaml"},
+		{Event: ingitdb.TriggerEventUpdated, Path: "notes/n1.yaml"},
+		{Event: ingitdb.TriggerEventUpdated, Path: "archive/t3.yaml", OldPath: "tasks/$records/t3.yaml"},
+	}
+
+	if got := matchChanges(nil, changes); len(got) != 4 {
+		t.Errorf("nil selector: expected all 4 changes, got %d", len(got))
+	}
+	got := matchChanges(&ingitdb.SubscriberFor{Paths: []string{"tasks"}}, changes)
+	if len(got) != 3 {
+		t.Errorf("path selector: expected 3 changes (incl. rename source), got %d: %+v", len(got), got)
+	}
+	got = matchChanges(&ingitdb.SubscriberFor{
+		Paths:  []string{"tasks"},
+		Events: []ingitdb.TriggerEventType{ingitdb.TriggerEventDeleted},
+	}, changes)
+	if len(got) != 1 || got[0].Path != "tasks/$records/t2.yaml" {
+		t.Errorf("path+event selector: got %+v", got)
+	}
+}
+
+func writeTask(t *testing.T, dbPath, key, content string) {
+	t.Helper()
+	recDir := filepath.Join(dbPath, "tasks", "$records")
+	if err := os.MkdirAll(recDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(recDir, key+".yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
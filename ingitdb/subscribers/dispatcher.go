@@ -0,0 +1,229 @@
+// Package subscribers delivers change notifications to the handlers declared
+// in .ingitdb/subscribers.yaml.
+//
+// A Dispatcher takes a git change set, normalizes it into Changes, selects the
+// changes each subscriber's `for` block asks for, and sends the resulting
+// Payload to every handler of that subscriber. RSS handlers are the exception:
+// rather than forwarding the payload they regenerate a feed file from the
+// latest records of their collection. Network access goes through
+// the Dispatcher's HTTPClient, SendMail and Endpoints fields so tests (and
+// self-hosted deployments) can point handlers at local stand-ins.
+package subscribers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/smtp"
+	"slices"
+	"time"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+const (
+	// DefaultMaxAttempts is how many times a handler is tried before its
+	// delivery is reported as failed.
+	DefaultMaxAttempts = 3
+	// DefaultBackoff is the delay before the first retry; each further retry
+	// doubles it.
+	DefaultBackoff = 500 * time.Millisecond
+)
+
+// Endpoints holds the base URLs of the third-party APIs handlers talk to.
+// Handlers that carry their own URL or host (webhooks, Slack, Discord, ntfy
+// with `server`, GitLab with `host`, search indexes with `host`) ignore these.
+type Endpoints struct {
+	Telegram string // Bot API base, e.g. https://api.telegram.org
+	Twilio   string // SMS and WhatsApp via Twilio
+	Vonage   string // SMS via Vonage
+	GitHub   string // REST API base for workflow_dispatch
+	GitLab   string // instance base for pipeline triggers
+	Ntfy     string // server used when a handler omits `server`
+}
+
+// DefaultEndpoints returns the public endpoints of every supported service.
+func DefaultEndpoints() Endpoints {
+	return Endpoints{
+		Telegram: "https://api.telegram.org",
+		Twilio:   "https://api.twilio.com",
+		Vonage:   "https://rest.nexmo.com",
+		GitHub:   "https://api.github.com",
+		GitLab:   "https://gitlab.com",
+		Ntfy:     "https://ntfy.sh",
+	}
+}
+
+// SendMailFunc has the signature of smtp.SendMail.
+type SendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// Dispatcher sends change payloads to subscriber handlers.
+type Dispatcher struct {
+	HTTPClient  *http.Client
+	SendMail    SendMailFunc
+	Endpoints   Endpoints
+	MaxAttempts int           // <= 0 means DefaultMaxAttempts
+	Backoff     time.Duration // delay before the first retry; doubled per retry
+	Feeds       FeedWriter    // regenerates RSS and Atom feeds
+
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewDispatcher returns a Dispatcher using http.DefaultClient, smtp.SendMail,
+// the public service endpoints and the default retry policy.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		HTTPClient:  http.DefaultClient,
+		SendMail:    smtp.SendMail,
+		Endpoints:   DefaultEndpoints(),
+		MaxAttempts: DefaultMaxAttempts,
+		Backoff:     DefaultBackoff,
+		Feeds:       NewFeedWriter(),
+	}
+}
+
+// HandlerResult is the delivery outcome of one handler.
+type HandlerResult struct {
+	Subscriber string // subscriber ID from subscribers.yaml
+	Kind       string // handler kind, e.g. "webhook", "email", "slack"
+	Index      int    // position within the subscriber's list of that kind
+	Name       string // the handler's optional `name`
+	Changes    int    // number of changes delivered
+	Attempts   int
+	Err        error // nil on success
+}
+
+// String identifies the handler as "<subscriber>/<kind>[<index>]", followed by
+// its name when it has one.
+func (r HandlerResult) String() string {
+	s := fmt.Sprintf("%s/%s[%d]", r.Subscriber, r.Kind, r.Index)
+	if r.Name != "" {
+		s += " (" + r.Name + ")"
+	}
+	return s
+}
+
+// Report collects the HandlerResults of one Dispatch, ordered by subscriber ID
+// and then by handler kind and position as declared.
+type Report struct {
+	Results []HandlerResult
+}
+
+// Failed returns the results whose delivery failed.
+func (r *Report) Failed() []HandlerResult {
+	var failed []HandlerResult
+	for _, res := range r.Results {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Err joins the errors of all failed handlers, or returns nil.
+func (r *Report) Err() error {
+	var errs []error
+	for _, res := range r.Failed() {
+		errs = append(errs, fmt.Errorf("%s: %w", res, res.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// Dispatch sends the change set to every subscriber whose `for` selector
+// matches at least one change. dbPath is the repository root the changed
+// paths are relative to; def is used to attribute record files to
+// collections.
+//
+// Handler failures do not stop the dispatch: each is retried per the
+// Dispatcher's policy and then recorded in the Report. The returned error is
+// non-nil only when ctx is cancelled.
+func (d *Dispatcher) Dispatch(
+	ctx context.Context,
+	dbPath string,
+	def *ingitdb.Definition,
+	subs map[string]*ingitdb.SubscriberDef,
+	changed []ingitdb.ChangedFile,
+) (*Report, error) {
+	report := &Report{}
+	if len(subs) == 0 || len(changed) == 0 {
+		return report, nil
+	}
+	changes := buildChanges(dbPath, def, changed)
+	for _, id := range slices.Sorted(maps.Keys(subs)) {
+		sub := subs[id]
+		matched := matchChanges(sub.For, changes)
+		if len(matched) == 0 {
+			continue
+		}
+		payload := Payload{Subscriber: id, Changes: matched}
+		for _, h := range handlersOf(sub, dbPath, def) {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			attempts, err := d.deliver(ctx, func(ctx context.Context) error {
+				return h.send(ctx, d, payload)
+			})
+			report.Results = append(report.Results, HandlerResult{
+				Subscriber: id,
+				Kind:       h.kind,
+				Index:      h.index,
+				Name:       h.name,
+				Changes:    len(matched),
+				Attempts:   attempts,
+				Err:        err,
+			})
+		}
+	}
+	return report, ctx.Err()
+}
+
+// permanentError marks a failure that retrying cannot fix, such as a 4xx
+// response or an unsupported provider.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// deliver calls send until it succeeds, fails permanently, or MaxAttempts is
+// reached, sleeping Backoff, 2*Backoff, ... between attempts.
+func (d *Dispatcher) deliver(ctx context.Context, send func(context.Context) error) (attempts int, err error) {
+	maxAttempts := d.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	sleep := d.sleep
+	if sleep == nil {
+		sleep = sleepCtx
+	}
+	delay := d.Backoff
+	for attempts = 1; ; attempts++ {
+		err = send(ctx)
+		if err == nil {
+			return attempts, nil
+		}
+		var perm permanentError
+		if errors.As(err, &perm) || attempts >= maxAttempts {
+			return attempts, err
+		}
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return attempts, err
+		}
+		delay *= 2
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,424 @@
+package subscribers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+type recordedRequest struct {
+	method string
+	path   string
+	header http.Header
+	body   string
+}
+
+// recordingServer answers each request with the next status from statuses
+// (200 once they run out) and records what it received.
+func recordingServer(t *testing.T, statuses ...int) (*httptest.Server, func() []recordedRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var reqs []recordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		n := len(reqs)
+		reqs = append(reqs, recordedRequest{method: r.Method, path: r.URL.RequestURI(), header: r.Header.Clone(), body: string(body)})
+		mu.Unlock()
+		if n < len(statuses) {
+			w.WriteHeader(statuses[n])
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []recordedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]recordedRequest(nil), reqs...)
+	}
+}
+
+func testDispatcher() *Dispatcher {
+	d := NewDispatcher()
+	d.Backoff = time.Millisecond
+	d.sleep = func(context.Context, time.Duration) error { return nil }
+	return d
+}
+
+var taskCreated = []ingitdb.ChangedFile{{Kind: ingitdb.ChangeKindAdded, Path: "tasks/$records/t1.yaml"}}
+
+func TestDispatch_Webhook(t *testing.T) {
+	t.Parallel()
+
+	srv, requests := recordingServer(t)
+	subs := map[string]*ingitdb.SubscriberDef{
+		"hooks": {
+			For:      &ingitdb.SubscriberFor{Paths: []string{"tasks"}},
+			Webhooks: []ingitdb.WebhookDef{{URL: srv.URL + "/hook", Method: "put", Headers: map[string]string{"X-Token": "s3cret"}}},
+		},
+	}
+	report, err := testDispatcher().Dispatch(context.Background(), "/db", testDef("/db"), subs, taskCreated)
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if len(report.Results) != 1 || report.Results[0].Err != nil || report.Results[0].Attempts != 1 {
+		t.Fatalf("unexpected report: %+v", report.Results)
+	}
+	reqs := requests()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(reqs))
+	}
+	if reqs[0].method != http.MethodPut || reqs[0].path != "/hook" || reqs[0].header.Get("X-Token") != "s3cret" {
+		t.Errorf("unexpected request: %+v", reqs[0])
+	}
+	var p Payload
+	if err := json.Unmarshal([]byte(reqs[0].body), &p); err != nil {
+		t.Fatalf("body is not a JSON payload: %v", err)
+	}
+	if p.Subscriber != "hooks" || len(p.Changes) != 1 || p.Changes[0].CollectionID != "tasks" ||
+		p.Changes[0].RecordKey != "t1" || p.Changes[0].Event != ingitdb.TriggerEventCreated {
+		t.Errorf("unexpected payload: %+v", p)
+	}
+}
+
+func TestDispatch_SkipsUnmatchedSubscribers(t *testing.T) {
+	t.Parallel()
+
+	srv, requests := recordingServer(t)
+	subs := map[string]*ingitdb.SubscriberDef{
+		"deletes_only": {
+			For:    &ingitdb.SubscriberFor{Events: []ingitdb.TriggerEventType{ingitdb.TriggerEventDeleted}},
+			Slacks: []ingitdb.SlackDef{{WebhookURL: srv.URL}},
+		},
+	}
+	report, err := testDispatcher().Dispatch(context.Background(), "/db", testDef("/db"), subs, taskCreated)
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if len(report.Results) != 0 || len(requests()) != 0 {
+		t.Errorf("expected no deliveries, got %+v", report.Results)
+	}
+}
+
+func TestDispatch_RetriesTransientFailures(t *testing.T) {
+	t.Parallel()
+
+	srv, requests := recordingServer(t, http.StatusBadGateway, http.StatusTooManyRequests)
+	var delays []time.Duration
+	d := testDispatcher()
+	d.sleep = func(_ context.Context, delay time.Duration) error {
+		delays = append(delays, delay)
+		return nil
+	}
+	subs := map[string]*ingitdb.SubscriberDef{
+		"chat": {For: &ingitdb.SubscriberFor{}, Discords: []ingitdb.DiscordDef{{WebhookURL: srv.URL}}},
+	}
+	report, err := d.Dispatch(context.Background(), "/db", testDef("/db"), subs, taskCreated)
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	res := report.Results[0]
+	if res.Err != nil || res.Attempts != 3 {
+		t.Fatalf("expected success on 3rd attempt, got %+v", res)
+	}
+	if len(requests()) != 3 {
+		t.Errorf("expected 3 requests, got %d", len(requests()))
+	}
+	if len(delays) != 2 || delays[1] != 2*delays[0] {
+		t.Errorf("expected doubling backoff, got %v", delays)
+	}
+	if !strings.Contains(requests()[2].body, `"content":"inGitDB: 1 change(s)\ncreated tasks/t1"`) {
+		t.Errorf("unexpected discord body: %s", requests()[2].body)
+	}
+}
+
+func TestDispatch_PermanentFailureIsNotRetried(t *testing.T) {
+	t.Parallel()
+
+	srv, requests := recordingServer(t, http.StatusBadRequest)
+	okSrv, okRequests := recordingServer(t)
+	subs := map[string]*ingitdb.SubscriberDef{
+		"chat": {
+			For:    &ingitdb.SubscriberFor{},
+			Slacks: []ingitdb.SlackDef{{HandlerBase: ingitdb.HandlerBase{Name: "broken"}, WebhookURL: srv.URL}, {WebhookURL: okSrv.URL}},
+		},
+	}
+	report, err := testDispatcher().Dispatch(context.Background(), "/db", testDef("/db"), subs, taskCreated)
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	failed := report.Failed()
+	if len(failed) != 1 || failed[0].Attempts != 1 || failed[0].Name != "broken" {
+		t.Fatalf("expected one failed handler tried once, got %+v", report.Results)
+	}
+	if len(requests()) != 1 || len(okRequests()) != 1 {
+		t.Errorf("expected one request per handler, got %d and %d", len(requests()), len(okRequests()))
+	}
+	if err := report.Err(); err == nil || !strings.Contains(err.Error(), "chat/slack[0] (broken)") {
+		t.Errorf("unexpected report error: %v", err)
+	}
+}
+
+func TestDispatch_EndpointsOverride(t *testing.T) {
+	t.Parallel()
+
+	srv, requests := recordingServer(t)
+	d := testDispatcher()
+	d.Endpoints.Telegram = srv.URL
+	d.Endpoints.Twilio = srv.URL
+	subs := map[string]*ingitdb.SubscriberDef{
+		"phones": {
+			For:       &ingitdb.SubscriberFor{},
+			Telegrams: []ingitdb.TelegramDef{{Token: "T0K", ChatID: "42"}},
+			SMS:       []ingitdb.SMSDef{{Provider: "twilio", From: "+1", To: "+2", AccountSID: "AC1", AuthToken: "tok"}},
+		},
+	}
+	report, err := d.Dispatch(context.Background(), "/db", testDef("/db"), subs, taskCreated)
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if err := report.Err(); err != nil {
+		t.Fatalf("unexpected failures: %v", err)
+	}
+	reqs := requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(reqs))
+	}
+	if reqs[0].path != "/botT0K/sendMessage" || !strings.Contains(reqs[0].body, `"chat_id":"42"`) {
+		t.Errorf("unexpected telegram request: %+v", reqs[0])
+	}
+	if reqs[1].path != "/2010-04-01/Accounts/AC1/Messages.json" || !strings.Contains(reqs[1].body, "To=%2B2") {
+		t.Errorf("unexpected twilio request: %+v", reqs[1])
+	}
+	if user, pass, ok := (&http.Request{Header: reqs[1].header}).BasicAuth(); !ok || user != "AC1" || pass != "tok" {
+		t.Errorf("expected twilio basic auth, got %q %q %v", user, pass, ok)
+	}
+}
+
+func TestDispatch_SearchIndex(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	writeTask(t, dbPath, "t1", "title: Buy milk\n")
+	srv, requests := recordingServer(t)
+	subs := map[string]*ingitdb.SubscriberDef{
+		"search": {
+			For:           &ingitdb.SubscriberFor{},
+			SearchIndexes: []ingitdb.SearchIndexDef{{Provider: "meilisearch", Index: "tasks", APIKey: "k", Host: srv.URL}},
+		},
+	}
+	changed := []ingitdb.ChangedFile{
+		{Kind: ingitdb.ChangeKindModified, Path: "tasks/$records/t1.yaml"},
+		{Kind: ingitdb.ChangeKindDeleted, Path: "tasks/$records/t2.yaml"},
+	}
+	report, err := testDispatcher().Dispatch(context.Background(), dbPath, testDef(dbPath), subs, changed)
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if err := report.Err(); err != nil {
+		t.Fatalf("unexpected failures: %v", err)
+	}
+	reqs := requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected upsert and delete requests, got %d", len(reqs))
+	}
+	var docs []map[string]any
+	if err := json.Unmarshal([]byte(reqs[0].body), &docs); err != nil {
+		t.Fatalf("upsert body: %v", err)
+	}
+	if len(docs) != 1 || docs[0]["id"] != "tasks__t1" || docs[0][RecordKeyField] != "t1" || docs[0]["title"] != "Buy milk" || docs[0][CollectionField] != "tasks" {
+		t.Errorf("unexpected upserted documents: %v", docs)
+	}
+	if reqs[0].header.Get("Authorization") != "Bearer k" {
+		t.Errorf("missing bearer token: %v", reqs[0].header)
+	}
+	if reqs[1].path != "/indexes/tasks/documents/delete-batch" || reqs[1].body != `["tasks__t2"]` {
+		t.Errorf("unexpected delete request: %+v", reqs[1])
+	}
+}
+
+// Records with the same key in two collections sharing one index get
+// distinct documents, and deleting one leaves the other indexed.
+func TestIndexOps_NamespacesByCollection(t *testing.T) {
+	t.Parallel()
+
+	upserts, deletes := indexOps(Payload{Changes: []Change{
+		{Event: ingitdb.TriggerEventUpdated, CollectionID: "tasks", RecordKey: "k1", Record: map[string]any{"title": "Task"}},
+		{Event: ingitdb.TriggerEventCreated, CollectionID: "notes", RecordKey: "k1", Record: map[string]any{"title": "Note"}},
+		{Event: ingitdb.TriggerEventDeleted, CollectionID: "notes", RecordKey: "k2"},
+	}}, documentID)
+	if len(upserts) != 2 || upserts[0]["id"] == upserts[1]["id"] {
+		t.Fatalf("want two distinct documents, got %v", upserts)
+	}
+	if upserts[0]["id"] != "tasks/k1" || upserts[1]["id"] != "notes/k1" {
+		t.Errorf("unexpected document ids: %v, %v", upserts[0]["id"], upserts[1]["id"])
+	}
+	if upserts[1][RecordKeyField] != "k1" || upserts[1][CollectionField] != "notes" {
+		t.Errorf("the document must keep its key and collection: %v", upserts[1])
+	}
+	if len(deletes) != 1 || deletes[0] != "notes/k2" {
+		t.Errorf("deletes = %v, want [notes/k2]", deletes)
+	}
+}
+
+// Meilisearch ids keep to [a-zA-Z0-9_-] and stay distinct for records that
+// the escaping could otherwise confuse.
+func TestMeilisearchDocumentID(t *testing.T) {
+	t.Parallel()
+
+	valid := regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	tests := []struct {
+		collection, key, want string
+	}{
+		{"tasks", "t1", "tasks__t1"},
+		{"shop.orders", "o-1", "shop_2eorders__o-1"},
+		{"a_b", "c", "a_5fb__c"},
+		{"a", "_b_c", "a___5fb_5fc"},
+		{"a", "é/x", "a___c3_a9_2fx"},
+	}
+	seen := map[string]bool{}
+	for _, tt := range tests {
+		got := meilisearchDocumentID(Change{CollectionID: tt.collection, RecordKey: tt.key})
+		if got != tt.want || !valid.MatchString(got) {
+			t.Errorf("meilisearchDocumentID(%q, %q) = %q, want %q", tt.collection, tt.key, got, tt.want)
+		}
+		if seen[got] {
+			t.Errorf("duplicate id %q", got)
+		}
+		seen[got] = true
+	}
+}
+
+func TestDispatch_UnsupportedProviderFails(t *testing.T) {
+	t.Parallel()
+
+	subs := map[string]*ingitdb.SubscriberDef{
+		"sms": {For: &ingitdb.SubscriberFor{}, SMS: []ingitdb.SMSDef{{Provider: "carrier-pigeon"}}},
+	}
+	report, err := testDispatcher().Dispatch(context.Background(), "/db", testDef("/db"), subs, taskCreated)
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if len(report.Failed()) != 1 || report.Failed()[0].Attempts != 1 {
+		t.Errorf("expected a single failed attempt, got %+v", report.Results)
+	}
+}
+
+func TestDispatch_Email(t *testing.T) {
+	t.Parallel()
+
+	addr, received := fakeSMTPServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+	portNum, _ := strconv.Atoi(port)
+	subs := map[string]*ingitdb.SubscriberDef{
+		"mail": {
+			For:    &ingitdb.SubscriberFor{},
+			Emails: []ingitdb.EmailDef{{From: "db@example.com", To: []string{"ops@example.com"}, SMTP: host, Port: portNum}},
+		},
+	}
+	report, err := testDispatcher().Dispatch(context.Background(), "/db", testDef("/db"), subs, taskCreated)
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if err := report.Err(); err != nil {
+		t.Fatalf("unexpected failures: %v", err)
+	}
+	select {
+	case msg := <-received:
+		if msg.from != "db@example.com" || len(msg.to) != 1 || msg.to[0] != "ops@example.com" {
+			t.Errorf("unexpected envelope: %+v", msg)
+		}
+		if !strings.Contains(msg.data, "Subject: inGitDB: 1 change(s) for mail") || !strings.Contains(msg.data, "created tasks/t1") {
+			t.Errorf("unexpected message:\n%s", msg.data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("fake SMTP server received no message")
+	}
+}
+
+func TestDispatch_CancelledContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	subs := map[string]*ingitdb.SubscriberDef{
+		"hooks": {For: &ingitdb.SubscriberFor{}, Webhooks: []ingitdb.WebhookDef{{URL: "http://127.0.0.1:1"}}},
+	}
+	_, err := testDispatcher().Dispatch(ctx, "/db", testDef("/db"), subs, taskCreated)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer accepts one SMTP session on a loopback port and reports the
+// message it receives. It speaks just enough of RFC 5321 for net/smtp.
+func fakeSMTPServer(t *testing.T) (string, <-chan smtpMessage) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	received := make(chan smtpMessage, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		tp := textproto.NewConn(conn)
+		_ = tp.PrintfLine("220 localhost fake")
+		var msg smtpMessage
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				_ = tp.PrintfLine("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				msg.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+				_ = tp.PrintfLine("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				msg.to = append(msg.to, strings.Trim(line[len("RCPT TO:"):], "<> "))
+				_ = tp.PrintfLine("250 OK")
+			case cmd == "DATA":
+				_ = tp.PrintfLine("354 go ahead")
+				data, err := io.ReadAll(bufio.NewReader(tp.DotReader()))
+				if err != nil {
+					return
+				}
+				msg.data = string(data)
+				_ = tp.PrintfLine("250 OK")
+				received <- msg
+			case cmd == "QUIT":
+				_ = tp.PrintfLine("221 bye")
+				return
+			default:
+				_ = tp.PrintfLine("250 OK")
+			}
+		}
+	}()
+	return ln.Addr().String(), received
+}
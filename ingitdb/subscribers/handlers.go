@@ -0,0 +1,284 @@
+package subscribers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// handler is one configured delivery target of a subscriber.
+type handler struct {
+	kind  string
+	index int
+	name  string
+	send  func(ctx context.Context, d *Dispatcher, p Payload) error
+}
+
+// handlersOf lists a subscriber's handlers in the order subscribers.yaml
+// declares the kinds. dbPath and def are needed by handlers that read the
+// database rather than just forward the payload (RSS feeds).
+func handlersOf(sub *ingitdb.SubscriberDef, dbPath string, def *ingitdb.Definition) []handler {
+	var hs []handler
+	add := func(kind string, i int, name string, send func(context.Context, *Dispatcher, Payload) error) {
+		hs = append(hs, handler{kind: kind, index: i, name: name, send: send})
+	}
+	for i := range sub.Webhooks {
+		h := sub.Webhooks[i]
+		add("webhook", i, h.Name, func(ctx context.Context, d *Dispatcher, p Payload) error { return d.sendWebhook(ctx, h, p) })
+	}
+	for i := range sub.Emails {
+		h := sub.Emails[i]
+		add("email", i, h.Name, func(_ context.Context, d *Dispatcher, p Payload) error { return d.sendEmail(h, p) })
+	}
+	for i := range sub.Telegrams {
+		h := sub.Telegrams[i]
+		add("telegram", i, h.Name, func(ctx context.Context, d *Dispatcher, p Payload) error { return d.sendTelegram(ctx, h, p) })
+	}
+	for i := range sub.WhatsApp {
+		h := sub.WhatsApp[i]
+		add("whatsapp", i, h.Name, func(ctx context.Context, d *Dispatcher, p Payload) error { return d.sendWhatsApp(ctx, h, p) })
+	}
+	for i := range sub.Slacks {
+		h := sub.Slacks[i]
+		add("slack", i, h.Name, func(ctx context.Context, d *Dispatcher, p Payload) error {
+			return d.postJSON(ctx, h.WebhookURL, nil, map[string]string{"text": p.Summary()})
+		})
+	}
+	for i := range sub.Discords {
+		h := sub.Discords[i]
+		add("discord", i, h.Name, func(ctx context.Context, d *Dispatcher, p Payload) error {
+			return d.postJSON(ctx, h.WebhookURL, nil, map[string]string{"content": p.Summary()})
+		})
+	}
+	for i := range sub.GitHubActions {
+		h := sub.GitHubActions[i]
+		add("github_action", i, h.Name, func(ctx context.Context, d *Dispatcher, p Payload) error { return d.sendGitHubAction(ctx, h) })
+	}
+	for i := range sub.GitLabCI {
+		h := sub.GitLabCI[i]
+		add("gitlab_ci", i, h.Name, func(ctx context.Context, d *Dispatcher, p Payload) error { return d.sendGitLabCI(ctx, h) })
+	}
+	for i := range sub.Ntfy {
+		h := sub.Ntfy[i]
+		add("ntfy", i, h.Name, func(ctx context.Context, d *Dispatcher, p Payload) error { return d.sendNtfy(ctx, h, p) })
+	}
+	for i := range sub.SMS {
+		h := sub.SMS[i]
+		add("sms", i, h.Name, func(ctx context.Context, d *Dispatcher, p Payload) error { return d.sendSMS(ctx, h, p) })
+	}
+	for i := range sub.SearchIndexes {
+		h := sub.SearchIndexes[i]
+		add("search_index", i, h.Name, func(ctx context.Context, d *Dispatcher, p Payload) error { return d.syncSearchIndex(ctx, h, p) })
+	}
+	for i := range sub.RSS {
+		h := sub.RSS[i]
+		add("rss", i, h.Name, func(ctx context.Context, d *Dispatcher, p Payload) error { return d.writeFeed(ctx, dbPath, def, h, p) })
+	}
+	return hs
+}
+
+// writeFeed regenerates the feed when a change in the payload touched its
+// collection. A feed write is local, so a failure is not retried.
+func (d *Dispatcher) writeFeed(ctx context.Context, dbPath string, def *ingitdb.Definition, h ingitdb.RSSDef, p Payload) error {
+	touched := false
+	for _, c := range p.Changes {
+		if c.CollectionID == h.Collection {
+			touched = true
+			break
+		}
+	}
+	if !touched {
+		return nil
+	}
+	if _, err := d.Feeds.WriteFeed(ctx, dbPath, def, h); err != nil {
+		return permanentError{err}
+	}
+	return nil
+}
+
+// do sends one HTTP request built by newReq. A 5xx or 429 response is
+// retryable; any other non-2xx response is a permanentError. newReq is called
+// per attempt so request bodies are never reused.
+func (d *Dispatcher) do(ctx context.Context, newReq func(ctx context.Context) (*http.Request, error)) error {
+	req, err := newReq(ctx)
+	if err != nil {
+		return permanentError{err}
+	}
+	client := d.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Redacted(), resp.Status, strings.TrimSpace(string(body)))
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return err
+	}
+	return permanentError{err}
+}
+
+func (d *Dispatcher) request(ctx context.Context, method, target, contentType string, body []byte, headers map[string]string) error {
+	return d.do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return req, nil
+	})
+}
+
+func (d *Dispatcher) postJSON(ctx context.Context, target string, headers map[string]string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return permanentError{err}
+	}
+	return d.request(ctx, http.MethodPost, target, "application/json", body, headers)
+}
+
+func (d *Dispatcher) postForm(ctx context.Context, target string, form url.Values, user, pass string) error {
+	return d.do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if user != "" {
+			req.SetBasicAuth(user, pass)
+		}
+		return req, nil
+	})
+}
+
+// sendWebhook sends the JSON-encoded Payload with the configured method
+// (POST by default) and headers.
+func (d *Dispatcher) sendWebhook(ctx context.Context, h ingitdb.WebhookDef, p Payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return permanentError{err}
+	}
+	method := strings.ToUpper(h.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+	return d.request(ctx, method, h.URL, "application/json", body, h.Headers)
+}
+
+// sendEmail sends the summary as the body of a plain-text mail. Port defaults
+// to 25, From to User, and PLAIN auth is used when User is set.
+func (d *Dispatcher) sendEmail(h ingitdb.EmailDef, p Payload) error {
+	port := h.Port
+	if port == 0 {
+		port = 25
+	}
+	from := h.From
+	if from == "" {
+		from = h.User
+	}
+	if from == "" {
+		return permanentError{errors.New("email from is required when user is not set")}
+	}
+	subject := h.Subject
+	if subject == "" {
+		subject = fmt.Sprintf("inGitDB: %d change(s) for %s", len(p.Changes), p.Subscriber)
+	}
+	var auth smtp.Auth
+	if h.User != "" {
+		auth = smtp.PlainAuth("", h.User, h.Pass, h.SMTP)
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(h.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(p.Summary(), "\n", "\r\n"))
+	msg.WriteString("\r\n")
+	sendMail := d.SendMail
+	if sendMail == nil {
+		sendMail = smtp.SendMail
+	}
+	return sendMail(net.JoinHostPort(h.SMTP, strconv.Itoa(port)), auth, from, h.To, msg.Bytes())
+}
+
+func (d *Dispatcher) sendTelegram(ctx context.Context, h ingitdb.TelegramDef, p Payload) error {
+	target := strings.TrimSuffix(d.Endpoints.Telegram, "/") + "/bot" + h.Token + "/sendMessage"
+	return d.postJSON(ctx, target, nil, map[string]string{"chat_id": h.ChatID, "text": p.Summary()})
+}
+
+func (d *Dispatcher) sendWhatsApp(ctx context.Context, h ingitdb.WhatsAppDef, p Payload) error {
+	return d.sendTwilio(ctx, h.AccountSID, h.AuthToken, "whatsapp:"+h.From, "whatsapp:"+h.To, p.Summary())
+}
+
+func (d *Dispatcher) sendTwilio(ctx context.Context, sid, token, from, to, text string) error {
+	target := strings.TrimSuffix(d.Endpoints.Twilio, "/") + "/2010-04-01/Accounts/" + url.PathEscape(sid) + "/Messages.json"
+	form := url.Values{"From": {from}, "To": {to}, "Body": {text}}
+	return d.postForm(ctx, target, form, sid, token)
+}
+
+func (d *Dispatcher) sendSMS(ctx context.Context, h ingitdb.SMSDef, p Payload) error {
+	switch h.Provider {
+	case "twilio":
+		return d.sendTwilio(ctx, h.AccountSID, h.AuthToken, h.From, h.To, p.Summary())
+	case "vonage":
+		target := strings.TrimSuffix(d.Endpoints.Vonage, "/") + "/sms/json"
+		form := url.Values{"api_key": {h.APIKey}, "api_secret": {h.AuthToken}, "from": {h.From}, "to": {h.To}, "text": {p.Summary()}}
+		return d.postForm(ctx, target, form, "", "")
+	default:
+		return permanentError{fmt.Errorf("unsupported sms provider %q", h.Provider)}
+	}
+}
+
+// sendGitHubAction triggers a workflow_dispatch run of the configured
+// workflow. The payload is not forwarded: the workflow reads the repository
+// at Ref itself.
+func (d *Dispatcher) sendGitHubAction(ctx context.Context, h ingitdb.GitHubActionDef) error {
+	target := fmt.Sprintf("%s/repos/%s/%s/actions/workflows/%s/dispatches",
+		strings.TrimSuffix(d.Endpoints.GitHub, "/"), url.PathEscape(h.Owner), url.PathEscape(h.Repo), url.PathEscape(h.Workflow))
+	headers := map[string]string{
+		"Authorization": "Bearer " + h.Token,
+		"Accept":        "application/vnd.github+json",
+	}
+	return d.postJSON(ctx, target, headers, map[string]string{"ref": h.Ref})
+}
+
+func (d *Dispatcher) sendGitLabCI(ctx context.Context, h ingitdb.GitLabCIDef) error {
+	host := h.Host
+	if host == "" {
+		host = d.Endpoints.GitLab
+	}
+	target := strings.TrimSuffix(host, "/") + "/api/v4/projects/" + url.PathEscape(h.ProjectID) + "/trigger/pipeline"
+	return d.postForm(ctx, target, url.Values{"token": {h.Token}, "ref": {h.Ref}}, "", "")
+}
+
+func (d *Dispatcher) sendNtfy(ctx context.Context, h ingitdb.NtfyDef, p Payload) error {
+	server := h.Server
+	if server == "" {
+		server = d.Endpoints.Ntfy
+	}
+	target := strings.TrimSuffix(server, "/") + "/" + url.PathEscape(h.Topic)
+	headers := map[string]string{"Title": "inGitDB: " + p.Subscriber}
+	return d.request(ctx, http.MethodPost, target, "text/plain; charset=utf-8", []byte(p.Summary()), headers)
+}
@@ -0,0 +1,314 @@
+package subscribers
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/materializer"
+)
+
+// feedItem is one record projected onto the columns an RSSDef maps.
+type feedItem struct {
+	key         string
+	title       string
+	description string
+	link        string
+	date        time.Time
+}
+
+// RenderFeed renders records as an RSS 2.0 or Atom document per h.
+//
+// Items are the records with a parseable DateColumn value, newest first with
+// ties broken by key, capped at MaxItems. Records without a date are left out
+// because a feed has no place to put them. The feed-level date is that of
+// the newest item rather than the time of generation, so rendering the same
+// records twice yields identical bytes and the feed file only changes in git
+// when its content does.
+func RenderFeed(h ingitdb.RSSDef, records []ingitdb.IRecordEntry) ([]byte, error) {
+	items := feedItems(h, records)
+	var doc any
+	if h.Format == ingitdb.RSSFormatAtom {
+		doc = atomFeed(h, items)
+	} else {
+		doc = rssFeed(h, items)
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode %s feed: %w", h.Output, err)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func feedItems(h ingitdb.RSSDef, records []ingitdb.IRecordEntry) []feedItem {
+	titleColumn := h.TitleColumn
+	if titleColumn == "" {
+		titleColumn = "title"
+	}
+	items := make([]feedItem, 0, len(records))
+	for _, rec := range records {
+		data := rec.GetData()
+		date, ok := parseFeedDate(data[h.DateColumn])
+		if !ok {
+			continue
+		}
+		key := rec.GetID()
+		item := feedItem{key: key, date: date, title: key}
+		if title := feedText(data[titleColumn]); title != "" {
+			item.title = title
+		}
+		if h.DescriptionColumn != "" {
+			item.description = feedText(data[h.DescriptionColumn])
+		}
+		if h.ItemLink != "" {
+			item.link = strings.ReplaceAll(h.ItemLink, "{key}", key)
+		}
+		items = append(items, item)
+	}
+	slices.SortFunc(items, func(a, b feedItem) int {
+		if c := b.date.Compare(a.date); c != 0 {
+			return c
+		}
+		return strings.Compare(a.key, b.key)
+	})
+	maxItems := h.MaxItems
+	if maxItems == 0 {
+		maxItems = ingitdb.DefaultRSSMaxItems
+	}
+	if len(items) > maxItems {
+		items = items[:maxItems]
+	}
+	return items
+}
+
+// feedDateLayouts are tried in order for string date columns.
+var feedDateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", time.DateOnly}
+
+func parseFeedDate(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t.UTC(), true
+	case string:
+		for _, layout := range feedDateLayouts {
+			if parsed, err := time.Parse(layout, t); err == nil {
+				return parsed.UTC(), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func feedText(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// itemID is a stable identifier for an item that has no link of its own.
+func itemID(h ingitdb.RSSDef, key string) string {
+	return strings.TrimSuffix(h.Link, "/") + "#" + h.Collection + "/" + key
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func rssFeed(h ingitdb.RSSDef, items []feedItem) rssDocument {
+	description := h.Description
+	if description == "" {
+		// RSS 2.0 requires a channel description.
+		description = h.Title
+	}
+	doc := rssDocument{
+		Version: "2.0",
+		Channel: rssChannel{Title: h.Title, Link: h.Link, Description: description},
+	}
+	if len(items) > 0 {
+		doc.Channel.LastBuildDate = items[0].date.Format(time.RFC1123Z)
+	}
+	for _, it := range items {
+		item := rssItem{
+			Title:       it.title,
+			Link:        it.link,
+			Description: it.description,
+			PubDate:     it.date.Format(time.RFC1123Z),
+		}
+		if it.link != "" {
+			item.GUID = rssGUID{IsPermaLink: true, Value: it.link}
+		} else {
+			item.GUID = rssGUID{Value: itemID(h, it.key)}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+	return doc
+}
+
+type atomDocument struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Link     atomLink    `xml:"link"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title   string    `xml:"title"`
+	Link    *atomLink `xml:"link,omitempty"`
+	ID      string    `xml:"id"`
+	Updated string    `xml:"updated"`
+	Summary string    `xml:"summary,omitempty"`
+}
+
+func atomFeed(h ingitdb.RSSDef, items []feedItem) atomDocument {
+	doc := atomDocument{
+		Title:    h.Title,
+		Subtitle: h.Description,
+		Link:     atomLink{Href: h.Link},
+		ID:       h.Link,
+	}
+	// Atom requires <updated>; an empty feed falls back to the zero time so the
+	// output still does not depend on when it was generated.
+	var updated time.Time
+	if len(items) > 0 {
+		updated = items[0].date
+	}
+	doc.Updated = updated.Format(time.RFC3339)
+	for _, it := range items {
+		entry := atomEntry{
+			Title:   it.title,
+			ID:      itemID(h, it.key),
+			Updated: it.date.Format(time.RFC3339),
+			Summary: it.description,
+		}
+		if it.link != "" {
+			entry.Link = &atomLink{Href: it.link}
+			entry.ID = it.link
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return doc
+}
+
+// FeedWriter regenerates RSSDef feeds from a collection's records. The zero
+// value reads records like NewFeedWriter and writes to the file system.
+type FeedWriter struct {
+	RecordsReader ingitdb.RecordsReader // nil means the NewFeedWriter default
+	readFile      func(string) ([]byte, error)
+	writeFile     func(string, []byte, os.FileMode) error
+	mkdirAll      func(string, os.FileMode) error
+}
+
+// NewFeedWriter returns a FeedWriter reading records from the working tree.
+func NewFeedWriter() FeedWriter {
+	return FeedWriter{
+		RecordsReader: materializer.ComputedColumnsReader{Reader: materializer.NewFileRecordsReader()},
+		readFile:      os.ReadFile,
+		writeFile:     os.WriteFile,
+		mkdirAll:      os.MkdirAll,
+	}
+}
+
+// withDefaults fills in the fields a zero FeedWriter leaves nil.
+func (w FeedWriter) withDefaults() FeedWriter {
+	defaults := NewFeedWriter()
+	if w.RecordsReader == nil {
+		w.RecordsReader = defaults.RecordsReader
+	}
+	if w.readFile == nil {
+		w.readFile = defaults.readFile
+	}
+	if w.writeFile == nil {
+		w.writeFile = defaults.writeFile
+	}
+	if w.mkdirAll == nil {
+		w.mkdirAll = defaults.mkdirAll
+	}
+	return w
+}
+
+// WriteFeed renders the feed described by h and writes it to h.Output under
+// dbPath. Like FileViewWriter, it leaves the file untouched when the content
+// is unchanged.
+func (w FeedWriter) WriteFeed(ctx context.Context, dbPath string, def *ingitdb.Definition, h ingitdb.RSSDef) (materializer.WriteOutcome, error) {
+	col := def.Collections[h.Collection]
+	if col == nil {
+		return materializer.WriteOutcomeUnchanged, fmt.Errorf("rss %s: unknown collection %q", h.Output, h.Collection)
+	}
+	// Output comes from subscribers.yaml; it must not write outside the database.
+	if !filepath.IsLocal(filepath.FromSlash(h.Output)) {
+		return materializer.WriteOutcomeUnchanged, fmt.Errorf("rss %s: output must be a path inside the database", h.Output)
+	}
+	w = w.withDefaults()
+	var records []ingitdb.IRecordEntry
+	err := w.RecordsReader.ReadRecords(ctx, dbPath, col, func(rec ingitdb.IRecordEntry) error {
+		records = append(records, rec)
+		return nil
+	})
+	if err != nil {
+		return materializer.WriteOutcomeUnchanged, fmt.Errorf("rss %s: failed to read records of %s: %w", h.Output, h.Collection, err)
+	}
+	content, err := RenderFeed(h, records)
+	if err != nil {
+		return materializer.WriteOutcomeUnchanged, err
+	}
+	outPath := filepath.Join(dbPath, filepath.FromSlash(h.Output))
+	existing, readErr := w.readFile(outPath)
+	if readErr == nil && bytes.Equal(existing, content) {
+		return materializer.WriteOutcomeUnchanged, nil
+	}
+	if err := w.mkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		return materializer.WriteOutcomeUnchanged, fmt.Errorf("failed to create directory for %s: %w", outPath, err)
+	}
+	if err := w.writeFile(outPath, content, 0o644); err != nil {
+		return materializer.WriteOutcomeUnchanged, fmt.Errorf("failed to write feed %s: %w", outPath, err)
+	}
+	if readErr == nil {
+		return materializer.WriteOutcomeUpdated, nil
+	}
+	return materializer.WriteOutcomeCreated, nil
+}
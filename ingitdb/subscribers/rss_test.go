@@ -0,0 +1,204 @@
+package subscribers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/materializer"
+)
+
+func feedRecords() []ingitdb.IRecordEntry {
+	return []ingitdb.IRecordEntry{
+		ingitdb.NewMapRecordEntry("old", map[string]any{"title": "Old", "date": "2024-01-01"}),
+		ingitdb.NewMapRecordEntry("new", map[string]any{"title": "New & shiny", "date": "2024-03-01T10:00:00Z", "body": "Hello"}),
+		ingitdb.NewMapRecordEntry("mid", map[string]any{"date": time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}),
+		ingitdb.NewMapRecordEntry("undated", map[string]any{"title": "No date"}),
+	}
+}
+
+func TestRenderFeed_RSS(t *testing.T) {
+	t.Parallel()
+
+	h := ingitdb.RSSDef{
+		Output: "feed.xml", Title: "Tasks", Link: "https://example.com/",
+		Collection: "tasks", DateColumn: "date", DescriptionColumn: "body", MaxItems: 2,
+	}
+	got, err := RenderFeed(h, feedRecords())
+	if err != nil {
+		t.Fatalf("RenderFeed: %v", err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Tasks</title>
+    <link>https://example.com/</link>
+    <description>Tasks</description>
+    <lastBuildDate>Fri, 01 Mar 2024 10:00:00 +0000</lastBuildDate>
+    <item>
+      <title>New &amp; shiny</title>
+      <description>Hello</description>
+      <guid isPermaLink="false">https://example.com#tasks/new</guid>
+      <pubDate>Fri, 01 Mar 2024 10:00:00 +0000</pubDate>
+    </item>
+    <item>
+      <title>mid</title>
+      <guid isPermaLink="false">https://example.com#tasks/mid</guid>
+      <pubDate>Thu, 01 Feb 2024 00:00:00 +0000</pubDate>
+    </item>
+  </channel>
+</rss>
+`
+	if string(got) != want {
+		t.Errorf("unexpected feed:\n%s\nwant:\n%s", got, want)
+	}
+
+	again, _ := RenderFeed(h, feedRecords())
+	if string(again) != string(got) {
+		t.Error("rendering the same records twice produced different output")
+	}
+}
+
+func TestRenderFeed_Atom(t *testing.T) {
+	t.Parallel()
+
+	h := ingitdb.RSSDef{
+		Output: "feed.atom", Title: "Tasks", Link: "https://example.com", Format: ingitdb.RSSFormatAtom,
+		Collection: "tasks", DateColumn: "date", ItemLink: "https://example.com/tasks/{key}",
+	}
+	got, err := RenderFeed(h, feedRecords())
+	if err != nil {
+		t.Fatalf("RenderFeed: %v", err)
+	}
+	s := string(got)
+	for _, want := range []string{
+		`<feed xmlns="http://www.w3.org/2005/Atom">`,
+		`<updated>2024-03-01T10:00:00Z</updated>`,
+		`<link href="https://example.com/tasks/new"></link>`,
+		`<id>https://example.com/tasks/old</id>`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("expected feed to contain %q:\n%s", want, s)
+		}
+	}
+	if strings.Count(s, "<entry>") != 3 {
+		t.Errorf("expected 3 dated entries:\n%s", s)
+	}
+	if strings.Index(s, "tasks/new") > strings.Index(s, "tasks/old") {
+		t.Errorf("expected newest entry first:\n%s", s)
+	}
+}
+
+func TestRenderFeed_EmptyAtomIsDeterministic(t *testing.T) {
+	t.Parallel()
+
+	h := ingitdb.RSSDef{Title: "T", Link: "https://example.com", Format: ingitdb.RSSFormatAtom, DateColumn: "date"}
+	got, err := RenderFeed(h, nil)
+	if err != nil {
+		t.Fatalf("RenderFeed: %v", err)
+	}
+	if !strings.Contains(string(got), "<updated>0001-01-01T00:00:00Z</updated>") {
+		t.Errorf("expected zero-time updated for empty feed:\n%s", got)
+	}
+}
+
+func TestFeedWriter_WriteFeed(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	writeTask(t, dbPath, "t1", "title: First\ndate: \"2024-01-01\"\n")
+	def := testDef(dbPath)
+	h := ingitdb.RSSDef{Output: "feeds/tasks.xml", Title: "Tasks", Link: "https://example.com", Collection: "tasks", DateColumn: "date"}
+	w := NewFeedWriter()
+	ctx := context.Background()
+
+	outcome, err := w.WriteFeed(ctx, dbPath, def, h)
+	if err != nil || outcome != materializer.WriteOutcomeCreated {
+		t.Fatalf("first write: outcome=%v err=%v", outcome, err)
+	}
+	outcome, err = w.WriteFeed(ctx, dbPath, def, h)
+	if err != nil || outcome != materializer.WriteOutcomeUnchanged {
+		t.Fatalf("second write: outcome=%v err=%v", outcome, err)
+	}
+	writeTask(t, dbPath, "t2", "title: Second\ndate: \"2024-02-01\"\n")
+	outcome, err = w.WriteFeed(ctx, dbPath, def, h)
+	if err != nil || outcome != materializer.WriteOutcomeUpdated {
+		t.Fatalf("third write: outcome=%v err=%v", outcome, err)
+	}
+	content, err := os.ReadFile(filepath.Join(dbPath, "feeds", "tasks.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "<title>Second</title>") {
+		t.Errorf("expected feed to contain the new record:\n%s", content)
+	}
+
+	h.Collection = "missing"
+	if _, err := w.WriteFeed(ctx, dbPath, def, h); err == nil || !strings.Contains(err.Error(), `unknown collection "missing"`) {
+		t.Errorf("expected unknown collection error, got %v", err)
+	}
+}
+
+// The zero FeedWriter, as held by a zero Dispatcher, falls back to the
+// defaults, and an output outside the database is refused.
+func TestFeedWriter_ZeroValue(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	writeTask(t, dbPath, "t1", "title: First\ndate: \"2024-01-01\"\n")
+	def := testDef(dbPath)
+	h := ingitdb.RSSDef{Output: "tasks.xml", Title: "Tasks", Link: "https://example.com", Collection: "tasks", DateColumn: "date"}
+	ctx := context.Background()
+
+	outcome, err := FeedWriter{}.WriteFeed(ctx, dbPath, def, h)
+	if err != nil || outcome != materializer.WriteOutcomeCreated {
+		t.Fatalf("zero FeedWriter: outcome=%v err=%v", outcome, err)
+	}
+	outcome, err = (&Dispatcher{}).Feeds.WriteFeed(ctx, dbPath, def, h)
+	if err != nil || outcome != materializer.WriteOutcomeUnchanged {
+		t.Fatalf("zero Dispatcher feeds: outcome=%v err=%v", outcome, err)
+	}
+
+	h.Output = "../escaped.xml"
+	if _, err = (FeedWriter{}).WriteFeed(ctx, dbPath, def, h); err == nil || !strings.Contains(err.Error(), "inside the database") {
+		t.Errorf("expected an escaping output to be refused, got %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(filepath.Dir(dbPath), "escaped.xml")); statErr == nil {
+		t.Error("the feed must not be written outside the database")
+	}
+}
+
+func TestDispatch_RSS(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	writeTask(t, dbPath, "t1", "title: First\ndate: \"2024-01-01\"\n")
+	subs := map[string]*ingitdb.SubscriberDef{
+		"feed": {
+			For: &ingitdb.SubscriberFor{},
+			RSS: []ingitdb.RSSDef{{Output: "feed.xml", Title: "Tasks", Link: "https://example.com", Collection: "tasks", DateColumn: "date"}},
+		},
+	}
+	feedPath := filepath.Join(dbPath, "feed.xml")
+
+	unrelated := []ingitdb.ChangedFile{{Kind: ingitdb.ChangeKindModified, Path: "README.md"}}
+	report, err := testDispatcher().Dispatch(context.Background(), dbPath, testDef(dbPath), subs, unrelated)
+	if err != nil || report.Err() != nil {
+		t.Fatalf("Dispatch: %v %v", err, report.Err())
+	}
+	if _, err := os.Stat(feedPath); !os.IsNotExist(err) {
+		t.Fatalf("feed should not be written for changes outside its collection, stat err=%v", err)
+	}
+
+	report, err = testDispatcher().Dispatch(context.Background(), dbPath, testDef(dbPath), subs, taskCreated)
+	if err != nil || report.Err() != nil {
+		t.Fatalf("Dispatch: %v %v", err, report.Err())
+	}
+	if _, err := os.Stat(feedPath); err != nil {
+		t.Errorf("expected feed to be written: %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package subscribers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// CollectionField is the document field that records which collection an
+// indexed record came from, so several collections can share one index.
+const CollectionField = "_collection"
+
+// RecordKeyField is the document field that holds the record's own key; the
+// document id is namespaced by collection (see documentID).
+const RecordKeyField = "_key"
+
+// documentID is the index document id of a record: "<collection>/<key>", so
+// records with the same key in two collections sharing an index stay apart.
+// Algolia and Typesense accept it as is.
+func documentID(c Change) string {
+	return c.CollectionID + "/" + c.RecordKey
+}
+
+// meilisearchDocumentID is documentID for Meilisearch, whose ids may hold only
+// [a-zA-Z0-9_-]: "<collection>__<key>", with every other byte of either part,
+// "_" included, escaped as "_" and two hex digits. As an escaped part never
+// holds "__", distinct records keep distinct ids.
+func meilisearchDocumentID(c Change) string {
+	escape := func(s string) string {
+		var b strings.Builder
+		for i := 0; i < len(s); i++ {
+			switch ch := s[i]; {
+			case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9', ch == '-':
+				b.WriteByte(ch)
+			default:
+				fmt.Fprintf(&b, "_%02x", ch)
+			}
+		}
+		return b.String()
+	}
+	return escape(c.CollectionID) + "__" + escape(c.RecordKey)
+}
+
+// indexOps splits a payload into documents to upsert and IDs to delete, with
+// ids built by id. Only keyed record changes are indexed; a created or updated
+// record whose content could not be read is skipped rather than indexed empty.
+func indexOps(p Payload, id func(Change) string) (upserts []map[string]any, deletes []string) {
+	for _, c := range p.Changes {
+		if c.RecordKey == "" {
+			continue
+		}
+		if c.Event == ingitdb.TriggerEventDeleted {
+			deletes = append(deletes, id(c))
+			continue
+		}
+		if c.Record == nil {
+			continue
+		}
+		doc := make(map[string]any, len(c.Record)+1)
+		for k, v := range c.Record {
+			doc[k] = v
+		}
+		doc[CollectionField] = c.CollectionID
+		doc[RecordKeyField] = c.RecordKey
+		doc["id"] = id(c)
+		upserts = append(upserts, doc)
+	}
+	return upserts, deletes
+}
+
+// syncSearchIndex upserts created and updated records into the index and
+// removes deleted ones, using the provider's batch API where it has one.
+func (d *Dispatcher) syncSearchIndex(ctx context.Context, h ingitdb.SearchIndexDef, p Payload) error {
+	id := documentID
+	if h.Provider == "meilisearch" {
+		id = meilisearchDocumentID
+	}
+	upserts, deletes := indexOps(p, id)
+	if len(upserts) == 0 && len(deletes) == 0 {
+		return nil
+	}
+	switch h.Provider {
+	case "algolia":
+		return d.syncAlgolia(ctx, h, upserts, deletes)
+	case "meilisearch":
+		return d.syncMeilisearch(ctx, h, upserts, deletes)
+	case "typesense":
+		return d.syncTypesense(ctx, h, upserts, deletes)
+	default:
+		return permanentError{fmt.Errorf("unsupported search index provider %q", h.Provider)}
+	}
+}
+
+func (d *Dispatcher) syncAlgolia(ctx context.Context, h ingitdb.SearchIndexDef, upserts []map[string]any, deletes []string) error {
+	host := h.Host
+	if host == "" {
+		host = "https://" + h.AppID + ".algolia.net"
+	}
+	type op struct {
+		Action string         `json:"action"`
+		Body   map[string]any `json:"body"`
+	}
+	ops := make([]op, 0, len(upserts)+len(deletes))
+	for _, doc := range upserts {
+		body := make(map[string]any, len(doc))
+		for k, v := range doc {
+			body[k] = v
+		}
+		body["objectID"] = body["id"]
+		delete(body, "id")
+		ops = append(ops, op{Action: "updateObject", Body: body})
+	}
+	for _, id := range deletes {
+		ops = append(ops, op{Action: "deleteObject", Body: map[string]any{"objectID": id}})
+	}
+	target := strings.TrimSuffix(host, "/") + "/1/indexes/" + url.PathEscape(h.Index) + "/batch"
+	headers := map[string]string{
+		"X-Algolia-Application-Id": h.AppID,
+		"X-Algolia-API-Key":        h.APIKey,
+	}
+	return d.postJSON(ctx, target, headers, map[string]any{"requests": ops})
+}
+
+func (d *Dispatcher) syncMeilisearch(ctx context.Context, h ingitdb.SearchIndexDef, upserts []map[string]any, deletes []string) error {
+	base := strings.TrimSuffix(h.Host, "/") + "/indexes/" + url.PathEscape(h.Index) + "/documents"
+	headers := map[string]string{"Authorization": "Bearer " + h.APIKey}
+	if len(upserts) > 0 {
+		if err := d.postJSON(ctx, base, headers, upserts); err != nil {
+			return err
+		}
+	}
+	if len(deletes) > 0 {
+		return d.postJSON(ctx, base+"/delete-batch", headers, deletes)
+	}
+	return nil
+}
+
+func (d *Dispatcher) syncTypesense(ctx context.Context, h ingitdb.SearchIndexDef, upserts []map[string]any, deletes []string) error {
+	base := strings.TrimSuffix(h.Host, "/") + "/collections/" + url.PathEscape(h.Index) + "/documents"
+	headers := map[string]string{"X-TYPESENSE-API-KEY": h.APIKey}
+	if len(upserts) > 0 {
+		var jsonl bytes.Buffer
+		enc := json.NewEncoder(&jsonl)
+		for _, doc := range upserts {
+			if err := enc.Encode(doc); err != nil {
+				return permanentError{err}
+			}
+		}
+		if err := d.request(ctx, http.MethodPost, base+"/import?action=upsert", "text/plain", jsonl.Bytes(), headers); err != nil {
+			return err
+		}
+	}
+	if len(deletes) > 0 {
+		// Deleting by filter, unlike deleting by ID, does not fail for records
+		// that were never indexed.
+		quoted := make([]string, len(deletes))
+		for i, id := range deletes {
+			quoted[i] = "`" + id + "`"
+		}
+		filter := url.Values{"filter_by": {"id:[" + strings.Join(quoted, ",") + "]"}}
+		return d.request(ctx, http.MethodDelete, base+"?"+filter.Encode(), "", nil, headers)
+	}
+	return nil
+}
@@ -1,5 +1,12 @@
 package ingitdb
 
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
 // TriggerEventType is the lifecycle event that fires a trigger.
 type TriggerEventType string
 
@@ -9,6 +16,15 @@ const (
 	TriggerEventDeleted TriggerEventType = "deleted"
 )
 
+// TriggerFilePrefix is the file-name prefix of trigger workflow files inside a
+// collection's schema directory: `.collection/trigger_<name>.yaml`. The
+// trigger's ID is the <name> part.
+const TriggerFilePrefix = "trigger_"
+
+// TriggerRunsOnLocal is the only supported TriggerJobDef.RunsOn value: the job
+// runs in the same environment as ingitdb itself.
+const TriggerRunsOnLocal = "."
+
 // TriggerStepDef is a single shell step within a trigger job.
 type TriggerStepDef struct {
 	Run string `yaml:"run"`
@@ -25,6 +41,66 @@ type TriggerJobDef struct {
 // (.collection/trigger_<name>.yaml).
 // Modelled after GitHub Actions workflow syntax.
 type TriggerDef struct {
+	ID   string                    `yaml:"-"` // taken from the file name
 	On   []TriggerEventType        `yaml:"on"`
 	Jobs map[string]*TriggerJobDef `yaml:"jobs"`
 }
+
+// Validate checks the trigger definition for consistency: at least one known
+// event, at least one job, every job running locally with at least one
+// non-empty step.
+func (t *TriggerDef) Validate() error {
+	if len(t.On) == 0 {
+		return fmt.Errorf("missing 'on' in trigger definition")
+	}
+	for i, event := range t.On {
+		switch event {
+		case TriggerEventCreated, TriggerEventUpdated, TriggerEventDeleted:
+		default:
+			return fmt.Errorf("on[%d]: unknown event %q, must be one of: created, updated, deleted", i, event)
+		}
+	}
+	if len(t.Jobs) == 0 {
+		return fmt.Errorf("missing 'jobs' in trigger definition")
+	}
+	for _, jobID := range slices.Sorted(maps.Keys(t.Jobs)) {
+		job := t.Jobs[jobID]
+		if job == nil {
+			return fmt.Errorf("job '%s' is empty", jobID)
+		}
+		if job.RunsOn != TriggerRunsOnLocal {
+			return fmt.Errorf("job '%s': unsupported runs-on %q, must be %q", jobID, job.RunsOn, TriggerRunsOnLocal)
+		}
+		if len(job.Steps) == 0 {
+			return fmt.Errorf("job '%s' has no steps", jobID)
+		}
+		for i, step := range job.Steps {
+			if strings.TrimSpace(step.Run) == "" {
+				return fmt.Errorf("job '%s': steps[%d] has an empty 'run'", jobID, i)
+			}
+		}
+	}
+	return nil
+}
+
+// FiresOn reports whether the trigger subscribes to event.
+func (t *TriggerDef) FiresOn(event TriggerEventType) bool {
+	return slices.Contains(t.On, event)
+}
+
+// TriggerEventForChange maps a file-level change kind to the trigger event it
+// fires. A rename surfaces the record under its new path, so it fires
+// "created" for the record it now is; the record it was is not visible in a
+// ChangedFile's new-path view. ok is false for an unknown kind.
+func TriggerEventForChange(kind ChangeKind) (event TriggerEventType, ok bool) {
+	switch kind {
+	case ChangeKindAdded, ChangeKindRenamed:
+		return TriggerEventCreated, true
+	case ChangeKindModified:
+		return TriggerEventUpdated, true
+	case ChangeKindDeleted:
+		return TriggerEventDeleted, true
+	default:
+		return "", false
+	}
+}
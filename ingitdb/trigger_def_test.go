@@ -0,0 +1,94 @@
+package ingitdb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTriggerDefValidate(t *testing.T) {
+	t.Parallel()
+
+	validJob := func() *TriggerJobDef {
+		return &TriggerJobDef{RunsOn: ".", Steps: []TriggerStepDef{{Run: "echo ok"}}}
+	}
+	tests := []struct {
+		name    string
+		def     TriggerDef
+		wantErr string
+	}{
+		{
+			name: "valid",
+			def:  TriggerDef{On: []TriggerEventType{TriggerEventCreated, TriggerEventDeleted}, Jobs: map[string]*TriggerJobDef{"notify": validJob()}},
+		},
+		{
+			name:    "missing_on",
+			def:     TriggerDef{Jobs: map[string]*TriggerJobDef{"notify": validJob()}},
+			wantErr: "missing 'on'",
+		},
+		{
+			name:    "unknown_event",
+			def:     TriggerDef{On: []TriggerEventType{"renamed"}, Jobs: map[string]*TriggerJobDef{"notify": validJob()}},
+			wantErr: `unknown event "renamed"`,
+		},
+		{
+			name:    "missing_jobs",
+			def:     TriggerDef{On: []TriggerEventType{TriggerEventUpdated}},
+			wantErr: "missing 'jobs'",
+		},
+		{
+			name: "remote_runner",
+			def: TriggerDef{On: []TriggerEventType{TriggerEventUpdated}, Jobs: map[string]*TriggerJobDef{
+				"notify": {RunsOn: "ubuntu-latest", Steps: []TriggerStepDef{{Run: "echo"}}},
+			}},
+			wantErr: `unsupported runs-on "ubuntu-latest"`,
+		},
+		{
+			name: "no_steps",
+			def: TriggerDef{On: []TriggerEventType{TriggerEventUpdated}, Jobs: map[string]*TriggerJobDef{
+				"notify": {RunsOn: "."},
+			}},
+			wantErr: "has no steps",
+		},
+		{
+			name: "empty_run",
+			def: TriggerDef{On: []TriggerEventType{TriggerEventUpdated}, Jobs: map[string]*TriggerJobDef{
+				"notify": {RunsOn: ".", Steps: []TriggerStepDef{{Run: "  "}}},
+			}},
+			wantErr: "steps[0] has an empty 'run'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.def.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTriggerEventForChange(t *testing.T) {
+	t.Parallel()
+
+	for kind, want := range map[ChangeKind]TriggerEventType{
+		ChangeKindAdded:    TriggerEventCreated,
+		ChangeKindRenamed:  TriggerEventCreated,
+		ChangeKindModified: TriggerEventUpdated,
+		ChangeKindDeleted:  TriggerEventDeleted,
+	} {
+		got, ok := TriggerEventForChange(kind)
+		if !ok || got != want {
+			t.Errorf("TriggerEventForChange(%s) = %s, %v; want %s", kind, got, ok, want)
+		}
+	}
+	if _, ok := TriggerEventForChange("copied"); ok {
+		t.Error("expected unknown change kind to map to no event")
+	}
+}
@@ -0,0 +1,169 @@
+// Package triggers runs collection trigger workflows
+// (.collection/trigger_<name>.yaml) for records touched by a change set.
+package triggers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/datavalidator"
+	"github.com/ingitdb/ingitdb-go/ingitdb/progress"
+)
+
+// TaskName is the ProgressEvent.TaskName of every event the runner reports.
+const TaskName = "trigger"
+
+// Environment variables a trigger step sees in addition to the inherited
+// process environment.
+const (
+	EnvCollection = "INGITDB_COLLECTION"  // collection ID, e.g. "todo.tasks"
+	EnvRecordKey  = "INGITDB_RECORD_KEY"  // record key; empty for a whole list/map file
+	EnvChangeKind = "INGITDB_CHANGE_KIND" // file-level change: added, modified, deleted, renamed
+	EnvEvent      = "INGITDB_EVENT"       // trigger event: created, updated, deleted
+	EnvFilePath   = "INGITDB_FILE_PATH"   // absolute path of the changed record file
+	EnvTrigger    = "INGITDB_TRIGGER"     // trigger ID (the <name> in trigger_<name>.yaml)
+	EnvJob        = "INGITDB_JOB"         // job ID within the trigger
+)
+
+// Runner executes the trigger workflows that match a set of changed records.
+type Runner interface {
+	// RunTriggers runs, for every affected record, each job of each trigger of
+	// the record's collection whose `on` list includes the record's event. It
+	// returns the joined step failures, or nil when every step succeeded.
+	RunTriggers(ctx context.Context, dbPath string, def *ingitdb.Definition, affected []datavalidator.AffectedRecord) error
+}
+
+// stepRunner executes one step's shell script in dir with env and returns its
+// combined output.
+type stepRunner func(ctx context.Context, dir string, env []string, script string) ([]byte, error)
+
+// NewRunner returns a Runner that executes steps locally with `sh -c` in the
+// database directory and reports step output and failures to reporter. A nil
+// reporter discards events.
+func NewRunner(reporter progress.ProgressReporter) Runner {
+	return &localRunner{reporter: reporter, runStep: runShellStep}
+}
+
+type localRunner struct {
+	reporter progress.ProgressReporter
+	// runStep is a seam over the shell; tests replace it to avoid spawning
+	// processes.
+	runStep stepRunner
+}
+
+// jobRun is one job to execute for one affected record.
+type jobRun struct {
+	record    datavalidator.AffectedRecord
+	event     ingitdb.TriggerEventType
+	triggerID string
+	jobID     string
+	job       *ingitdb.TriggerJobDef
+}
+
+func (r *localRunner) RunTriggers(ctx context.Context, dbPath string, def *ingitdb.Definition, affected []datavalidator.AffectedRecord) error {
+	runs := planJobRuns(def, affected)
+	total := 0
+	for _, run := range runs {
+		total += len(run.job.Steps)
+	}
+	r.report(ingitdb.ProgressEvent{Kind: ingitdb.ProgressKindStarted, TaskName: TaskName, Total: total})
+
+	var failures []error
+	done := 0
+	for _, run := range runs {
+		env := append(os.Environ(),
+			EnvCollection+"="+run.record.CollectionID,
+			EnvRecordKey+"="+run.record.RecordKey,
+			EnvChangeKind+"="+string(run.record.ChangeKind),
+			EnvEvent+"="+string(run.event),
+			EnvFilePath+"="+run.record.FilePath,
+			EnvTrigger+"="+run.triggerID,
+			EnvJob+"="+run.jobID,
+		)
+		for i, step := range run.job.Steps {
+			if err := ctx.Err(); err != nil {
+				r.report(ingitdb.ProgressEvent{Kind: ingitdb.ProgressKindAborted, TaskName: TaskName, Done: done, Total: total})
+				return err
+			}
+			out, err := r.runStep(ctx, dbPath, env, step.Run)
+			done++
+			if err != nil {
+				// A failing step ends its job, as in GitHub Actions; other jobs
+				// and other records still run.
+				failure := &ingitdb.ValidationError{
+					Severity:     ingitdb.SeverityError,
+					CollectionID: run.record.CollectionID,
+					FilePath:     run.record.FilePath,
+					RecordKey:    run.record.RecordKey,
+					Message:      fmt.Sprintf("trigger '%s' job '%s' step %d failed", run.triggerID, run.jobID, i+1),
+					Err:          err,
+				}
+				failures = append(failures, failure)
+				r.report(ingitdb.ProgressEvent{
+					Kind: ingitdb.ProgressKindError, TaskName: TaskName,
+					Scope: run.record.CollectionID, ItemKey: run.record.RecordKey,
+					Done: done, Total: total, Err: failure, Message: string(out),
+				})
+				// Count the job's remaining steps as done so Done still reaches Total.
+				done += len(run.job.Steps) - i - 1
+				break
+			}
+			r.report(ingitdb.ProgressEvent{
+				Kind: ingitdb.ProgressKindItemDone, TaskName: TaskName,
+				Scope: run.record.CollectionID, ItemKey: run.record.RecordKey,
+				Done: done, Total: total, Message: string(out),
+			})
+		}
+	}
+	r.report(ingitdb.ProgressEvent{Kind: ingitdb.ProgressKindCompleted, TaskName: TaskName, Done: done, Total: total})
+	return errors.Join(failures...)
+}
+
+// planJobRuns expands affected records into the job runs they fire, in a
+// deterministic order: records as given, then trigger IDs and job IDs sorted.
+func planJobRuns(def *ingitdb.Definition, affected []datavalidator.AffectedRecord) []jobRun {
+	var runs []jobRun
+	for _, ar := range affected {
+		colDef := def.Collections[ar.CollectionID]
+		if colDef == nil || len(colDef.Triggers) == 0 {
+			continue
+		}
+		event, ok := ingitdb.TriggerEventForChange(ar.ChangeKind)
+		if !ok {
+			continue
+		}
+		for _, triggerID := range slices.Sorted(maps.Keys(colDef.Triggers)) {
+			trigger := colDef.Triggers[triggerID]
+			if !trigger.FiresOn(event) {
+				continue
+			}
+			for _, jobID := range slices.Sorted(maps.Keys(trigger.Jobs)) {
+				job := trigger.Jobs[jobID]
+				if job == nil {
+					continue
+				}
+				runs = append(runs, jobRun{record: ar, event: event, triggerID: triggerID, jobID: jobID, job: job})
+			}
+		}
+	}
+	return runs
+}
+
+func (r *localRunner) report(event ingitdb.ProgressEvent) {
+	if r.reporter != nil {
+		r.reporter.Report(event)
+	}
+}
+
+func runShellStep(ctx context.Context, dir string, env []string, script string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", script)
+	cmd.Dir = dir
+	cmd.Env = env
+	return cmd.CombinedOutput()
+}
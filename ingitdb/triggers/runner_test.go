@@ -0,0 +1,181 @@
+package triggers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/datavalidator"
+)
+
+type recordingReporter struct {
+	mu     sync.Mutex
+	events []ingitdb.ProgressEvent
+}
+
+func (r *recordingReporter) Report(event ingitdb.ProgressEvent) {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+}
+
+func (r *recordingReporter) kinds() []ingitdb.ProgressKind {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kinds := make([]ingitdb.ProgressKind, len(r.events))
+	for i, e := range r.events {
+		kinds[i] = e.Kind
+	}
+	return kinds
+}
+
+func triggerDefinition(triggers map[string]*ingitdb.TriggerDef) *ingitdb.Definition {
+	return &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"todo.tasks": {ID: "todo.tasks", Triggers: triggers},
+	}}
+}
+
+func TestRunTriggers_RunsMatchingStepsWithRecordEnvironment(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	def := triggerDefinition(map[string]*ingitdb.TriggerDef{
+		"log": {
+			On: []ingitdb.TriggerEventType{ingitdb.TriggerEventUpdated},
+			Jobs: map[string]*ingitdb.TriggerJobDef{
+				"write": {RunsOn: ".", Steps: []ingitdb.TriggerStepDef{
+					{Run: `echo "$INGITDB_COLLECTION|$INGITDB_RECORD_KEY|$INGITDB_CHANGE_KIND|$INGITDB_EVENT|$INGITDB_FILE_PATH|$INGITDB_TRIGGER|$INGITDB_JOB" > out.txt`},
+					{Run: `echo done`},
+				}},
+			},
+		},
+		"on_delete": {
+			On: []ingitdb.TriggerEventType{ingitdb.TriggerEventDeleted},
+			Jobs: map[string]*ingitdb.TriggerJobDef{
+				"never": {RunsOn: ".", Steps: []ingitdb.TriggerStepDef{{Run: `touch deleted.txt`}}},
+			},
+		},
+	})
+	affected := []datavalidator.AffectedRecord{{
+		CollectionID: "todo.tasks",
+		FilePath:     "/db/todo/tasks/$records/t1.yaml",
+		RecordKey:    "t1",
+		ChangeKind:   ingitdb.ChangeKindModified,
+	}}
+	reporter := &recordingReporter{}
+
+	if err := NewRunner(reporter).RunTriggers(context.Background(), dir, def, affected); err != nil {
+		t.Fatalf("RunTriggers: %v", err)
+	}
+
+	out, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+	if err != nil {
+		t.Fatalf("step did not run in dbPath: %v", err)
+	}
+	want := "todo.tasks|t1|modified|updated|/db/todo/tasks/$records/t1.yaml|log|write\n"
+	if string(out) != want {
+		t.Errorf("step environment = %q, want %q", out, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "deleted.txt")); !os.IsNotExist(err) {
+		t.Error("trigger not subscribed to 'updated' must not run")
+	}
+
+	kinds := reporter.kinds()
+	wantKinds := []ingitdb.ProgressKind{
+		ingitdb.ProgressKindStarted, ingitdb.ProgressKindItemDone, ingitdb.ProgressKindItemDone, ingitdb.ProgressKindCompleted,
+	}
+	if strings.Join(toStrings(kinds), ",") != strings.Join(toStrings(wantKinds), ",") {
+		t.Errorf("events = %v, want %v", kinds, wantKinds)
+	}
+	if last := reporter.events[2]; last.Message != "done\n" || last.Done != 2 || last.Total != 2 {
+		t.Errorf("unexpected step event: %+v", last)
+	}
+}
+
+func TestRunTriggers_StepFailureStopsJobAndIsReported(t *testing.T) {
+	t.Parallel()
+
+	def := triggerDefinition(map[string]*ingitdb.TriggerDef{
+		"check": {
+			On: []ingitdb.TriggerEventType{ingitdb.TriggerEventCreated},
+			Jobs: map[string]*ingitdb.TriggerJobDef{
+				"a_fail": {RunsOn: ".", Steps: []ingitdb.TriggerStepDef{{Run: "fail"}, {Run: "skipped"}}},
+				"b_ok":   {RunsOn: ".", Steps: []ingitdb.TriggerStepDef{{Run: "ok"}}},
+			},
+		},
+	})
+	var ran []string
+	runner := &localRunner{
+		reporter: &recordingReporter{},
+		runStep: func(_ context.Context, _ string, _ []string, script string) ([]byte, error) {
+			ran = append(ran, script)
+			if script == "fail" {
+				return []byte("boom\n"), errors.New("exit status 1")
+			}
+			return nil, nil
+		},
+	}
+	affected := []datavalidator.AffectedRecord{{CollectionID: "todo.tasks", RecordKey: "t2", ChangeKind: ingitdb.ChangeKindAdded}}
+
+	err := runner.RunTriggers(context.Background(), "/db", def, affected)
+	if err == nil || !strings.Contains(err.Error(), "trigger 'check' job 'a_fail' step 1 failed") {
+		t.Fatalf("expected step failure, got %v", err)
+	}
+	if strings.Join(ran, ",") != "fail,ok" {
+		t.Errorf("steps run = %v, want [fail ok]", ran)
+	}
+	reporter := runner.reporter.(*recordingReporter)
+	var failure *ingitdb.ProgressEvent
+	for i := range reporter.events {
+		if reporter.events[i].Kind == ingitdb.ProgressKindError {
+			failure = &reporter.events[i]
+		}
+	}
+	if failure == nil {
+		t.Fatal("expected an error event")
+	}
+	if failure.Err == nil || failure.Err.RecordKey != "t2" || failure.Message != "boom\n" {
+		t.Errorf("unexpected error event: %+v", failure)
+	}
+	if completed := reporter.events[len(reporter.events)-1]; completed.Done != completed.Total {
+		t.Errorf("completed event Done=%d, Total=%d", completed.Done, completed.Total)
+	}
+}
+
+func TestRunTriggers_CancelledContextAborts(t *testing.T) {
+	t.Parallel()
+
+	def := triggerDefinition(map[string]*ingitdb.TriggerDef{
+		"t": {
+			On:   []ingitdb.TriggerEventType{ingitdb.TriggerEventCreated},
+			Jobs: map[string]*ingitdb.TriggerJobDef{"j": {RunsOn: ".", Steps: []ingitdb.TriggerStepDef{{Run: "x"}}}},
+		},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	reporter := &recordingReporter{}
+	runner := &localRunner{reporter: reporter, runStep: func(context.Context, string, []string, string) ([]byte, error) {
+		t.Error("no step may run after cancellation")
+		return nil, nil
+	}}
+	affected := []datavalidator.AffectedRecord{{CollectionID: "todo.tasks", ChangeKind: ingitdb.ChangeKindAdded}}
+	if err := runner.RunTriggers(ctx, "/db", def, affected); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if kinds := reporter.kinds(); kinds[len(kinds)-1] != ingitdb.ProgressKindAborted {
+		t.Errorf("expected final aborted event, got %v", kinds)
+	}
+}
+
+func toStrings(kinds []ingitdb.ProgressKind) []string {
+	out := make([]string, len(kinds))
+	for i, k := range kinds {
+		out[i] = string(k)
+	}
+	return out
+}
@@ -0,0 +1,32 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package tui
+
+import (
+	"os"
+	"time"
+)
+
+// keyReader reads keystrokes from a file. Without poll(2), a pending Read can
+// only be woken where the file supports read deadlines.
+type keyReader struct {
+	f *os.File
+}
+
+func newKeyReader(f *os.File) (*keyReader, error) {
+	return &keyReader{f: f}, nil
+}
+
+func (k *keyReader) Read(p []byte) (int, error) {
+	return k.f.Read(p)
+}
+
+// interrupt wakes a pending Read. It fails where the file has no read
+// deadlines, and Read may then stay blocked until the next keystroke.
+func (k *keyReader) interrupt() error {
+	return k.f.SetReadDeadline(time.Now())
+}
+
+func (k *keyReader) close() error {
+	return nil
+}
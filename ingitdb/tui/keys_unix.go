@@ -0,0 +1,78 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package tui
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// keyReader reads keystrokes from the terminal behind a file. Read waits in
+// poll(2) on the terminal and on a self-pipe, so interrupt can wake it even
+// where the file does not support read deadlines, as a blocking stdin does
+// not. The terminal is read only once poll reports input, so a keystroke
+// typed after interrupt stays in the terminal for whoever reads it next.
+type keyReader struct {
+	fd           int
+	wakeR, wakeW int
+}
+
+func newKeyReader(f *os.File) (*keyReader, error) {
+	var p [2]int
+	if err := unix.Pipe(p[:]); err != nil {
+		return nil, err
+	}
+	unix.CloseOnExec(p[0])
+	unix.CloseOnExec(p[1])
+	return &keyReader{fd: int(f.Fd()), wakeR: p[0], wakeW: p[1]}, nil
+}
+
+// Read returns the next keystrokes, or io.EOF once interrupt was called.
+func (k *keyReader) Read(p []byte) (int, error) {
+	fds := []unix.PollFd{
+		{Fd: int32(k.fd), Events: unix.POLLIN},
+		{Fd: int32(k.wakeR), Events: unix.POLLIN},
+	}
+	for {
+		if _, err := unix.Poll(fds, -1); err != nil {
+			if err == unix.EINTR {
+				continue
+			}
+			return 0, err
+		}
+		if fds[1].Revents != 0 {
+			return 0, io.EOF
+		}
+		if fds[0].Revents == 0 {
+			continue
+		}
+		n, err := unix.Read(k.fd, p)
+		switch {
+		case err == unix.EINTR || err == unix.EAGAIN:
+			continue
+		case err != nil:
+			return 0, err
+		case n == 0:
+			return 0, io.EOF
+		}
+		return n, nil
+	}
+}
+
+// interrupt wakes a pending Read, and makes every later one return io.EOF.
+func (k *keyReader) interrupt() error {
+	_, err := unix.Write(k.wakeW, []byte{0})
+	return err
+}
+
+// close releases the self-pipe. Read must no longer be running.
+func (k *keyReader) close() error {
+	errR := unix.Close(k.wakeR)
+	errW := unix.Close(k.wakeW)
+	if errR != nil {
+		return errR
+	}
+	return errW
+}
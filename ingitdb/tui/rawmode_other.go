@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package tui
+
+import "os"
+
+// makeRaw is a no-op where termios is unavailable: keys then arrive a line
+// at a time, after Enter.
+func makeRaw(*os.File) (restore func() error, err error) {
+	return func() error { return nil }, nil
+}
@@ -0,0 +1,398 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/progress"
+)
+
+const (
+	refreshInterval = 50 * time.Millisecond
+	barWidth        = 24
+	errorListRows   = 10
+)
+
+// ANSI sequences used by the terminal TUI.
+const (
+	ansiAltScreenOn  = "\x1b[?1049h"
+	ansiAltScreenOff = "\x1b[?1049l"
+	ansiHideCursor   = "\x1b[?25l"
+	ansiShowCursor   = "\x1b[?25h"
+	ansiHomeClear    = "\x1b[H\x1b[2J"
+	ansiReverse      = "\x1b[7m"
+	ansiBold         = "\x1b[1m"
+	ansiReset        = "\x1b[0m"
+)
+
+// progressRow is the on-screen state of one task scope (a collection).
+type progressRow struct {
+	task, scope string
+	done, total int
+	errors      int
+	state       ingitdb.ProgressKind
+}
+
+// detailView is what ShowDetail displays in place of the error list.
+type detailView struct {
+	item ingitdb.ProgressEvent
+	errs []ingitdb.ValidationError
+}
+
+type terminalTUI struct {
+	keys     *keyReader
+	keysDone chan struct{} // closed when readKeys returns
+	out      io.Writer
+	restore  func() error
+
+	mu       sync.Mutex
+	rows     []*progressRow
+	rowIndex map[string]*progressRow
+	errs     []ingitdb.ProgressEvent // error events carrying a ValidationError
+	selected int
+	detail   *detailView
+	dirty    bool
+
+	signal    atomic.Int32
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewTerminalTUI returns a full-screen TUI that draws to out and reads
+// keystrokes from in, which it switches to raw mode until Close.
+//
+// The screen shows one progress bar per collection (per task scope) with its
+// error count, and below it a live list of the ValidationErrors reported in
+// ProgressKindError events — the same errors the running task appends to its
+// ValidationResult. Keys:
+//
+//	s            skip the current item (progress.SignalSkipItem)
+//	q, Ctrl-C    abort (progress.SignalAbort)
+//	↑/↓, k/j     select an error
+//	Enter, d     drill down: show every error of the selected record
+//	Esc, b       back from the detail view
+func NewTerminalTUI(in *os.File, out io.Writer) (TUI, error) {
+	keys, err := newKeyReader(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys from the terminal: %w", err)
+	}
+	restore, err := makeRaw(in)
+	if err != nil {
+		_ = keys.close()
+		return nil, fmt.Errorf("failed to switch terminal to raw mode: %w", err)
+	}
+	t := &terminalTUI{
+		keys:     keys,
+		keysDone: make(chan struct{}),
+		out:      out,
+		restore:  restore,
+		rowIndex: make(map[string]*progressRow),
+		stop:     make(chan struct{}),
+		dirty:    true,
+	}
+	_, _ = io.WriteString(out, ansiAltScreenOn+ansiHideCursor)
+	t.wg.Add(1)
+	go t.renderLoop()
+	go t.readKeys()
+	return t, nil
+}
+
+// Update records a progress event; the screen is redrawn on the next refresh.
+func (t *terminalTUI) Update(event ingitdb.ProgressEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := event.TaskName + "\x00" + event.Scope
+	row := t.rowIndex[key]
+	if row == nil {
+		row = &progressRow{task: event.TaskName, scope: event.Scope}
+		t.rowIndex[key] = row
+		t.rows = append(t.rows, row)
+	}
+	if event.Total > 0 {
+		row.total = event.Total
+	}
+	if event.Done > row.done {
+		row.done = event.Done
+	}
+	switch event.Kind {
+	case ingitdb.ProgressKindError:
+		row.errors++
+		if event.Err != nil {
+			t.errs = append(t.errs, event)
+		}
+	case ingitdb.ProgressKindStarted, ingitdb.ProgressKindCompleted, ingitdb.ProgressKindAborted:
+		row.state = event.Kind
+	}
+	t.dirty = true
+}
+
+// ShowDetail replaces the error list with item's errors until the user goes
+// back with Esc.
+func (t *terminalTUI) ShowDetail(item ingitdb.ProgressEvent, errs []ingitdb.ValidationError) {
+	t.mu.Lock()
+	t.detail = &detailView{item: item, errs: errs}
+	t.dirty = true
+	t.mu.Unlock()
+}
+
+// Steer returns the last signal requested by a keystroke and resets it.
+func (t *terminalTUI) Steer() progress.Signal {
+	return progress.Signal(t.signal.Swap(int32(progress.SignalNone)))
+}
+
+// Close draws the final state, restores the terminal and leaves the
+// alternate screen.
+func (t *terminalTUI) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		close(t.stop)
+		t.wg.Wait()
+		// Stop readKeys before handing the terminal back, or it would
+		// swallow the next keystroke meant for whatever reads it after us.
+		if t.keys.interrupt() == nil {
+			<-t.keysDone
+			_ = t.keys.close()
+		}
+		_, _ = io.WriteString(t.out, ansiShowCursor+ansiAltScreenOff)
+		err = t.restore()
+	})
+	return err
+}
+
+func (t *terminalTUI) renderLoop() {
+	defer t.wg.Done()
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			t.render()
+			return
+		case <-ticker.C:
+			t.render()
+		}
+	}
+}
+
+func (t *terminalTUI) readKeys() {
+	defer close(t.keysDone)
+	buf := make([]byte, 64)
+	for {
+		n, err := t.keys.Read(buf)
+		if n > 0 {
+			t.handleKeys(buf[:n])
+		}
+		if err != nil {
+			return
+		}
+		select {
+		case <-t.stop:
+			return
+		default:
+		}
+	}
+}
+
+// handleKeys interprets one read's worth of input. Arrow keys arrive as
+// ESC [ A / ESC [ B; a lone ESC means "back".
+func (t *terminalTUI) handleKeys(keys []byte) {
+	for i := 0; i < len(keys); i++ {
+		switch k := keys[i]; {
+		case k == 0x1b && i+2 < len(keys) && keys[i+1] == '[':
+			switch keys[i+2] {
+			case 'A':
+				t.moveSelection(-1)
+			case 'B':
+				t.moveSelection(1)
+			}
+			i += 2
+		case k == 0x1b || k == 'b':
+			t.closeDetail()
+		case k == 's':
+			t.raise(progress.SignalSkipItem)
+		case k == 'q' || k == 0x03:
+			t.raise(progress.SignalAbort)
+		case k == 'k':
+			t.moveSelection(-1)
+		case k == 'j':
+			t.moveSelection(1)
+		case k == '\r' || k == '\n' || k == 'd':
+			t.drillDown()
+		}
+	}
+}
+
+func (t *terminalTUI) moveSelection(delta int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.selected = max(0, min(len(t.errs)-1, t.selected+delta))
+	t.dirty = true
+}
+
+func (t *terminalTUI) closeDetail() {
+	t.mu.Lock()
+	t.detail = nil
+	t.dirty = true
+	t.mu.Unlock()
+}
+
+// drillDown opens the detail view for the selected error's record, listing
+// every error reported for the same file and record key.
+func (t *terminalTUI) drillDown() {
+	t.mu.Lock()
+	if len(t.errs) == 0 {
+		t.mu.Unlock()
+		return
+	}
+	item := t.errs[t.selected]
+	var errs []ingitdb.ValidationError
+	for _, e := range t.errs {
+		if e.Err.CollectionID == item.Err.CollectionID && e.Err.FilePath == item.Err.FilePath && e.Err.RecordKey == item.Err.RecordKey {
+			errs = append(errs, *e.Err)
+		}
+	}
+	t.mu.Unlock()
+	t.raise(progress.SignalDrillDown)
+	t.ShowDetail(item, errs)
+}
+
+// raise makes sig the pending signal unless a more important one is already
+// pending, so a later keystroke never cancels an abort or a skip that Steer
+// has not read yet.
+func (t *terminalTUI) raise(sig progress.Signal) {
+	for {
+		pending := progress.Signal(t.signal.Load())
+		if signalRank(pending) > signalRank(sig) {
+			return
+		}
+		if t.signal.CompareAndSwap(int32(pending), int32(sig)) {
+			return
+		}
+	}
+}
+
+// signalRank orders signals by importance: abort, then skip, then drill-down.
+func signalRank(sig progress.Signal) int {
+	switch sig {
+	case progress.SignalAbort:
+		return 3
+	case progress.SignalSkipItem:
+		return 2
+	case progress.SignalDrillDown:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (t *terminalTUI) render() {
+	t.mu.Lock()
+	if !t.dirty {
+		t.mu.Unlock()
+		return
+	}
+	t.dirty = false
+	var b bytes.Buffer
+	b.WriteString(ansiHomeClear)
+	t.drawProgress(&b)
+	if t.detail != nil {
+		t.drawDetail(&b)
+	} else {
+		t.drawErrors(&b)
+	}
+	t.mu.Unlock()
+	// Raw mode turns off output post-processing, so lines end in CRLF.
+	_, _ = io.WriteString(t.out, strings.ReplaceAll(b.String(), "\n", "\r\n"))
+}
+
+func (t *terminalTUI) drawProgress(b *bytes.Buffer) {
+	fmt.Fprintf(b, "%sinGitDB%s\n\n", ansiBold, ansiReset)
+	width := 0
+	for _, r := range t.rows {
+		width = max(width, len(r.task)+1+len(r.scope))
+	}
+	for _, r := range t.rows {
+		label := r.task + " " + r.scope
+		fmt.Fprintf(b, "%-*s  %s  %s", width, label, progressBar(r.done, r.total), progressCount(r.done, r.total))
+		if r.errors > 0 {
+			fmt.Fprintf(b, "  errors: %d", r.errors)
+		}
+		switch r.state {
+		case ingitdb.ProgressKindCompleted:
+			b.WriteString("  done")
+		case ingitdb.ProgressKindAborted:
+			b.WriteString("  aborted")
+		}
+		b.WriteByte('\n')
+	}
+}
+
+func progressBar(done, total int) string {
+	filled := 0
+	if total > 0 {
+		filled = min(barWidth, done*barWidth/total)
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", barWidth-filled) + "]"
+}
+
+func progressCount(done, total int) string {
+	if total == 0 {
+		return fmt.Sprintf("%d", done)
+	}
+	return fmt.Sprintf("%d/%d", done, total)
+}
+
+func (t *terminalTUI) drawErrors(b *bytes.Buffer) {
+	fmt.Fprintf(b, "\n%sErrors (%d)%s\n", ansiBold, len(t.errs), ansiReset)
+	// Scroll the window so the selection stays visible.
+	start := max(0, t.selected-errorListRows+1)
+	end := min(len(t.errs), start+errorListRows)
+	for i := start; i < end; i++ {
+		line := "  " + errorLine(t.errs[i])
+		if i == t.selected {
+			line = ansiReverse + "> " + errorLine(t.errs[i]) + ansiReset
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n[s] skip  [q] abort  [↑/↓] select  [enter] details\n")
+}
+
+func errorLine(e ingitdb.ProgressEvent) string {
+	v := e.Err
+	where := v.CollectionID
+	if v.RecordKey != "" {
+		where += "/" + v.RecordKey
+	}
+	if v.FieldName != "" {
+		where += "." + v.FieldName
+	}
+	return where + ": " + v.Error()
+}
+
+func (t *terminalTUI) drawDetail(b *bytes.Buffer) {
+	d := t.detail
+	title := d.item.Scope
+	if d.item.ItemKey != "" {
+		title += "/" + d.item.ItemKey
+	}
+	fmt.Fprintf(b, "\n%sDetails: %s%s\n", ansiBold, title, ansiReset)
+	if len(d.errs) > 0 && d.errs[0].FilePath != "" {
+		fmt.Fprintf(b, "file: %s\n", d.errs[0].FilePath)
+	}
+	for _, e := range d.errs {
+		field := e.FieldName
+		if field == "" {
+			field = "(record)"
+		}
+		fmt.Fprintf(b, "  - %s: %s\n", field, e.Error())
+	}
+	b.WriteString("\n[esc] back\n")
+}
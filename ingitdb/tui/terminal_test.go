@@ -0,0 +1,274 @@
+//go:build linux
+
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/progress"
+)
+
+// openPTY allocates a pseudo-terminal pair: the TUI gets the slave side as
+// its terminal, the test drives it through the master side.
+func openPTY(t *testing.T) (master, slave *os.File) {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("pseudo-terminals unavailable: %v", err)
+	}
+	fd := int(master.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		t.Fatalf("unlock pty: %v", err)
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		t.Fatalf("pty number: %v", err)
+	}
+	slave, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("cannot open pty slave: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = slave.Close()
+		_ = master.Close()
+	})
+	return master, slave
+}
+
+// screenReader accumulates everything the TUI writes to the terminal.
+type screenReader struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func readScreen(master *os.File) *screenReader {
+	s := &screenReader{}
+	go func() {
+		chunk := make([]byte, 4096)
+		for {
+			n, err := master.Read(chunk)
+			s.mu.Lock()
+			s.buf.Write(chunk[:n])
+			s.mu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// waitFor waits until the output written since the last clear-screen contains
+// every want string.
+func (s *screenReader) waitFor(t *testing.T, want ...string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		out := s.buf.String()
+		s.mu.Unlock()
+		if i := strings.LastIndex(out, ansiHomeClear); i >= 0 {
+			out = out[i:]
+		}
+		missing := ""
+		for _, w := range want {
+			if !strings.Contains(out, w) {
+				missing = w
+				break
+			}
+		}
+		if missing == "" {
+			return out
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("screen never showed %q; last frame:\n%s", missing, out)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func waitForSignal(t *testing.T, s progress.Steerer, want progress.Signal) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if got := s.Steer(); got == want {
+			return
+		} else if got != progress.SignalNone {
+			t.Fatalf("Steer() = %v, want %v", got, want)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("signal %v never arrived", want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func newTestTerminal(t *testing.T) (TUI, *os.File, *screenReader) {
+	t.Helper()
+	master, slave := openPTY(t)
+	screen := readScreen(master)
+	ui, err := NewTerminalTUI(slave, slave)
+	if err != nil {
+		t.Fatalf("NewTerminalTUI: %v", err)
+	}
+	t.Cleanup(func() { _ = ui.Close() })
+	return ui, master, screen
+}
+
+func validationErrorEvent(key, field, msg string) ingitdb.ProgressEvent {
+	return ingitdb.ProgressEvent{
+		Kind: ingitdb.ProgressKindError, TaskName: "validate", Scope: "countries", ItemKey: key,
+		Err: &ingitdb.ValidationError{
+			Severity: ingitdb.SeverityError, CollectionID: "countries", FilePath: "/db/countries/" + key + ".yaml",
+			RecordKey: key, FieldName: field, Message: msg,
+		},
+	}
+}
+
+func TestTerminalTUI_ProgressAndErrors(t *testing.T) {
+	t.Parallel()
+
+	ui, _, screen := newTestTerminal(t)
+	ui.Update(ingitdb.ProgressEvent{Kind: ingitdb.ProgressKindStarted, TaskName: "validate", Scope: "countries", Total: 4})
+	ui.Update(ingitdb.ProgressEvent{Kind: ingitdb.ProgressKindItemDone, TaskName: "validate", Scope: "countries", Done: 2, Total: 4})
+	ui.Update(validationErrorEvent("fr", "name", "wrong type"))
+	ui.Update(ingitdb.ProgressEvent{Kind: ingitdb.ProgressKindCompleted, TaskName: "validate", Scope: "users", Done: 3, Total: 3})
+
+	screen.waitFor(t,
+		"validate countries  ["+strings.Repeat("#", barWidth/2)+strings.Repeat("-", barWidth/2)+"]  2/4  errors: 1",
+		"validate users      ["+strings.Repeat("#", barWidth)+"]  3/3  done",
+		"Errors (1)",
+		"countries/fr.name: error: wrong type",
+	)
+}
+
+func TestTerminalTUI_KeysSteer(t *testing.T) {
+	t.Parallel()
+
+	ui, master, _ := newTestTerminal(t)
+	if got := ui.Steer(); got != progress.SignalNone {
+		t.Fatalf("initial Steer() = %v, want SignalNone", got)
+	}
+	_, _ = master.Write([]byte("s"))
+	waitForSignal(t, ui, progress.SignalSkipItem)
+	if got := ui.Steer(); got != progress.SignalNone {
+		t.Errorf("Steer() did not reset, got %v", got)
+	}
+	// Ctrl-C reaches the TUI as a byte because raw mode disables ISIG.
+	_, _ = master.Write([]byte{0x03})
+	waitForSignal(t, ui, progress.SignalAbort)
+}
+
+func TestTerminalTUI_DrillDown(t *testing.T) {
+	t.Parallel()
+
+	ui, master, screen := newTestTerminal(t)
+	ui.Update(validationErrorEvent("fr", "name", "wrong type"))
+	ui.Update(validationErrorEvent("de", "code", "missing required field"))
+	ui.Update(validationErrorEvent("de", "name", "too long"))
+	screen.waitFor(t, "Errors (3)")
+
+	// Down arrow to the first "de" error, then Enter.
+	_, _ = master.Write([]byte("\x1b[B\r"))
+	waitForSignal(t, ui, progress.SignalDrillDown)
+	frame := screen.waitFor(t, "Details: countries/de", "file: /db/countries/de.yaml", "code: error: missing required field", "name: error: too long")
+	if strings.Contains(frame, "wrong type") {
+		t.Errorf("detail view should list only the selected record's errors:\n%s", frame)
+	}
+
+	_, _ = master.Write([]byte("b"))
+	screen.waitFor(t, "Errors (3)")
+}
+
+func TestTerminalTUI_CloseRestoresTerminal(t *testing.T) {
+	t.Parallel()
+
+	master, slave := openPTY(t)
+	readScreen(master) // drain output so writes never block
+	before, err := unix.IoctlGetTermios(int(slave.Fd()), ioctlGetTermios)
+	if err != nil {
+		t.Fatalf("get termios: %v", err)
+	}
+	ui, err := NewTerminalTUI(slave, slave)
+	if err != nil {
+		t.Fatalf("NewTerminalTUI: %v", err)
+	}
+	during, _ := unix.IoctlGetTermios(int(slave.Fd()), ioctlGetTermios)
+	if during.Lflag&unix.ICANON != 0 {
+		t.Error("expected canonical mode to be off while the TUI runs")
+	}
+	if err := ui.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	after, _ := unix.IoctlGetTermios(int(slave.Fd()), ioctlGetTermios)
+	if after.Lflag != before.Lflag || after.Iflag != before.Iflag || after.Oflag != before.Oflag {
+		t.Error("Close did not restore the terminal mode")
+	}
+	if err := ui.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+// After Close, the key reader is gone: a keystroke typed then is left in the
+// terminal for the next reader instead of being swallowed.
+func TestTerminalTUI_CloseStopsKeyReader(t *testing.T) {
+	t.Parallel()
+
+	master, slave := openPTY(t)
+	readScreen(master)
+	ui, err := NewTerminalTUI(slave, slave)
+	if err != nil {
+		t.Fatalf("NewTerminalTUI: %v", err)
+	}
+	if err = ui.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	_, _ = master.Write([]byte("x\n"))
+
+	fds := []unix.PollFd{{Fd: int32(slave.Fd()), Events: unix.POLLIN}}
+	if n, pollErr := unix.Poll(fds, 5000); pollErr != nil || n == 0 {
+		t.Fatalf("keystroke never reached the terminal: n=%d err=%v", n, pollErr)
+	}
+	buf := make([]byte, 16)
+	n, err := unix.Read(int(slave.Fd()), buf)
+	if err != nil || !strings.HasPrefix(string(buf[:n]), "x") {
+		t.Errorf("read %q, %v; want the keystroke typed after Close", buf[:n], err)
+	}
+}
+
+func TestTerminalTUI_SignalPrecedence(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		raised []progress.Signal
+		want   progress.Signal
+	}{
+		{"drill-down keeps abort", []progress.Signal{progress.SignalAbort, progress.SignalDrillDown}, progress.SignalAbort},
+		{"drill-down keeps skip", []progress.Signal{progress.SignalSkipItem, progress.SignalDrillDown}, progress.SignalSkipItem},
+		{"skip keeps abort", []progress.Signal{progress.SignalAbort, progress.SignalSkipItem}, progress.SignalAbort},
+		{"abort replaces drill-down", []progress.Signal{progress.SignalDrillDown, progress.SignalAbort}, progress.SignalAbort},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ui := &terminalTUI{}
+			for _, sig := range tc.raised {
+				ui.raise(sig)
+			}
+			if got := ui.Steer(); got != tc.want {
+				t.Errorf("Steer() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
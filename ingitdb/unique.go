@@ -0,0 +1,85 @@
+package ingitdb
+
+// specscore: feature/column-validation
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// uniqueColumnTypes are the column types whose values can be compared for
+// uniqueness. Lists, maps and `any` have no single value to compare.
+var uniqueColumnTypes = []ColumnType{
+	ColumnTypeString,
+	ColumnTypeInt,
+	ColumnTypeFloat,
+	ColumnTypeBool,
+	ColumnTypeDate,
+	ColumnTypeTime,
+	ColumnTypeDateTime,
+}
+
+// validateUniqueColumn checks that a column declared `unique: true` can be
+// compared: it must be a stored scalar column.
+func validateUniqueColumn(col *ColumnDef) error {
+	if col.Formula != "" {
+		return fmt.Errorf("a computed column cannot be unique")
+	}
+	if !slices.Contains(uniqueColumnTypes, col.Type) {
+		return fmt.Errorf("a column of type '%s' cannot be unique", col.Type)
+	}
+	return nil
+}
+
+// validateUniqueConstraints checks the collection-level `unique:` tuples. Each
+// must name at least one column, every column must be declared, stored and
+// scalar, and no column may appear twice in a tuple. A tuple repeating another
+// one, in any column order, is rejected as a likely copy-paste mistake.
+func (v *CollectionDef) validateUniqueConstraints() error {
+	seen := make(map[string]int, len(v.Unique))
+	for i, tuple := range v.Unique {
+		if len(tuple) == 0 {
+			return fmt.Errorf("collection '%s': unique[%d] lists no columns", v.ID, i)
+		}
+		for j, colName := range tuple {
+			col, ok := v.Columns[colName]
+			if !ok {
+				return fmt.Errorf("collection '%s': unique[%d] references unspecified column: %s", v.ID, i, colName)
+			}
+			if err := validateUniqueColumn(col); err != nil {
+				return fmt.Errorf("collection '%s': unique[%d] column '%s': %w", v.ID, i, colName, err)
+			}
+			if slices.Contains(tuple[:j], colName) {
+				return fmt.Errorf("collection '%s': unique[%d] lists column '%s' twice", v.ID, i, colName)
+			}
+		}
+		key := strings.Join(slices.Sorted(slices.Values(tuple)), "\x00")
+		if prev, ok := seen[key]; ok {
+			return fmt.Errorf("collection '%s': unique[%d] repeats unique[%d]", v.ID, i, prev)
+		}
+		seen[key] = i
+	}
+	return nil
+}
+
+// UniqueConstraints returns every uniqueness constraint of col as a column
+// tuple: first each `unique: true` column on its own, in name order, then the
+// collection-level tuples as declared. A column-level constraint that is also
+// declared as a one-column tuple is listed once.
+func UniqueConstraints(col *CollectionDef) [][]string {
+	var constraints [][]string
+	for _, name := range slices.Sorted(maps.Keys(col.Columns)) {
+		if col.Columns[name].Unique {
+			constraints = append(constraints, []string{name})
+		}
+	}
+	for _, tuple := range col.Unique {
+		if len(tuple) == 1 && col.Columns[tuple[0]] != nil && col.Columns[tuple[0]].Unique {
+			continue
+		}
+		constraints = append(constraints, tuple)
+	}
+	return constraints
+}
@@ -0,0 +1,85 @@
+package ingitdb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func uniqueCollection(columns map[string]*ColumnDef, unique ...[]string) *CollectionDef {
+	return &CollectionDef{
+		ID:         "c",
+		RecordFile: &RecordFileDef{Name: "{key}.json", Format: RecordFormatJSON, RecordType: "map[string]any"},
+		Columns:    columns,
+		Unique:     unique,
+	}
+}
+
+func TestUnique_DefinitionValidation(t *testing.T) {
+	t.Parallel()
+
+	columns := func() map[string]*ColumnDef {
+		return map[string]*ColumnDef{
+			"code":    {Type: ColumnTypeString},
+			"country": {Type: ColumnTypeString},
+			"tags":    {Type: "[]string"},
+			"total":   {Type: ColumnTypeInt, Formula: "1 + 1"},
+		}
+	}
+	cases := []struct {
+		name    string
+		def     *CollectionDef
+		wantErr string
+	}{
+		{name: "column and tuple", def: func() *CollectionDef {
+			c := uniqueCollection(columns(), []string{"country", "code"})
+			c.Columns["code"].Unique = true
+			return c
+		}()},
+		{name: "unique computed column", def: func() *CollectionDef {
+			c := uniqueCollection(columns())
+			c.Columns["total"].Unique = true
+			return c
+		}(), wantErr: "computed column cannot be unique"},
+		{name: "unique list column", def: func() *CollectionDef {
+			c := uniqueCollection(columns())
+			c.Columns["tags"].Unique = true
+			return c
+		}(), wantErr: "type '[]string' cannot be unique"},
+		{name: "empty tuple", def: uniqueCollection(columns(), []string{}), wantErr: "unique[0] lists no columns"},
+		{name: "unknown column", def: uniqueCollection(columns(), []string{"nope"}), wantErr: "unspecified column: nope"},
+		{name: "computed column in tuple", def: uniqueCollection(columns(), []string{"code", "total"}), wantErr: "computed column cannot be unique"},
+		{name: "column twice", def: uniqueCollection(columns(), []string{"code", "code"}), wantErr: "lists column 'code' twice"},
+		{name: "repeated tuple", def: uniqueCollection(columns(), []string{"country", "code"}, []string{"code", "country"}), wantErr: "unique[1] repeats unique[0]"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.def.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestUniqueConstraints(t *testing.T) {
+	t.Parallel()
+
+	col := uniqueCollection(map[string]*ColumnDef{
+		"b":       {Type: ColumnTypeString, Unique: true},
+		"a":       {Type: ColumnTypeString, Unique: true},
+		"code":    {Type: ColumnTypeString},
+		"country": {Type: ColumnTypeString},
+	}, []string{"country", "code"}, []string{"a"})
+	want := [][]string{{"a"}, {"b"}, {"country", "code"}}
+	if got := UniqueConstraints(col); !reflect.DeepEqual(got, want) {
+		t.Errorf("UniqueConstraints = %v, want %v", got, want)
+	}
+}
@@ -1,7 +1,9 @@
 package ingitdb
 
 import (
+	"cmp"
 	"fmt"
+	"slices"
 	"sync"
 )
 
@@ -88,6 +90,23 @@ func (r *ValidationResult) Filter(fn func(ValidationError) bool) []ValidationErr
 	return out
 }
 
+// SortErrors orders findings by collection, file, record key, field and
+// message, so a report does not depend on the order concurrent validation
+// happened to find them in.
+func (r *ValidationResult) SortErrors() {
+	r.mu.Lock()
+	slices.SortStableFunc(r.errors, func(a, b ValidationError) int {
+		return cmp.Or(
+			cmp.Compare(a.CollectionID, b.CollectionID),
+			cmp.Compare(a.FilePath, b.FilePath),
+			cmp.Compare(a.RecordKey, b.RecordKey),
+			cmp.Compare(a.FieldName, b.FieldName),
+			cmp.Compare(a.Message, b.Message),
+		)
+	})
+	r.mu.Unlock()
+}
+
 // SetRecordCount sets the number of records validated for a collection.
 func (r *ValidationResult) SetRecordCount(collectionID string, count int) {
 	r.mu.Lock()
@@ -478,3 +478,34 @@ func TestValidationResult_SetRecordCounts_Concurrent(t *testing.T) {
 		}
 	}
 }
+
+func TestValidationResult_SortErrors(t *testing.T) {
+	t.Parallel()
+
+	result := &ValidationResult{}
+	for _, e := range []ValidationError{
+		{CollectionID: "b", FilePath: "/b/1.yaml", Message: "x"},
+		{CollectionID: "a", FilePath: "/a/2.yaml", FieldName: "title", Message: "x"},
+		{CollectionID: "a", FilePath: "/a/1.yaml", FieldName: "title", Message: "x"},
+		{CollectionID: "a", FilePath: "/a/1.yaml", FieldName: "done", Message: "x"},
+		{CollectionID: "a", FilePath: "/a/1.yaml", Message: "x"},
+	} {
+		result.Append(e)
+	}
+	result.SortErrors()
+
+	var got []string
+	for _, e := range result.Errors() {
+		got = append(got, e.CollectionID+" "+e.FilePath+" "+e.FieldName)
+	}
+	want := []string{
+		"a /a/1.yaml ",
+		"a /a/1.yaml done",
+		"a /a/1.yaml title",
+		"a /a/2.yaml title",
+		"b /b/1.yaml ",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("sorted = %q, want %q", got, want)
+	}
+}
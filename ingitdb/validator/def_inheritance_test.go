@@ -247,6 +247,60 @@ func TestInheritance_NearerBaseWins(t *testing.T) {
 	}
 }
 
+// AC: unique-constraints-accumulate — the base's collection-level unique
+// tuples stay in force next to the child's own, and a tuple both declare is
+// kept once.
+func TestInheritance_UniqueConstraintsAccumulate(t *testing.T) {
+	dir := writeInheritanceDB(t, map[string]string{
+		".ingitdb/root-collections.yaml": rootStates,
+		"states/.collection/$base.yaml":  "unique: [[code], [code, name]]\ncolumns:\n  code:\n    type: string\n",
+		"states/.collection/definition.yaml": "inherits: $base.yaml\n" + mapRecordFile +
+			"unique: [[name, code], [name]]\ncolumns:\n  name:\n    type: string\n",
+		"states/records.json": `{"r1": {"name": "X", "code": "aa"}, "r2": {"name": "Y", "code": "aa"}}` + "\n",
+	})
+	def, viols := validateDB(t, dir)
+	if got := def.Collections["states"].Unique; len(got) != 3 {
+		t.Errorf("want the child's 2 tuples plus the base's [code], got %v", got)
+	}
+	if len(viols) == 0 || !strings.Contains(viols[0].Error(), "code") {
+		t.Errorf("the inherited unique [code] must reject the repeated code; got %v", viols)
+	}
+}
+
+// AC: foreign-keys-inherited — a composite foreign key declared only by the
+// base still checks the child's records; one the child redeclares over the
+// same columns replaces it.
+func TestInheritance_ForeignKeysInherited(t *testing.T) {
+	const cities = "record_file:\n  name: records.json\n  format: json\n  type: \"[]map[string]any\"\n" +
+		"primary_key: [country, code]\ncolumns:\n  country:\n    type: string\n  code:\n    type: string\n"
+	dir := writeInheritanceDB(t, map[string]string{
+		".ingitdb/root-collections.yaml":     "states: states\ncities: cities\n",
+		"cities/.collection/definition.yaml": cities,
+		"cities/records.json":                `[{"country": "ie", "code": "dub"}]` + "\n",
+		"states/.collection/$base.yaml": "foreign_keys:\n  - columns: [country, capital]\n    references: cities\n" +
+			"  - columns: [capital, country]\n    references: cities\n" +
+			"columns:\n  country:\n    type: string\n  capital:\n    type: string\n",
+		"states/.collection/definition.yaml": "inherits: $base.yaml\n" + mapRecordFile +
+			"foreign_keys:\n  - columns: [capital, country]\n    references: cities\n    on_delete: cascade\n" +
+			"columns:\n  name:\n    type: string\n",
+		"states/records.json": `{"r1": {"name": "X", "country": "ie", "capital": "cork"}}` + "\n",
+	})
+	def, viols := validateDB(t, dir)
+	fks := def.Collections["states"].ForeignKeys
+	if len(fks) != 2 || fks[0].OnDelete != ingitdb.OnDeleteCascade {
+		t.Errorf("want the child's [capital, country] key plus the base's [country, capital], got %+v", fks)
+	}
+	found := false
+	for _, v := range viols {
+		if strings.Contains(v.Error(), "cork") && strings.Contains(v.Error(), "country") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("the inherited foreign key must reject the unknown city; got %v", viols)
+	}
+}
+
 func keysOf(m map[string]*ingitdb.ColumnDef) []string {
 	ks := make([]string, 0, len(m))
 	for k := range m {
@@ -9,8 +9,10 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/ingitdb/ingitdb-go/ingitdb"
@@ -102,7 +104,8 @@ func absInheritPath(p string) string {
 }
 
 // overlayCollectionDef fills fields child leaves unset from base and merges
-// columns and titles by key, with child winning. Structural fields populated
+// columns, titles, checks and composite foreign keys by key, with child
+// winning. Unique constraints add up rather than override. Structural fields populated
 // from the filesystem (ID, DirPath, SubCollections, Views) are never taken from
 // base. See spec/features/definition-inheritance REQ column-merge-child-wins and
 // REQ scalar-and-map-field-inheritance.
@@ -131,6 +134,33 @@ func overlayCollectionDef(child, base *ingitdb.CollectionDef) {
 			}
 		}
 	}
+	// Checks merge by name like columns: a check child declares replaces base's
+	// check of the same name.
+	if len(base.Checks) > 0 {
+		if child.Checks == nil {
+			child.Checks = make(map[string]*ingitdb.CheckDef, len(base.Checks))
+		}
+		for name, check := range base.Checks {
+			if _, ok := child.Checks[name]; !ok {
+				child.Checks[name] = check
+			}
+		}
+	}
+	// Unique constraints accumulate: the child keeps every tuple of base and
+	// adds its own. A tuple both declare, in any column order, is kept once.
+	for _, tuple := range base.Unique {
+		if !slices.ContainsFunc(child.Unique, func(own []string) bool { return sameColumnSet(own, tuple) }) {
+			child.Unique = append(child.Unique, tuple)
+		}
+	}
+	// Composite foreign keys merge by their column list: a key child declares
+	// over the same columns, in the same order, replaces base's (to change its
+	// target or on_delete); every other key of base is inherited.
+	for _, fk := range base.ForeignKeys {
+		if !slices.ContainsFunc(child.ForeignKeys, func(own ingitdb.ForeignKeyDef) bool { return slices.Equal(own.Columns, fk.Columns) }) {
+			child.ForeignKeys = append(child.ForeignKeys, fk)
+		}
+	}
 	if child.RecordFile == nil {
 		child.RecordFile = base.RecordFile
 	}
@@ -154,6 +184,12 @@ func overlayCollectionDef(child, base *ingitdb.CollectionDef) {
 	}
 }
 
+// sameColumnSet reports whether two column tuples name the same columns,
+// in any order.
+func sameColumnSet(a, b []string) bool {
+	return len(a) == len(b) && slices.Equal(slices.Sorted(slices.Values(a)), slices.Sorted(slices.Values(b)))
+}
+
 // definitionReader wraps ReadDefinition to satisfy ingitdb.CollectionsReader.
 type definitionReader struct{}
 
@@ -177,19 +213,28 @@ func newDefLoader() defLoader {
 }
 
 func ReadDefinition(rootPath string, o ...ingitdb.ReadOption) (def *ingitdb.Definition, err error) {
-	opts := ingitdb.NewReadOptions(o...)
+	return readDefinition(rootPath, ingitdb.NewReadOptions(o...), newDefLoader(), config.ReadRootConfigFromFile)
+}
+
+// readDefinition reads the definition at rootPath through dl, which also
+// reads the subscribers config, and readRootConfig.
+func readDefinition(
+	rootPath string,
+	opts ingitdb.ReadOptions,
+	dl defLoader,
+	readRootConfig func(string, ingitdb.ReadOptions) (config.RootConfig, error),
+) (def *ingitdb.Definition, err error) {
 	var rootConfig config.RootConfig
-	rootConfig, err = config.ReadRootConfigFromFile(rootPath, opts)
+	rootConfig, err = readRootConfig(rootPath, opts)
 	if err != nil {
 		err = fmt.Errorf("failed to read root config from %s: %v", config.IngitDBDirName, err)
 		return
 	}
-	dl := newDefLoader()
 	def, err = dl.readRootCollections(rootPath, rootConfig, opts)
 	if err != nil {
 		return nil, err
 	}
-	def.Subscribers, err = ReadSubscribers(rootPath, opts)
+	def.Subscribers, err = readSubscribers(rootPath, opts, dl.readFile)
 	if err != nil {
 		return nil, err
 	}
@@ -364,6 +409,9 @@ func (dl defLoader) readCollectionDef(rootPath, relPath, parentPath, id string,
 			return nil, fmt.Errorf("failed to load views for '%s': %w", id, err)
 		}
 	}
+	if colDef.Triggers, err = dl.loadTriggers(schemaDir, o); err != nil {
+		return nil, fmt.Errorf("failed to load triggers for '%s': %w", id, err)
+	}
 
 	if colDef.DefaultView != nil {
 		colDef.DefaultView.ID = ingitdb.DefaultViewID
@@ -374,6 +422,12 @@ func (dl defLoader) readCollectionDef(rootPath, relPath, parentPath, id string,
 		colDef.Views[ingitdb.DefaultViewID] = colDef.DefaultView
 	}
 
+	if o.IsValidationRequired() {
+		if err = validateViewsWhere(colDef); err != nil {
+			return nil, err
+		}
+	}
+
 	return
 }
 
@@ -428,6 +482,11 @@ func (dl defLoader) readCollectionDefShared(schemaDir, dataBaseDir, parentPath,
 		return nil, fmt.Errorf("failed to load views for '%s': %w", id, err)
 	}
 
+	colDef.Triggers, err = dl.loadTriggers(schemaDir, o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load triggers for '%s': %w", id, err)
+	}
+
 	if colDef.DefaultView != nil {
 		colDef.DefaultView.ID = ingitdb.DefaultViewID
 		colDef.DefaultView.IsDefault = true
@@ -437,9 +496,29 @@ func (dl defLoader) readCollectionDefShared(schemaDir, dataBaseDir, parentPath,
 		colDef.Views[ingitdb.DefaultViewID] = colDef.DefaultView
 	}
 
+	if o.IsValidationRequired() {
+		if err = validateViewsWhere(colDef); err != nil {
+			return nil, err
+		}
+	}
+
 	return colDef, nil
 }
 
+// validateViewsWhere resolves every loaded view's `where` against the
+// collection's columns. It runs after loadViews rather than inside
+// CollectionDef.Validate because views are read from their own files once the
+// collection definition has already been validated, and a view on its own
+// (ViewDef.Validate) cannot see the columns its expression names.
+func validateViewsWhere(colDef *ingitdb.CollectionDef) error {
+	for _, id := range slices.Sorted(maps.Keys(colDef.Views)) {
+		if err := ingitdb.ValidateViewWhere(colDef.ID, colDef.Views[id], colDef.Columns); err != nil {
+			return fmt.Errorf("not valid definition of view '%s': %w", id, err)
+		}
+	}
+	return nil
+}
+
 // loadSubCollectionsShared discovers subcollections in the new shared-directory
 // layout. Each non-$-prefixed sub-directory of schemaDir that contains a
 // definition.yaml is treated as a subcollection.
@@ -558,3 +637,55 @@ func (dl defLoader) loadViews(viewsDir string, o ingitdb.ReadOptions) (map[strin
 	}
 	return views, nil
 }
+
+// loadTriggers reads the trigger_<name>.yaml workflow files that sit next to a
+// collection's definition.yaml in schemaDir. A trigger's ID is the <name> part
+// of its file name. Unknown keys are rejected, as for collection definitions
+// (decodeCollectionDef), so a misspelled `runs_on:` fails loudly instead of
+// leaving a job that never runs.
+func (dl defLoader) loadTriggers(schemaDir string, o ingitdb.ReadOptions) (map[string]*ingitdb.TriggerDef, error) {
+	entries, err := dl.readDir(schemaDir)
+	if os.IsNotExist(err) {
+		return nil, nil // No schema directory, no triggers
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema directory: %w", err)
+	}
+
+	var triggers map[string]*ingitdb.TriggerDef
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, ingitdb.TriggerFilePrefix) || !strings.HasSuffix(name, ".yaml") {
+			continue
+		}
+		id := strings.TrimSuffix(strings.TrimPrefix(name, ingitdb.TriggerFilePrefix), ".yaml")
+		triggerFilePath := filepath.Join(schemaDir, name)
+
+		fileContent, err := dl.readFile(triggerFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", triggerFilePath, err)
+		}
+
+		triggerDef := new(ingitdb.TriggerDef)
+		dec := yaml.NewDecoder(bytes.NewReader(fileContent))
+		dec.KnownFields(true)
+		if err = dec.Decode(triggerDef); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML file %s: %w", triggerFilePath, err)
+		}
+		triggerDef.ID = id
+
+		if o.IsValidationRequired() {
+			if err = triggerDef.Validate(); err != nil {
+				return nil, fmt.Errorf("not valid definition of trigger '%s': %w", id, err)
+			}
+			log.Printf("Definition of trigger '%s' is valid", id)
+		}
+
+		if triggers == nil {
+			triggers = make(map[string]*ingitdb.TriggerDef)
+		}
+		triggers[id] = triggerDef
+	}
+	return triggers, nil
+}
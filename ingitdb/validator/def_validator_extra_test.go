@@ -110,6 +110,30 @@ func TestReadCollectionDef_InvalidDefinitionWithValidation(t *testing.T) {
 	}
 }
 
+func TestReadCollectionDef_InvalidViewWhereNamesCollectionID(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "tags")
+	writeCollectionDef(t, dir, "record_file:\n  name: \"{key}.yaml\"\n  type: \"map[string]any\"\n  format: yaml\n"+
+		"columns:\n  title:\n    type: string\n")
+	viewsDir := filepath.Join(dir, ingitdb.SchemaDir, "views")
+	if err := os.MkdirAll(viewsDir, 0o777); err != nil {
+		t.Fatalf("failed to create views dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(viewsDir, "recent.yaml"), []byte("where: titel == 'x'\n"), 0o666); err != nil {
+		t.Fatalf("failed to write view file: %v", err)
+	}
+
+	_, err := newDefLoader().readCollectionDef(root, "tags", "", "tags", nil, ingitdb.NewReadOptions(ingitdb.Validate()))
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if errMsg := err.Error(); !strings.Contains(errMsg, "collection 'tags': invalid where for view 'recent'") {
+		t.Fatalf("unexpected error: %s", errMsg)
+	}
+}
+
 func TestLoadSubCollections_InvalidSubCollectionWithValidation(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,31 @@
+package validator
+
+import (
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/config"
+	"github.com/ingitdb/ingitdb-go/ingitdb/gittree"
+)
+
+// gitDefinitionReader reads definitions from a git tree instead of the
+// working tree.
+type gitDefinitionReader struct {
+	paths gittree.Paths
+}
+
+// NewGitCollectionsReader returns an ingitdb.CollectionsReader that reads the
+// definition as of tree's ref. dbPath is an OS path under the tree's
+// repository directory, as if the ref were checked out there; the collection
+// DirPaths of the result point there too, so they can be handed to a
+// RecordsReader over the same tree (see materializer.NewGitRecordsReader).
+// Namespace imports from outside the repository do not resolve.
+func NewGitCollectionsReader(tree *gittree.Tree) ingitdb.CollectionsReader {
+	return gitDefinitionReader{paths: tree.Paths()}
+}
+
+func (r gitDefinitionReader) ReadDefinition(dbPath string, opts ...ingitdb.ReadOption) (*ingitdb.Definition, error) {
+	dl := defLoader{readFile: r.paths.ReadFile, readDir: r.paths.ReadDir}
+	readRootConfig := func(dirPath string, o ingitdb.ReadOptions) (config.RootConfig, error) {
+		return config.ReadRootConfigFromFS(dirPath, o, r.paths)
+	}
+	return readDefinition(dbPath, ingitdb.NewReadOptions(opts...), dl, readRootConfig)
+}
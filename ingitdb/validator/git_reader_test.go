@@ -0,0 +1,53 @@
+package validator
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb/gittree"
+)
+
+// Every file the definition reader looks at comes from the tree: the legacy
+// layout marker committed at the ref is found although the working tree no
+// longer has it.
+func TestGitCollectionsReader_ReadsTreeNotWorkingTree(t *testing.T) {
+	t.Parallel()
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		c := exec.Command("git", args...)
+		c.Dir = repo
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test")
+	if err := os.WriteFile(filepath.Join(repo, ".ingitdb.yaml"), []byte("collections: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "-A")
+	git("commit", "-q", "-m", "legacy layout")
+	if err := os.Remove(filepath.Join(repo, ".ingitdb.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadDefinition(repo); err != nil {
+		t.Fatalf("working tree: ReadDefinition: %v", err)
+	}
+	tree, err := gittree.Open(context.Background(), repo, "HEAD")
+	if err != nil {
+		t.Fatalf("gittree.Open: %v", err)
+	}
+	defer func() { _ = tree.Close() }()
+	_, err = NewGitCollectionsReader(tree).ReadDefinition(repo)
+	if err == nil || !strings.Contains(err.Error(), ".ingitdb.yaml") {
+		t.Errorf("at HEAD: want the legacy layout error, got %v", err)
+	}
+}
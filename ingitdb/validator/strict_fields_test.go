@@ -10,6 +10,9 @@ import (
 // REQ:reject-unknown-column-keys — an unrecognised key in a column definition
 // is rejected at load, naming the key. Silently discarding unknown keys is what
 // lets a plausible-looking enum: or one_of: appear enforced while doing nothing.
+//
+// `unique` is deliberately NOT in this list any more: it is modelled now (see
+// TestDecodeCollectionDef_AcceptsUnique).
 func TestDecodeCollectionDef_RejectsUnknownColumnKey(t *testing.T) {
 	cases := []struct {
 		name string
@@ -24,11 +27,6 @@ func TestDecodeCollectionDef_RejectsUnknownColumnKey(t *testing.T) {
 			key:  "primaryKey",
 			yaml: "columns:\n  id:\n    type: string\n    primaryKey: true\n",
 		},
-		{
-			name: "unique on a column",
-			key:  "unique",
-			yaml: "columns:\n  id:\n    type: string\n    unique: true\n",
-		},
 		{
 			// The motivating case from the Feature: a constraint that looks enforced.
 			name: "one_of instead of enum",
@@ -83,6 +81,15 @@ func TestDecodeCollectionDef_AcceptsModelledKeys(t *testing.T) {
 primary_key: ["id"]
 min_records_count: 1
 max_records_count: 100
+unique: [[state, name]]
+foreign_keys:
+  - columns: [state, name]
+    references: states
+    on_delete: cascade
+checks:
+  named_unless_absent:
+    expr: 'state == "absent" or name != None'
+    message: a present capability needs a name
 record_file:
   name: "{key}.json"
   type: "map[string]any"
@@ -91,6 +98,7 @@ columns:
   id:
     type: string
     required: true
+    unique: true
   state:
     type: string
     enum: [native, absent]
@@ -100,6 +108,9 @@ columns:
   docs:
     type: "[]string"
     min_length: 1
+    pattern: '^https://'
+    format: uri
+    validate_format: true
   population:
     type: int
     min_value: 0
@@ -116,6 +127,18 @@ columns_order: [id, state, name]
 	if colDef.Columns["state"].Enum == nil {
 		t.Error("enum must survive decoding")
 	}
+	if !colDef.Columns["id"].Unique || len(colDef.Unique) != 1 {
+		t.Error("column and collection unique must survive decoding")
+	}
+	if len(colDef.ForeignKeys) != 1 || colDef.ForeignKeys[0].OnDelete != ingitdb.OnDeleteCascade {
+		t.Error("foreign_keys must survive decoding")
+	}
+	if docs := colDef.Columns["docs"]; docs.Pattern == "" || !docs.ValidateFormat {
+		t.Error("pattern and validate_format must survive decoding")
+	}
+	if check := colDef.Checks["named_unless_absent"]; check == nil || check.Message == "" {
+		t.Error("checks must survive decoding")
+	}
 	// Verifies record-count-constraints#ac:record-count-bounds-decode-under-strict-fields.
 	if colDef.MinRecordsCount == nil || *colDef.MinRecordsCount != 1 {
 		t.Error("min_records_count must survive decoding as a modelled key")
@@ -138,3 +161,16 @@ func TestDecodeCollectionDef_AcceptsInherits(t *testing.T) {
 		t.Errorf("inherits must survive decoding, got %q", colDef.Inherits)
 	}
 }
+
+// The column key `unique` was rejected as unknown until unique constraints were
+// modelled; the same document now decodes and keeps the constraint.
+func TestDecodeCollectionDef_AcceptsUnique(t *testing.T) {
+	y := "columns:\n  id:\n    type: string\n    unique: true\n"
+	var colDef ingitdb.CollectionDef
+	if err := decodeCollectionDef([]byte(y), &colDef); err != nil {
+		t.Fatalf("unique must decode as a modelled key, got: %v", err)
+	}
+	if !colDef.Columns["id"].Unique {
+		t.Error("unique must survive decoding")
+	}
+}
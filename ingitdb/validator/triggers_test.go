@@ -0,0 +1,100 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// writeTriggerFixture lays out a one-collection database whose collection has
+// the given trigger file content, and returns the database root.
+func writeTriggerFixture(t *testing.T, triggerYAML string) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		".ingitdb/root-collections.yaml": "tasks: tasks\n",
+		"tasks/.collection/definition.yaml": `record_file:
+  name: "{key}.yaml"
+  format: yaml
+  type: "map[string]any"
+columns:
+  title:
+    type: string
+`,
+		"tasks/.collection/trigger_notify.yaml": triggerYAML,
+	}
+	for rel, content := range files {
+		p := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestReadDefinition_LoadsTriggers(t *testing.T) {
+	t.Parallel()
+
+	root := writeTriggerFixture(t, `on: [created, updated]
+jobs:
+  echo:
+    runs-on: .
+    steps:
+      - run: echo "$INGITDB_RECORD_KEY"
+`)
+	def, err := ReadDefinition(root, ingitdb.Validate())
+	if err != nil {
+		t.Fatalf("ReadDefinition: %v", err)
+	}
+	trigger := def.Collections["tasks"].Triggers["notify"]
+	if trigger == nil {
+		t.Fatalf("expected trigger 'notify', got %v", def.Collections["tasks"].Triggers)
+	}
+	if trigger.ID != "notify" {
+		t.Errorf("expected trigger ID 'notify', got %q", trigger.ID)
+	}
+	if !trigger.FiresOn(ingitdb.TriggerEventUpdated) || trigger.FiresOn(ingitdb.TriggerEventDeleted) {
+		t.Errorf("unexpected events: %v", trigger.On)
+	}
+	if got := trigger.Jobs["echo"].Steps[0].Run; !strings.Contains(got, "INGITDB_RECORD_KEY") {
+		t.Errorf("unexpected step: %q", got)
+	}
+}
+
+func TestReadDefinition_RejectsInvalidTrigger(t *testing.T) {
+	t.Parallel()
+
+	root := writeTriggerFixture(t, `on: [created]
+jobs:
+  echo:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo hi
+`)
+	_, err := ReadDefinition(root, ingitdb.Validate())
+	if err == nil || !strings.Contains(err.Error(), "not valid definition of trigger 'notify'") {
+		t.Fatalf("expected invalid trigger error, got %v", err)
+	}
+}
+
+func TestReadDefinition_RejectsUnknownTriggerKey(t *testing.T) {
+	t.Parallel()
+
+	root := writeTriggerFixture(t, `on: [created]
+jobs:
+  echo:
+    runs_on: .
+    steps:
+      - run: echo hi
+`)
+	_, err := ReadDefinition(root)
+	if err == nil || !strings.Contains(err.Error(), "runs_on") {
+		t.Fatalf("expected unknown-key error naming runs_on, got %v", err)
+	}
+}
@@ -18,7 +18,10 @@ type ViewDef struct {
 	// How many records to include; 0 means all
 	Top int `yaml:"top,omitempty"`
 
-	// Where holds filtering condition
+	// Where holds filtering condition: a single Starlark expression over the
+	// collection's stored columns, evaluated per record (MatchesWhere). Records
+	// for which it is not True are dropped before OrderBy and Top apply. It is
+	// resolved against the columns at definition load (ValidateViewWhere).
 	Where string `yaml:"where,omitempty"`
 
 	// Template path relative to the collection directory.
@@ -0,0 +1,69 @@
+package ingitdb
+
+import (
+	"fmt"
+)
+
+// ValidateViewWhere resolves a view's `where` expression against the
+// collection's stored columns, so a typo'd field name or a reference to a
+// computed column fails when the definition loads rather than silently
+// filtering out every record at materialization.
+//
+// `where` is the same single Starlark expression dialect as `formula` and
+// `required_when`, resolved by the same strict compile path
+// (compileFormulaStrict); see validateFormulaExpr for why resolution is
+// delegated to Starlark's resolver. An empty expression is valid and means
+// "no filter".
+func ValidateViewWhere(collectionID string, view *ViewDef, columns map[string]*ColumnDef) error {
+	if view == nil || view.Where == "" {
+		return nil
+	}
+	stored := make([]string, 0, len(columns))
+	for name, def := range columns {
+		if def.Formula == "" {
+			stored = append(stored, name)
+		}
+	}
+	if _, err := compileFormulaStrict(view.Where, stored); err != nil {
+		if ref := computedColumnReference(err, columns); ref != "" {
+			return fmt.Errorf("collection '%s': where for view '%s' references computed column '%s': a where may reference only stored fields",
+				collectionID, view.ID, ref)
+		}
+		return fmt.Errorf("collection '%s': invalid where for view '%s': %w", collectionID, view.ID, err)
+	}
+	return nil
+}
+
+// MatchesWhere evaluates a view's `where` expression against one record and
+// reports whether the record belongs in the view. An empty expression matches
+// every record.
+//
+// Every stored column of the collection is bound, taking the record's value
+// where present and None otherwise, so `where: 'state != None'` is a valid
+// question to ask of a record that omits state — the same binding rule
+// required_when uses.
+//
+// The expression MUST evaluate to True or False. Anything else is an error
+// rather than a truthiness coercion, so `where: 'name'` does not silently mean
+// "where name is non-empty".
+func MatchesWhere(where string, columns map[string]*ColumnDef, data map[string]any) (bool, error) {
+	if where == "" {
+		return true, nil
+	}
+	fields := make(map[string]any, len(columns))
+	for name, def := range columns {
+		if def.Formula != "" {
+			continue
+		}
+		fields[name] = data[name]
+	}
+	result, err := EvaluateFormula(where, fields)
+	if err != nil {
+		return false, fmt.Errorf("where: %w", err)
+	}
+	matched, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("where must evaluate to True or False, got %T", result)
+	}
+	return matched, nil
+}
@@ -0,0 +1,114 @@
+package ingitdb
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func whereColumns() map[string]*ColumnDef {
+	return map[string]*ColumnDef{
+		"state":      {Type: ColumnTypeString},
+		"population": {Type: ColumnTypeInt},
+		"label":      {Type: ColumnTypeString, Formula: `state + "!"`},
+	}
+}
+
+func TestValidateViewWhere(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		where   string
+		wantErr string
+	}{
+		{name: "empty", where: ""},
+		{name: "stored_fields", where: `state == "active" and population > 1000`},
+		{name: "builtin", where: `len(state) > 0`},
+		{name: "undeclared", where: `nosuchfield > 1`, wantErr: "nosuchfield"},
+		{name: "computed", where: `label == "x"`, wantErr: "references computed column 'label'"},
+		{name: "syntax", where: `state ==`, wantErr: "invalid where for view 'v'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateViewWhere("c", &ViewDef{ID: "v", Where: tt.where}, whereColumns())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %q does not contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchesWhere(t *testing.T) {
+	t.Parallel()
+
+	cols := whereColumns()
+	matched, err := MatchesWhere(`population > 1000`, cols, map[string]any{"state": "a", "population": 5000})
+	if err != nil || !matched {
+		t.Fatalf("expected match, got %v, %v", matched, err)
+	}
+	matched, err = MatchesWhere(`population > 1000`, cols, map[string]any{"state": "a", "population": 10})
+	if err != nil || matched {
+		t.Fatalf("expected no match, got %v, %v", matched, err)
+	}
+	// An omitted sibling binds as None rather than failing as undefined.
+	matched, err = MatchesWhere(`state == None`, cols, map[string]any{"population": 1})
+	if err != nil || !matched {
+		t.Fatalf("expected omitted field to bind as None, got %v, %v", matched, err)
+	}
+	if matched, err = MatchesWhere("", cols, nil); err != nil || !matched {
+		t.Fatalf("empty where must match every record, got %v, %v", matched, err)
+	}
+}
+
+func TestMatchesWhere_RejectsNonBoolResult(t *testing.T) {
+	t.Parallel()
+
+	_, err := MatchesWhere(`state`, whereColumns(), map[string]any{"state": "x"})
+	if err == nil {
+		t.Fatal("expected error for non-bool where result")
+	}
+	if !strings.Contains(err.Error(), "True or False") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// A YAML timestamp is not bound as a string, so a where clause cannot
+// compare it against a date string by accident.
+func TestMatchesWhere_RejectsTimeValue(t *testing.T) {
+	t.Parallel()
+
+	cols := map[string]*ColumnDef{"since": {Type: ColumnTypeDate}}
+	record := map[string]any{"since": time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+	if _, err := MatchesWhere(`since >= "2024-01-01"`, cols, record); err == nil {
+		t.Fatal("expected error for a time value in a where clause")
+	}
+}
+
+func TestCollectionDefValidate_RejectsInvalidDefaultViewWhere(t *testing.T) {
+	t.Parallel()
+
+	def := &CollectionDef{
+		ID:          "c",
+		RecordFile:  &RecordFileDef{Name: "{key}.json", Format: RecordFormatJSON, RecordType: SingleRecord},
+		Columns:     whereColumns(),
+		DefaultView: &ViewDef{Where: `nosuchfield == 1`},
+	}
+	err := def.Validate()
+	if err == nil {
+		t.Fatal("expected load error for undeclared identifier in default_view where")
+	}
+	if !strings.Contains(err.Error(), "nosuchfield") {
+		t.Errorf("error must name the undeclared identifier, got: %v", err)
+	}
+}